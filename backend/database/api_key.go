@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"web-checkly/models"
+)
+
+// ErrAPIKeyNotFound API 密钥不存在（或不属于该用户）
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// apiKeyColumns api_keys 表查询列（与 scanAPIKey 的扫描顺序一致，不含 key_hash）
+const apiKeyColumns = `id, user_id, name, key_prefix, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+// scanAPIKey 扫描一行 API 密钥
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopesJSON string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&scopesJSON,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopesJSON), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal api key scopes: %w", err)
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
+
+// CreateAPIKey 创建 API 密钥（只保存密钥哈希）
+func CreateAPIKey(key *models.APIKey, keyHash string) error {
+	scopesJSON, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + apiKeyColumns
+
+	created, err := scanAPIKey(DB.QueryRow(
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		keyHash,
+		string(scopesJSON),
+		key.ExpiresAt,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	*key = *created
+	return nil
+}
+
+// GetAPIKeyByHash 按密钥哈希获取 API 密钥（认证时使用）
+func GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := scanAPIKey(DB.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys 获取用户的全部 API 密钥（含已吊销的）
+func GetUserAPIKeys(userID string) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// CountActiveUserAPIKeys 统计用户未吊销且未过期的 API 密钥数量
+func CountActiveUserAPIKeys(userID string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`
+	var count int
+	if err := DB.QueryRow(query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count api keys: %w", err)
+	}
+	return count, nil
+}
+
+// RevokeAPIKey 吊销 API 密钥（已吊销的密钥保持原吊销时间）
+func RevokeAPIKey(keyID, userID string) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(DB.QueryRow(query, keyID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey 更新 API 密钥的最近使用时间（一分钟内只更新一次，减少写入）
+func TouchAPIKey(keyID string) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	if _, err := DB.Exec(query, keyID); err != nil {
+		return fmt.Errorf("failed to update api key last used time: %w", err)
+	}
+	return nil
+}
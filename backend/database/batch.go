@@ -0,0 +1,252 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"web-checkly/models"
+)
+
+// ErrScanBatchNotFound 批量扫描不存在（或不属于该用户）
+var ErrScanBatchNotFound = errors.New("scan batch not found")
+
+// scanBatchColumns scan_batches 表查询列（与 scanScanBatch 的扫描顺序一致）
+const scanBatchColumns = `id, user_id, name, options, language, ai_mode, policy, total_tasks, created_at`
+
+// scanScanBatch 扫描一行批量扫描记录
+func scanScanBatch(row rowScanner) (*models.ScanBatch, error) {
+	var batch models.ScanBatch
+	var optionsJSON string
+	var policyJSON sql.NullString
+	err := row.Scan(
+		&batch.ID,
+		&batch.UserID,
+		&batch.Name,
+		&optionsJSON,
+		&batch.Language,
+		&batch.AIMode,
+		&policyJSON,
+		&batch.TotalTasks,
+		&batch.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(optionsJSON), &batch.Options); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch options: %w", err)
+	}
+	if policyJSON.Valid {
+		if err := json.Unmarshal([]byte(policyJSON.String), &batch.Policy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal batch policy: %w", err)
+		}
+	}
+	return &batch, nil
+}
+
+// CreateScanBatch 创建批量扫描
+func CreateScanBatch(batch *models.ScanBatch) error {
+	optionsJSON, err := json.Marshal(batch.Options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal options: %w", err)
+	}
+	var policyJSON interface{}
+	if batch.Policy != nil {
+		policyBytes, err := json.Marshal(batch.Policy)
+		if err != nil {
+			return fmt.Errorf("failed to marshal policy: %w", err)
+		}
+		policyJSON = string(policyBytes)
+	}
+
+	query := `
+		INSERT INTO scan_batches (user_id, name, options, language, ai_mode, policy, total_tasks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + scanBatchColumns
+
+	created, err := scanScanBatch(DB.QueryRow(
+		query,
+		batch.UserID,
+		batch.Name,
+		string(optionsJSON),
+		batch.Language,
+		batch.AIMode,
+		policyJSON,
+		batch.TotalTasks,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create scan batch: %w", err)
+	}
+
+	*batch = *created
+	return nil
+}
+
+// SetScanBatchTotalTasks 更新批量扫描实际创建的子任务数
+func SetScanBatchTotalTasks(batchID string, total int) error {
+	if _, err := DB.Exec(`UPDATE scan_batches SET total_tasks = $1 WHERE id = $2`, total, batchID); err != nil {
+		return fmt.Errorf("failed to update scan batch: %w", err)
+	}
+	return nil
+}
+
+// DeleteScanBatch 删除批量扫描（子任务保留，batch_id 置空）
+func DeleteScanBatch(batchID string) error {
+	if _, err := DB.Exec(`DELETE FROM scan_batches WHERE id = $1`, batchID); err != nil {
+		return fmt.Errorf("failed to delete scan batch: %w", err)
+	}
+	return nil
+}
+
+// GetScanBatch 获取用户的批量扫描
+func GetScanBatch(batchID, userID string) (*models.ScanBatch, error) {
+	query := `SELECT ` + scanBatchColumns + ` FROM scan_batches WHERE id = $1 AND user_id = $2`
+
+	batch, err := scanScanBatch(DB.QueryRow(query, batchID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrScanBatchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scan batch: %w", err)
+	}
+	return batch, nil
+}
+
+// GetUserScanBatches 获取用户的批量扫描列表（按创建时间倒序）
+func GetUserScanBatches(userID string, limit, offset int) ([]*models.ScanBatch, error) {
+	query := `
+		SELECT ` + scanBatchColumns + `
+		FROM scan_batches
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := DB.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := make([]*models.ScanBatch, 0)
+	for rows.Next() {
+		batch, err := scanScanBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scan batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+	return batches, rows.Err()
+}
+
+// CountUserScanBatches 统计用户的批量扫描数量
+func CountUserScanBatches(userID string) (int, error) {
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM scan_batches WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count scan batches: %w", err)
+	}
+	return count, nil
+}
+
+// GetScanBatchProgress 按子任务状态和门禁结论统计批量扫描进度
+func GetScanBatchProgress(batchID string) (*models.BatchProgress, error) {
+	query := `
+		SELECT status,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE (verdict->>'passed')::boolean),
+		       COUNT(*) FILTER (WHERE NOT (verdict->>'passed')::boolean)
+		FROM tasks
+		WHERE batch_id = $1
+		GROUP BY status
+	`
+	rows, err := DB.Query(query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan batch progress: %w", err)
+	}
+	defer rows.Close()
+
+	progress := &models.BatchProgress{}
+	for rows.Next() {
+		var status models.TaskStatus
+		var count, passed, failed int
+		if err := rows.Scan(&status, &count, &passed, &failed); err != nil {
+			return nil, fmt.Errorf("failed to scan scan batch progress: %w", err)
+		}
+		switch status {
+		case models.TaskStatusPending:
+			progress.Pending += count
+		case models.TaskStatusRunning:
+			progress.Running += count
+		case models.TaskStatusCompleted:
+			progress.Completed += count
+		case models.TaskStatusFailed:
+			progress.Failed += count
+		case models.TaskStatusCanceled:
+			progress.Canceled += count
+		}
+		progress.Total += count
+		progress.VerdictPassed += passed
+		progress.VerdictFailed += failed
+	}
+	return progress, rows.Err()
+}
+
+// GetScanBatchTaskSummaries 获取批量扫描的子任务摘要（不含结果，按创建顺序）
+func GetScanBatchTaskSummaries(batchID string) ([]*models.BatchTask, error) {
+	query := `
+		SELECT id, target_url, status, progress, error, (verdict->>'passed')::boolean, created_at, completed_at
+		FROM tasks
+		WHERE batch_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := DB.Query(query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan batch tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*models.BatchTask, 0)
+	for rows.Next() {
+		var task models.BatchTask
+		var progressJSON, errorMsg sql.NullString
+		var passed sql.NullBool
+		var completedAt sql.NullTime
+		if err := rows.Scan(&task.ID, &task.TargetURL, &task.Status, &progressJSON, &errorMsg, &passed, &task.CreatedAt, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scan batch task: %w", err)
+		}
+		if progressJSON.Valid {
+			if err := json.Unmarshal([]byte(progressJSON.String), &task.Progress); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal task progress: %w", err)
+			}
+		}
+		task.Error = errorMsg.String
+		if passed.Valid {
+			task.Passed = &passed.Bool
+		}
+		if completedAt.Valid {
+			task.CompletedAt = &completedAt.Time
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetScanBatchTasks 获取批量扫描的完整子任务（含结果，按创建顺序，导出时使用）
+func GetScanBatchTasks(batchID string) ([]*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE batch_id = $1 ORDER BY created_at, id`
+	rows, err := DB.Query(query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan batch tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*models.Task, 0)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"web-checkly/models"
+)
+
+// ErrScanScheduleNotFound 定时扫描计划不存在（或不属于该用户）
+var ErrScanScheduleNotFound = errors.New("scan schedule not found")
+
+// scanScheduleColumns scan_schedules 表查询列（与 scanScanSchedule 的扫描顺序一致）
+const scanScheduleColumns = `
+	id, user_id, name, target_url, options, language, ai_mode, cron_expression, timezone,
+	status, paused_reason, next_run_at, last_run_at, last_task_id, created_at, updated_at`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanScanSchedule 扫描一行定时扫描计划
+func scanScanSchedule(row rowScanner) (*models.ScanSchedule, error) {
+	var schedule models.ScanSchedule
+	var optionsJSON string
+	var pausedReason, lastTaskID sql.NullString
+	var nextRunAt, lastRunAt sql.NullTime
+
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.UserID,
+		&schedule.Name,
+		&schedule.TargetURL,
+		&optionsJSON,
+		&schedule.Language,
+		&schedule.AIMode,
+		&schedule.CronExpression,
+		&schedule.Timezone,
+		&schedule.Status,
+		&pausedReason,
+		&nextRunAt,
+		&lastRunAt,
+		&lastTaskID,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(optionsJSON), &schedule.Options); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schedule options: %w", err)
+	}
+	if pausedReason.Valid {
+		schedule.PausedReason = pausedReason.String
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+	if lastTaskID.Valid {
+		schedule.LastTaskID = &lastTaskID.String
+	}
+	return &schedule, nil
+}
+
+// nullableString 空字符串写入为 NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// CreateScanSchedule 创建定时扫描计划
+func CreateScanSchedule(schedule *models.ScanSchedule) error {
+	optionsJSON, err := json.Marshal(schedule.Options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal options: %w", err)
+	}
+
+	query := `
+		INSERT INTO scan_schedules (
+			user_id, name, target_url, options, language, ai_mode, cron_expression, timezone,
+			status, paused_reason, next_run_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + scanScheduleColumns
+
+	created, err := scanScanSchedule(DB.QueryRow(
+		query,
+		schedule.UserID,
+		schedule.Name,
+		schedule.TargetURL,
+		string(optionsJSON),
+		schedule.Language,
+		schedule.AIMode,
+		schedule.CronExpression,
+		schedule.Timezone,
+		string(schedule.Status),
+		nullableString(schedule.PausedReason),
+		schedule.NextRunAt,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create scan schedule: %w", err)
+	}
+
+	*schedule = *created
+	return nil
+}
+
+// GetScanSchedule 获取用户的定时扫描计划
+func GetScanSchedule(scheduleID, userID string) (*models.ScanSchedule, error) {
+	query := `SELECT ` + scanScheduleColumns + ` FROM scan_schedules WHERE id = $1 AND user_id = $2`
+
+	schedule, err := scanScanSchedule(DB.QueryRow(query, scheduleID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrScanScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scan schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// GetUserScanSchedules 获取用户的全部定时扫描计划
+func GetUserScanSchedules(userID string) ([]*models.ScanSchedule, error) {
+	query := `SELECT ` + scanScheduleColumns + ` FROM scan_schedules WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]*models.ScanSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanScanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// CountUserScanSchedules 统计用户的定时扫描计划数量
+func CountUserScanSchedules(userID string) (int, error) {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM scan_schedules WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count scan schedules: %w", err)
+	}
+	return count, nil
+}
+
+// UpdateScanSchedule 更新定时扫描计划的可编辑字段、状态和下次执行时间
+func UpdateScanSchedule(schedule *models.ScanSchedule) error {
+	optionsJSON, err := json.Marshal(schedule.Options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal options: %w", err)
+	}
+
+	query := `
+		UPDATE scan_schedules
+		SET name = $1, target_url = $2, options = $3, language = $4, ai_mode = $5,
+		    cron_expression = $6, timezone = $7, status = $8, paused_reason = $9,
+		    next_run_at = $10, updated_at = NOW()
+		WHERE id = $11 AND user_id = $12
+		RETURNING ` + scanScheduleColumns
+
+	updated, err := scanScanSchedule(DB.QueryRow(
+		query,
+		schedule.Name,
+		schedule.TargetURL,
+		string(optionsJSON),
+		schedule.Language,
+		schedule.AIMode,
+		schedule.CronExpression,
+		schedule.Timezone,
+		string(schedule.Status),
+		nullableString(schedule.PausedReason),
+		schedule.NextRunAt,
+		schedule.ID,
+		schedule.UserID,
+	))
+	if err == sql.ErrNoRows {
+		return ErrScanScheduleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update scan schedule: %w", err)
+	}
+
+	*schedule = *updated
+	return nil
+}
+
+// DeleteScanSchedule 删除用户的定时扫描计划（已创建的任务保留，schedule_id 置为 NULL）
+func DeleteScanSchedule(scheduleID, userID string) error {
+	result, err := DB.Exec(`DELETE FROM scan_schedules WHERE id = $1 AND user_id = $2`, scheduleID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete scan schedule: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrScanScheduleNotFound
+	}
+	return nil
+}
+
+// ClaimDueScanSchedules 领取到期的定时扫描计划
+// 领取时将 next_run_at 推迟 leaseDuration，避免多个 API 实例重复触发；
+// 触发成功后由 RecordScanScheduleRun 写入真正的下次执行时间，触发失败时租约到期后会被重新领取
+func ClaimDueScanSchedules(limit int, leaseDuration time.Duration) ([]*models.ScanSchedule, error) {
+	query := `
+		UPDATE scan_schedules
+		SET next_run_at = NOW() + ($1 * INTERVAL '1 second')
+		WHERE id IN (
+			SELECT id FROM scan_schedules
+			WHERE status = 'active' AND next_run_at IS NOT NULL AND next_run_at <= NOW()
+			ORDER BY next_run_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scanScheduleColumns
+
+	rows, err := DB.Query(query, int(leaseDuration.Seconds()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due scan schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*models.ScanSchedule
+	for rows.Next() {
+		schedule, err := scanScanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// RecordScanScheduleRun 记录定时扫描计划的一次执行，并写入下次执行时间
+// 执行期间被用户暂停的计划保持暂停状态（不写入下次执行时间）
+func RecordScanScheduleRun(scheduleID, taskID string, runAt time.Time, nextRunAt *time.Time) error {
+	query := `
+		UPDATE scan_schedules
+		SET last_run_at = $1, last_task_id = $2,
+		    next_run_at = CASE WHEN status = 'active' THEN $3 ELSE next_run_at END,
+		    updated_at = NOW()
+		WHERE id = $4
+	`
+	if _, err := DB.Exec(query, runAt, taskID, nextRunAt, scheduleID); err != nil {
+		return fmt.Errorf("failed to record scan schedule run: %w", err)
+	}
+	return nil
+}
+
+// PauseScanSchedule 暂停定时扫描计划并记录原因（如积分不足）
+func PauseScanSchedule(scheduleID, reason string) error {
+	query := `
+		UPDATE scan_schedules
+		SET status = 'paused', paused_reason = $1, next_run_at = NULL, updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err := DB.Exec(query, nullableString(reason), scheduleID); err != nil {
+		return fmt.Errorf("failed to pause scan schedule: %w", err)
+	}
+	return nil
+}
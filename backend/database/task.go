@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 	"web-checkly/models"
+	"web-checkly/utils"
 )
 
 // CreateTask 创建任务到数据库
@@ -41,6 +42,51 @@ func CreateTask(task *models.Task) error {
 		resultsJSON = nil
 	}
 
+	// 序列化门禁策略（可选）
+	var policyJSON interface{}
+	if task.Policy != nil {
+		policyBytes, err := json.Marshal(task.Policy)
+		if err != nil {
+			return fmt.Errorf("failed to marshal policy: %w", err)
+		}
+		policyJSON = string(policyBytes)
+	}
+
+	// 序列化爬取配置（可选）
+	var crawlJSON interface{}
+	if task.Crawl != nil {
+		crawlBytes, err := json.Marshal(task.Crawl)
+		if err != nil {
+			return fmt.Errorf("failed to marshal crawl config: %w", err)
+		}
+		crawlJSON = string(crawlBytes)
+	}
+
+	// 加密认证扫描配置（可选，凭据不以明文落库）
+	var authConfig interface{}
+	if task.Auth != nil {
+		authBytes, err := json.Marshal(task.Auth)
+		if err != nil {
+			return fmt.Errorf("failed to marshal auth config: %w", err)
+		}
+		encrypted, err := utils.EncryptSecret(authBytes)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt auth config: %w", err)
+		}
+		authConfig = encrypted
+		task.Authenticated = true
+	}
+
+	// 序列化预扣费的使用记录（批量扫描创建任务时预扣）
+	var prepaidUsageRecordsJSON interface{}
+	if len(task.PrepaidUsageRecords) > 0 {
+		recordsBytes, err := json.Marshal(task.PrepaidUsageRecords)
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage records: %w", err)
+		}
+		prepaidUsageRecordsJSON = string(recordsBytes)
+	}
+
 	// 处理 user_id（可能为 nil）
 	var userIDPtr interface{}
 	if task.UserID != nil {
@@ -53,8 +99,9 @@ func CreateTask(task *models.Task) error {
 		INSERT INTO tasks (
 			id, user_id, status, target_url, options, language, ai_mode,
 			is_public, progress, modules, results, error,
-			created_at, updated_at, started_at, completed_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			created_at, updated_at, started_at, completed_at, schedule_id, policy,
+			batch_id, prepaid_usage_records, crawl_config, auth_config
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 
 	_, err = DB.Exec(
@@ -75,6 +122,12 @@ func CreateTask(task *models.Task) error {
 		task.UpdatedAt,
 		task.StartedAt,
 		task.CompletedAt,
+		task.ScheduleID,
+		policyJSON,
+		task.BatchID,
+		prepaidUsageRecordsJSON,
+		crawlJSON,
+		authConfig,
 	)
 
 	if err != nil {
@@ -84,23 +137,37 @@ func CreateTask(task *models.Task) error {
 	return nil
 }
 
+// taskColumns tasks 表的完整查询列（与 scanTask 的扫描顺序一致）
+const taskColumns = `
+	id, user_id, status, target_url, options, language, ai_mode,
+	is_public, progress, modules, results, error,
+	created_at, updated_at, started_at, completed_at,
+	retry_modules, prepaid_usage_records, schedule_id, policy, verdict, batch_id, crawl_config, auth_config`
+
 // GetTask 从数据库获取任务
 func GetTask(taskID string) (*models.Task, error) {
+	task, err := scanTask(DB.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = $1`, taskID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return task, nil
+}
+
+// scanTask 扫描一行完整的任务记录（列见 taskColumns）
+func scanTask(row rowScanner) (*models.Task, error) {
 	var task models.Task
-	var userID sql.NullString
+	var userID, scheduleID, batchID sql.NullString
 	var optionsJSON, progressJSON, modulesJSON sql.NullString
 	var resultsJSON sql.NullString
+	var retryModulesJSON, prepaidUsageRecordsJSON sql.NullString
+	var policyJSON, verdictJSON, crawlJSON sql.NullString
+	var authConfig sql.NullString
 	var startedAt, completedAt sql.NullTime
 
-	query := `
-		SELECT id, user_id, status, target_url, options, language, ai_mode,
-		       is_public, progress, modules, results, error,
-		       created_at, updated_at, started_at, completed_at
-		FROM tasks
-		WHERE id = $1
-	`
-
-	err := DB.QueryRow(query, taskID).Scan(
+	err := row.Scan(
 		&task.ID,
 		&userID,
 		&task.Status,
@@ -117,13 +184,17 @@ func GetTask(taskID string) (*models.Task, error) {
 		&task.UpdatedAt,
 		&startedAt,
 		&completedAt,
+		&retryModulesJSON,
+		&prepaidUsageRecordsJSON,
+		&scheduleID,
+		&policyJSON,
+		&verdictJSON,
+		&batchID,
+		&crawlJSON,
+		&authConfig,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("task not found: %s", taskID)
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get task: %w", err)
+		return nil, err
 	}
 
 	// 处理 user_id
@@ -131,6 +202,12 @@ func GetTask(taskID string) (*models.Task, error) {
 		userIDStr := userID.String
 		task.UserID = &userIDStr
 	}
+	if scheduleID.Valid {
+		task.ScheduleID = &scheduleID.String
+	}
+	if batchID.Valid {
+		task.BatchID = &batchID.String
+	}
 
 	// 反序列化 options
 	if optionsJSON.Valid {
@@ -166,6 +243,48 @@ func GetTask(taskID string) (*models.Task, error) {
 		task.Results = &results
 	}
 
+	// 反序列化重新执行信息
+	if retryModulesJSON.Valid {
+		if err := json.Unmarshal([]byte(retryModulesJSON.String), &task.RetryModules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal retry modules: %w", err)
+		}
+	}
+	if prepaidUsageRecordsJSON.Valid {
+		if err := json.Unmarshal([]byte(prepaidUsageRecordsJSON.String), &task.PrepaidUsageRecords); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal prepaid usage records: %w", err)
+		}
+	}
+
+	// 反序列化门禁策略和结论
+	if policyJSON.Valid {
+		if err := json.Unmarshal([]byte(policyJSON.String), &task.Policy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal policy: %w", err)
+		}
+	}
+	if verdictJSON.Valid {
+		if err := json.Unmarshal([]byte(verdictJSON.String), &task.Verdict); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal verdict: %w", err)
+		}
+	}
+	if crawlJSON.Valid {
+		if err := json.Unmarshal([]byte(crawlJSON.String), &task.Crawl); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal crawl config: %w", err)
+		}
+	}
+
+	// 解密认证扫描配置；解密失败（如密钥已更换）时只记录日志，不影响任务查询，由执行器拒绝执行
+	if authConfig.Valid && authConfig.String != "" {
+		task.Authenticated = true
+		authBytes, err := utils.DecryptSecret(authConfig.String)
+		if err == nil {
+			err = json.Unmarshal(authBytes, &task.Auth)
+		}
+		if err != nil {
+			task.Auth = nil
+			log.Printf("[Database] Failed to decrypt auth config for task %s: %v", task.ID, err)
+		}
+	}
+
 	// 处理时间字段
 	if startedAt.Valid {
 		task.StartedAt = &startedAt.Time
@@ -177,9 +296,9 @@ func GetTask(taskID string) (*models.Task, error) {
 	return &task, nil
 }
 
-// UpdateTaskStatus 更新任务状态
+// UpdateTaskStatus 更新任务状态（已取消的任务不会被覆盖）
 func UpdateTaskStatus(taskID string, status models.TaskStatus) error {
-	query := `UPDATE tasks SET status = $1, updated_at = NOW() WHERE id = $2`
+	query := `UPDATE tasks SET status = $1, updated_at = NOW() WHERE id = $2 AND status <> 'canceled'`
 	_, err := DB.Exec(query, string(status), taskID)
 	if err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
@@ -187,117 +306,202 @@ func UpdateTaskStatus(taskID string, status models.TaskStatus) error {
 	return nil
 }
 
-// UpdateTaskProgress 更新任务进度
-func UpdateTaskProgress(taskID string, progress models.TaskProgress, modules map[string]*models.ModuleStatus) error {
+// UpdateTaskResults 更新任务结果
+func UpdateTaskResults(taskID string, results *models.TaskResults) error {
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	now := time.Now()
+	query := `
+		UPDATE tasks 
+		SET results = $1, status = 'completed', completed_at = $2, updated_at = $2
+		WHERE id = $3 AND status <> 'canceled'
+	`
+	_, err = DB.Exec(query, string(resultsJSON), now, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to update task results: %w", err)
+	}
+
+	log.Printf("[Database] Successfully updated task results for %s", taskID)
+	return nil
+}
+
+// SetTaskProgress 只更新任务进度（不覆盖模块状态）
+func SetTaskProgress(taskID string, progress models.TaskProgress) error {
 	progressJSON, err := json.Marshal(progress)
 	if err != nil {
 		return fmt.Errorf("failed to marshal progress: %w", err)
 	}
 
-	modulesJSON, err := json.Marshal(modules)
+	query := `UPDATE tasks SET progress = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := DB.Exec(query, string(progressJSON), taskID); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+	return nil
+}
+
+// ModifyTaskModules 在事务中锁定任务行并修改模块状态（并发执行的插件不会互相覆盖）
+// modify 返回 false 时不写回
+func ModifyTaskModules(taskID string, modify func(status models.TaskStatus, modules map[string]*models.ModuleStatus) bool) error {
+	tx, err := DB.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to marshal modules: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	query := `
-		UPDATE tasks 
-		SET progress = $1, modules = $2, updated_at = NOW()
-		WHERE id = $3
-	`
-	_, err = DB.Exec(query, string(progressJSON), string(modulesJSON), taskID)
+	var status string
+	var modulesJSON []byte
+	err = tx.QueryRow(`SELECT status, modules FROM tasks WHERE id = $1 FOR UPDATE`, taskID).Scan(&status, &modulesJSON)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("task not found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update task progress: %w", err)
+		return fmt.Errorf("failed to get task modules: %w", err)
+	}
+
+	modules := make(map[string]*models.ModuleStatus)
+	if len(modulesJSON) > 0 {
+		if err := json.Unmarshal(modulesJSON, &modules); err != nil {
+			return fmt.Errorf("failed to unmarshal modules: %w", err)
+		}
+		if modules == nil {
+			modules = make(map[string]*models.ModuleStatus)
+		}
+	}
+	if !modify(models.TaskStatus(status), modules) {
+		return nil
+	}
+
+	newModulesJSON, err := json.Marshal(modules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal modules: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE tasks SET modules = $1, updated_at = NOW() WHERE id = $2`, string(newModulesJSON), taskID); err != nil {
+		return fmt.Errorf("failed to update task modules: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return nil
 }
 
-// UpdateTaskResults 更新任务结果
-func UpdateTaskResults(taskID string, results *models.TaskResults) error {
+// UpdateTaskResultsWithoutStatus 更新任务结果（不更新状态）
+// 用于插件完成后立即保存部分结果：只替换 results 中 results 参数非空的顶层字段（jsonb ||），
+// 单条语句原子执行，不会覆盖并发保存的其他模块结果和实时追加的 katana_results
+func UpdateTaskResultsWithoutStatus(taskID string, results *models.TaskResults) error {
 	resultsJSON, err := json.Marshal(results)
 	if err != nil {
 		return fmt.Errorf("failed to marshal results: %w", err)
 	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(resultsJSON, &fields); err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	for key, value := range fields {
+		if string(value) == "null" {
+			delete(fields, key)
+		}
+	}
+	// 空的 summary 不覆盖已有的统计
+	if results.Summary.Total == 0 {
+		delete(fields, "summary")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	patchJSON, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
 
-	now := time.Now()
 	query := `
 		UPDATE tasks 
-		SET results = $1, status = 'completed', completed_at = $2, updated_at = $2
-		WHERE id = $3
+		SET results = COALESCE(results, '{}'::jsonb) || $1::jsonb, updated_at = NOW()
+		WHERE id = $2
 	`
-	_, err = DB.Exec(query, string(resultsJSON), now, taskID)
-	if err != nil {
+	if _, err := DB.Exec(query, string(patchJSON), taskID); err != nil {
 		return fmt.Errorf("failed to update task results: %w", err)
 	}
-
-	log.Printf("[Database] Successfully updated task results for %s", taskID)
 	return nil
 }
 
-// UpdateTaskResultsWithoutStatus 更新任务结果（不更新状态）
-// 用于实时推送时更新结果，避免覆盖任务状态和已完成的结果字段
-func UpdateTaskResultsWithoutStatus(taskID string, results *models.TaskResults) error {
-	// 从数据库读取现有结果，合并而不是完全替换
-	existingTask, err := GetTask(taskID)
+// ModifyTaskResults 在事务中锁定任务行，读取已保存的结果交给 modify 修改后写回
+// 用于需要与已保存结果合并的部分结果（如 Lighthouse 安全检测与 js-libraries 共用 security_risk）
+func ModifyTaskResults(taskID string, modify func(results *models.TaskResults)) error {
+	tx, err := DB.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to get existing task: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// 如果现有结果中有 link_health 等字段，保留它们
-	if existingTask.Results != nil {
-		// 保留已有的 link_health（如果新结果中没有）
-		if results.LinkHealth == nil && existingTask.Results.LinkHealth != nil {
-			results.LinkHealth = existingTask.Results.LinkHealth
-		}
-		// 保留其他已有字段
-		if results.WebsiteInfo == nil && existingTask.Results.WebsiteInfo != nil {
-			results.WebsiteInfo = existingTask.Results.WebsiteInfo
-		}
-		if results.DomainInfo == nil && existingTask.Results.DomainInfo != nil {
-			results.DomainInfo = existingTask.Results.DomainInfo
-		}
-		if results.SSLInfo == nil && existingTask.Results.SSLInfo != nil {
-			results.SSLInfo = existingTask.Results.SSLInfo
-		}
-		if results.TechStack == nil && existingTask.Results.TechStack != nil {
-			results.TechStack = existingTask.Results.TechStack
-		}
-		if results.Performance == nil && existingTask.Results.Performance != nil {
-			results.Performance = existingTask.Results.Performance
-		}
-		if results.SEOCompliance == nil && existingTask.Results.SEOCompliance != nil {
-			results.SEOCompliance = existingTask.Results.SEOCompliance
-		}
-		if results.SecurityRisk == nil && existingTask.Results.SecurityRisk != nil {
-			results.SecurityRisk = existingTask.Results.SecurityRisk
-		}
-		if results.Accessibility == nil && existingTask.Results.Accessibility != nil {
-			results.Accessibility = existingTask.Results.Accessibility
-		}
-		if results.AIAnalysis == nil && existingTask.Results.AIAnalysis != nil {
-			results.AIAnalysis = existingTask.Results.AIAnalysis
-		}
-		// 保留 summary（如果新结果中没有或为空）
-		if results.Summary.Total == 0 && existingTask.Results.Summary.Total > 0 {
-			results.Summary = existingTask.Results.Summary
+	var resultsJSON []byte
+	err = tx.QueryRow(`SELECT results FROM tasks WHERE id = $1 FOR UPDATE`, taskID).Scan(&resultsJSON)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("task not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get task results: %w", err)
+	}
+
+	results := &models.TaskResults{}
+	if len(resultsJSON) > 0 {
+		if err := json.Unmarshal(resultsJSON, results); err != nil {
+			return fmt.Errorf("failed to unmarshal results: %w", err)
 		}
 	}
+	modify(results)
 
-	resultsJSON, err := json.Marshal(results)
+	newResultsJSON, err := json.Marshal(results)
 	if err != nil {
 		return fmt.Errorf("failed to marshal results: %w", err)
 	}
+	if _, err := tx.Exec(`UPDATE tasks SET results = $1, updated_at = NOW() WHERE id = $2`, string(newResultsJSON), taskID); err != nil {
+		return fmt.Errorf("failed to update task results: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AppendTaskKatanaResults 原子地把爬虫发现的链接追加到 results.katana_results（用于实时推送）
+func AppendTaskKatanaResults(taskID string, items []interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal katana results: %w", err)
+	}
 
-	now := time.Now()
 	query := `
-		UPDATE tasks 
-		SET results = $1, updated_at = $2
-		WHERE id = $3
+		UPDATE tasks
+		SET results = jsonb_set(
+		        COALESCE(results, '{}'::jsonb), '{katana_results}',
+		        CASE WHEN jsonb_typeof(results->'katana_results') = 'array'
+		             THEN results->'katana_results' ELSE '[]'::jsonb END || $1::jsonb),
+		    updated_at = NOW()
+		WHERE id = $2
 	`
-	_, err = DB.Exec(query, string(resultsJSON), now, taskID)
+	if _, err := DB.Exec(query, string(itemsJSON), taskID); err != nil {
+		return fmt.Errorf("failed to append katana results: %w", err)
+	}
+	return nil
+}
+
+// SetTaskVerdict 保存任务的门禁结论
+func SetTaskVerdict(taskID string, verdict *models.ScanVerdict) error {
+	verdictJSON, err := json.Marshal(verdict)
 	if err != nil {
-		return fmt.Errorf("failed to update task results: %w", err)
+		return fmt.Errorf("failed to marshal verdict: %w", err)
 	}
 
+	query := `UPDATE tasks SET verdict = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := DB.Exec(query, string(verdictJSON), taskID); err != nil {
+		return fmt.Errorf("failed to set task verdict: %w", err)
+	}
 	return nil
 }
 
@@ -307,7 +511,7 @@ func SetTaskError(taskID string, errorMsg string) error {
 	query := `
 		UPDATE tasks 
 		SET error = $1, status = 'failed', completed_at = $2, updated_at = $2
-		WHERE id = $3
+		WHERE id = $3 AND status <> 'canceled'
 	`
 	_, err := DB.Exec(query, errorMsg, now, taskID)
 	if err != nil {
@@ -316,6 +520,57 @@ func SetTaskError(taskID string, errorMsg string) error {
 	return nil
 }
 
+// CancelTask 将待执行或执行中的任务标记为已取消，同时释放队列租约
+// 返回 false 表示任务不存在或已经结束（无法取消）
+func CancelTask(taskID string, reason string) (bool, error) {
+	query := `
+		UPDATE tasks
+		SET status = 'canceled', error = $1, lease_owner = NULL, lease_expires_at = NULL,
+		    completed_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status IN ('pending', 'running')
+	`
+	result, err := DB.Exec(query, reason, taskID)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel task: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// ResetTaskForRetry 将已结束的任务重新放回队列，只重新执行 retryModules 对应的模块
+// 同时重置重试次数、队列租约和上次的门禁结论（任务结束时重新计算），保存重置后的模块状态和本次预扣费的使用记录
+// 返回 false 表示任务不存在或未结束（无法重新执行）
+func ResetTaskForRetry(taskID string, retryModules []string, usageRecordIDs map[string]string, modules map[string]*models.ModuleStatus) (bool, error) {
+	retryModulesJSON, err := json.Marshal(retryModules)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal retry modules: %w", err)
+	}
+
+	usageRecordsJSON, err := json.Marshal(usageRecordIDs)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal prepaid usage records: %w", err)
+	}
+
+	modulesJSON, err := json.Marshal(modules)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal modules: %w", err)
+	}
+
+	query := `
+		UPDATE tasks
+		SET status = 'pending', error = '', completed_at = NULL, verdict = NULL,
+		    attempts = 0, lease_owner = NULL, lease_expires_at = NULL,
+		    retry_modules = $1, prepaid_usage_records = $2, modules = $3, updated_at = NOW()
+		WHERE id = $4 AND status IN ('completed', 'failed', 'canceled')
+	`
+	result, err := DB.Exec(query, string(retryModulesJSON), string(usageRecordsJSON), string(modulesJSON), taskID)
+	if err != nil {
+		return false, fmt.Errorf("failed to reset task for retry: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
 // SetTaskStarted 设置任务开始时间
 func SetTaskStarted(taskID string) error {
 	now := time.Now()
@@ -433,7 +688,7 @@ func DeleteExpiredTasks() error {
 	// 基础版：30天，专业版：90天，高级版：永久，免费用户：24小时
 	query := `
 		DELETE FROM tasks
-		WHERE status IN ('completed', 'failed')
+		WHERE status IN ('completed', 'failed', 'canceled')
 		AND completed_at IS NOT NULL
 		AND (
 			-- 免费用户：24小时
@@ -675,3 +930,34 @@ func DeleteTaskByID(taskID string) error {
 	}
 	return nil
 }
+
+// GetPreviousCompletedTaskID 获取同一用户对同一URL的上一次已完成扫描（创建时间早于指定任务）
+// 匿名任务只与匿名任务比较；没有更早的扫描时返回空字符串
+func GetPreviousCompletedTaskID(task *models.Task) (string, error) {
+	query := `
+		SELECT id FROM tasks
+		WHERE target_url = $1
+		  AND user_id IS NOT DISTINCT FROM $2
+		  AND id <> $3
+		  AND status = 'completed'
+		  AND results IS NOT NULL
+		  AND created_at < $4
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var userID interface{}
+	if task.UserID != nil {
+		userID = *task.UserID
+	}
+
+	var previousID string
+	err := DB.QueryRow(query, task.TargetURL, userID, task.ID, task.CreatedAt).Scan(&previousID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get previous task: %w", err)
+	}
+	return previousID, nil
+}
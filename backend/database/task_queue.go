@@ -0,0 +1,121 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ClaimNextTask 从持久化队列中领取一个待执行任务
+// 可领取的任务：pending 状态的任务，或租约已过期的 running 任务（执行该任务的 worker 已崩溃或重启）
+// 使用 FOR UPDATE SKIP LOCKED 保证多个 worker 并发领取时不会拿到同一个任务
+// 没有可领取的任务时返回空字符串
+func ClaimNextTask(workerID string, leaseDuration time.Duration, maxAttempts int) (string, int, error) {
+	query := `
+		UPDATE tasks
+		SET status = 'running',
+		    lease_owner = $1,
+		    lease_expires_at = NOW() + ($2 * INTERVAL '1 second'),
+		    heartbeat_at = NOW(),
+		    attempts = attempts + 1,
+		    started_at = COALESCE(started_at, NOW()),
+		    updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM tasks
+			WHERE (status = 'pending'
+			       OR (status = 'running' AND lease_expires_at IS NOT NULL AND lease_expires_at < NOW()))
+			  AND attempts < $3
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, attempts
+	`
+
+	var taskID string
+	var attempts int
+	err := DB.QueryRow(query, workerID, int(leaseDuration.Seconds()), maxAttempts).Scan(&taskID, &attempts)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to claim task: %w", err)
+	}
+	return taskID, attempts, nil
+}
+
+// RenewTaskLease 续约任务（心跳）
+// 只有当前租约持有者才能续约，返回 false 表示租约已丢失（任务已被其他 worker 领取或已结束）
+func RenewTaskLease(taskID, workerID string, leaseDuration time.Duration) (bool, error) {
+	query := `
+		UPDATE tasks
+		SET lease_expires_at = NOW() + ($1 * INTERVAL '1 second'), heartbeat_at = NOW()
+		WHERE id = $2 AND lease_owner = $3 AND status = 'running'
+	`
+	result, err := DB.Exec(query, int(leaseDuration.Seconds()), taskID, workerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew task lease: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// ReleaseTaskLease 释放任务租约（任务执行结束后调用）
+func ReleaseTaskLease(taskID, workerID string) error {
+	query := `
+		UPDATE tasks
+		SET lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $1 AND lease_owner = $2
+	`
+	_, err := DB.Exec(query, taskID, workerID)
+	if err != nil {
+		return fmt.Errorf("failed to release task lease: %w", err)
+	}
+	return nil
+}
+
+// RequeueWorkerTasks 将指定 worker 持有的 running 任务放回队列（worker 优雅退出时调用）
+// 主动放回的任务不计入重试次数
+func RequeueWorkerTasks(workerID string) (int64, error) {
+	query := `
+		UPDATE tasks
+		SET status = 'pending', lease_owner = NULL, lease_expires_at = NULL,
+		    attempts = GREATEST(attempts - 1, 0), updated_at = NOW()
+		WHERE lease_owner = $1 AND status = 'running'
+	`
+	result, err := DB.Exec(query, workerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue worker tasks: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected, nil
+}
+
+// FailExhaustedTasks 将重试次数已用尽且租约已过期的任务标记为失败
+// 返回被标记为失败的任务ID列表
+func FailExhaustedTasks(maxAttempts int, errorMsg string) ([]string, error) {
+	query := `
+		UPDATE tasks
+		SET status = 'failed', error = $1, lease_owner = NULL, lease_expires_at = NULL,
+		    completed_at = NOW(), updated_at = NOW()
+		WHERE status IN ('pending', 'running')
+		  AND attempts >= $2
+		  AND (status = 'pending' OR lease_expires_at IS NULL OR lease_expires_at < NOW())
+		RETURNING id
+	`
+	rows, err := DB.Query(query, errorMsg, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail exhausted tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var taskIDs []string
+	for rows.Next() {
+		var taskID string
+		if err := rows.Scan(&taskID); err != nil {
+			return nil, fmt.Errorf("failed to scan task id: %w", err)
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+	return taskIDs, rows.Err()
+}
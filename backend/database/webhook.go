@@ -0,0 +1,325 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"web-checkly/models"
+)
+
+// ErrWebhookNotFound Webhook 不存在（或不属于该用户）
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// webhookEndpointColumns webhook_endpoints 表查询列（与 scanWebhookEndpoint 的扫描顺序一致）
+const webhookEndpointColumns = `id, user_id, url, description, secret, events, is_active, created_at, updated_at`
+
+// webhookDeliveryColumns webhook_deliveries 表查询列（与 scanWebhookDelivery 的扫描顺序一致）
+const webhookDeliveryColumns = `
+	id, endpoint_id, event, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, response_body, delivered_at, created_at, updated_at`
+
+// scanWebhookEndpoint 扫描一行 Webhook 接收地址
+func scanWebhookEndpoint(row rowScanner) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	var eventsJSON string
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.URL,
+		&endpoint.Description,
+		&endpoint.Secret,
+		&eventsJSON,
+		&endpoint.IsActive,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(eventsJSON), &endpoint.Events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook events: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// scanWebhookDelivery 扫描一行 Webhook 投递记录
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload string
+	var nextAttemptAt, deliveredAt sql.NullTime
+	var lastStatusCode sql.NullInt64
+	var lastError, responseBody sql.NullString
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.Event,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&lastStatusCode,
+		&lastError,
+		&responseBody,
+		&deliveredAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastStatusCode.Valid {
+		code := int(lastStatusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	delivery.LastError = lastError.String
+	delivery.ResponseBody = responseBody.String
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+// CreateWebhookEndpoint 创建 Webhook 接收地址
+func CreateWebhookEndpoint(endpoint *models.WebhookEndpoint) error {
+	eventsJSON, err := json.Marshal(endpoint.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhook_endpoints (user_id, url, description, secret, events, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + webhookEndpointColumns
+
+	created, err := scanWebhookEndpoint(DB.QueryRow(
+		query,
+		endpoint.UserID,
+		endpoint.URL,
+		endpoint.Description,
+		endpoint.Secret,
+		string(eventsJSON),
+		endpoint.IsActive,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	*endpoint = *created
+	return nil
+}
+
+// GetWebhookEndpoint 获取用户的 Webhook 接收地址
+func GetWebhookEndpoint(endpointID, userID string) (*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1 AND user_id = $2`
+
+	endpoint, err := scanWebhookEndpoint(DB.QueryRow(query, endpointID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// GetWebhookEndpointByID 获取 Webhook 接收地址（投递时使用，不校验用户）
+func GetWebhookEndpointByID(endpointID string) (*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1`
+
+	endpoint, err := scanWebhookEndpoint(DB.QueryRow(query, endpointID))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// GetUserWebhookEndpoints 获取用户的全部 Webhook 接收地址
+func GetUserWebhookEndpoints(userID string) ([]*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE user_id = $1 ORDER BY created_at DESC`
+	return queryWebhookEndpoints(query, userID)
+}
+
+// GetSubscribedWebhookEndpoints 获取用户订阅了指定事件的已启用 Webhook 接收地址
+func GetSubscribedWebhookEndpoints(userID, event string) ([]*models.WebhookEndpoint, error) {
+	query := `
+		SELECT ` + webhookEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE user_id = $1 AND is_active = true AND events ? $2
+	`
+	return queryWebhookEndpoints(query, userID, event)
+}
+
+// queryWebhookEndpoints 查询 Webhook 接收地址列表
+func queryWebhookEndpoints(query string, args ...interface{}) ([]*models.WebhookEndpoint, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := make([]*models.WebhookEndpoint, 0)
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+// CountUserWebhookEndpoints 统计用户的 Webhook 接收地址数量
+func CountUserWebhookEndpoints(userID string) (int, error) {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM webhook_endpoints WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook endpoints: %w", err)
+	}
+	return count, nil
+}
+
+// UpdateWebhookEndpoint 更新 Webhook 接收地址（不修改密钥）
+func UpdateWebhookEndpoint(endpoint *models.WebhookEndpoint) error {
+	eventsJSON, err := json.Marshal(endpoint.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $1, description = $2, events = $3, is_active = $4, updated_at = NOW()
+		WHERE id = $5 AND user_id = $6
+		RETURNING ` + webhookEndpointColumns
+
+	updated, err := scanWebhookEndpoint(DB.QueryRow(
+		query,
+		endpoint.URL,
+		endpoint.Description,
+		string(eventsJSON),
+		endpoint.IsActive,
+		endpoint.ID,
+		endpoint.UserID,
+	))
+	if err == sql.ErrNoRows {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	*endpoint = *updated
+	return nil
+}
+
+// DeleteWebhookEndpoint 删除 Webhook 接收地址（投递记录随之删除）
+func DeleteWebhookEndpoint(endpointID, userID string) error {
+	result, err := DB.Exec(`DELETE FROM webhook_endpoints WHERE id = $1 AND user_id = $2`, endpointID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// CreateWebhookDelivery 创建待投递的 Webhook 记录
+func CreateWebhookDelivery(deliveryID, endpointID, event string, payload []byte) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, endpoint_id, event, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, 'pending', NOW())
+	`
+	if _, err := DB.Exec(query, deliveryID, endpointID, event, string(payload)); err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDeliveries 获取 Webhook 接收地址的投递记录（按创建时间倒序）
+func GetWebhookDeliveries(endpointID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := DB.Query(query, endpointID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return collectWebhookDeliveries(rows)
+}
+
+// ClaimDueWebhookDeliveries 领取到期的待投递记录
+// 领取时尝试次数加一并将 next_attempt_at 推迟 leaseDuration，投递进程崩溃时租约到期后会被重新领取
+func ClaimDueWebhookDeliveries(limit int, leaseDuration time.Duration) ([]*models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1,
+		    next_attempt_at = NOW() + ($1 * INTERVAL '1 second'),
+		    updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := DB.Query(query, int(leaseDuration.Seconds()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return collectWebhookDeliveries(rows)
+}
+
+// collectWebhookDeliveries 读取投递记录列表
+func collectWebhookDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordWebhookDeliveryAttempt 记录一次投递尝试的结果
+// status 为 pending 时 nextAttemptAt 为下次重试时间；success 时同时写入 delivered_at
+func RecordWebhookDeliveryAttempt(deliveryID string, status models.WebhookDeliveryStatus, statusCode int, lastError, responseBody string, nextAttemptAt *time.Time) error {
+	var code interface{}
+	if statusCode > 0 {
+		code = statusCode
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, last_status_code = $2, last_error = $3, response_body = $4,
+		    next_attempt_at = $5,
+		    delivered_at = CASE WHEN $1 = 'success' THEN NOW() ELSE delivered_at END,
+		    updated_at = NOW()
+		WHERE id = $6
+	`
+	_, err := DB.Exec(query, string(status), code, nullableString(lastError), nullableString(responseBody), nextAttemptAt, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+// Package fingerprints 内置的技术指纹签名库和 JavaScript 库漏洞库
+// technologies.json 使用 Wappalyzer 格式（categories + technologies），
+// 设置 TECH_SIGNATURES_PATH 后改为从磁盘加载，可以在不重新编译的情况下更新签名；
+// jsrepository.json 使用 retire.js 格式，设置 RETIRE_JS_REPOSITORY 后改为从磁盘加载完整的 retire.js 漏洞库
+package fingerprints
+
+import _ "embed"
+
+// Technologies 内置的技术指纹签名（Wappalyzer JSON）
+//
+//go:embed technologies.json
+var Technologies []byte
+
+// JSRepository 内置的常见 JavaScript 库漏洞库（retire.js jsrepository.json 的子集）
+//
+//go:embed jsrepository.json
+var JSRepository []byte
@@ -17,12 +17,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 	"web-checkly/database"
 	"web-checkly/middleware"
+	"web-checkly/models"
 	"web-checkly/routes"
 	"web-checkly/services"
 	"web-checkly/services/payment"
@@ -54,10 +61,64 @@ func healthCheckHandler(c *fiber.Ctx) error {
 	})
 }
 
+// 运行模式
+const (
+	runModeAll    = "all"    // API 服务 + 任务 worker（默认，单机部署）
+	runModeAPI    = "api"    // 仅 API 服务：只负责创建任务（入队），不执行扫描
+	runModeWorker = "worker" // 仅任务 worker：从数据库队列领取任务并执行插件，可水平扩展多个实例
+)
+
+// resolveRunMode 确定运行模式：命令行参数 -mode 优先，其次环境变量 RUN_MODE，默认 all
+func resolveRunMode(flagValue string) string {
+	mode := strings.ToLower(strings.TrimSpace(flagValue))
+	if mode == "" {
+		mode = strings.ToLower(strings.TrimSpace(os.Getenv("RUN_MODE")))
+	}
+	switch mode {
+	case runModeAPI, runModeWorker, runModeAll:
+		return mode
+	case "":
+		return runModeAll
+	default:
+		log.Fatalf("[Main] Invalid run mode %q (expected: all, api, worker)", mode)
+		return ""
+	}
+}
+
+// positiveIntEnv 读取正整数环境变量，未设置或无效时返回默认值
+func positiveIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("[Main] Invalid %s %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// workerConcurrency 读取单个 worker 进程的最大并发任务数（环境变量 TASK_WORKER_CONCURRENCY，默认 3）
+func workerConcurrency() int {
+	return positiveIntEnv("TASK_WORKER_CONCURRENCY", 3)
+}
+
+// pluginParallelism 读取单个任务内最多同时执行的插件数（环境变量 TASK_PLUGIN_PARALLELISM，默认 4）
+func pluginParallelism() int {
+	return positiveIntEnv("TASK_PLUGIN_PARALLELISM", 4)
+}
+
 func main() {
+	modeFlag := flag.String("mode", "", "run mode: all (default), api or worker")
+	flag.Parse()
+
 	// 优先从本地环境变量文件加载配置（.env.local / .env）
 	utils.LoadEnvFromFile()
 
+	runMode := resolveRunMode(*modeFlag)
+	log.Printf("[Main] Run mode: %s", runMode)
+
 	// 初始化数据库
 	if err := database.InitDB(); err != nil {
 		log.Fatalf("[Main] Failed to initialize database: %v", err)
@@ -73,6 +134,39 @@ func main() {
 		log.Fatalf("[Main] Failed to run migrations: %v", err)
 	}
 
+	// Webhook 投递（投递记录持久化在数据库中，API 和 worker 进程都参与投递）
+	services.StartWebhookDispatcher()
+
+	// 初始化插件系统（所有运行模式都需要：API 进程创建任务和重新执行模块时按插件注册表确定模块，worker 进程执行插件）
+	plugins.InitPlugins()
+
+	// 任务 worker：执行扫描插件，通过数据库队列与 API 实例协作
+	if runMode != runModeAPI {
+		// 验证必需的命令是否可用（非阻塞，只记录警告）
+		if err := services.VerifyCommands(); err != nil {
+			log.Printf("[Main] Warning: Some required commands are not available: %v", err)
+			log.Printf("[Main] Note: This may cause some features to fail. Please ensure katana and lighthouse are installed and in PATH.")
+		} else {
+			log.Printf("[Main] All required commands verified successfully")
+		}
+
+		// 启动任务队列 worker（领取 pending 任务和租约过期的 running 任务）
+		workerCtx, stopWorker := context.WithCancel(context.Background())
+		defer stopWorker()
+		routes.StartTaskWorker(workerCtx, workerConcurrency(), pluginParallelism())
+
+		if runMode == runModeWorker {
+			// 仅 worker 模式：不启动 HTTP 服务，收到退出信号后将执行中的任务放回队列
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			sig := <-quit
+			log.Printf("[Main] Received signal %v, shutting down worker...", sig)
+			stopWorker()
+			routes.StopTaskWorker()
+			return
+		}
+	}
+
 	// 初始化JWT
 	if err := utils.InitJWT(); err != nil {
 		log.Fatalf("[Main] Failed to initialize JWT: %v", err)
@@ -85,14 +179,6 @@ func main() {
 	// 	log.Printf("[Main] Stripe payment initialized successfully")
 	// }
 
-	// 验证必需的命令是否可用（非阻塞，只记录警告）
-	if err := services.VerifyCommands(); err != nil {
-		log.Printf("[Main] Warning: Some required commands are not available: %v", err)
-		log.Printf("[Main] Note: This may cause some features to fail. Please ensure katana and lighthouse are installed and in PATH.")
-	} else {
-		log.Printf("[Main] All required commands verified successfully")
-	}
-
 	// 初始化PayPal（可选，如果未配置则只记录警告）
 	if err := payment.InitPayPal(); err != nil {
 		log.Printf("[Main] Warning: PayPal payment is not configured: %v. PayPal payment features will be disabled.", err)
@@ -102,9 +188,7 @@ func main() {
 
 	// 启动定时任务
 	services.StartScheduler()
-
-	// 初始化插件系统
-	plugins.InitPlugins()
+	routes.StartScanScheduler()
 
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  30 * time.Second,
@@ -193,19 +277,53 @@ func main() {
 	})
 
 	// 任务管理 API 路由
-	// 使用OptionalAuth中间件，支持匿名和已登录用户
-	taskRoutes := app.Group("/api/scans", middleware.OptionalAuth())
+	// 使用OptionalAuth中间件，支持匿名用户、已登录用户和 API 密钥
+	// 使用 API 密钥的请求需要专业版/高级版订阅，按套餐计量，并校验密钥的权限范围
+	taskRoutes := app.Group("/api/scans", middleware.OptionalAuth(), middleware.RequireAPIAccess())
+	scansWrite := middleware.RequireAPIKeyScope(models.APIKeyScopeScansWrite)
+	scansRead := middleware.RequireAPIKeyScope(models.APIKeyScopeScansRead)
+	reportsRead := middleware.RequireAPIKeyScope(models.APIKeyScopeReportsRead)
 	// 任务创建端点使用限流器
-	taskRoutes.Post("/", scanCreateLimiter, routes.CreateTaskHandler)
-	taskRoutes.Get("/:id", routes.GetTaskStatusHandler)
-	taskRoutes.Get("/:id/results", routes.GetTaskResultsHandler)
-	taskRoutes.Get("/:id/stream", routes.StreamTaskHandler) // SSE流式响应端点
+	taskRoutes.Post("/", scansWrite, scanCreateLimiter, routes.CreateTaskHandler)
+	taskRoutes.Get("/:id", scansRead, routes.GetTaskStatusHandler)
+	taskRoutes.Get("/:id/results", scansRead, routes.GetTaskResultsHandler)
+	taskRoutes.Get("/:id/stream", scansRead, routes.StreamTaskHandler)      // SSE流式响应端点
+	taskRoutes.Post("/:id/cancel", scansWrite, routes.CancelTaskHandler)    // 取消任务（所有者或管理员）
+	taskRoutes.Post("/:id/retry", scansWrite, routes.RetryTaskHandler)      // 重新执行失败的模块（所有者或管理员）
+	taskRoutes.Get("/:id/report", reportsRead, routes.GetTaskReportHandler) // 导出 PDF/HTML/SARIF/JUnit 报告
+	taskRoutes.Get("/:id/diff", scansRead, routes.DiffTaskHandler)          // 与同一URL的上一次扫描对比
+	taskRoutes.Get("/:id/diff/:otherId", scansRead, routes.DiffTaskHandler) // 与指定扫描对比
+
+	// 批量扫描（需要登录或 API 密钥，子任务与单个任务一样通过 /api/scans 查询）
+	batchRoutes := app.Group("/api/batches", middleware.OptionalAuth(), middleware.RequireAPIAccess())
+	batchRoutes.Post("/", scansWrite, scanCreateLimiter, routes.CreateBatchHandler)
+	batchRoutes.Get("/", scansRead, routes.GetBatchesHandler)
+	batchRoutes.Get("/:id", scansRead, routes.GetBatchHandler)
+	batchRoutes.Get("/:id/export", reportsRead, routes.ExportBatchHandler) // 导出合并结果（CSV/JSON）
 
 	// 用户任务列表（需要认证，已移除限流）
 	userTaskRoutes := app.Group("/api/tasks", middleware.RequireAuth())
 	userTaskRoutes.Get("/", routes.GetUserTasksHandler)
 	userTaskRoutes.Delete("/:id", routes.DeleteUserTaskHandler)
 
+	// 定时扫描计划（需要认证）
+	scheduleRoutes := app.Group("/api/schedules", middleware.RequireAuth())
+	scheduleRoutes.Get("/", routes.GetSchedulesHandler)
+	scheduleRoutes.Post("/", routes.CreateScheduleHandler)
+	scheduleRoutes.Get("/:id", routes.GetScheduleHandler)
+	scheduleRoutes.Put("/:id", routes.UpdateScheduleHandler)
+	scheduleRoutes.Delete("/:id", routes.DeleteScheduleHandler)
+
+	// Webhook（需要认证）
+	webhookRoutes := app.Group("/api/webhooks", middleware.RequireAuth())
+	webhookRoutes.Get("/", routes.GetWebhooksHandler)
+	webhookRoutes.Post("/", routes.CreateWebhookHandler)
+	webhookRoutes.Get("/:id", routes.GetWebhookHandler)
+	webhookRoutes.Put("/:id", routes.UpdateWebhookHandler)
+	webhookRoutes.Delete("/:id", routes.DeleteWebhookHandler)
+	webhookRoutes.Get("/:id/deliveries", routes.GetWebhookDeliveriesHandler)
+	webhookRoutes.Post("/:id/test", routes.TestWebhookHandler)
+
 	// 付费系统路由（需要认证）
 	paymentRoutes := app.Group("/api/payment", middleware.RequireAuth())
 	paymentRoutes.Post("/create-checkout", routes.CreateCheckoutHandler)
@@ -288,6 +406,12 @@ func main() {
 	apiAccessStatsRoutes.Get("/stats", routes.GetAPIAccessStatsHandler)
 	apiAccessStatsRoutes.Get("/records", routes.GetAPIAccessRecordsHandler)
 
+	// API 密钥管理（需要登录，不能使用 API 密钥本身管理）
+	apiKeyRoutes := app.Group("/api/api-keys", middleware.RequireAuth())
+	apiKeyRoutes.Get("/", routes.GetAPIKeysHandler)
+	apiKeyRoutes.Post("/", routes.CreateAPIKeyHandler)
+	apiKeyRoutes.Delete("/:id", routes.RevokeAPIKeyHandler)
+
 	// Swagger文档路由
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
@@ -10,9 +10,14 @@ import (
 )
 
 // RequireAPIAccess 要求API访问权限的中间件
-// 只有专业版和高级版用户可以访问API
+// 只有专业版和高级版用户可以访问API，并按套餐的月度 API 调用次数限制计量
+// 只对使用 API 密钥的请求生效（网页端使用 JWT 或匿名访问，不计入 API 调用），须在 OptionalAuth() 之后使用
 func RequireAPIAccess() fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if GetAPIKey(c) == nil {
+			return c.Next()
+		}
+
 		userID := GetUserID(c)
 		if userID == nil {
 			return c.Status(401).JSON(fiber.Map{
@@ -46,14 +51,15 @@ func RequireAPIAccess() fiber.Handler {
 		}
 
 		// 记录API访问（异步，不阻塞请求）
+		// 请求信息须在启动 goroutine 前复制：请求结束后 fiber 会复用 Ctx
+		endpoint := c.Path()
+		method := c.Method()
+		ipAddress := c.IP()
+		if forwarded := c.Get("X-Forwarded-For"); forwarded != "" {
+			ipAddress = forwarded
+		}
+		userAgent := c.Get("User-Agent")
 		go func() {
-			endpoint := c.Path()
-			method := c.Method()
-			ipAddress := c.IP()
-			if forwarded := c.Get("X-Forwarded-For"); forwarded != "" {
-				ipAddress = forwarded
-			}
-			userAgent := c.Get("User-Agent")
 			var ipPtr *string
 			var userAgentPtr *string
 			if ipAddress != "" {
@@ -73,6 +79,21 @@ func RequireAPIAccess() fiber.Handler {
 	}
 }
 
+// RequireAPIKeyScope 要求 API 密钥拥有指定权限
+// 使用 JWT 或匿名访问的请求不受影响，须在 OptionalAuth() 之后使用
+func RequireAPIKeyScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := GetAPIKey(c)
+		if key != nil && !key.HasScope(scope) {
+			return c.Status(403).JSON(fiber.Map{
+				"error":   "Insufficient scope",
+				"details": fmt.Sprintf("This API key does not have the %s scope.", scope),
+			})
+		}
+		return c.Next()
+	}
+}
+
 // RecordAPIAccessResponse 记录API访问响应（在响应发送后调用）
 func RecordAPIAccessResponse(c *fiber.Ctx, statusCode int, responseTime time.Duration) {
 	userID := GetUserID(c)
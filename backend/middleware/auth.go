@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"errors"
+	"log"
 	"strings"
+	"web-checkly/models"
 	"web-checkly/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,6 +21,14 @@ func RequireAuth() fiber.Handler {
 			})
 		}
 
+		// API 密钥只能访问 /api/scans（避免密钥被用来管理账户、密钥或 Webhook）
+		if services.IsAPIKey(token) {
+			return c.Status(401).JSON(fiber.Map{
+				"error":   "Invalid token",
+				"details": "API keys can only be used with /api/scans and /api/batches endpoints.",
+			})
+		}
+
 		authService := services.NewAuthService()
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
@@ -34,7 +45,7 @@ func RequireAuth() fiber.Handler {
 	}
 }
 
-// OptionalAuth 可选认证中间件（支持匿名和已登录用户）
+// OptionalAuth 可选认证中间件（支持匿名用户、已登录用户和 API 密钥）
 func OptionalAuth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		token, err := extractToken(c)
@@ -43,6 +54,28 @@ func OptionalAuth() fiber.Handler {
 			return c.Next()
 		}
 
+		// API 密钥无效时直接拒绝，而不是降级为匿名用户（避免自动化脚本在密钥失效后静默创建匿名任务）
+		if services.IsAPIKey(token) {
+			key, user, err := services.AuthenticateAPIKey(token)
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				return c.Status(401).JSON(fiber.Map{
+					"error":   "Invalid API key",
+					"details": err.Error(),
+				})
+			}
+			if err != nil {
+				log.Printf("[OptionalAuth] Error authenticating API key: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"error": "Internal server error",
+				})
+			}
+
+			c.Locals("userID", user.ID)
+			c.Locals("userEmail", user.Email)
+			c.Locals("apiKey", key)
+			return c.Next()
+		}
+
 		authService := services.NewAuthService()
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
@@ -67,6 +100,15 @@ func GetUserID(c *fiber.Ctx) *uuid.UUID {
 	return &userID
 }
 
+// GetAPIKey 从context获取本次请求使用的 API 密钥（使用 JWT 或匿名访问时为 nil）
+func GetAPIKey(c *fiber.Ctx) *models.APIKey {
+	key, ok := c.Locals("apiKey").(*models.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
 // GetUserEmail 从context获取用户邮箱
 func GetUserEmail(c *fiber.Ctx) string {
 	email, ok := c.Locals("userEmail").(string)
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tasks_lease_expires_at;
+DROP INDEX IF EXISTS idx_tasks_queue;
+
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS attempts,
+DROP COLUMN IF EXISTS heartbeat_at,
+DROP COLUMN IF EXISTS lease_expires_at,
+DROP COLUMN IF EXISTS lease_owner;
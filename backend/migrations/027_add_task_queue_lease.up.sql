@@ -0,0 +1,17 @@
+-- 为 tasks 表添加任务队列租约字段（持久化队列，支持进程重启后恢复任务）
+-- lease_owner: 当前持有任务的 worker 标识
+-- lease_expires_at: 租约过期时间（可见性超时），过期后其他 worker 可重新领取
+-- heartbeat_at: 最近一次心跳时间
+-- attempts: 任务被领取执行的次数
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS lease_owner VARCHAR(255),
+ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+
+-- 队列领取索引（只索引待执行和执行中的任务）
+CREATE INDEX IF NOT EXISTS idx_tasks_queue ON tasks(created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_tasks_lease_expires_at ON tasks(lease_expires_at) WHERE status = 'running';
+
+-- 历史遗留的 running 任务没有租约，视为已过期，交由队列重新领取
+UPDATE tasks SET lease_expires_at = NOW() WHERE status = 'running' AND lease_expires_at IS NULL;
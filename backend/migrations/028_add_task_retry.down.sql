@@ -0,0 +1,6 @@
+ALTER TABLE usage_records
+DROP COLUMN IF EXISTS deducted_at;
+
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS retry_usage_records,
+DROP COLUMN IF EXISTS retry_modules;
@@ -0,0 +1,19 @@
+-- 支持只重新执行任务中失败或指定的模块
+-- retry_modules: 本次重新执行的模块（扫描选项），NULL 表示完整执行
+-- retry_usage_records: 重新执行时预扣费产生的使用记录（功能代码 -> 使用记录ID），失败或取消时据此退款
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS retry_modules JSONB,
+ADD COLUMN IF NOT EXISTS retry_usage_records JSONB;
+
+-- 使用记录的扣费时间：NULL 表示尚未扣除积分（任务完成时扣除），避免重复扣费
+ALTER TABLE usage_records
+ADD COLUMN IF NOT EXISTS deducted_at TIMESTAMP WITH TIME ZONE;
+
+-- 已完成任务的使用记录在任务完成时已扣除积分
+UPDATE usage_records ur
+SET deducted_at = COALESCE(t.completed_at, ur.created_at)
+FROM tasks t
+WHERE ur.task_id = t.id
+  AND t.status = 'completed'
+  AND ur.is_refunded = false
+  AND ur.deducted_at IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_schedule_id;
+
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS scan_schedules;
@@ -0,0 +1,29 @@
+-- 创建 scan_schedules 表（用户定时扫描计划）
+CREATE TABLE IF NOT EXISTS scan_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    target_url TEXT NOT NULL,
+    options JSONB NOT NULL DEFAULT '[]',
+    language VARCHAR(10) NOT NULL DEFAULT 'zh',
+    ai_mode VARCHAR(50) NOT NULL DEFAULT 'balanced',
+    cron_expression VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused')),
+    paused_reason TEXT,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_task_id UUID REFERENCES tasks(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_scan_schedules_user_id ON scan_schedules(user_id);
+CREATE INDEX IF NOT EXISTS idx_scan_schedules_due ON scan_schedules(next_run_at) WHERE status = 'active';
+
+-- 任务关联的定时扫描计划（手动创建的任务为 NULL）
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS schedule_id UUID REFERENCES scan_schedules(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_schedule_id ON tasks(schedule_id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS verdict,
+DROP COLUMN IF EXISTS policy;
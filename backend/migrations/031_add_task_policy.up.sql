@@ -0,0 +1,4 @@
+-- 扫描门禁：创建任务时可指定阈值策略（policy），任务结束时计算通过/未通过结论（verdict），供 CI 流水线判断
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS policy JSONB,
+ADD COLUMN IF NOT EXISTS verdict JSONB;
@@ -0,0 +1,20 @@
+-- 创建 scan_batches 表（一次提交多个URL的批量扫描）
+CREATE TABLE IF NOT EXISTS scan_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    options JSONB NOT NULL DEFAULT '[]',
+    language VARCHAR(10) NOT NULL DEFAULT 'zh',
+    ai_mode VARCHAR(50) NOT NULL DEFAULT 'balanced',
+    policy JSONB,
+    total_tasks INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scan_batches_user_id ON scan_batches(user_id, created_at DESC);
+
+-- 任务所属的批量扫描（单独创建的任务为 NULL）
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS batch_id UUID REFERENCES scan_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_batch_id ON tasks(batch_id);
@@ -0,0 +1 @@
+DELETE FROM feature_pricing WHERE feature_code = 'sitemap-audit';
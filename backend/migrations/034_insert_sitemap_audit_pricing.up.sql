@@ -0,0 +1,5 @@
+-- 插入多页面审计（sitemap-audit）功能定价
+-- 每次按 sitemap 抽样最多 PAGE_AUDIT_MAX_PAGES 个页面运行 Lighthouse
+INSERT INTO feature_pricing (feature_code, feature_name, feature_category, single_price, single_price_usd, credits_cost, is_premium, is_available) VALUES
+('sitemap-audit', '多页面审计', 'premium', 20.00, 2.80, 20, true, true)
+ON CONFLICT (feature_code) DO NOTHING;
@@ -0,0 +1,3 @@
+-- 全站链接检查的爬取配置：创建任务时可指定爬取范围（include/exclude 正则）、页面/深度/时间预算、请求间隔和 robots.txt 策略
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS crawl_config JSONB;
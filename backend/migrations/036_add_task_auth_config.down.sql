@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS auth_config;
@@ -0,0 +1,3 @@
+-- 认证扫描配置：请求头、Cookie、Basic 认证和表单登录凭据，以 AES-GCM 加密后的文本存储（密钥为 SCAN_AUTH_ENCRYPTION_KEY）
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS auth_config TEXT;
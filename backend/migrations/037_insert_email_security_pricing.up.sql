@@ -0,0 +1,5 @@
+-- 插入邮件安全检测（email-security）功能定价
+-- 只做 DNS 查询和 MTA-STS 策略文件读取，作为基础功能免费提供
+INSERT INTO feature_pricing (feature_code, feature_name, feature_category, single_price, single_price_usd, credits_cost, is_premium, is_available) VALUES
+('email-security', '邮件安全检测', 'basic', 0.00, 0.00, 0, false, true)
+ON CONFLICT (feature_code) DO NOTHING;
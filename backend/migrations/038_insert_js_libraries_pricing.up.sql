@@ -0,0 +1,5 @@
+-- 插入已知漏洞 JavaScript 库检测（js-libraries）功能定价
+-- 只下载页面引用的脚本并与本地漏洞库比对，作为基础功能免费提供
+INSERT INTO feature_pricing (feature_code, feature_name, feature_category, single_price, single_price_usd, credits_cost, is_premium, is_available) VALUES
+('js-libraries', '已知漏洞JS库检测', 'basic', 0.00, 0.00, 0, false, true)
+ON CONFLICT (feature_code) DO NOTHING;
@@ -0,0 +1,5 @@
+-- 插入 Cookie 安全审计（cookies）功能定价
+-- 只请求目标页面和爬虫发现的同站页面并检查响应的 Set-Cookie，作为基础功能免费提供
+INSERT INTO feature_pricing (feature_code, feature_name, feature_category, single_price, single_price_usd, credits_cost, is_premium, is_available) VALUES
+('cookies', 'Cookie安全审计', 'basic', 0.00, 0.00, 0, false, true)
+ON CONFLICT (feature_code) DO NOTHING;
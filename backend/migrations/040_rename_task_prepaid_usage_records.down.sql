@@ -0,0 +1,6 @@
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'tasks' AND column_name = 'prepaid_usage_records') THEN
+        ALTER TABLE tasks RENAME COLUMN prepaid_usage_records TO retry_usage_records;
+    END IF;
+END $$;
@@ -0,0 +1,10 @@
+-- retry_usage_records 同时保存批量扫描创建任务时和重新执行模块时预扣费的使用记录，重命名为 prepaid_usage_records
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'tasks' AND column_name = 'retry_usage_records') THEN
+        ALTER TABLE tasks RENAME COLUMN retry_usage_records TO prepaid_usage_records;
+    END IF;
+END $$;
+
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS prepaid_usage_records JSONB;
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// APIKeyPrefix API 密钥前缀（便于识别和密钥扫描工具检测）
+const APIKeyPrefix = "wck_"
+
+// API 密钥权限范围
+const (
+	APIKeyScopeScansWrite  = "scans:write"  // 创建、取消、重试扫描任务
+	APIKeyScopeScansRead   = "scans:read"   // 查询任务状态、结果和对比
+	APIKeyScopeReportsRead = "reports:read" // 导出报告（PDF/HTML/SARIF/JUnit）
+)
+
+// APIKeyScopes 全部权限范围
+var APIKeyScopes = []string{
+	APIKeyScopeScansWrite,
+	APIKeyScopeScansRead,
+	APIKeyScopeReportsRead,
+}
+
+// APIKey 用户的 API 密钥
+// @Description 长期有效、可吊销的 API 密钥，以 Authorization: Bearer wck_... 调用 /api/scans 和 /api/batches，服务端只保存哈希
+type APIKey struct {
+	ID         string     `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name" example:"GitHub Actions"`                        // 名称
+	Prefix     string     `json:"prefix" example:"wck_3f9a1c2b"`                        // 密钥前缀（用于识别密钥）
+	Key        string     `json:"key,omitempty" example:"wck_3f9a1c2b..."`              // 完整密钥（仅创建时返回）
+	Scopes     []string   `json:"scopes" example:"scans:write,scans:read,reports:read"` // 权限范围
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`                                 // 过期时间（为空表示不过期）
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`                               // 最近使用时间
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`                                 // 吊销时间
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope 是否拥有指定权限
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest 创建 API 密钥请求
+// @Description scopes 为空时授予全部权限，expires_in_days 为空或 0 表示不过期
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" example:"GitHub Actions"`
+	Scopes        []string `json:"scopes" example:"scans:write,scans:read,reports:read"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty" example:"90"`
+}
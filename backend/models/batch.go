@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// 批量扫描状态（根据子任务状态计算）
+const (
+	BatchStatusRunning  = "running"  // 仍有子任务等待或正在执行
+	BatchStatusFinished = "finished" // 所有子任务都已结束
+)
+
+// ScanBatch 批量扫描
+// @Description 一次提交多个URL的批量扫描，每个URL对应一个子任务，积分在创建时一次性预扣
+type ScanBatch struct {
+	ID         string         `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID     string         `json:"user_id"`
+	Name       string         `json:"name" example:"客户网站月度巡检"`                    // 名称
+	Options    []string       `json:"options" example:"website-info,link-health"` // 所有子任务共用的扫描选项
+	Language   string         `json:"language" example:"zh"`                      // 语言 (zh/en)
+	AIMode     string         `json:"ai_mode" example:"balanced"`                 // AI分析模式
+	Policy     *ScanPolicy    `json:"policy,omitempty"`                           // 所有子任务共用的门禁策略
+	TotalTasks int            `json:"total_tasks" example:"300"`                  // 子任务数
+	CreatedAt  time.Time      `json:"created_at"`
+	Progress   *BatchProgress `json:"progress,omitempty"` // 汇总进度
+	Tasks      []*BatchTask   `json:"tasks,omitempty"`    // 子任务（仅详情接口返回）
+}
+
+// BatchProgress 批量扫描汇总进度
+type BatchProgress struct {
+	Status        string `json:"status" example:"running" enums:"running,finished"` // 批量扫描状态
+	Total         int    `json:"total" example:"300"`                               // 子任务数
+	Pending       int    `json:"pending" example:"200"`                             // 等待执行
+	Running       int    `json:"running" example:"4"`                               // 正在执行
+	Completed     int    `json:"completed" example:"90"`                            // 已完成
+	Failed        int    `json:"failed" example:"5"`                                // 失败
+	Canceled      int    `json:"canceled" example:"1"`                              // 已取消
+	Percent       int    `json:"percent" example:"32"`                              // 已结束子任务的百分比
+	VerdictPassed int    `json:"verdict_passed" example:"80"`                       // 门禁结论为通过的子任务数
+	VerdictFailed int    `json:"verdict_failed" example:"15"`                       // 门禁结论为未通过的子任务数
+}
+
+// BatchTask 批量扫描中的子任务摘要
+type BatchTask struct {
+	ID          string       `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	TargetURL   string       `json:"target_url" example:"https://example.com"`
+	Status      TaskStatus   `json:"status" example:"completed"`
+	Progress    TaskProgress `json:"progress"`
+	Error       string       `json:"error,omitempty"`
+	Passed      *bool        `json:"passed,omitempty"` // 门禁结论（任务结束后才有）
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+}
+
+// CreateBatchRequest 创建批量扫描请求
+// @Description 提交多个URL，使用相同的扫描选项；也可以用 multipart/form-data 上传 CSV 文件（字段 file，每行第一列为URL）
+type CreateBatchRequest struct {
+	Name     string      `json:"name" example:"客户网站月度巡检"`                                                                 // 名称（可选）
+	URLs     []string    `json:"urls" example:"https://example.com,https://example.org"`                                  // 目标URL列表
+	Options  []string    `json:"options" example:"website-info,link-health"`                                              // 扫描选项
+	Language string      `json:"language" example:"zh" enums:"zh,en" default:"zh"`                                        // 语言 (zh/en)
+	AIMode   string      `json:"ai_mode" example:"balanced" enums:"performance,security,seo,balanced" default:"balanced"` // AI分析模式
+	Policy   *ScanPolicy `json:"policy,omitempty"`                                                                        // 门禁策略（可选）
+}
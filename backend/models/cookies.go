@@ -0,0 +1,54 @@
+package models
+
+// Cookie 的来源
+const (
+	CookieSourceHeader = "header" // 响应的 Set-Cookie
+	CookieSourceScript = "script" // 根据页面引用的跟踪脚本推断（由 JavaScript 设置，属性未知）
+)
+
+// 跟踪 Cookie 的分类
+const (
+	TrackerCategoryAnalytics   = "analytics"
+	TrackerCategoryAdvertising = "advertising"
+)
+
+// CookieAuditResult Cookie 安全审计结果
+// @Description 收集目标页面和爬虫发现的同站页面（含重定向）设置的 Cookie，检查 Secure、HttpOnly、SameSite、
+// @Description Domain/Path 范围、会话 Cookie 有效期和 __Host-/__Secure- 前缀，并识别已知的第三方跟踪 Cookie
+type CookieAuditResult struct {
+	PagesChecked int             `json:"pages_checked" example:"12"` // 检查的页面数
+	Cookies      []CookieInfo    `json:"cookies"`                    // 发现的 Cookie（按名称、Domain、Path 去重）
+	Trackers     int             `json:"trackers" example:"3"`       // 跟踪 Cookie 数量
+	Findings     []CookieFinding `json:"findings"`                   // 发现的问题（严重程度高的在前）
+}
+
+// CookieInfo 网站设置的一个 Cookie
+type CookieInfo struct {
+	Name              string         `json:"name" example:"PHPSESSID"`
+	Domain            string         `json:"domain,omitempty" example:"example.com"`                    // Domain 属性（为空时只发送给设置它的主机）
+	Path              string         `json:"path,omitempty" example:"/"`                                // Path 属性
+	SetBy             string         `json:"set_by" example:"https://example.com/login"`                // 第一次设置该 Cookie 的地址（脚本推断时为引用脚本的页面）
+	Source            string         `json:"source" example:"header" enums:"header,script"`             // 来源
+	Secure            bool           `json:"secure"`                                                    // Secure 属性
+	HttpOnly          bool           `json:"http_only"`                                                 // HttpOnly 属性
+	SameSite          string         `json:"same_site,omitempty" example:"Lax" enums:"Strict,Lax,None"` // SameSite 属性（没有设置时为空）
+	Persistent        bool           `json:"persistent"`                                                // 设置了 Expires 或 Max-Age（浏览器关闭后仍保留）
+	MaxAge            int64          `json:"max_age,omitempty" example:"86400"`                         // 剩余有效期（秒，持久 Cookie）
+	SessionIdentifier bool           `json:"session_identifier,omitempty"`                              // 名称看起来是会话或认证 Cookie（如 PHPSESSID、JSESSIONID）
+	ThirdParty        bool           `json:"third_party,omitempty"`                                     // 由其他网站设置（重定向到其他网站时）
+	Tracker           *CookieTracker `json:"tracker,omitempty"`                                         // 已知的跟踪 Cookie
+}
+
+// CookieTracker 跟踪 Cookie 所属的服务
+type CookieTracker struct {
+	Vendor   string `json:"vendor" example:"Google Analytics"`
+	Category string `json:"category" example:"analytics" enums:"analytics,advertising"`
+}
+
+// CookieFinding Cookie 审计发现的问题
+type CookieFinding struct {
+	Cookie   string `json:"cookie" example:"PHPSESSID"`                                    // Cookie 名称（跟踪服务的发现为服务名称）
+	ID       string `json:"id" example:"cookie_no_httponly"`                               // 问题标识（稳定，可用于前端本地化）
+	Severity string `json:"severity" example:"high" enums:"critical,high,medium,low,info"` // 严重程度
+	Message  string `json:"message" example:"会话 Cookie 没有设置 HttpOnly，XSS 可以读取并窃取会话"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// CrawlConfig 全站链接检查（katana 模块）的爬取配置
+// @Description 所有字段可选，未设置时使用默认值。include/exclude 为正则表达式，匹配完整URL：
+// @Description 设置了 include 时只请求匹配任一 include 的URL，匹配任一 exclude 的URL不会被请求。
+// @Description 只有进程内爬虫支持全部配置，使用 katana 后端（CRAWLER_BACKEND=katana）时只有 max_depth 生效
+type CrawlConfig struct {
+	Include      []string `json:"include,omitempty" example:"^https://example\\.com/docs/"` // 爬取范围（正则）
+	Exclude      []string `json:"exclude,omitempty" example:"/logout,\\?sort="`             // 排除范围（正则）
+	MaxPages     int      `json:"max_pages,omitempty" example:"200"`                        // 最多爬取（下载并解析链接）的页面数，默认 200，最大 1000
+	MaxDepth     int      `json:"max_depth,omitempty" example:"3"`                          // 最大深度（1 表示只爬取目标页面），默认 3，最大 10
+	MaxDuration  int      `json:"max_duration,omitempty" example:"90"`                      // 爬取时间上限（秒），默认 90，最大 110
+	DelayMs      int      `json:"delay_ms,omitempty" example:"200"`                         // 同一主机相邻两次请求的最小间隔（毫秒），默认 200
+	IgnoreRobots bool     `json:"ignore_robots,omitempty" example:"false"`                  // 是否忽略 robots.txt（默认遵守）
+	SkipSitemap  bool     `json:"skip_sitemap,omitempty" example:"false"`                   // 是否不使用 sitemap 中的页面作为起始页面
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// ScanDiff 两次扫描结果的对比
+// @Description 当前扫描相对基准扫描的变化（新增/修复的失效链接、安全响应头、评分、证书和技术栈变化）
+type ScanDiff struct {
+	TaskID         string    `json:"task_id" example:"550e8400-e29b-41d4-a716-446655440000"`      // 当前扫描任务ID
+	BaseTaskID     string    `json:"base_task_id" example:"6ba7b810-9dad-11d1-80b4-00c04fd430c8"` // 基准扫描任务ID
+	TargetURL      string    `json:"target_url" example:"https://example.com"`                    // 当前扫描目标URL
+	BaseTargetURL  string    `json:"base_target_url" example:"https://example.com"`               // 基准扫描目标URL
+	CreatedAt      time.Time `json:"created_at"`                                                  // 当前扫描创建时间
+	BaseCreatedAt  time.Time `json:"base_created_at"`                                             // 基准扫描创建时间
+	HasRegression  bool      `json:"has_regression" example:"true"`                               // 是否存在变差的项
+	Regressions    []string  `json:"regressions" example:"2 new broken links"`                    // 变差项的说明
+	Improvements   []string  `json:"improvements" example:"performance score improved 72 -> 85"`  // 改善项的说明
+	ComparedScopes []string  `json:"compared_scopes" example:"links,scores"`                      // 两次扫描都包含、因而参与对比的部分
+
+	Links           *LinkDiff        `json:"links,omitempty"`            // 链接健康变化
+	SecurityHeaders []HeaderChange   `json:"security_headers,omitempty"` // 安全响应头变化
+	Scores          []ScoreDelta     `json:"scores,omitempty"`           // Lighthouse 评分和指标变化
+	Certificate     *CertificateDiff `json:"certificate,omitempty"`      // SSL 证书变化
+	Technologies    *TechnologyDiff  `json:"technologies,omitempty"`     // 技术栈变化
+}
+
+// LinkDiff 链接健康变化
+// @Description 新出现和已修复的失效链接（状态码为 0 或 >= 400）
+type LinkDiff struct {
+	NewBroken   []HttpxResult `json:"new_broken"`                    // 新出现的失效链接
+	Fixed       []HttpxResult `json:"fixed"`                         // 已修复的链接（基准扫描中失效，当前扫描中可用）
+	BrokenCount int           `json:"broken_count" example:"3"`      // 当前扫描的失效链接数
+	BaseBroken  int           `json:"base_broken_count" example:"1"` // 基准扫描的失效链接数
+}
+
+// HeaderChange 安全响应头变化
+// @Description 单个安全响应头的变化
+type HeaderChange struct {
+	Header string `json:"header" example:"Content-Security-Policy"`               // 响应头名称
+	Change string `json:"change" example:"removed" enums:"added,removed,changed"` // 变化类型
+	Before string `json:"before,omitempty" example:"default-src 'self'"`          // 基准扫描中的值
+	After  string `json:"after,omitempty" example:""`                             // 当前扫描中的值
+}
+
+// ScoreDelta 评分或指标变化
+// @Description Lighthouse 评分（越高越好）或指标（越低越好）的变化
+type ScoreDelta struct {
+	Metric        string  `json:"metric" example:"performance"`    // 评分或指标名称
+	Before        float64 `json:"before" example:"92"`             // 基准扫描中的值
+	After         float64 `json:"after" example:"78"`              // 当前扫描中的值
+	Delta         float64 `json:"delta" example:"-14"`             // 变化量（after - before）
+	Regression    bool    `json:"regression" example:"true"`       // 是否变差超过阈值
+	LowerIsBetter bool    `json:"lower_is_better" example:"false"` // 是否为越低越好的指标（如 LCP）
+}
+
+// CertificateDiff SSL 证书变化
+// @Description 证书字段变化（不含每天都会变化的剩余天数）
+type CertificateDiff struct {
+	Changed bool          `json:"changed" example:"true"` // 证书是否发生变化
+	Changes []FieldChange `json:"changes,omitempty"`      // 变化的字段
+}
+
+// FieldChange 字段变化
+type FieldChange struct {
+	Field  string `json:"field" example:"issuer"`
+	Before string `json:"before" example:"CN=R3"`
+	After  string `json:"after" example:"CN=R10"`
+}
+
+// TechnologyDiff 技术栈变化
+// @Description 新检测到和不再检测到的技术
+type TechnologyDiff struct {
+	Added   []string `json:"added" example:"React"`    // 新检测到的技术
+	Removed []string `json:"removed" example:"jQuery"` // 不再检测到的技术
+}
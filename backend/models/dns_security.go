@@ -0,0 +1,69 @@
+package models
+
+// 域名 DNS 安全检查项
+const (
+	DNSCheckDNSSEC = "dnssec"
+	DNSCheckCAA    = "caa"
+	DNSCheckCNAME  = "cname"
+	DNSCheckNS     = "ns"
+)
+
+// DNSFinding 域名 DNS 安全检查发现的问题
+type DNSFinding struct {
+	Check    string `json:"check" example:"dnssec" enums:"dnssec,caa,cname,ns"`
+	ID       string `json:"id" example:"dnssec_ds_mismatch"`                                   // 问题标识（稳定，可用于前端本地化）
+	Severity string `json:"severity" example:"critical" enums:"critical,high,medium,low,info"` // 严重程度
+	Message  string `json:"message" example:"父区域的 DS 记录与区域的 DNSKEY 不匹配，启用验证的解析器将无法解析该域名"`
+}
+
+// DNSSECInfo DNSSEC 检查结果
+// @Description 从递归解析器获取（CD 标志，不依赖解析器验证）DS、DNSKEY 和 RRSIG，
+// @Description 自行验证 DS -> DNSKEY -> 记录签名的链接关系；上级区域到根的信任链由解析器的 AD 标志反映
+type DNSSECInfo struct {
+	Zone              string   `json:"zone" example:"example.com"`                                 // 目标主机名所在的 DNS 区域
+	Signed            bool     `json:"signed" example:"true"`                                      // 区域发布了 DNSKEY
+	DSPresent         bool     `json:"ds_present" example:"true"`                                  // 父区域发布了 DS
+	Valid             bool     `json:"valid" example:"true"`                                       // DS -> DNSKEY -> 记录签名验证通过
+	ResolverValidated bool     `json:"resolver_validated" example:"true"`                          // 递归解析器返回了 AD（已验证）标志
+	Algorithms        []string `json:"algorithms,omitempty" example:"ECDSAP256SHA256"`             // DNSKEY 算法
+	DigestTypes       []string `json:"digest_types,omitempty" example:"SHA-256"`                   // DS 摘要算法
+	KeyTags           []int    `json:"key_tags,omitempty" example:"2371"`                          // 与 DS 匹配的 KSK 的 key tag
+	SignatureExpires  string   `json:"signature_expires,omitempty" example:"2024-02-01T00:00:00Z"` // 已验证签名中最早的过期时间
+	Errors            []string `json:"errors,omitempty"`                                           // 验证失败的原因
+}
+
+// CAAInfo CAA 记录检查结果
+type CAAInfo struct {
+	Domain            string      `json:"domain,omitempty" example:"example.com"` // CAA 记录所在的域名（从主机名逐级向上查找到的第一个）
+	Records           []CAARecord `json:"records,omitempty"`
+	Issuers           []string    `json:"issuers,omitempty" example:"letsencrypt.org"` // issue 允许的 CA（空字符串表示禁止签发）
+	WildcardIssuers   []string    `json:"wildcard_issuers,omitempty"`                  // issuewild 允许的 CA
+	IODEF             []string    `json:"iodef,omitempty" example:"mailto:security@example.com"`
+	CertificateIssuer string      `json:"certificate_issuer,omitempty" example:"CN=R3,O=Let's Encrypt,C=US"` // 当前证书的颁发者（来自 ssl-info）
+	IssuerAllowed     *bool       `json:"issuer_allowed,omitempty"`                                          // 当前证书的颁发者是否被 CAA 允许（无法判断时为空）
+}
+
+// CAARecord CAA 记录
+type CAARecord struct {
+	Flags int    `json:"flags" example:"0"`
+	Tag   string `json:"tag" example:"issue"`
+	Value string `json:"value" example:"letsencrypt.org"`
+}
+
+// NSConsistency 权威域名服务器一致性检查结果
+type NSConsistency struct {
+	Zone       string     `json:"zone" example:"example.com"`
+	Delegation []string   `json:"delegation,omitempty" example:"ns1.example.com"` // 父区域委派的 NS
+	ZoneNS     []string   `json:"zone_ns,omitempty" example:"ns1.example.com"`    // 区域自身（权威服务器）声明的 NS
+	Servers    []NSServer `json:"servers,omitempty"`
+	Consistent bool       `json:"consistent" example:"true"` // 委派与区域一致、所有服务器权威应答且 SOA 序列号相同
+}
+
+// NSServer 单个权威服务器的检查结果
+type NSServer struct {
+	Host          string   `json:"host" example:"ns1.example.com"`
+	IPs           []string `json:"ips,omitempty" example:"192.0.2.53"`
+	Authoritative bool     `json:"authoritative" example:"true"` // 对区域返回了权威应答（AA）
+	Serial        uint32   `json:"serial,omitempty" example:"2024010101"`
+	Error         string   `json:"error,omitempty"`
+}
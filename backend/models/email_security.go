@@ -0,0 +1,104 @@
+package models
+
+// 邮件安全检查项
+const (
+	EmailCheckMX     = "mx"
+	EmailCheckSPF    = "spf"
+	EmailCheckDMARC  = "dmarc"
+	EmailCheckDKIM   = "dkim"
+	EmailCheckMTASTS = "mta-sts"
+	EmailCheckTLSRPT = "tls-rpt"
+	EmailCheckBIMI   = "bimi"
+)
+
+// 发现的严重程度（从高到低）
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+// EmailSecurityResult 邮件安全检测结果
+// @Description 解析并校验域名的 SPF、DMARC、DKIM（常见选择器）、MTA-STS、TLS-RPT 和 BIMI 记录，
+// @Description 按发现的严重程度计算 0-100 评分和 A-F 等级
+type EmailSecurityResult struct {
+	Domain   string                 `json:"domain" example:"example.com"` // 检测的邮件域名（目标主机名去掉 www.）
+	Score    int                    `json:"score" example:"75"`           // 评分 (0-100)
+	Grade    string                 `json:"grade" example:"C" enums:"A,B,C,D,F"`
+	MX       []string               `json:"mx,omitempty" example:"mx1.example.com"` // MX 主机（按优先级排序）
+	NullMX   bool                   `json:"null_mx,omitempty"`                      // 声明了不接收邮件（RFC 7505 空 MX）
+	SPF      *SPFRecord             `json:"spf,omitempty"`
+	DMARC    *DMARCRecord           `json:"dmarc,omitempty"`
+	DKIM     []DKIMRecord           `json:"dkim,omitempty"` // 在常见选择器中找到的 DKIM 公钥
+	MTASTS   *MTASTSRecord          `json:"mta_sts,omitempty"`
+	TLSRPT   *TLSRPTRecord          `json:"tls_rpt,omitempty"`
+	BIMI     *BIMIRecord            `json:"bimi,omitempty"`
+	Findings []EmailSecurityFinding `json:"findings"` // 发现的问题（严重程度高的在前）
+}
+
+// EmailSecurityFinding 邮件安全检测发现的问题
+type EmailSecurityFinding struct {
+	Check    string `json:"check" example:"spf" enums:"mx,spf,dmarc,dkim,mta-sts,tls-rpt,bimi"`
+	ID       string `json:"id" example:"spf_plus_all"`                                         // 问题标识（稳定，可用于前端本地化）
+	Severity string `json:"severity" example:"critical" enums:"critical,high,medium,low,info"` // 严重程度
+	Message  string `json:"message" example:"SPF 以 +all 结尾，允许任何服务器以该域名发送邮件"`                   // 说明（任务语言）
+}
+
+// SPFRecord SPF 记录解析结果
+type SPFRecord struct {
+	Record      string   `json:"record" example:"v=spf1 include:_spf.google.com ~all"`
+	All         string   `json:"all,omitempty" example:"~all"`                    // all 机制（含限定符），没有时为空
+	Redirect    string   `json:"redirect,omitempty"`                              // redirect= 修饰符
+	Includes    []string `json:"includes,omitempty"`                              // include 的域名（仅顶层）
+	DNSLookups  int      `json:"dns_lookups" example:"4"`                         // 需要 DNS 查询的机制数（含嵌套 include，上限 10）
+	VoidLookups int      `json:"void_lookups" example:"0"`                        // 没有结果的 DNS 查询数（上限 2）
+	Valid       bool     `json:"valid" example:"true"`                            // 是否能被接收方正常评估（没有 permerror）
+	Errors      []string `json:"errors,omitempty" example:"too many DNS lookups"` // 评估错误
+}
+
+// DMARCRecord DMARC 记录解析结果
+type DMARCRecord struct {
+	Record          string   `json:"record" example:"v=DMARC1; p=reject; rua=mailto:dmarc@example.com"`
+	Domain          string   `json:"domain" example:"example.com"` // 记录所在的域名（子域名没有记录时使用组织域名的记录）
+	Policy          string   `json:"policy" example:"reject" enums:"none,quarantine,reject"`
+	SubdomainPolicy string   `json:"subdomain_policy,omitempty" example:"reject"`
+	Percent         int      `json:"pct" example:"100"`
+	RUA             []string `json:"rua,omitempty" example:"mailto:dmarc@example.com"` // 聚合报告地址
+	RUF             []string `json:"ruf,omitempty"`                                    // 失败报告地址
+	ADKIM           string   `json:"adkim,omitempty" example:"r"`                      // DKIM 对齐模式（r/s）
+	ASPF            string   `json:"aspf,omitempty" example:"r"`                       // SPF 对齐模式（r/s）
+}
+
+// DKIMRecord DKIM 公钥记录
+type DKIMRecord struct {
+	Selector string `json:"selector" example:"google"`
+	KeyType  string `json:"key_type" example:"rsa"`
+	KeyBits  int    `json:"key_bits,omitempty" example:"2048"` // RSA 密钥长度
+	Revoked  bool   `json:"revoked,omitempty"`                 // p= 为空（公钥已撤销）
+	Testing  bool   `json:"testing,omitempty"`                 // t=y 测试模式
+}
+
+// MTASTSRecord MTA-STS 记录和策略
+type MTASTSRecord struct {
+	Record    string   `json:"record" example:"v=STSv1; id=20240101"`
+	PolicyURL string   `json:"policy_url" example:"https://mta-sts.example.com/.well-known/mta-sts.txt"`
+	Mode      string   `json:"mode,omitempty" example:"enforce" enums:"enforce,testing,none"`
+	MX        []string `json:"mx,omitempty" example:"*.example.com"`
+	MaxAge    int      `json:"max_age,omitempty" example:"604800"`
+	Error     string   `json:"error,omitempty"` // 策略文件读取或解析失败的原因
+}
+
+// TLSRPTRecord SMTP TLS 报告（TLS-RPT）记录
+type TLSRPTRecord struct {
+	Record string   `json:"record" example:"v=TLSRPTv1; rua=mailto:tls@example.com"`
+	RUA    []string `json:"rua,omitempty"`
+}
+
+// BIMIRecord BIMI 记录
+type BIMIRecord struct {
+	Record   string `json:"record" example:"v=BIMI1; l=https://example.com/logo.svg"`
+	Logo     string `json:"logo,omitempty" example:"https://example.com/logo.svg"` // l= 品牌标识（SVG）
+	Evidence string `json:"evidence,omitempty"`                                    // a= 标记证书（VMC）
+}
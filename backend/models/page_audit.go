@@ -0,0 +1,51 @@
+package models
+
+// 多页面审计的页面来源
+const (
+	PageAuditSourceSitemap = "sitemap" // 从 robots.txt 声明的 sitemap 或 /sitemap.xml 中抽样
+	PageAuditSourceLinks   = "links"   // 网站没有可用的 sitemap，从首页链接中抽样
+)
+
+// PageAuditResult 多页面 Lighthouse 审计结果
+// @Description 读取 robots.txt 和 sitemap（含 sitemap 索引），按 URL 模板每类抽样一个页面运行 Lighthouse，
+// @Description 返回每个页面的评分和汇总评分（平均、最低及最差页面）
+type PageAuditResult struct {
+	Source         string             `json:"source" example:"sitemap" enums:"sitemap,links"`               // 页面来源
+	Sitemaps       []string           `json:"sitemaps,omitempty" example:"https://example.com/sitemap.xml"` // 读取的 sitemap 地址
+	DiscoveredURLs int                `json:"discovered_urls" example:"1280"`                               // 发现的同站页面数
+	Templates      int                `json:"templates" example:"12"`                                       // URL 模板数
+	PageBudget     int                `json:"page_budget" example:"5"`                                      // 最多审计的页面数
+	Pages          []PageAudit        `json:"pages"`                                                        // 各页面审计结果（首页在前）
+	Aggregate      PageAuditAggregate `json:"aggregate"`                                                    // 汇总评分
+}
+
+// PageAudit 单个页面的 Lighthouse 审计结果
+type PageAudit struct {
+	URL           string  `json:"url" example:"https://example.com/blog/hello-world"`
+	Template      string  `json:"template" example:"/blog/*"`        // 页面所属的 URL 模板
+	TemplatePages int     `json:"template_pages" example:"340"`      // 该模板下发现的页面数
+	Performance   int     `json:"performance" example:"62"`          // 性能评分 (0-100)
+	SEO           int     `json:"seo" example:"91"`                  // SEO 评分 (0-100)
+	Accessibility int     `json:"accessibility" example:"78"`        // 可访问性评分 (0-100)
+	LCP           float64 `json:"lcp" example:"3200.5"`              // Largest Contentful Paint (ms)
+	CLS           float64 `json:"cls" example:"0.12"`                // Cumulative Layout Shift
+	TBT           float64 `json:"tbt" example:"350"`                 // Total Blocking Time (ms)
+	Error         string  `json:"error,omitempty" example:"timeout"` // 审计失败原因（失败时评分为 0，不计入汇总）
+}
+
+// PageAuditAggregate 多页面审计汇总
+type PageAuditAggregate struct {
+	Audited       int             `json:"audited" example:"5"` // 审计成功的页面数
+	Failed        int             `json:"failed" example:"0"`  // 审计失败的页面数
+	Performance   *ScoreAggregate `json:"performance,omitempty"`
+	SEO           *ScoreAggregate `json:"seo,omitempty"`
+	Accessibility *ScoreAggregate `json:"accessibility,omitempty"`
+}
+
+// ScoreAggregate 一项评分在多个页面上的汇总
+type ScoreAggregate struct {
+	Average  int    `json:"average" example:"74"`                                 // 平均分
+	Min      int    `json:"min" example:"48"`                                     // 最低分
+	Max      int    `json:"max" example:"95"`                                     // 最高分
+	WorstURL string `json:"worst_url" example:"https://example.com/products/123"` // 得分最低的页面
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ScanPolicy 扫描门禁策略
+// @Description 任务结束时按这些阈值计算通过/未通过结论，所有字段可选，只检查设置了的项
+type ScanPolicy struct {
+	MinPerformance     *int `json:"min_performance,omitempty" example:"80"`    // 性能评分下限
+	MinSEO             *int `json:"min_seo,omitempty" example:"90"`            // SEO 评分下限
+	MinSecurity        *int `json:"min_security,omitempty" example:"70"`       // 安全评分下限
+	MinAccessibility   *int `json:"min_accessibility,omitempty" example:"80"`  // 可访问性评分下限
+	MaxBrokenLinks     *int `json:"max_broken_links,omitempty" example:"0"`    // 失效链接数上限
+	MaxVulnerabilities *int `json:"max_vulnerabilities,omitempty" example:"0"` // 安全问题（SecurityRisk.Vulnerabilities）数上限
+	MinSSLDays         *int `json:"min_ssl_days,omitempty" example:"14"`       // SSL 证书剩余天数下限
+}
+
+// ScanVerdict 扫描门禁结论
+// @Description 任务结束时按门禁策略计算的结论，未指定策略时检查任务是否完成、失效链接数为 0 以及证书有效（仅检查已执行的项）
+type ScanVerdict struct {
+	Passed      bool          `json:"passed" example:"false"` // 是否通过
+	Checks      []PolicyCheck `json:"checks"`                 // 各项检查结果
+	EvaluatedAt time.Time     `json:"evaluated_at"`           // 计算时间
+}
+
+// PolicyCheck 单项门禁检查
+type PolicyCheck struct {
+	Name     string   `json:"name" example:"min_performance"`                          // 检查项（与策略字段同名）
+	Passed   bool     `json:"passed" example:"false"`                                  // 是否通过
+	Expected string   `json:"expected" example:">= 80"`                                // 期望值
+	Actual   string   `json:"actual" example:"72"`                                     // 实际值（未检测时为 not measured）
+	Message  string   `json:"message" example:"performance score 72 is below 80"`      // 说明
+	Details  []string `json:"details,omitempty" example:"https://example.com/missing"` // 相关条目（如失效链接）
+}
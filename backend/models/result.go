@@ -1,15 +1,23 @@
 package models
 
 // HttpxResult 链接健康检查结果
-// @Description httpx检测的单个URL结果
+// @Description 单个URL的检测结果（进程内检查器或 httpx）。进程内检查器跟随重定向，status 为最终响应的状态码，
+// @Description 并额外返回重定向链、软 404 和锚点检查结果
 type HttpxResult struct {
-	URL          string `json:"url" example:"https://example.com"`
-	StatusCode   int    `json:"status" example:"200"`
-	Title        string `json:"title" example:"Example Domain"`
-	ResponseTime int    `json:"response_time" example:"150"`
-	IP           string `json:"ip" example:"93.184.216.34"`
-	TLS          bool   `json:"tls" example:"true"`
-	CDN          bool   `json:"cdn" example:"false"`
+	URL           string   `json:"url" example:"https://example.com"`
+	StatusCode    int      `json:"status" example:"200"`
+	Title         string   `json:"title" example:"Example Domain"`
+	ResponseTime  int      `json:"response_time" example:"150"`
+	IP            string   `json:"ip" example:"93.184.216.34"`
+	TLS           bool     `json:"tls" example:"true"`
+	CDN           bool     `json:"cdn" example:"false"`
+	Method        string   `json:"method,omitempty" example:"HEAD" enums:"HEAD,GET"`                         // 得到最终结果的请求方法（HEAD 失败时回退到 GET）
+	RedirectChain []string `json:"redirect_chain,omitempty" example:"https://www.example.com/"`              // 依次跳转到的地址（不含原始地址）
+	FinalURL      string   `json:"final_url,omitempty" example:"https://www.example.com/"`                   // 重定向后的最终地址
+	Soft404       bool     `json:"soft_404,omitempty" example:"false"`                                       // 返回 200 但内容是“页面不存在”
+	AnchorMissing bool     `json:"anchor_missing,omitempty" example:"false"`                                 // 链接的 #fragment 在页面中不存在
+	Error         string   `json:"error,omitempty" example:"dial tcp: lookup example.invalid: no such host"` // 请求失败原因
+	Skipped       bool     `json:"skipped,omitempty" example:"false"`                                        // 到达时间上限前没有检查（不计入可用或失效）
 }
 
 // WebsiteInfo 网站信息
@@ -29,41 +37,55 @@ type WebsiteInfo struct {
 // DomainInfo 域名信息
 // @Description 域名DNS和地理位置信息
 type DomainInfo struct {
-	Domain       string   `json:"domain" example:"example.com"`
-	IP           string   `json:"ip" example:"93.184.216.34"`
-	IPv4         []string `json:"ipv4" example:"93.184.216.34"`
-	IPv6         []string `json:"ipv6" example:"2606:2800:220:1:248:1893:25c8:1946"`
-	MX           []string `json:"mx" example:"mail.example.com"`                      // MX记录
-	NS           []string `json:"ns" example:"ns1.example.com"`                       // NS记录
-	TXT          []string `json:"txt" example:"v=spf1 include:_spf.example.com ~all"` // TXT记录
-	ASN          string   `json:"asn" example:"AS15133"`                              // ASN信息
-	ASNName      string   `json:"asn_name" example:"Edgecast Inc."`                   // ASN名称
-	Country      string   `json:"country" example:"US"`                               // 国家
-	City         string   `json:"city" example:"New York"`                            // 城市
-	ISP          string   `json:"isp" example:"Edgecast Inc."`                        // ISP
-	Organization string   `json:"organization" example:"Example Inc."`                // 组织
+	Domain       string         `json:"domain" example:"example.com"`
+	IP           string         `json:"ip" example:"93.184.216.34"`
+	IPv4         []string       `json:"ipv4" example:"93.184.216.34"`
+	IPv6         []string       `json:"ipv6" example:"2606:2800:220:1:248:1893:25c8:1946"`
+	MX           []string       `json:"mx" example:"mail.example.com"`                      // MX记录
+	NS           []string       `json:"ns" example:"ns1.example.com"`                       // NS记录
+	TXT          []string       `json:"txt" example:"v=spf1 include:_spf.example.com ~all"` // TXT记录
+	ASN          string         `json:"asn" example:"AS15133"`                              // ASN信息
+	ASNName      string         `json:"asn_name" example:"Edgecast Inc."`                   // ASN名称
+	Country      string         `json:"country" example:"US"`                               // 国家
+	City         string         `json:"city" example:"New York"`                            // 城市
+	ISP          string         `json:"isp" example:"Edgecast Inc."`                        // ISP
+	Organization string         `json:"organization" example:"Example Inc."`                // 组织
+	CNAME        []string       `json:"cname,omitempty" example:"example.github.io"`        // CNAME 链
+	DNSSEC       *DNSSECInfo    `json:"dnssec,omitempty"`
+	CAA          *CAAInfo       `json:"caa,omitempty"`
+	Nameservers  *NSConsistency `json:"ns_consistency,omitempty"`
+	Findings     []DNSFinding   `json:"findings,omitempty"` // DNS 安全检查发现的问题（按严重程度排序）
 }
 
 // SSLInfo SSL证书信息
 // @Description SSL/TLS证书详细信息
 type SSLInfo struct {
-	Issuer           string   `json:"issuer" example:"CN=Let's Encrypt Authority X3"`  // 颁发者
-	Subject          string   `json:"subject" example:"CN=example.com"`                // 主题
-	ValidFrom        string   `json:"valid_from" example:"2024-01-01T00:00:00Z"`       // 有效期开始
-	ValidTo          string   `json:"valid_to" example:"2024-04-01T00:00:00Z"`         // 有效期结束
-	IsValid          bool     `json:"is_valid" example:"true"`                         // 是否有效
-	DaysRemaining    int      `json:"days_remaining" example:"90"`                     // 剩余天数
-	SignatureAlg     string   `json:"signature_alg" example:"SHA256-RSA"`              // 签名算法
-	PublicKeyAlg     string   `json:"public_key_alg" example:"RSA"`                    // 公钥算法
-	KeySize          int      `json:"key_size" example:"2048"`                         // 密钥长度
-	SerialNumber     string   `json:"serial_number" example:"1234567890ABCDEF"`        // 序列号
-	DNSNames         []string `json:"dns_names" example:"example.com,www.example.com"` // SAN域名列表
-	CommonName       string   `json:"common_name" example:"example.com"`               // 通用名称
-	Organization     string   `json:"organization" example:"Example Inc."`             // 组织
-	OrganizationUnit string   `json:"organization_unit" example:"IT Department"`       // 组织单位
-	Country          string   `json:"country" example:"US"`                            // 国家
-	Locality         string   `json:"locality" example:"San Francisco"`                // 地区
-	Province         string   `json:"province" example:"CA"`                           // 省份
+	Issuer           string             `json:"issuer" example:"CN=Let's Encrypt Authority X3"`  // 颁发者
+	Subject          string             `json:"subject" example:"CN=example.com"`                // 主题
+	ValidFrom        string             `json:"valid_from" example:"2024-01-01T00:00:00Z"`       // 有效期开始
+	ValidTo          string             `json:"valid_to" example:"2024-04-01T00:00:00Z"`         // 有效期结束
+	IsValid          bool               `json:"is_valid" example:"true"`                         // 是否有效
+	DaysRemaining    int                `json:"days_remaining" example:"90"`                     // 剩余天数
+	SignatureAlg     string             `json:"signature_alg" example:"SHA256-RSA"`              // 签名算法
+	PublicKeyAlg     string             `json:"public_key_alg" example:"RSA"`                    // 公钥算法
+	KeySize          int                `json:"key_size" example:"2048"`                         // 密钥长度
+	SerialNumber     string             `json:"serial_number" example:"1234567890ABCDEF"`        // 序列号
+	DNSNames         []string           `json:"dns_names" example:"example.com,www.example.com"` // SAN域名列表
+	CommonName       string             `json:"common_name" example:"example.com"`               // 通用名称
+	Organization     string             `json:"organization" example:"Example Inc."`             // 组织
+	OrganizationUnit string             `json:"organization_unit" example:"IT Department"`       // 组织单位
+	Country          string             `json:"country" example:"US"`                            // 国家
+	Locality         string             `json:"locality" example:"San Francisco"`                // 地区
+	Province         string             `json:"province" example:"CA"`                           // 省份
+	Chain            []ChainCertificate `json:"chain,omitempty"`                                 // 证书链（叶子证书在前；可信时为验证通过的路径）
+	ChainTrusted     bool               `json:"chain_trusted" example:"true"`                    // 证书链可以验证到受信任的根证书
+	ChainComplete    bool               `json:"chain_complete" example:"true"`                   // 服务器发送了全部中间证书
+	HostnameCoverage []HostnameCoverage `json:"hostname_coverage,omitempty"`                     // 目标主机名及其 apex/www 变体是否被证书覆盖
+	OCSPStapled      bool               `json:"ocsp_stapled" example:"false"`                    // 服务器在握手中附带了 OCSP 响应
+	MustStaple       bool               `json:"must_staple,omitempty" example:"false"`           // 证书要求 OCSP 装订（TLS Feature 扩展）
+	Revocation       *RevocationInfo    `json:"revocation,omitempty"`                            // 吊销状态（OCSP 装订、OCSP 查询或 CRL）
+	SCTCount         int                `json:"sct_count" example:"2"`                           // Certificate Transparency SCT 数量（证书内嵌和 TLS 扩展）
+	Findings         []SSLFinding       `json:"findings,omitempty"`                              // 发现的问题（按严重程度排序）
 }
 
 // TechStack 技术栈信息（整合了服务器信息和技术栈检测）
@@ -90,6 +112,21 @@ type TechStack struct {
 	Database      []string          `json:"database" example:"MySQL"`                // 数据库
 	OS            string            `json:"os" example:"Linux"`                      // 操作系统
 	MetaTags      map[string]string `json:"meta_tags" example:"generator:WordPress"` // 技术相关元标签（已排除网站信息中的标准标签）
+
+	// 签名库指纹识别结果（含版本和置信度；上面的分类列表只包含置信度不低于 50 的技术）
+	Detections []TechDetection `json:"detections,omitempty"`
+
+	// 安全响应头深度分析（CSP、HSTS 等的解析结果、A-F 等级和修复建议）
+	HeaderAnalysis *SecurityHeadersAnalysis `json:"header_analysis,omitempty"`
+}
+
+// TechDetection 指纹签名库识别到的技术
+type TechDetection struct {
+	Name       string   `json:"name" example:"jQuery"`
+	Version    string   `json:"version,omitempty" example:"3.6.0"`
+	Confidence int      `json:"confidence" example:"100"` // 置信度（0-100）
+	Categories []string `json:"categories,omitempty" example:"JavaScript libraries"`
+	Website    string   `json:"website,omitempty" example:"https://jquery.com"`
 }
 
 // ScanSummary 扫描统计概要，供 AI 分析使用
@@ -98,6 +135,7 @@ type ScanSummary struct {
 	Total       int  `json:"total" example:"100"`        // 总 URL 数
 	Alive       int  `json:"alive" example:"95"`         // 可用 URL 数
 	Dead        int  `json:"dead" example:"5"`           // 不可用 URL 数
+	Skipped     int  `json:"skipped" example:"0"`        // 超时未检查的 URL 数
 	AvgResponse int  `json:"avg_response" example:"150"` // 平均响应时间（毫秒）
 	Timeout     bool `json:"timeout" example:"false"`    // 是否发生超时
 }
@@ -140,6 +178,28 @@ type SecurityRisk struct {
 	ScriptCount       int               `json:"script_count" example:"10"`                                             // 脚本总数
 	SecurityHeaders   map[string]string `json:"security_headers" example:"Strict-Transport-Security:max-age=31536000"` // 安全响应头 (CSP, HSTS等)
 	Vulnerabilities   []string          `json:"vulnerabilities" example:"Missing CSP header"`                          // 发现的潜在漏洞
+
+	VulnerableLibraries []VulnerableLibrary `json:"vulnerable_libraries,omitempty"` // 存在已知漏洞的 JavaScript 库（js-libraries 模块）
+}
+
+// VulnerableLibrary 存在已知漏洞的 JavaScript 库
+// @Description 页面引用的 JavaScript 库及其版本命中的已知漏洞（retire.js 格式漏洞库）
+type VulnerableLibrary struct {
+	Library         string                 `json:"library" example:"jquery"`                                           // 库名称（漏洞库中的组件名）
+	Version         string                 `json:"version" example:"1.12.4"`                                           // 识别到的版本
+	URL             string                 `json:"url" example:"https://code.jquery.com/jquery-1.12.4.min.js"`         // 脚本地址（同一版本出现在多个脚本中时为第一个）
+	Detection       string                 `json:"detection" example:"filename" enums:"uri,filename,filecontent,hash"` // 版本识别方式
+	Severity        string                 `json:"severity" example:"medium" enums:"critical,high,medium,low"`         // 最高严重程度
+	Vulnerabilities []LibraryVulnerability `json:"vulnerabilities"`                                                    // 命中的漏洞
+}
+
+// LibraryVulnerability JavaScript 库的单个已知漏洞
+type LibraryVulnerability struct {
+	Identifiers []string `json:"identifiers" example:"CVE-2020-11022"`                                            // CVE 编号（没有 CVE 时为 GHSA 等其他编号）
+	Severity    string   `json:"severity" example:"medium" enums:"critical,high,medium,low"`                      // 严重程度
+	Summary     string   `json:"summary,omitempty" example:"Regex in its jQuery.htmlPrefilter may introduce XSS"` // 漏洞说明
+	FixedIn     string   `json:"fixed_in,omitempty" example:"3.5.0"`                                              // 修复版本（受影响范围的上界）
+	Info        []string `json:"info,omitempty" example:"https://nvd.nist.gov/vuln/detail/CVE-2020-11022"`        // 参考链接
 }
 
 // AccessibilityInfo 可访问性信息
@@ -151,19 +211,21 @@ type AccessibilityInfo struct {
 
 // AIAnalysisInput 提供给 AI 的分析输入数据
 type AIAnalysisInput struct {
-	Target        string              `json:"target"`        // 目标网站
-	Summary       ScanSummary         `json:"summary"`       // 扫描概要
-	Results       []HttpxResult       `json:"results"`       // 链接健康检查结果（最多 200 条）
-	WebsiteInfo   *WebsiteInfo        `json:"website_info"`  // 网站基础信息
-	DomainInfo    *DomainInfo         `json:"domain_info"`   // 域名信息
-	SSLInfo       *SSLInfo            `json:"ssl_info"`      // SSL 证书信息
-	TechStack     *TechStack          `json:"tech_stack"`    // 技术栈信息
-	Performance   *PerformanceMetrics `json:"performance"`   // 性能指标
-	SEO           *SEOCompliance      `json:"seo"`           // SEO 合规性
-	Security      *SecurityRisk       `json:"security"`      // 安全风险
-	Accessibility *AccessibilityInfo  `json:"accessibility"` // 可访问性信息
-	Mode          string              `json:"mode"`          // 分析模式：balanced / performance / security / seo
-	Language      string              `json:"language"`      // 输出语言：zh / en
+	Target        string               `json:"target"`         // 目标网站
+	Summary       ScanSummary          `json:"summary"`        // 扫描概要
+	Results       []HttpxResult        `json:"results"`        // 链接健康检查结果（最多 200 条）
+	WebsiteInfo   *WebsiteInfo         `json:"website_info"`   // 网站基础信息
+	DomainInfo    *DomainInfo          `json:"domain_info"`    // 域名信息
+	SSLInfo       *SSLInfo             `json:"ssl_info"`       // SSL 证书信息
+	TechStack     *TechStack           `json:"tech_stack"`     // 技术栈信息
+	Performance   *PerformanceMetrics  `json:"performance"`    // 性能指标
+	SEO           *SEOCompliance       `json:"seo"`            // SEO 合规性
+	Security      *SecurityRisk        `json:"security"`       // 安全风险
+	Accessibility *AccessibilityInfo   `json:"accessibility"`  // 可访问性信息
+	EmailSecurity *EmailSecurityResult `json:"email_security"` // 邮件安全（SPF、DMARC 等）
+	Cookies       *CookieAuditResult   `json:"cookies"`        // Cookie 安全审计
+	Mode          string               `json:"mode"`           // 分析模式：balanced / performance / security / seo
+	Language      string               `json:"language"`       // 输出语言：zh / en
 }
 
 // AIAnalysis AI 分析报告结果
@@ -0,0 +1,30 @@
+package models
+
+// ScanAuth 认证扫描配置：扫描需要登录才能访问的页面
+// @Description 可以组合使用自定义请求头、Cookie、HTTP Basic 认证和表单登录。认证信息加密存储，不会在任务详情中返回；
+// @Description 只发送给与目标同站（忽略 www. 前缀）的请求，外部链接检查不携带认证信息。
+// @Description 需要服务端配置 SCAN_AUTH_ENCRYPTION_KEY
+type ScanAuth struct {
+	Headers   map[string]string `json:"headers,omitempty"`    // 自定义请求头（如 Authorization: Bearer xxx）
+	Cookies   map[string]string `json:"cookies,omitempty"`    // Cookie（名称 -> 值）
+	BasicAuth *BasicAuth        `json:"basic_auth,omitempty"` // HTTP Basic 认证
+	FormLogin *FormLogin        `json:"form_login,omitempty"` // 表单登录（任务开始执行时登录，获得的 Cookie 用于整个任务）
+}
+
+// BasicAuth HTTP Basic 认证
+type BasicAuth struct {
+	Username string `json:"username" example:"admin"`
+	Password string `json:"password" example:"secret"`
+}
+
+// FormLogin 表单登录配置
+// @Description 执行任务前先打开 login_url，读取包含密码字段的表单（含隐藏字段，如 CSRF token），
+// @Description 填入用户名和密码后提交；登录后页面仍显示密码字段或没有获得 Cookie 时视为登录失败
+type FormLogin struct {
+	LoginURL      string            `json:"login_url" example:"https://example.com/login"` // 登录页面地址
+	UsernameField string            `json:"username_field,omitempty" example:"username"`   // 用户名字段名，默认 username
+	PasswordField string            `json:"password_field,omitempty" example:"password"`   // 密码字段名，默认 password
+	Username      string            `json:"username" example:"admin"`                      // 用户名
+	Password      string            `json:"password" example:"secret"`                     // 密码
+	ExtraFields   map[string]string `json:"extra_fields,omitempty"`                        // 额外提交的字段（覆盖表单中的同名字段）
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ScheduleStatus 定时扫描计划状态
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive ScheduleStatus = "active" // 启用
+	ScheduleStatusPaused ScheduleStatus = "paused" // 已暂停（用户手动暂停或积分不足等原因自动暂停）
+)
+
+// ScanSchedule 定时扫描计划
+// @Description 按 cron 表达式定期创建扫描任务的计划
+type ScanSchedule struct {
+	ID             string         `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID         string         `json:"user_id"`
+	Name           string         `json:"name" example:"官网每日巡检"`                                          // 计划名称
+	TargetURL      string         `json:"target_url" example:"https://example.com"`                       // 目标URL
+	Options        []string       `json:"options" example:"website-info,link-health"`                     // 扫描选项
+	Language       string         `json:"language" example:"zh"`                                          // 语言 (zh/en)
+	AIMode         string         `json:"ai_mode" example:"balanced"`                                     // AI分析模式
+	CronExpression string         `json:"cron_expression" example:"0 9 * * *"`                            // cron 表达式（分 时 日 月 周）
+	Timezone       string         `json:"timezone" example:"Asia/Shanghai"`                               // cron 表达式使用的时区
+	Status         ScheduleStatus `json:"status" example:"active" enums:"active,paused"`                  // 计划状态
+	PausedReason   string         `json:"paused_reason,omitempty" example:"insufficient credits"`         // 自动暂停原因
+	NextRunAt      *time.Time     `json:"next_run_at,omitempty" example:"2024-01-01T09:00:00Z"`           // 下次执行时间
+	LastRunAt      *time.Time     `json:"last_run_at,omitempty" example:"2024-01-01T09:00:00Z"`           // 上次执行时间
+	LastTaskID     *string        `json:"last_task_id,omitempty" example:"550e8400-e29b-41d4-a716-44665"` // 上次创建的任务ID
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// CreateScheduleRequest 创建定时扫描计划请求
+// @Description 创建定时扫描计划的请求参数
+type CreateScheduleRequest struct {
+	Name           string   `json:"name" example:"官网每日巡检"`                                                                   // 计划名称（可选）
+	URL            string   `json:"url" example:"https://example.com"`                                                       // 目标URL
+	Options        []string `json:"options" example:"website-info,link-health"`                                              // 扫描选项
+	Language       string   `json:"language" example:"zh" enums:"zh,en" default:"zh"`                                        // 语言 (zh/en)
+	AIMode         string   `json:"ai_mode" example:"balanced" enums:"performance,security,seo,balanced" default:"balanced"` // AI分析模式
+	CronExpression string   `json:"cron_expression" example:"0 9 * * *"`                                                     // cron 表达式（分 时 日 月 周）
+	Timezone       string   `json:"timezone" example:"Asia/Shanghai" default:"UTC"`                                          // 时区（IANA 名称）
+}
+
+// UpdateScheduleRequest 更新定时扫描计划请求（只更新提供的字段）
+// @Description 更新定时扫描计划，status 设为 active 可恢复被暂停的计划
+type UpdateScheduleRequest struct {
+	Name           *string         `json:"name,omitempty"`
+	URL            *string         `json:"url,omitempty"`
+	Options        []string        `json:"options,omitempty"`
+	Language       *string         `json:"language,omitempty"`
+	AIMode         *string         `json:"ai_mode,omitempty"`
+	CronExpression *string         `json:"cron_expression,omitempty"`
+	Timezone       *string         `json:"timezone,omitempty"`
+	Status         *ScheduleStatus `json:"status,omitempty" enums:"active,paused"`
+}
@@ -0,0 +1,62 @@
+package models
+
+// 安全响应头检查项
+const (
+	HeaderCheckCSP         = "csp"
+	HeaderCheckHSTS        = "hsts"
+	HeaderCheckFrame       = "x-frame-options"
+	HeaderCheckContentType = "x-content-type-options"
+	HeaderCheckReferrer    = "referrer-policy"
+	HeaderCheckPermissions = "permissions-policy"
+	HeaderCheckCOOP        = "coop"
+	HeaderCheckCOEP        = "coep"
+	HeaderCheckCORP        = "corp"
+)
+
+// SecurityHeadersAnalysis 安全响应头深度分析结果
+// @Description 解析目标页面的 Content-Security-Policy、HSTS、Permissions-Policy、COOP/COEP/CORP、
+// @Description Referrer-Policy、X-Frame-Options 和 X-Content-Type-Options，按发现的严重程度计算 0-100 评分和 A-F 等级
+type SecurityHeadersAnalysis struct {
+	Score    int                     `json:"score" example:"70"` // 评分 (0-100)
+	Grade    string                  `json:"grade" example:"C" enums:"A,B,C,D,F"`
+	CSP      *CSPAnalysis            `json:"csp,omitempty"`  // Content-Security-Policy 解析结果（没有该响应头时为空）
+	HSTS     *HSTSAnalysis           `json:"hsts,omitempty"` // Strict-Transport-Security 解析结果（没有该响应头时为空）
+	Headers  []SecurityHeaderResult  `json:"headers"`        // 每个响应头的结论和修复建议
+	Findings []SecurityHeaderFinding `json:"findings"`       // 发现的问题（严重程度高的在前）
+}
+
+// SecurityHeaderResult 单个安全响应头的结论
+type SecurityHeaderResult struct {
+	Check       string `json:"check" example:"hsts" enums:"csp,hsts,x-frame-options,x-content-type-options,referrer-policy,permissions-policy,coop,coep,corp"`
+	Header      string `json:"header" example:"Strict-Transport-Security"`                                                        // 响应头名称
+	Value       string `json:"value,omitempty" example:"max-age=300"`                                                             // 响应头的值（没有时为空）
+	Severity    string `json:"severity,omitempty" example:"medium" enums:"critical,high,medium,low,info"`                         // 该响应头最严重的发现，没有问题时为空
+	Remediation string `json:"remediation,omitempty" example:"设置 Strict-Transport-Security: max-age=31536000; includeSubDomains"` // 修复建议（任务语言），没有问题时为空
+}
+
+// SecurityHeaderFinding 安全响应头分析发现的问题
+type SecurityHeaderFinding struct {
+	Check    string `json:"check" example:"csp" enums:"csp,hsts,x-frame-options,x-content-type-options,referrer-policy,permissions-policy,coop,coep,corp"`
+	ID       string `json:"id" example:"csp_unsafe_inline"`                                // 问题标识（稳定，可用于前端本地化）
+	Severity string `json:"severity" example:"high" enums:"critical,high,medium,low,info"` // 严重程度
+	Message  string `json:"message" example:"script-src 允许 'unsafe-inline' 且没有使用 nonce 或 hash，CSP 无法阻止 XSS"`
+}
+
+// CSPAnalysis Content-Security-Policy 解析结果
+type CSPAnalysis struct {
+	Policy        string              `json:"policy" example:"default-src 'self'; script-src 'self' 'nonce-abc'"`
+	ReportOnly    bool                `json:"report_only,omitempty"`                                         // 只有 Content-Security-Policy-Report-Only（不拦截）
+	Directives    map[string][]string `json:"directives" example:"script-src:'self' 'nonce-abc'"`            // 指令及其来源列表（指令名小写）
+	UsesNonce     bool                `json:"uses_nonce,omitempty"`                                          // script-src 使用了 nonce
+	UsesHash      bool                `json:"uses_hash,omitempty"`                                           // script-src 使用了 hash
+	StrictDynamic bool                `json:"strict_dynamic,omitempty"`                                      // script-src 使用了 'strict-dynamic'
+	ReportURI     string              `json:"report_uri,omitempty" example:"https://example.com/csp-report"` // report-uri 或 report-to
+}
+
+// HSTSAnalysis Strict-Transport-Security 解析结果
+type HSTSAnalysis struct {
+	MaxAge            int64 `json:"max_age" example:"31536000"` // 秒
+	IncludeSubDomains bool  `json:"include_subdomains"`
+	Preload           bool  `json:"preload"`          // 声明了 preload 指令
+	PreloadEligible   bool  `json:"preload_eligible"` // 响应头满足 hstspreload.org 的要求（max-age ≥ 1 年、includeSubDomains、preload）
+}
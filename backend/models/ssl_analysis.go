@@ -0,0 +1,55 @@
+package models
+
+// SSL 证书检查项
+const (
+	SSLCheckExpiry     = "expiry"
+	SSLCheckHostname   = "hostname"
+	SSLCheckChain      = "chain"
+	SSLCheckKey        = "key"
+	SSLCheckRevocation = "revocation"
+	SSLCheckCT         = "ct"
+)
+
+// 证书吊销状态
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+)
+
+// SSLFinding SSL 证书检查发现的问题
+type SSLFinding struct {
+	Check    string `json:"check" example:"chain" enums:"expiry,hostname,chain,key,revocation,ct"`
+	ID       string `json:"id" example:"chain_incomplete"`                                 // 问题标识（稳定，可用于前端本地化）
+	Severity string `json:"severity" example:"high" enums:"critical,high,medium,low,info"` // 严重程度
+	Message  string `json:"message" example:"服务器没有发送中间证书，部分客户端（curl、移动应用等）将无法建立连接"`
+}
+
+// ChainCertificate 证书链中的一个证书
+type ChainCertificate struct {
+	Subject      string `json:"subject" example:"CN=R3,O=Let's Encrypt,C=US"`
+	Issuer       string `json:"issuer" example:"CN=ISRG Root X1,O=Internet Security Research Group,C=US"`
+	NotAfter     string `json:"not_after" example:"2025-09-15T16:00:00Z"`
+	SignatureAlg string `json:"signature_alg" example:"SHA256-RSA"`
+	PublicKeyAlg string `json:"public_key_alg" example:"RSA"`
+	KeySize      int    `json:"key_size" example:"2048"`
+	IsCA         bool   `json:"is_ca" example:"true"`
+	SelfSigned   bool   `json:"self_signed" example:"false"`
+	Source       string `json:"source" example:"server" enums:"server,aia,trust_store"` // 来源：服务器发送、通过 AIA 下载、本地信任库
+}
+
+// HostnameCoverage 主机名是否被证书覆盖
+type HostnameCoverage struct {
+	Host    string `json:"host" example:"www.example.com"`
+	Covered bool   `json:"covered" example:"true"`
+	Target  bool   `json:"target" example:"false"` // 是否为扫描目标的主机名（否则为 apex/www 变体）
+}
+
+// RevocationInfo 证书吊销状态
+type RevocationInfo struct {
+	Method    string `json:"method" example:"ocsp_stapled" enums:"ocsp_stapled,ocsp,crl"` // 检查方式
+	Status    string `json:"status" example:"good" enums:"good,revoked,unknown"`
+	RevokedAt string `json:"revoked_at,omitempty" example:"2024-02-01T00:00:00Z"`
+	Source    string `json:"source,omitempty" example:"http://r3.o.lencr.org"` // OCSP 响应服务器或 CRL 地址
+	Error     string `json:"error,omitempty"`
+}
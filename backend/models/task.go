@@ -10,6 +10,7 @@ const (
 	TaskStatusRunning   TaskStatus = "running"   // 执行中
 	TaskStatusCompleted TaskStatus = "completed" // 已完成
 	TaskStatusFailed    TaskStatus = "failed"    // 执行失败
+	TaskStatusCanceled  TaskStatus = "canceled"  // 已取消
 )
 
 // TaskProgress 任务进度
@@ -22,12 +23,12 @@ type TaskProgress struct {
 // ModuleStatus 模块执行状态
 // @Description 单个检测模块的执行状态
 type ModuleStatus struct {
-	Name        string       `json:"name" example:"website-info"`                                       // 模块名称
-	Status      TaskStatus   `json:"status" example:"running" enums:"pending,running,completed,failed"` // 模块状态
-	Progress    TaskProgress `json:"progress"`                                                          // 模块进度
-	Error       string       `json:"error,omitempty" example:""`                                        // 错误信息（如果有）
-	StartedAt   *time.Time   `json:"started_at,omitempty" example:"2024-01-01T00:00:00Z"`               // 开始时间
-	CompletedAt *time.Time   `json:"completed_at,omitempty" example:"2024-01-01T00:00:00Z"`             // 完成时间
+	Name        string       `json:"name" example:"website-info"`                                                // 模块名称
+	Status      TaskStatus   `json:"status" example:"running" enums:"pending,running,completed,failed,canceled"` // 模块状态
+	Progress    TaskProgress `json:"progress"`                                                                   // 模块进度
+	Error       string       `json:"error,omitempty" example:""`                                                 // 错误信息（如果有）
+	StartedAt   *time.Time   `json:"started_at,omitempty" example:"2024-01-01T00:00:00Z"`                        // 开始时间
+	CompletedAt *time.Time   `json:"completed_at,omitempty" example:"2024-01-01T00:00:00Z"`                      // 完成时间
 }
 
 // Task 扫描任务
@@ -49,6 +50,23 @@ type Task struct {
 	UserID   *string `json:"user_id,omitempty"`   // 用户ID（UUID字符串）
 	IsPublic bool    `json:"is_public,omitempty"` // 是否公开（预留功能）
 
+	// 定时扫描计划（由定时扫描计划创建的任务才有）
+	ScheduleID *string `json:"schedule_id,omitempty"` // 定时扫描计划ID
+
+	// 批量扫描（由批量扫描创建的任务才有）
+	BatchID *string `json:"batch_id,omitempty"` // 批量扫描ID
+
+	// 扫描门禁（任务结束时按策略计算结论）
+	Policy  *ScanPolicy  `json:"policy,omitempty"`  // 门禁策略
+	Verdict *ScanVerdict `json:"verdict,omitempty"` // 门禁结论
+
+	// 全站链接检查的爬取配置（可选）
+	Crawl *CrawlConfig `json:"crawl,omitempty"`
+
+	// 认证扫描（凭据加密存储，不在任务详情中返回）
+	Auth          *ScanAuth `json:"-"`                       // 认证配置（已解密；解密失败时为 nil）
+	Authenticated bool      `json:"authenticated,omitempty"` // 是否为认证扫描
+
 	// 进度信息
 	Progress TaskProgress             `json:"progress"` // 整体进度
 	Modules  map[string]*ModuleStatus `json:"modules"`  // 各模块状态
@@ -58,6 +76,10 @@ type Task struct {
 
 	// 错误信息
 	Error string `json:"error,omitempty"` // 全局错误（如果有）
+
+	// 重新执行（仅执行失败或指定的模块）
+	RetryModules        []string          `json:"retry_modules,omitempty"` // 本次重新执行的扫描选项（为空表示完整执行）
+	PrepaidUsageRecords map[string]string `json:"-"`                       // 本次执行预扣费的使用记录（批量扫描创建时或重新执行时预扣，功能代码 -> 使用记录ID），任务失败或取消时退回
 }
 
 // TaskResults 任务结果聚合
@@ -72,12 +94,21 @@ type TaskResults struct {
 	SSLInfo     *SSLInfo     `json:"ssl_info,omitempty"`
 	TechStack   *TechStack   `json:"tech_stack,omitempty"`
 
+	// 邮件安全（SPF、DMARC、DKIM、MTA-STS、TLS-RPT、BIMI）
+	EmailSecurity *EmailSecurityResult `json:"email_security,omitempty"`
+
+	// Cookie 安全审计（目标页面和爬虫发现的页面设置的 Cookie）
+	Cookies *CookieAuditResult `json:"cookies,omitempty"`
+
 	// Lighthouse 相关结果
 	Performance   *PerformanceMetrics `json:"performance,omitempty"`
 	SEOCompliance *SEOCompliance      `json:"seo_compliance,omitempty"`
 	SecurityRisk  *SecurityRisk       `json:"security_risk,omitempty"`
 	Accessibility *AccessibilityInfo  `json:"accessibility,omitempty"`
 
+	// 多页面审计（按 sitemap 抽样的页面 Lighthouse 评分）
+	PageAudits *PageAuditResult `json:"page_audits,omitempty"`
+
 	// AI 分析
 	AIAnalysis *AIAnalysis `json:"ai_analysis,omitempty"`
 
@@ -97,6 +128,23 @@ type CreateTaskRequest struct {
 	Options  []string `json:"options" example:"website-info,domain-info"`                                              // 扫描选项
 	Language string   `json:"language" example:"zh" enums:"zh,en" default:"zh"`                                        // 语言 (zh/en)
 	AIMode   string   `json:"ai_mode" example:"balanced" enums:"performance,security,seo,balanced" default:"balanced"` // AI分析模式
+
+	Policy *ScanPolicy `json:"policy,omitempty"` // 门禁策略（可选，任务结束时据此计算通过/未通过结论）
+
+	Crawl *CrawlConfig `json:"crawl,omitempty"` // 全站链接检查（katana）的爬取配置（可选）
+
+	Auth *ScanAuth `json:"auth,omitempty"` // 认证扫描配置（可选，请求头、Cookie、Basic 认证或表单登录）
+
+	ScheduleID *string `json:"-"` // 定时扫描计划ID（仅由定时扫描调度器设置）
+
+	BatchID             *string           `json:"-"` // 批量扫描ID（仅由批量扫描设置）
+	PrepaidUsageRecords map[string]string `json:"-"` // 创建时已预扣费的使用记录（批量扫描），任务失败或取消时退回
+}
+
+// RetryTaskRequest 重新执行任务模块请求
+// @Description 重新执行任务中失败或指定的模块，modules 为空时重新执行所有失败（或已取消）的模块
+type RetryTaskRequest struct {
+	Modules []string `json:"modules,omitempty" example:"lighthouse,ai-analysis"` // 需要重新执行的模块（可选）
 }
 
 // CreateTaskResponse 创建任务响应
@@ -118,4 +166,5 @@ type TaskStatusResponse struct {
 	Progress  TaskProgress             `json:"progress"`
 	Modules   map[string]*ModuleStatus `json:"modules"`
 	Error     string                   `json:"error,omitempty" example:""`
+	Verdict   *ScanVerdict             `json:"verdict,omitempty"` // 门禁结论（任务结束后）
 }
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook 事件类型
+const (
+	WebhookEventTaskCompleted      = "task.completed"       // 任务完成（至少一个模块成功）
+	WebhookEventTaskFailed         = "task.failed"          // 任务失败
+	WebhookEventModuleFailed       = "module.failed"        // 单个模块失败
+	WebhookEventScheduleRegression = "schedule.regression"  // 定时扫描结果相对上一次扫描变差
+	WebhookEventCertExpiring       = "certificate.expiring" // SSL 证书剩余天数跨过告警阈值（30/14/7/1 天）
+	WebhookEventPing               = "ping"                 // 测试事件（仅由测试接口发送）
+)
+
+// WebhookEvents 可订阅的事件类型
+var WebhookEvents = []string{
+	WebhookEventTaskCompleted,
+	WebhookEventTaskFailed,
+	WebhookEventModuleFailed,
+	WebhookEventScheduleRegression,
+	WebhookEventCertExpiring,
+}
+
+// WebhookDeliveryStatus Webhook 投递状态
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending" // 等待投递（含等待重试）
+	WebhookDeliverySuccess WebhookDeliveryStatus = "success" // 投递成功（接收方返回 2xx）
+	WebhookDeliveryFailed  WebhookDeliveryStatus = "failed"  // 重试次数用尽仍未成功
+)
+
+// WebhookEndpoint Webhook 接收地址
+// @Description 用户注册的 Webhook 接收地址，事件以 HMAC-SHA256 签名后 POST 到该地址
+type WebhookEndpoint struct {
+	ID          string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID      string    `json:"user_id"`
+	URL         string    `json:"url" example:"https://ci.example.com/hooks/webcheckly"` // 接收地址
+	Description string    `json:"description" example:"部署流水线"`                           // 描述
+	Secret      string    `json:"secret,omitempty" example:"whsec_0123456789abcdef"`     // 签名密钥（仅创建时返回）
+	Events      []string  `json:"events" example:"task.completed,task.failed"`           // 订阅的事件
+	IsActive    bool      `json:"is_active" example:"true"`                              // 是否启用
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery Webhook 投递记录
+// @Description 单次事件投递及其重试情况
+type WebhookDelivery struct {
+	ID             string                `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	EndpointID     string                `json:"endpoint_id"`
+	Event          string                `json:"event" example:"task.completed"`                          // 事件类型
+	Payload        json.RawMessage       `json:"payload" swaggertype:"object"`                            // 投递的请求体
+	Status         WebhookDeliveryStatus `json:"status" example:"success" enums:"pending,success,failed"` // 投递状态
+	Attempts       int                   `json:"attempts" example:"1"`                                    // 已尝试次数
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`                               // 下次重试时间
+	LastStatusCode *int                  `json:"last_status_code,omitempty" example:"200"`                // 最近一次响应状态码
+	LastError      string                `json:"last_error,omitempty" example:""`                         // 最近一次错误
+	ResponseBody   string                `json:"response_body,omitempty" example:"ok"`                    // 最近一次响应体（截断）
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`                                  // 投递成功时间
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// CreateWebhookRequest 创建 Webhook 请求
+// @Description 注册 Webhook 接收地址，events 为空时订阅全部事件
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" example:"https://ci.example.com/hooks/webcheckly"`
+	Description string   `json:"description" example:"部署流水线"`
+	Events      []string `json:"events" example:"task.completed,task.failed,module.failed,schedule.regression,certificate.expiring"`
+}
+
+// UpdateWebhookRequest 更新 Webhook 请求（只更新提供的字段）
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// WebhookPayload Webhook 请求体
+// @Description POST 到接收地址的请求体
+type WebhookPayload struct {
+	ID        string           `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"` // 投递ID（同 X-WebCheckly-Delivery 请求头）
+	Event     string           `json:"event" example:"task.completed"`                    // 事件类型
+	CreatedAt time.Time        `json:"created_at"`                                        // 事件发生时间
+	Data      WebhookEventData `json:"data"`                                              // 事件数据
+}
+
+// WebhookEventData Webhook 事件数据
+type WebhookEventData struct {
+	Task        *WebhookTaskSummary     `json:"task,omitempty"`        // 任务摘要
+	Module      *ModuleStatus           `json:"module,omitempty"`      // 失败的模块（module.failed）
+	Diff        *ScanDiff               `json:"diff,omitempty"`        // 与上一次扫描的对比（schedule.regression）
+	Certificate *CertificateExpiryAlert `json:"certificate,omitempty"` // 即将过期的证书（certificate.expiring）
+}
+
+// CertificateExpiryAlert 证书即将过期告警
+type CertificateExpiryAlert struct {
+	Host          string `json:"host" example:"example.com"`
+	Issuer        string `json:"issuer" example:"CN=R3,O=Let's Encrypt,C=US"`
+	SerialNumber  string `json:"serial_number" example:"1234567890ABCDEF"`
+	ValidTo       string `json:"valid_to" example:"2024-04-01T00:00:00Z"`
+	DaysRemaining int    `json:"days_remaining" example:"13"`
+	Threshold     int    `json:"threshold" example:"14"` // 本次跨过的告警阈值（天）
+}
+
+// WebhookTaskSummary Webhook 中的任务摘要
+type WebhookTaskSummary struct {
+	ID          string                `json:"id"`
+	Status      TaskStatus            `json:"status"`
+	TargetURL   string                `json:"target_url"`
+	Options     []string              `json:"options"`
+	ScheduleID  *string               `json:"schedule_id,omitempty"`
+	Error       string                `json:"error,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+	Modules     map[string]TaskStatus `json:"modules"`           // 各模块状态
+	Summary     *ScanSummary          `json:"summary,omitempty"` // 链接统计
+	Scores      map[string]int        `json:"scores,omitempty"`  // Lighthouse 评分
+	Verdict     *ScanVerdict          `json:"verdict,omitempty"` // 门禁结论
+}
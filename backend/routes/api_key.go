@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"web-checkly/database"
+	"web-checkly/middleware"
+	"web-checkly/models"
+	"web-checkly/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiKeyErrorResponse 将 API 密钥服务的错误转换为 HTTP 响应
+func apiKeyErrorResponse(c *fiber.Ctx, handler string, err error) error {
+	switch {
+	case errors.Is(err, database.ErrAPIKeyNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": "API key not found",
+		})
+	case errors.Is(err, services.ErrAPIKeyLimitReached):
+		return c.Status(409).JSON(fiber.Map{
+			"error":   "API key limit reached",
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidAPIKeyRequest):
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid API key request",
+			"message": err.Error(),
+		})
+	default:
+		log.Printf("[%s] Error: %v", handler, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Internal server error",
+			"details": err.Error(),
+		})
+	}
+}
+
+// CreateAPIKeyHandler 创建 API 密钥
+// @Summary 创建 API 密钥
+// @Description 创建长期有效的 API 密钥，以 "Authorization: Bearer wck_..." 调用 /api/scans 和 /api/batches 接口（不能用于其他接口）。
+// @Description scopes 可选 scans:write、scans:read、reports:read，为空时授予全部权限；expires_in_days 为空表示不过期。
+// @Description 完整密钥 key 只在创建时返回一次，服务端只保存哈希。使用 API 密钥的请求需要专业版或高级版订阅，并计入套餐的月度 API 调用次数。
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.CreateAPIKeyRequest true "API 密钥参数"
+// @Success 201 {object} models.APIKey "创建的 API 密钥（含完整密钥）"
+// @Failure 400 {object} map[string]string "参数无效"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 409 {object} map[string]string "API 密钥数量已达上限"
+// @Router /api/api-keys [post]
+func CreateAPIKeyHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	key, err := services.CreateAPIKey(userID.String(), &req)
+	if err != nil {
+		return apiKeyErrorResponse(c, "CreateAPIKeyHandler", err)
+	}
+
+	return c.Status(201).JSON(key)
+}
+
+// GetAPIKeysHandler 获取当前用户的 API 密钥列表
+// @Summary 获取 API 密钥列表
+// @Description 获取当前用户的全部 API 密钥（含已吊销的，只返回密钥前缀）
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.APIKey "API 密钥列表"
+// @Failure 401 {object} map[string]string "未登录"
+// @Router /api/api-keys [get]
+func GetAPIKeysHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	keys, err := services.GetUserAPIKeys(userID.String())
+	if err != nil {
+		return apiKeyErrorResponse(c, "GetAPIKeysHandler", err)
+	}
+
+	return c.JSON(keys)
+}
+
+// RevokeAPIKeyHandler 吊销 API 密钥
+// @Summary 吊销 API 密钥
+// @Description 吊销当前用户的指定 API 密钥，吊销后立即失效（记录保留，便于审计）
+// @Tags API密钥
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API 密钥ID"
+// @Success 200 {object} models.APIKey "已吊销的 API 密钥"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "API 密钥不存在"
+// @Router /api/api-keys/{id} [delete]
+func RevokeAPIKeyHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	key, err := services.RevokeAPIKey(c.Params("id"), userID.String())
+	if err != nil {
+		return apiKeyErrorResponse(c, "RevokeAPIKeyHandler", err)
+	}
+
+	return c.JSON(key)
+}
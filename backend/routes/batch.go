@@ -0,0 +1,242 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"web-checkly/database"
+	"web-checkly/middleware"
+	"web-checkly/models"
+	"web-checkly/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxBatchCSVSize 批量扫描 CSV 文件大小上限
+const maxBatchCSVSize = 1 << 20
+
+// batchErrorResponse 将批量扫描服务的错误转换为 HTTP 响应
+func batchErrorResponse(c *fiber.Ctx, handler string, err error) error {
+	var invalidURLs *services.InvalidBatchURLsError
+	switch {
+	case errors.As(err, &invalidURLs):
+		return c.Status(400).JSON(fiber.Map{
+			"error":        "Invalid URLs",
+			"message":      "Some URLs cannot be scanned. Fix or remove them and submit the batch again.",
+			"invalid_urls": invalidURLs.URLs,
+		})
+	case errors.Is(err, database.ErrScanBatchNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Batch not found",
+		})
+	case errors.Is(err, services.ErrInvalidScanBatch):
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid batch",
+			"message": err.Error(),
+		})
+	case services.IsCreditsError(err):
+		return c.Status(402).JSON(creditsErrorDetails(err))
+	default:
+		log.Printf("[%s] Error: %v", handler, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Internal server error",
+			"details": err.Error(),
+		})
+	}
+}
+
+// parseBatchForm 解析 multipart/form-data 形式的批量扫描请求（file 为 CSV 文件，其余参数为表单字段）
+func parseBatchForm(c *fiber.Ctx, req *models.CreateBatchRequest) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return fmt.Errorf("%w: csv file is required (form field \"file\")", services.ErrInvalidScanBatch)
+	}
+	if fileHeader.Size > maxBatchCSVSize {
+		return fmt.Errorf("%w: csv file must be at most %d bytes", services.ErrInvalidScanBatch, maxBatchCSVSize)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open csv file: %w", err)
+	}
+	defer file.Close()
+
+	req.URLs, err = services.ParseScanBatchCSV(file)
+	if err != nil {
+		return err
+	}
+	req.Name = c.FormValue("name")
+	req.Language = c.FormValue("language")
+	req.AIMode = c.FormValue("ai_mode")
+	for _, option := range strings.Split(c.FormValue("options"), ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			req.Options = append(req.Options, option)
+		}
+	}
+	if policy := c.FormValue("policy"); policy != "" {
+		if err := json.Unmarshal([]byte(policy), &req.Policy); err != nil {
+			return fmt.Errorf("%w: policy must be a JSON object", services.ErrInvalidScanBatch)
+		}
+	}
+	return nil
+}
+
+// CreateBatchHandler 创建批量扫描
+// @Summary 创建批量扫描
+// @Description 一次提交多个URL（最多 500 个），所有URL使用相同的扫描选项、语言、AI模式和门禁策略，每个URL创建一个子任务。
+// @Description 请求体可以是 JSON（urls 数组），也可以是 multipart/form-data：file 为 CSV 文件（每行第一列为URL，可有表头，# 开头为注释），
+// @Description options 为逗号分隔的扫描选项，policy 为 JSON 字符串。有任何无效URL时整批拒绝并在 invalid_urls 中列出，重复URL只扫描一次。
+// @Description 全部子任务的积分在创建时一次性预扣：积分不足以支付整批时不创建任何任务；子任务失败或被取消时退还该任务的积分。
+// @Tags 批量扫描
+// @Accept json,mpfd
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.CreateBatchRequest true "批量扫描参数"
+// @Success 201 {object} models.ScanBatch "创建的批量扫描"
+// @Failure 400 {object} map[string]interface{} "参数无效或包含无效URL"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 402 {object} map[string]string "积分不足"
+// @Failure 403 {object} map[string]string "用户在黑名单中"
+// @Router /api/batches [post]
+func CreateBatchHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req models.CreateBatchRequest
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		if err := parseBatchForm(c, &req); err != nil {
+			return batchErrorResponse(c, "CreateBatchHandler", err)
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if database.IsUserBlacklisted(*userID) {
+		log.Printf("[CreateBatchHandler] User is blacklisted, aborting batch creation: %s", userID.String())
+		return c.Status(403).JSON(fiber.Map{
+			"error":   "User blacklisted",
+			"message": "Your account has been restricted from creating detection tasks. Please contact support for assistance.",
+		})
+	}
+
+	batch, err := executor.CreateScanBatch(userID.String(), &req)
+	if err != nil {
+		return batchErrorResponse(c, "CreateBatchHandler", err)
+	}
+
+	return c.Status(201).JSON(batch)
+}
+
+// GetBatchesHandler 获取当前用户的批量扫描列表
+// @Summary 获取批量扫描列表
+// @Description 按创建时间倒序获取当前用户的批量扫描及汇总进度
+// @Tags 批量扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "每页数量（默认20，最大100）"
+// @Param offset query int false "偏移量"
+// @Success 200 {object} map[string]interface{} "批量扫描列表（batches、total、limit、offset）"
+// @Failure 401 {object} map[string]string "未登录"
+// @Router /api/batches [get]
+func GetBatchesHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	batches, total, err := services.GetUserScanBatches(userID.String(), limit, offset)
+	if err != nil {
+		return batchErrorResponse(c, "GetBatchesHandler", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"batches": batches,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// GetBatchHandler 获取批量扫描详情
+// @Summary 获取批量扫描详情
+// @Description 获取批量扫描的汇总进度（各状态子任务数、完成百分比、门禁通过数）和全部子任务摘要
+// @Tags 批量扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "批量扫描ID"
+// @Success 200 {object} models.ScanBatch "批量扫描详情"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "批量扫描不存在"
+// @Router /api/batches/{id} [get]
+func GetBatchHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	batch, err := services.GetScanBatch(c.Params("id"), userID.String())
+	if err != nil {
+		return batchErrorResponse(c, "GetBatchHandler", err)
+	}
+
+	return c.JSON(batch)
+}
+
+// ExportBatchHandler 导出批量扫描的合并结果
+// @Summary 导出批量扫描结果
+// @Description 导出全部子任务的合并结果：csv 为每个URL一行的摘要（状态、门禁结论、各项评分、失效链接数、证书剩余天数），
+// @Description json 为批量扫描信息和全部子任务的完整结果。可在批量扫描进行中导出（未结束的子任务没有结果）。
+// @Tags 批量扫描
+// @Produce text/csv,json
+// @Security ApiKeyAuth
+// @Param id path string true "批量扫描ID"
+// @Param format query string false "导出格式" Enums(csv, json) default(csv)
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} map[string]string "不支持的格式"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "批量扫描不存在"
+// @Router /api/batches/{id}/export [get]
+func ExportBatchHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	format := strings.ToLower(c.Query("format", "csv"))
+	data, contentType, filename, err := services.ExportScanBatch(c.Params("id"), userID.String(), format)
+	if err != nil {
+		return batchErrorResponse(c, "ExportBatchHandler", err)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(data)
+}
@@ -251,7 +251,7 @@ func ScanHandler(c *fiber.Ctx) error {
 		go func() {
 			defer wg.Done()
 			log.Printf("[ScanHandler] Collecting website info...")
-			wInfo, err := services.CollectWebsiteInfo(target)
+			wInfo, err := services.CollectWebsiteInfo(target, nil)
 			if err != nil {
 				log.Printf("[ScanHandler] Error collecting website info: %v", err)
 			} else {
@@ -267,7 +267,7 @@ func ScanHandler(c *fiber.Ctx) error {
 		go func() {
 			defer wg.Done()
 			log.Printf("[ScanHandler] Collecting domain info...")
-			dInfo, err := services.CollectDomainInfo(target)
+			dInfo, err := services.CollectDomainInfo(ctx, target, lang, nil)
 			if err != nil {
 				log.Printf("[ScanHandler] Error collecting domain info: %v", err)
 			} else {
@@ -283,7 +283,7 @@ func ScanHandler(c *fiber.Ctx) error {
 		go func() {
 			defer wg.Done()
 			log.Printf("[ScanHandler] Collecting SSL certificate info...")
-			sInfo, err := services.CollectSSLInfo(target)
+			sInfo, err := services.CollectSSLInfo(ctx, target, lang)
 			if err != nil {
 				log.Printf("[ScanHandler] Error collecting SSL info: %v", err)
 			} else {
@@ -299,7 +299,7 @@ func ScanHandler(c *fiber.Ctx) error {
 		go func() {
 			defer wg.Done()
 			log.Printf("[ScanHandler] Collecting tech stack info...")
-			tStack, err := services.CollectTechStack(target)
+			tStack, err := services.CollectTechStack(ctx, target, lang, nil)
 			if err != nil {
 				log.Printf("[ScanHandler] Error collecting tech stack: %v", err)
 			} else {
@@ -379,22 +379,22 @@ func ScanHandler(c *fiber.Ctx) error {
 		"message": "Starting scan...",
 	})
 
-	// 启动httpx检测
+	// 启动链接检测
 	results := make(chan models.HttpxResult, 100)
 	errChan := make(chan error, 1)
 
 	go func() {
 		defer close(errChan)
-		// RunHttpx 内部负责关闭 channel，这里不需要关闭
-		err := services.RunHttpx(ctx, urls, results)
+		// RunLinkCheck 内部负责关闭 channel，这里不需要关闭
+		err := services.RunLinkCheck(ctx, urls, results, nil)
 		if err != nil {
-			log.Printf("[ScanHandler] RunHttpx returned error: %v", err)
+			log.Printf("[ScanHandler] RunLinkCheck returned error: %v", err)
 			select {
 			case errChan <- err:
 			default:
 			}
 		} else {
-			log.Printf("[ScanHandler] RunHttpx completed without error")
+			log.Printf("[ScanHandler] RunLinkCheck completed without error")
 		}
 	}()
 
@@ -425,9 +425,9 @@ func ScanHandler(c *fiber.Ctx) error {
 				continue
 			}
 			if err != nil {
-				log.Printf("[ScanHandler] Received error from httpx: %v", err)
+				log.Printf("[ScanHandler] Received error from link check: %v", err)
 				services.SendSSE(c, "error", fiber.Map{
-					"message": "httpx execution failed: " + err.Error(),
+					"message": "link check failed: " + err.Error(),
 				})
 				return nil
 			}
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"web-checkly/database"
+	"web-checkly/middleware"
+	"web-checkly/models"
+	"web-checkly/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StartScanScheduler 启动定时扫描调度器（到期的计划通过任务队列创建扫描任务）
+func StartScanScheduler() {
+	services.StartScanScheduler(executor)
+}
+
+// scheduleErrorResponse 将定时扫描计划服务的错误转换为 HTTP 响应
+func scheduleErrorResponse(c *fiber.Ctx, handler string, err error) error {
+	switch {
+	case errors.Is(err, database.ErrScanScheduleNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Schedule not found",
+		})
+	case errors.Is(err, services.ErrScheduleTargetBlacklisted):
+		return c.Status(403).JSON(fiber.Map{
+			"error":   "Website blacklisted",
+			"message": "This website is not allowed for detection. Please contact support if you believe this is an error.",
+		})
+	case errors.Is(err, services.ErrScanScheduleLimitReached):
+		return c.Status(409).JSON(fiber.Map{
+			"error":   "Schedule limit reached",
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidScanSchedule):
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid schedule",
+			"message": err.Error(),
+		})
+	default:
+		log.Printf("[%s] Error: %v", handler, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Internal server error",
+			"details": err.Error(),
+		})
+	}
+}
+
+// CreateScheduleHandler 创建定时扫描计划
+// @Summary 创建定时扫描计划
+// @Description 按 cron 表达式（分 时 日 月 周，按 timezone 解释）定期扫描指定URL。每次执行创建一个扫描任务并按选项扣除积分，
+// @Description 积分不足时计划自动暂停（paused_reason 记录原因），充值后可通过更新接口将 status 设为 active 恢复。两次执行间隔不得小于 1 小时。
+// @Tags 定时扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.CreateScheduleRequest true "定时扫描计划参数"
+// @Success 201 {object} models.ScanSchedule "创建的计划"
+// @Failure 400 {object} map[string]string "参数无效（URL、cron 表达式或时区）"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 403 {object} map[string]string "用户或网站在黑名单中"
+// @Failure 409 {object} map[string]string "计划数量已达上限"
+// @Router /api/schedules [post]
+func CreateScheduleHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req models.CreateScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if database.IsUserBlacklisted(*userID) {
+		log.Printf("[CreateScheduleHandler] User is blacklisted, aborting schedule creation: %s", userID.String())
+		return c.Status(403).JSON(fiber.Map{
+			"error":   "User blacklisted",
+			"message": "Your account has been restricted from creating detection tasks. Please contact support for assistance.",
+		})
+	}
+
+	schedule, err := services.CreateScanSchedule(userID.String(), &req)
+	if err != nil {
+		return scheduleErrorResponse(c, "CreateScheduleHandler", err)
+	}
+
+	return c.Status(201).JSON(schedule)
+}
+
+// GetSchedulesHandler 获取当前用户的定时扫描计划列表
+// @Summary 获取定时扫描计划列表
+// @Description 获取当前用户的全部定时扫描计划，包括下次执行时间和上次创建的任务
+// @Tags 定时扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.ScanSchedule "计划列表"
+// @Failure 401 {object} map[string]string "未登录"
+// @Router /api/schedules [get]
+func GetSchedulesHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	schedules, err := services.GetUserScanSchedules(userID.String())
+	if err != nil {
+		return scheduleErrorResponse(c, "GetSchedulesHandler", err)
+	}
+
+	return c.JSON(schedules)
+}
+
+// GetScheduleHandler 获取定时扫描计划详情
+// @Summary 获取定时扫描计划详情
+// @Tags 定时扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "计划ID"
+// @Success 200 {object} models.ScanSchedule "计划详情"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "计划不存在"
+// @Router /api/schedules/{id} [get]
+func GetScheduleHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	schedule, err := services.GetScanSchedule(c.Params("id"), userID.String())
+	if err != nil {
+		return scheduleErrorResponse(c, "GetScheduleHandler", err)
+	}
+
+	return c.JSON(schedule)
+}
+
+// UpdateScheduleHandler 更新定时扫描计划
+// @Summary 更新定时扫描计划
+// @Description 只更新请求中提供的字段。status 设为 paused 暂停计划，设为 active 恢复计划（从当前时间重新计算下次执行时间）
+// @Tags 定时扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "计划ID"
+// @Param request body models.UpdateScheduleRequest true "需要更新的字段"
+// @Success 200 {object} models.ScanSchedule "更新后的计划"
+// @Failure 400 {object} map[string]string "参数无效"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 403 {object} map[string]string "网站在黑名单中"
+// @Failure 404 {object} map[string]string "计划不存在"
+// @Router /api/schedules/{id} [put]
+func UpdateScheduleHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req models.UpdateScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	schedule, err := services.UpdateScanSchedule(c.Params("id"), userID.String(), &req)
+	if err != nil {
+		return scheduleErrorResponse(c, "UpdateScheduleHandler", err)
+	}
+
+	return c.JSON(schedule)
+}
+
+// DeleteScheduleHandler 删除定时扫描计划
+// @Summary 删除定时扫描计划
+// @Description 删除计划后不再创建新任务，已创建的扫描任务保留
+// @Tags 定时扫描
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "计划ID"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "计划不存在"
+// @Router /api/schedules/{id} [delete]
+func DeleteScheduleHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if err := services.DeleteScanSchedule(c.Params("id"), userID.String()); err != nil {
+		return scheduleErrorResponse(c, "DeleteScheduleHandler", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Schedule deleted successfully",
+	})
+}
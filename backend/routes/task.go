@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -23,6 +25,19 @@ var (
 	executor    = services.NewExecutor(taskManager, 3) // 最多3个并发任务
 )
 
+// StartTaskWorker 启动任务队列 worker（从数据库领取并执行扫描任务）
+// concurrency 为本进程最大并发任务数，pluginParallelism 为单个任务内最多同时执行的插件数，<=0 时使用默认值
+func StartTaskWorker(ctx context.Context, concurrency int, pluginParallelism int) {
+	executor.SetMaxWorkers(concurrency)
+	executor.SetPluginParallelism(pluginParallelism)
+	executor.Start(ctx)
+}
+
+// StopTaskWorker 停止任务队列 worker，并将本进程执行中的任务放回队列
+func StopTaskWorker() {
+	executor.Shutdown()
+}
+
 // canAccessTask 检查用户是否有权限访问任务
 // 规则：
 // 1. 如果任务没有user_id（匿名任务），任何人都可以访问
@@ -57,13 +72,21 @@ func canAccessTask(c *fiber.Ctx, task *models.Task) bool {
 // @Description - website-info: 网站基础信息（标题、描述、关键词等）
 // @Description - domain-info: 域名DNS信息（IP、MX、NS、TXT记录等）
 // @Description - ssl-info: SSL证书信息（有效期、签名算法等）
-// @Description - tech-stack: 技术栈识别（框架、CMS、CDN等）
+// @Description - tech-stack: 技术栈识别（框架、CMS、CDN等）和安全响应头分析（CSP、HSTS 等的解析、A-F 等级和修复建议）
+// @Description - email-security: 邮件安全检测（解析并校验 SPF、DMARC、常见选择器的 DKIM、MTA-STS、TLS-RPT 和 BIMI 记录，给出分级发现和 A-F 等级）
+// @Description - js-libraries: 已知漏洞 JavaScript 库检测（从页面脚本的地址、版权声明和哈希识别库版本，与 retire.js 漏洞库比对，结果写入 security_risk.vulnerable_libraries）
+// @Description - cookies: Cookie 安全审计（检查目标页面和爬虫发现页面设置的 Cookie 的 Secure、HttpOnly、SameSite、Domain/Path 范围和有效期，识别跟踪 Cookie）
 // @Description - link-health: 链接健康检查（检测页面内所有链接的可用性）
 // @Description - performance: 性能检测（Lighthouse性能指标）
 // @Description - seo: SEO合规性检测（Lighthouse SEO指标）
 // @Description - security: 安全风险检测（Lighthouse安全指标）
 // @Description - accessibility: 可访问性检测（Lighthouse A11y指标）
+// @Description - sitemap-audit: 多页面审计（读取 robots.txt 和 sitemap，按 URL 模板抽样页面运行 Lighthouse，返回各页面和汇总评分）
 // @Description - ai-analysis: AI智能分析报告（需要配置DEEPSEEK_API_KEY）
+// @Description
+// @Description 可选的 policy 为门禁阈值（如 min_performance、max_broken_links），任务结束时计算 verdict（通过/未通过），可用于 CI 流水线判断。
+// @Description 可选的 crawl 为全站链接检查（katana）的爬取配置：include/exclude 正则范围、max_pages/max_depth/max_duration 预算、delay_ms 请求间隔，默认遵守 robots.txt 并使用 sitemap 中的页面作为起始页面。
+// @Description 可选的 auth 为认证扫描配置：headers 请求头、cookies、basic_auth 或 form_login 表单登录，凭据加密存储且不会在任务详情中返回，只发送给与目标同站的请求（需要服务端配置 SCAN_AUTH_ENCRYPTION_KEY）。
 // @Tags 任务管理
 // @Accept json
 // @Produce json
@@ -88,6 +111,30 @@ func CreateTaskHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// 验证门禁策略
+	if err := services.ValidateScanPolicy(req.Policy); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid policy",
+			"message": err.Error(),
+		})
+	}
+
+	// 验证爬取配置
+	if err := services.ValidateCrawlConfig(req.Crawl); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid crawl config",
+			"message": err.Error(),
+		})
+	}
+
+	// 验证认证扫描配置
+	if err := services.ValidateScanAuth(req.Auth); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid auth config",
+			"message": err.Error(),
+		})
+	}
+
 	// 规范化 URL
 	target, err := utils.NormalizeURL(req.URL)
 	if err != nil {
@@ -200,50 +247,7 @@ func CreateTaskHandler(c *fiber.Ctx) error {
 		// 最后一次尝试失败，返回错误
 		log.Printf("[CreateTaskHandler] Failed to batch create usage records after %d attempts: %v", maxRetries, deductErr)
 
-		// 解析错误信息，提取功能名称和积分信息
-		errorMsg := deductErr.Error()
-		var errorDetails fiber.Map = fiber.Map{
-			"error":   "Credits required",
-			"message": errorMsg,
-		}
-
-		// 尝试提取功能名称和所需积分
-		if strings.Contains(errorMsg, "access denied for feature") {
-			// 提取功能代码和名称
-			parts := strings.Split(errorMsg, ":")
-			if len(parts) > 0 {
-				featurePart := strings.TrimSpace(parts[0])
-				if strings.HasPrefix(featurePart, "access denied for feature") {
-					featureInfo := strings.TrimPrefix(featurePart, "access denied for feature")
-					featureInfo = strings.TrimSpace(featureInfo)
-					// 提取功能代码（第一个单词）
-					featureCode := strings.Fields(featureInfo)[0]
-					errorDetails["feature"] = featureCode
-				}
-			}
-			// 提取所需积分
-			if strings.Contains(errorMsg, "need") {
-				re := regexp.MustCompile(`need (\d+)`)
-				matches := re.FindStringSubmatch(errorMsg)
-				if len(matches) > 1 {
-					if creditsNeeded, err := strconv.Atoi(matches[1]); err == nil {
-						errorDetails["credits_required"] = creditsNeeded
-					}
-				}
-			}
-			// 提取当前积分
-			if strings.Contains(errorMsg, "have") {
-				re := regexp.MustCompile(`have (\d+)`)
-				matches := re.FindStringSubmatch(errorMsg)
-				if len(matches) > 1 {
-					if currentCredits, err := strconv.Atoi(matches[1]); err == nil {
-						errorDetails["current_credits"] = currentCredits
-					}
-				}
-			}
-		}
-
-		return c.Status(402).JSON(errorDetails)
+		return c.Status(402).JSON(creditsErrorDetails(deductErr))
 	}
 
 	// 创建任务
@@ -267,19 +271,7 @@ func CreateTaskHandler(c *fiber.Ctx) error {
 	}
 
 	// 更新使用记录中的task_id（将预扣的使用记录关联到任务）
-	// 注意：在释放锁之前完成所有数据库操作，确保数据一致性
-	for feature, urID := range usageRecordIDs {
-		if urID == "" {
-			continue // 跳过匿名用户使用基础功能的情况
-		}
-		// 更新usage_record的task_id
-		query := `UPDATE usage_records SET task_id = $1 WHERE id = $2`
-		if _, err := database.GetDB().Exec(query, task.ID, urID); err != nil {
-			log.Printf("[CreateTaskHandler] Warning: Failed to link usage record %s to task %s: %v", urID, task.ID, err)
-		} else {
-			log.Printf("[CreateTaskHandler] Linked usage record %s to task %s for feature %s", urID, task.ID, feature)
-		}
-	}
+	services.LinkUsageRecordsToTask(task.ID, usageRecordIDs)
 
 	log.Printf("[CreateTaskHandler] Created task: %s for URL: %s", task.ID, req.URL)
 
@@ -294,6 +286,54 @@ func CreateTaskHandler(c *fiber.Ctx) error {
 	})
 }
 
+// creditsErrorDetails 将积分/权限不足的错误转换为响应内容（提取功能代码、所需积分和当前积分）
+func creditsErrorDetails(deductErr error) fiber.Map {
+	// 解析错误信息，提取功能名称和积分信息
+	errorMsg := deductErr.Error()
+	var errorDetails fiber.Map = fiber.Map{
+		"error":   "Credits required",
+		"message": errorMsg,
+	}
+
+	// 尝试提取功能名称和所需积分
+	if strings.Contains(errorMsg, "access denied for feature") {
+		// 提取功能代码和名称
+		parts := strings.Split(errorMsg, ":")
+		if len(parts) > 0 {
+			featurePart := strings.TrimSpace(parts[0])
+			if strings.HasPrefix(featurePart, "access denied for feature") {
+				featureInfo := strings.TrimPrefix(featurePart, "access denied for feature")
+				featureInfo = strings.TrimSpace(featureInfo)
+				// 提取功能代码（第一个单词）
+				featureCode := strings.Fields(featureInfo)[0]
+				errorDetails["feature"] = featureCode
+			}
+		}
+		// 提取所需积分
+		if strings.Contains(errorMsg, "need") {
+			re := regexp.MustCompile(`need (\d+)`)
+			matches := re.FindStringSubmatch(errorMsg)
+			if len(matches) > 1 {
+				if creditsNeeded, err := strconv.Atoi(matches[1]); err == nil {
+					errorDetails["credits_required"] = creditsNeeded
+				}
+			}
+		}
+		// 提取当前积分
+		if strings.Contains(errorMsg, "have") {
+			re := regexp.MustCompile(`have (\d+)`)
+			matches := re.FindStringSubmatch(errorMsg)
+			if len(matches) > 1 {
+				if currentCredits, err := strconv.Atoi(matches[1]); err == nil {
+					errorDetails["current_credits"] = currentCredits
+				}
+			}
+		}
+	}
+
+	return errorDetails
+}
+
 // GetTaskStatusHandler 获取任务状态
 // @Summary 获取任务状态
 // @Description 查询指定任务的执行状态和进度信息，包括各模块的执行状态
@@ -391,6 +431,92 @@ func GetTaskResultsHandler(c *fiber.Ctx) error {
 	return c.JSON(results)
 }
 
+// GetTaskReportHandler 导出任务报告
+// @Summary 导出任务报告
+// @Description 将任务结果（网站信息、域名、SSL、技术栈、Lighthouse 指标、链接健康和 AI 分析）渲染为可直接分享的报告。
+// @Description format 为 pdf（默认）或 html（单文件，样式内联，不引用外部资源）。报告语言跟随任务的语言设置（zh/en）。
+// @Description format 为 sarif 或 junit 时导出供 CI 使用的 SARIF 2.1.0 / JUnit XML，门禁结论同时写入响应头 X-WebCheckly-Verdict（passed/failed）。
+// @Description 可通过查询参数（min_performance、min_seo、min_security、min_accessibility、max_broken_links、max_vulnerabilities、min_ssl_days）覆盖创建任务时的门禁策略。
+// @Tags 任务管理
+// @Produce application/pdf
+// @Produce text/html
+// @Produce application/sarif+json
+// @Produce application/xml
+// @Param id path string true "任务ID" example:"550e8400-e29b-41d4-a716-446655440000"
+// @Param format query string false "报告格式" Enums(pdf, html, sarif, junit) default(pdf)
+// @Param min_performance query int false "性能评分下限（0-100）"
+// @Param max_broken_links query int false "允许的失效链接数上限"
+// @Success 200 {file} file "报告文件"
+// @Failure 400 {object} map[string]string "不支持的格式或门禁参数无效"
+// @Failure 403 {object} map[string]string "无权访问"
+// @Failure 404 {object} map[string]string "任务不存在"
+// @Failure 409 {object} map[string]string "任务尚未完成"
+// @Router /api/scans/{id}/report [get]
+func GetTaskReportHandler(c *fiber.Ctx) error {
+	taskID := c.Params("id")
+	format := strings.ToLower(c.Query("format", services.ReportFormatPDF))
+	switch format {
+	case services.ReportFormatPDF, services.ReportFormatHTML, services.ReportFormatSARIF, services.ReportFormatJUnit:
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid format",
+			"message": "format must be pdf, html, sarif or junit",
+		})
+	}
+
+	task, err := taskManager.GetTask(taskID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	}
+	if !canAccessTask(c, task) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+	if task.Status == models.TaskStatusPending || task.Status == models.TaskStatusRunning || task.Results == nil {
+		return c.Status(409).JSON(fiber.Map{
+			"error":   "Task has no results yet",
+			"message": "The report is available once the scan is finished.",
+			"status":  task.Status,
+		})
+	}
+
+	// 查询参数中的门禁阈值覆盖任务的策略，并按新策略重新计算结论
+	policy, err := services.ApplyScanPolicyParams(task.Policy, func(name string) string { return c.Query(name) })
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid policy",
+			"message": err.Error(),
+		})
+	}
+	if policy != nil {
+		task.Policy = policy
+		task.Verdict = services.EvaluateScanPolicy(task, policy)
+	} else if task.Verdict == nil {
+		task.Verdict = services.EvaluateScanPolicy(task, task.Policy)
+	}
+
+	content, contentType, filename, err := services.RenderTaskReport(task, format)
+	if err != nil {
+		log.Printf("[GetTaskReportHandler] Error rendering %s report for task %s: %v", format, taskID, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to generate report",
+			"details": err.Error(),
+		})
+	}
+
+	verdict := "passed"
+	if !task.Verdict.Passed {
+		verdict = "failed"
+	}
+	c.Set("X-WebCheckly-Verdict", verdict)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(content)
+}
+
 // StreamTaskHandler SSE流式响应端点
 func StreamTaskHandler(c *fiber.Ctx) error {
 	taskID := c.Params("id")
@@ -496,6 +622,15 @@ func StreamTaskHandler(c *fiber.Ctx) error {
 				}
 			}
 
+			// 如果任务已取消，结束推送
+			if currentStatus.Status == models.TaskStatusCanceled {
+				services.SendSSE(c, "done", fiber.Map{
+					"task_id": taskID,
+					"status":  "canceled",
+				})
+				return nil
+			}
+
 			// 如果任务失败，发送错误
 			if currentStatus.Status == models.TaskStatusFailed {
 				services.SendSSE(c, "error", fiber.Map{
@@ -525,7 +660,7 @@ func GetUserTasksHandler(c *fiber.Ctx) error {
 
 	limitStr := c.Query("limit", "20")
 	offsetStr := c.Query("offset", "0")
-	status := c.Query("status", "") // 筛选状态：pending, running, completed, failed
+	status := c.Query("status", "") // 筛选状态：pending, running, completed, failed, canceled
 	search := c.Query("search", "") // 搜索URL
 
 	limit, err := strconv.Atoi(limitStr)
@@ -630,3 +765,264 @@ func DeleteUserTaskHandler(c *fiber.Ctx) error {
 		"message": "Task deleted successfully",
 	})
 }
+
+// isTaskOwnerOrAdmin 检查当前用户是否为任务所有者或管理员（用于取消、重试等写操作）
+func isTaskOwnerOrAdmin(c *fiber.Ctx, task *models.Task) bool {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return false
+	}
+
+	if task.UserID != nil && *task.UserID == userID.String() {
+		return true
+	}
+
+	user, err := database.GetUserByID(*userID)
+	if err != nil || user == nil {
+		return false
+	}
+	return user.Role == models.UserRoleAdmin
+}
+
+// CancelTaskHandler 取消扫描任务
+// @Summary 取消扫描任务
+// @Description 取消等待执行或执行中的任务（仅任务所有者或管理员）。
+// @Description 正在运行的插件和外部工具（katana、lighthouse、testssl、whatweb 等）会被终止，未结束的模块标记为 canceled，已预扣的积分将退回。
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path string true "任务ID" example:"550e8400-e29b-41d4-a716-446655440000"
+// @Success 200 {object} map[string]interface{} "任务已取消"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 403 {object} map[string]string "无权操作"
+// @Failure 404 {object} map[string]string "任务不存在"
+// @Failure 409 {object} map[string]string "任务已结束，无法取消"
+// @Router /api/scans/{id}/cancel [post]
+func CancelTaskHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	taskID := c.Params("id")
+	if taskID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Task ID is required",
+		})
+	}
+
+	task, err := taskManager.GetTask(taskID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	}
+
+	if !isTaskOwnerOrAdmin(c, task) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if err := executor.CancelTask(taskID); err != nil {
+		if errors.Is(err, services.ErrTaskNotCancelable) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":   "Task cannot be canceled",
+				"message": "Only pending or running tasks can be canceled.",
+				"status":  task.Status,
+			})
+		}
+		log.Printf("[CancelTaskHandler] Error canceling task %s: %v", taskID, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to cancel task",
+			"details": err.Error(),
+		})
+	}
+
+	log.Printf("[CancelTaskHandler] Task %s canceled by user %s", taskID, userID.String())
+	return c.JSON(fiber.Map{
+		"id":      taskID,
+		"status":  models.TaskStatusCanceled,
+		"message": "Task canceled successfully",
+	})
+}
+
+// RetryTaskHandler 重新执行任务中失败或指定的模块
+// @Summary 重新执行失败的模块
+// @Description 重新执行已结束任务（completed/failed/canceled）中失败的模块，或请求中指定的模块（仅任务所有者或管理员）。
+// @Description 只为重新执行的模块扣除积分，新结果合并到任务已有的结果中；重新执行的模块全部失败或任务被取消时退回本次扣除的积分。
+// @Description 指定 lighthouse 时重新执行任务选中的 performance/seo/security/accessibility 子模块。
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path string true "任务ID" example:"550e8400-e29b-41d4-a716-446655440000"
+// @Param request body models.RetryTaskRequest false "需要重新执行的模块（为空时重新执行所有失败的模块）"
+// @Success 202 {object} map[string]interface{} "任务已重新加入队列"
+// @Failure 400 {object} map[string]string "模块不属于该任务或没有需要重新执行的模块"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 402 {object} map[string]string "积分不足"
+// @Failure 403 {object} map[string]string "无权操作"
+// @Failure 404 {object} map[string]string "任务不存在"
+// @Failure 409 {object} map[string]string "任务未结束，无法重新执行"
+// @Router /api/scans/{id}/retry [post]
+func RetryTaskHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	taskID := c.Params("id")
+	if taskID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Task ID is required",
+		})
+	}
+
+	var req models.RetryTaskRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	task, err := taskManager.GetTask(taskID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	}
+
+	if !isTaskOwnerOrAdmin(c, task) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	retryOptions, err := executor.ResolveRetryOptions(task, req.Modules)
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotRetryable) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":   "Task cannot be retried",
+				"message": "Only completed, failed or canceled tasks can be retried.",
+				"status":  task.Status,
+			})
+		}
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid modules",
+			"message": err.Error(),
+		})
+	}
+
+	// 只为重新执行的模块扣除积分（费用记在任务所有者名下）
+	ownerID := ""
+	if task.UserID != nil {
+		ownerID = *task.UserID
+	}
+	usageRecordIDs, err := services.BatchPreDeductFeatureCosts(ownerID, taskID, retryOptions)
+	if err != nil {
+		log.Printf("[RetryTaskHandler] Failed to deduct credits for task %s: %v", taskID, err)
+		return c.Status(402).JSON(creditsErrorDetails(err))
+	}
+
+	if err := executor.RetryTask(taskID, retryOptions, usageRecordIDs); err != nil {
+		if errors.Is(err, services.ErrTaskNotRetryable) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":   "Task cannot be retried",
+				"message": "Only completed, failed or canceled tasks can be retried.",
+			})
+		}
+		log.Printf("[RetryTaskHandler] Error retrying task %s: %v", taskID, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retry task",
+			"details": err.Error(),
+		})
+	}
+
+	log.Printf("[RetryTaskHandler] Task %s queued for retry by user %s: %v", taskID, userID.String(), retryOptions)
+	return c.Status(202).JSON(fiber.Map{
+		"id":      taskID,
+		"status":  models.TaskStatusPending,
+		"modules": retryOptions,
+	})
+}
+
+// DiffTaskHandler 对比两次扫描结果
+// @Summary 对比两次扫描结果
+// @Description 对比当前扫描（id）相对基准扫描（otherId）的变化：新出现/已修复的失效链接、安全响应头变化、Lighthouse 评分和指标变化、SSL 证书变化以及新检测到的技术。
+// @Description 不指定 otherId（GET /api/scans/{id}/diff）或 otherId 为 previous 时，自动与同一用户对同一URL的上一次已完成扫描对比。
+// @Description 只对比两次扫描都包含的部分（compared_scopes），has_regression 表示是否存在变差的项。
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path string true "当前扫描任务ID" example:"550e8400-e29b-41d4-a716-446655440000"
+// @Param otherId path string true "基准扫描任务ID，或 previous" example:"previous"
+// @Success 200 {object} models.ScanDiff "对比结果"
+// @Failure 403 {object} map[string]string "无权访问"
+// @Failure 404 {object} map[string]string "任务不存在或没有可对比的上一次扫描"
+// @Failure 409 {object} map[string]string "任务尚未完成"
+// @Router /api/scans/{id}/diff/{otherId} [get]
+func DiffTaskHandler(c *fiber.Ctx) error {
+	taskID := c.Params("id")
+	otherID := c.Params("otherId", "previous")
+
+	task, err := taskManager.GetTask(taskID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	}
+	if !canAccessTask(c, task) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var base *models.Task
+	if otherID == "previous" {
+		base, err = services.FindPreviousTask(task)
+		if err != nil {
+			log.Printf("[DiffTaskHandler] Error finding previous scan for task %s: %v", taskID, err)
+			return c.Status(500).JSON(fiber.Map{
+				"error":   "Failed to find previous scan",
+				"details": err.Error(),
+			})
+		}
+		if base == nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error":   "No previous scan",
+				"message": "No earlier completed scan of this URL was found.",
+			})
+		}
+	} else {
+		base, err = taskManager.GetTask(otherID)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Task not found",
+			})
+		}
+		if !canAccessTask(c, base) {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Unauthorized",
+			})
+		}
+	}
+
+	for _, t := range []*models.Task{task, base} {
+		if t.Status == models.TaskStatusPending || t.Status == models.TaskStatusRunning || t.Results == nil {
+			return c.Status(409).JSON(fiber.Map{
+				"error":   "Task has no results yet",
+				"message": "Both scans must be finished before they can be compared.",
+				"task_id": t.ID,
+				"status":  t.Status,
+			})
+		}
+	}
+
+	return c.JSON(services.DiffTasks(task, base))
+}
@@ -0,0 +1,280 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"web-checkly/database"
+	"web-checkly/middleware"
+	"web-checkly/models"
+	"web-checkly/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// webhookErrorResponse 将 Webhook 服务的错误转换为 HTTP 响应
+func webhookErrorResponse(c *fiber.Ctx, handler string, err error) error {
+	switch {
+	case errors.Is(err, database.ErrWebhookNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Webhook not found",
+		})
+	case errors.Is(err, services.ErrWebhookLimitReached):
+		return c.Status(409).JSON(fiber.Map{
+			"error":   "Webhook limit reached",
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidWebhook):
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid webhook",
+			"message": err.Error(),
+		})
+	default:
+		log.Printf("[%s] Error: %v", handler, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Internal server error",
+			"details": err.Error(),
+		})
+	}
+}
+
+// CreateWebhookHandler 注册 Webhook
+// @Summary 注册 Webhook
+// @Description 注册接收任务事件的 Webhook（task.completed、task.failed、module.failed、schedule.regression、certificate.expiring，events 为空时订阅全部）。
+// @Description 每次投递以 JSON POST 到 url，请求头 X-WebCheckly-Signature 为 "t=<unix 时间戳>,v1=<hex(HMAC-SHA256(secret, \"<t>.<body>\"))>"。
+// @Description 签名密钥 secret 只在创建时返回一次。接收方返回非 2xx 时按指数退避重试，最多投递 6 次。
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.CreateWebhookRequest true "Webhook 参数"
+// @Success 201 {object} models.WebhookEndpoint "创建的 Webhook（含签名密钥）"
+// @Failure 400 {object} map[string]string "参数无效（URL 或事件类型）"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 409 {object} map[string]string "Webhook 数量已达上限"
+// @Router /api/webhooks [post]
+func CreateWebhookHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	endpoint, err := services.CreateWebhookEndpoint(userID.String(), &req)
+	if err != nil {
+		return webhookErrorResponse(c, "CreateWebhookHandler", err)
+	}
+
+	return c.Status(201).JSON(endpoint)
+}
+
+// GetWebhooksHandler 获取当前用户的 Webhook 列表
+// @Summary 获取 Webhook 列表
+// @Description 获取当前用户注册的全部 Webhook（不含签名密钥）
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.WebhookEndpoint "Webhook 列表"
+// @Failure 401 {object} map[string]string "未登录"
+// @Router /api/webhooks [get]
+func GetWebhooksHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	endpoints, err := services.GetUserWebhookEndpoints(userID.String())
+	if err != nil {
+		return webhookErrorResponse(c, "GetWebhooksHandler", err)
+	}
+
+	return c.JSON(endpoints)
+}
+
+// GetWebhookHandler 获取单个 Webhook
+// @Summary 获取 Webhook 详情
+// @Description 获取当前用户的指定 Webhook（不含签名密钥）
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookEndpoint "Webhook"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "Webhook 不存在"
+// @Router /api/webhooks/{id} [get]
+func GetWebhookHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	endpoint, err := services.GetWebhookEndpoint(c.Params("id"), userID.String())
+	if err != nil {
+		return webhookErrorResponse(c, "GetWebhookHandler", err)
+	}
+
+	return c.JSON(endpoint)
+}
+
+// UpdateWebhookHandler 更新 Webhook
+// @Summary 更新 Webhook
+// @Description 更新 Webhook 的地址、描述、订阅事件或启用状态（只更新提供的字段，签名密钥不变）
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Param request body models.UpdateWebhookRequest true "需要更新的字段"
+// @Success 200 {object} models.WebhookEndpoint "更新后的 Webhook"
+// @Failure 400 {object} map[string]string "参数无效"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "Webhook 不存在"
+// @Router /api/webhooks/{id} [put]
+func UpdateWebhookHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	endpoint, err := services.UpdateWebhookEndpoint(c.Params("id"), userID.String(), &req)
+	if err != nil {
+		return webhookErrorResponse(c, "UpdateWebhookHandler", err)
+	}
+
+	return c.JSON(endpoint)
+}
+
+// DeleteWebhookHandler 删除 Webhook
+// @Summary 删除 Webhook
+// @Description 删除当前用户的指定 Webhook 及其投递记录
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} map[string]string "删除成功"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "Webhook 不存在"
+// @Router /api/webhooks/{id} [delete]
+func DeleteWebhookHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if err := services.DeleteWebhookEndpoint(c.Params("id"), userID.String()); err != nil {
+		return webhookErrorResponse(c, "DeleteWebhookHandler", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook deleted",
+	})
+}
+
+// GetWebhookDeliveriesHandler 获取 Webhook 投递记录
+// @Summary 获取 Webhook 投递记录
+// @Description 按创建时间倒序获取指定 Webhook 的投递记录，包括请求体、尝试次数、最近一次响应状态码和错误
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "每页数量（默认 20，最大 100）"
+// @Param offset query int false "偏移量（默认 0）"
+// @Success 200 {object} map[string]interface{} "投递记录列表"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "Webhook 不存在"
+// @Router /api/webhooks/{id}/deliveries [get]
+func GetWebhookDeliveriesHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	limit := 20
+	offset := 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	deliveries, err := services.GetWebhookDeliveries(c.Params("id"), userID.String(), limit, offset)
+	if err != nil {
+		return webhookErrorResponse(c, "GetWebhookDeliveriesHandler", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// TestWebhookHandler 发送测试事件
+// @Summary 发送 Webhook 测试事件
+// @Description 向指定 Webhook 发送一条 ping 事件（与普通事件一样签名并记录投递结果），用于验证接收地址和签名校验
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Success 202 {object} map[string]string "已加入投递队列"
+// @Failure 401 {object} map[string]string "未登录"
+// @Failure 404 {object} map[string]string "Webhook 不存在"
+// @Router /api/webhooks/{id}/test [post]
+func TestWebhookHandler(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	deliveryID, err := services.SendWebhookPing(c.Params("id"), userID.String())
+	if err != nil {
+		return webhookErrorResponse(c, "TestWebhookHandler", err)
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"message":     "Ping event queued",
+		"delivery_id": deliveryID,
+	})
+}
@@ -148,6 +148,12 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 				input.DomainInfo.ISP,
 				input.DomainInfo.Organization,
 			)
+			if len(input.DomainInfo.Findings) > 0 {
+				fmt.Fprintf(builder, "DNS security issues (DNSSEC, CAA, CNAME, NS):\n")
+				for _, f := range input.DomainInfo.Findings {
+					fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+				}
+			}
 		}
 
 		if input.SSLInfo != nil {
@@ -165,6 +171,10 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 				input.SSLInfo.PublicKeyAlg,
 				input.SSLInfo.KeySize,
 			)
+			fmt.Fprintf(builder, "Chain Trusted: %v, Chain Complete: %v\n", input.SSLInfo.ChainTrusted, input.SSLInfo.ChainComplete)
+			for _, f := range input.SSLInfo.Findings {
+				fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+			}
 		}
 
 		if input.TechStack != nil {
@@ -198,6 +208,12 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 			if len(input.TechStack.Database) > 0 {
 				fmt.Fprintf(builder, "Database: %v\n", input.TechStack.Database)
 			}
+			if analysis := input.TechStack.HeaderAnalysis; analysis != nil {
+				fmt.Fprintf(builder, "Security Headers Score: %d (Grade %s)\n", analysis.Score, analysis.Grade)
+				for _, f := range analysis.Findings {
+					fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+				}
+			}
 		}
 
 		if input.Performance != nil {
@@ -222,6 +238,40 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 			if len(input.Security.Vulnerabilities) > 0 {
 				fmt.Fprintf(builder, "Vulnerabilities: %v\n", input.Security.Vulnerabilities)
 			}
+			if len(input.Security.VulnerableLibraries) > 0 {
+				fmt.Fprintf(builder, "JavaScript libraries with known vulnerabilities:\n")
+				for _, lib := range input.Security.VulnerableLibraries {
+					fmt.Fprintf(builder, "- [%s] %s %s: %s, fixed in %s\n",
+						lib.Severity, lib.Library, lib.Version, strings.Join(jsLibraryIdentifiers(lib), ", "), jsLibraryFixedVersion(lib))
+				}
+			}
+		}
+
+		if input.EmailSecurity != nil {
+			fmt.Fprintf(builder, "\n[Email Security]\n")
+			fmt.Fprintf(builder, "Domain: %s, Score: %d, Grade: %s\n",
+				input.EmailSecurity.Domain, input.EmailSecurity.Score, input.EmailSecurity.Grade)
+			if input.EmailSecurity.SPF != nil {
+				fmt.Fprintf(builder, "SPF: %s\n", input.EmailSecurity.SPF.Record)
+			}
+			if input.EmailSecurity.DMARC != nil {
+				fmt.Fprintf(builder, "DMARC: %s\n", input.EmailSecurity.DMARC.Record)
+			}
+			for _, f := range input.EmailSecurity.Findings {
+				fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+			}
+		}
+
+		if input.Cookies != nil {
+			fmt.Fprintf(builder, "\n[Cookies]\n")
+			fmt.Fprintf(builder, "Pages Checked: %d, Cookies: %d, Tracking Cookies: %d\n",
+				input.Cookies.PagesChecked, len(input.Cookies.Cookies), input.Cookies.Trackers)
+			if vendors := cookieTrackerVendors(input.Cookies); len(vendors) > 0 {
+				fmt.Fprintf(builder, "Trackers: %s\n", strings.Join(vendors, ", "))
+			}
+			for _, f := range input.Cookies.Findings {
+				fmt.Fprintf(builder, "- [%s] %s: %s\n", f.Severity, f.Cookie, f.Message)
+			}
 		}
 
 		if input.Accessibility != nil {
@@ -283,6 +333,12 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 				input.DomainInfo.ISP,
 				input.DomainInfo.Organization,
 			)
+			if len(input.DomainInfo.Findings) > 0 {
+				fmt.Fprintf(builder, "DNS 安全问题（DNSSEC、CAA、CNAME、NS）:\n")
+				for _, f := range input.DomainInfo.Findings {
+					fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+				}
+			}
 		}
 
 		if input.SSLInfo != nil {
@@ -300,6 +356,10 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 				input.SSLInfo.PublicKeyAlg,
 				input.SSLInfo.KeySize,
 			)
+			fmt.Fprintf(builder, "证书链可信: %v, 证书链完整: %v\n", input.SSLInfo.ChainTrusted, input.SSLInfo.ChainComplete)
+			for _, f := range input.SSLInfo.Findings {
+				fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+			}
 		}
 
 		if input.TechStack != nil {
@@ -333,6 +393,12 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 			if len(input.TechStack.Database) > 0 {
 				fmt.Fprintf(builder, "数据库: %v\n", input.TechStack.Database)
 			}
+			if analysis := input.TechStack.HeaderAnalysis; analysis != nil {
+				fmt.Fprintf(builder, "安全响应头评分: %d（等级 %s）\n", analysis.Score, analysis.Grade)
+				for _, f := range analysis.Findings {
+					fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+				}
+			}
 		}
 
 		if input.Performance != nil {
@@ -357,6 +423,40 @@ func buildAIAnalysisPrompt(input *models.AIAnalysisInput) string {
 			if len(input.Security.Vulnerabilities) > 0 {
 				fmt.Fprintf(builder, "安全漏洞: %v\n", input.Security.Vulnerabilities)
 			}
+			if len(input.Security.VulnerableLibraries) > 0 {
+				fmt.Fprintf(builder, "存在已知漏洞的 JavaScript 库:\n")
+				for _, lib := range input.Security.VulnerableLibraries {
+					fmt.Fprintf(builder, "- [%s] %s %s: %s, 修复版本 %s\n",
+						lib.Severity, lib.Library, lib.Version, strings.Join(jsLibraryIdentifiers(lib), ", "), jsLibraryFixedVersion(lib))
+				}
+			}
+		}
+
+		if input.EmailSecurity != nil {
+			fmt.Fprintf(builder, "\n[邮件安全]\n")
+			fmt.Fprintf(builder, "域名: %s, 评分: %d, 等级: %s\n",
+				input.EmailSecurity.Domain, input.EmailSecurity.Score, input.EmailSecurity.Grade)
+			if input.EmailSecurity.SPF != nil {
+				fmt.Fprintf(builder, "SPF: %s\n", input.EmailSecurity.SPF.Record)
+			}
+			if input.EmailSecurity.DMARC != nil {
+				fmt.Fprintf(builder, "DMARC: %s\n", input.EmailSecurity.DMARC.Record)
+			}
+			for _, f := range input.EmailSecurity.Findings {
+				fmt.Fprintf(builder, "- [%s] %s\n", f.Severity, f.Message)
+			}
+		}
+
+		if input.Cookies != nil {
+			fmt.Fprintf(builder, "\n[Cookie]\n")
+			fmt.Fprintf(builder, "检查页面数: %d, Cookie 数量: %d, 跟踪 Cookie: %d\n",
+				input.Cookies.PagesChecked, len(input.Cookies.Cookies), input.Cookies.Trackers)
+			if vendors := cookieTrackerVendors(input.Cookies); len(vendors) > 0 {
+				fmt.Fprintf(builder, "跟踪服务: %s\n", strings.Join(vendors, ", "))
+			}
+			for _, f := range input.Cookies.Findings {
+				fmt.Fprintf(builder, "- [%s] %s: %s\n", f.Severity, f.Cookie, f.Message)
+			}
 		}
 
 		if input.Accessibility != nil {
@@ -23,7 +23,7 @@ func FilterImportantMetrics(input *models.AIAnalysisInput) *models.AIAnalysisInp
 		}
 	}
 
-	// 域名信息：只保留关键字段
+	// 域名信息：只保留关键字段和非提示类的 DNS 安全发现
 	if input.DomainInfo != nil {
 		filtered.DomainInfo = &models.DomainInfo{
 			Domain:       input.DomainInfo.Domain,
@@ -33,6 +33,15 @@ func FilterImportantMetrics(input *models.AIAnalysisInput) *models.AIAnalysisInp
 			ISP:          input.DomainInfo.ISP,
 			Organization: input.DomainInfo.Organization,
 		}
+		for _, f := range input.DomainInfo.Findings {
+			if f.Severity == models.SeverityInfo {
+				continue
+			}
+			filtered.DomainInfo.Findings = append(filtered.DomainInfo.Findings, f)
+			if len(filtered.DomainInfo.Findings) >= 10 {
+				break
+			}
+		}
 	}
 
 	// SSL信息：只保留关键字段
@@ -46,6 +55,17 @@ func FilterImportantMetrics(input *models.AIAnalysisInput) *models.AIAnalysisInp
 			DaysRemaining: input.SSLInfo.DaysRemaining,
 			SignatureAlg:  input.SSLInfo.SignatureAlg,
 			KeySize:       input.SSLInfo.KeySize,
+			ChainTrusted:  input.SSLInfo.ChainTrusted,
+			ChainComplete: input.SSLInfo.ChainComplete,
+		}
+		for _, f := range input.SSLInfo.Findings {
+			if f.Severity == models.SeverityInfo {
+				continue
+			}
+			filtered.SSLInfo.Findings = append(filtered.SSLInfo.Findings, f)
+			if len(filtered.SSLInfo.Findings) >= 10 {
+				break
+			}
 		}
 	}
 
@@ -72,6 +92,22 @@ func FilterImportantMetrics(input *models.AIAnalysisInput) *models.AIAnalysisInp
 		if len(filtered.TechStack.CDN) > 3 {
 			filtered.TechStack.CDN = filtered.TechStack.CDN[:3]
 		}
+		// 安全响应头只保留评分和问题（最多 10 个，去掉提示类发现）
+		if analysis := input.TechStack.HeaderAnalysis; analysis != nil {
+			filtered.TechStack.HeaderAnalysis = &models.SecurityHeadersAnalysis{
+				Score: analysis.Score,
+				Grade: analysis.Grade,
+			}
+			for _, f := range analysis.Findings {
+				if f.Severity == models.SeverityInfo {
+					continue
+				}
+				filtered.TechStack.HeaderAnalysis.Findings = append(filtered.TechStack.HeaderAnalysis.Findings, f)
+				if len(filtered.TechStack.HeaderAnalysis.Findings) >= 10 {
+					break
+				}
+			}
+		}
 	}
 
 	// 性能指标：保留所有字段（数据量不大）
@@ -99,6 +135,60 @@ func FilterImportantMetrics(input *models.AIAnalysisInput) *models.AIAnalysisInp
 		if len(filtered.Security.Vulnerabilities) > 10 {
 			filtered.Security.Vulnerabilities = filtered.Security.Vulnerabilities[:10]
 		}
+		// 存在漏洞的 JavaScript 库：最多 10 个，去掉参考链接和漏洞说明
+		for i, lib := range input.Security.VulnerableLibraries {
+			if i >= 10 {
+				break
+			}
+			vulns := make([]models.LibraryVulnerability, 0, len(lib.Vulnerabilities))
+			for _, v := range lib.Vulnerabilities {
+				vulns = append(vulns, models.LibraryVulnerability{Identifiers: v.Identifiers, Severity: v.Severity, FixedIn: v.FixedIn})
+			}
+			lib.Vulnerabilities = vulns
+			filtered.Security.VulnerableLibraries = append(filtered.Security.VulnerableLibraries, lib)
+		}
+	}
+
+	// 邮件安全：只保留评分、SPF/DMARC 记录和非提示类的发现
+	if input.EmailSecurity != nil {
+		filtered.EmailSecurity = &models.EmailSecurityResult{
+			Domain: input.EmailSecurity.Domain,
+			Score:  input.EmailSecurity.Score,
+			Grade:  input.EmailSecurity.Grade,
+			SPF:    input.EmailSecurity.SPF,
+			DMARC:  input.EmailSecurity.DMARC,
+		}
+		for _, f := range input.EmailSecurity.Findings {
+			if f.Severity == models.SeverityInfo {
+				continue
+			}
+			filtered.EmailSecurity.Findings = append(filtered.EmailSecurity.Findings, f)
+			if len(filtered.EmailSecurity.Findings) >= 10 {
+				break
+			}
+		}
+	}
+
+	// Cookie：只保留统计、跟踪 Cookie（名称和服务）和非提示类的发现（最多 10 个）
+	if input.Cookies != nil {
+		filtered.Cookies = &models.CookieAuditResult{
+			PagesChecked: input.Cookies.PagesChecked,
+			Trackers:     input.Cookies.Trackers,
+		}
+		for _, cookie := range input.Cookies.Cookies {
+			if cookie.Tracker != nil {
+				filtered.Cookies.Cookies = append(filtered.Cookies.Cookies, models.CookieInfo{Name: cookie.Name, Tracker: cookie.Tracker})
+			}
+		}
+		for _, f := range input.Cookies.Findings {
+			if f.Severity == models.SeverityInfo {
+				continue
+			}
+			filtered.Cookies.Findings = append(filtered.Cookies.Findings, f)
+			if len(filtered.Cookies.Findings) >= 10 {
+				break
+			}
+		}
 	}
 
 	// 可访问性：保留所有字段（数据量不大）
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"web-checkly/database"
+	"web-checkly/models"
+
+	"github.com/google/uuid"
+)
+
+// API 密钥参数
+const (
+	apiKeySecretBytes    = 32   // 密钥随机部分的字节数
+	apiKeyDisplayLength  = 12   // 保存和展示的密钥前缀长度（含 wck_）
+	apiKeyMaxExpiresDays = 3650 // 最长有效期（天）
+	maxAPIKeysPerUser    = 20   // 每个用户最多持有的有效密钥数
+)
+
+var (
+	// ErrInvalidAPIKeyRequest 创建 API 密钥的参数无效
+	ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
+	// ErrAPIKeyLimitReached 用户的有效 API 密钥数量已达上限
+	ErrAPIKeyLimitReached = fmt.Errorf("api key limit reached (max %d active keys per user)", maxAPIKeysPerUser)
+	// ErrInvalidAPIKey API 密钥无效（不存在、已吊销或已过期）
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// IsAPIKey 判断 Bearer 凭证是否为 API 密钥（而不是 JWT）
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, models.APIKeyPrefix)
+}
+
+// hashAPIKey 计算密钥哈希（密钥为高熵随机值，直接使用 SHA-256 即可）
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey 生成 API 密钥
+func generateAPIKey() (string, error) {
+	buf := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return models.APIKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// normalizeAPIKeyScopes 校验并去重权限范围，为空时授予全部权限
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string(nil), models.APIKeyScopes...), nil
+	}
+
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !containsString(models.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q (supported: %s)", ErrInvalidAPIKeyRequest, scope, strings.Join(models.APIKeyScopes, ", "))
+		}
+		if !containsString(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// CreateAPIKey 创建 API 密钥，返回的记录包含完整密钥（之后不再返回）
+func CreateAPIKey(userID string, req *models.CreateAPIKeyRequest) (*models.APIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if len(name) > 255 {
+		return nil, fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidAPIKeyRequest)
+	}
+	scopes, err := normalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil && *req.ExpiresInDays != 0 {
+		days := *req.ExpiresInDays
+		if days < 0 || days > apiKeyMaxExpiresDays {
+			return nil, fmt.Errorf("%w: expires_in_days must be between 1 and %d", ErrInvalidAPIKeyRequest, apiKeyMaxExpiresDays)
+		}
+		expires := time.Now().AddDate(0, 0, days)
+		expiresAt = &expires
+	}
+
+	count, err := database.CountActiveUserAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, ErrAPIKeyLimitReached
+	}
+
+	secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:apiKeyDisplayLength],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := database.CreateAPIKey(key, hashAPIKey(secret)); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[APIKey] Created api key %s (%s) for user %s with scopes %v", key.ID, key.Prefix, userID, key.Scopes)
+	key.Key = secret
+	return key, nil
+}
+
+// GetUserAPIKeys 获取用户的全部 API 密钥（不含完整密钥）
+func GetUserAPIKeys(userID string) ([]*models.APIKey, error) {
+	return database.GetUserAPIKeys(userID)
+}
+
+// RevokeAPIKey 吊销 API 密钥，吊销后立即失效
+func RevokeAPIKey(keyID, userID string) (*models.APIKey, error) {
+	if _, err := uuid.Parse(keyID); err != nil {
+		return nil, database.ErrAPIKeyNotFound
+	}
+	key, err := database.RevokeAPIKey(keyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[APIKey] Revoked api key %s (%s) for user %s", key.ID, key.Prefix, userID)
+	return key, nil
+}
+
+// AuthenticateAPIKey 校验 API 密钥，返回密钥和所属用户
+func AuthenticateAPIKey(secret string) (*models.APIKey, *models.User, error) {
+	if !IsAPIKey(secret) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := database.GetAPIKeyByHash(hashAPIKey(secret))
+	if errors.Is(err, database.ErrAPIKeyNotFound) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, nil, fmt.Errorf("%w: key has been revoked", ErrInvalidAPIKey)
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return nil, nil, fmt.Errorf("%w: key has expired", ErrInvalidAPIKey)
+	}
+
+	userID, err := uuid.Parse(key.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get api key owner: %w", err)
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	go func() {
+		if err := database.TouchAPIKey(key.ID); err != nil {
+			log.Printf("[APIKey] Failed to update last used time for key %s: %v", key.ID, err)
+		}
+	}()
+	return key, user, nil
+}
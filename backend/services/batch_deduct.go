@@ -214,7 +214,7 @@ func BatchCreateUsageRecords(userID string, taskID string, features []string) (m
 }
 
 // BatchDeductCreditsForTask 在任务完成时扣除积分
-// 根据任务关联的使用记录扣除积分（幂等性：如果已扣除，则跳过）
+// 根据任务关联的使用记录扣除积分（幂等性：已扣除的记录带有 deducted_at，会被跳过）
 func BatchDeductCreditsForTask(taskID string) error {
 	// 查询任务关联的使用记录（未退款且未扣除的）
 	// 添加检查：只处理未标记为已扣除的记录
@@ -223,6 +223,7 @@ func BatchDeductCreditsForTask(taskID string) error {
 		FROM usage_records ur
 		WHERE ur.task_id = $1 
 		  AND ur.is_refunded = false
+		  AND ur.deducted_at IS NULL
 		  AND NOT EXISTS (
 			SELECT 1 FROM usage_records ur2 
 			WHERE ur2.id = ur.id 
@@ -293,7 +294,18 @@ func BatchDeductCreditsForTask(taskID string) error {
 		// 实际上，由于每个使用记录只关联一个任务，我们可以直接检查用户积分
 
 		// 更简单的方法：直接尝试扣除，如果积分不足（且不是因为其他原因），说明可能已经扣除过
-		// 但这样不够准确，我们使用标记字段来确保幂等性
+		// 但这样不够准确，我们使用标记字段来确保幂等性：
+		// 在事务中设置 deducted_at，并发扣除时只有一个事务能标记成功
+		result, err := tx.Exec(
+			`UPDATE usage_records SET deducted_at = NOW() WHERE id = $1 AND deducted_at IS NULL`,
+			record.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark usage record %s as deducted: %w", record.ID, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			continue
+		}
 
 		validRecords = append(validRecords, record)
 	}
@@ -354,11 +366,31 @@ func BatchDeductCreditsForTask(taskID string) error {
 
 // BatchPreDeductFeatureCosts 批量预扣功能使用成本（使用单个事务，确保原子性）
 // 返回 usageRecordIDs 映射（feature -> usageRecordID）
-// 注意：此函数已废弃，请使用 BatchCreateUsageRecords + BatchDeductCreditsForTask
+// 注意：新建任务请使用 BatchCreateUsageRecords + BatchDeductCreditsForTask，预扣只用于重新执行模块和批量扫描
 func BatchPreDeductFeatureCosts(userID string, taskID string, features []string) (map[string]string, error) {
-	if userID == "" {
+	usageRecordIDs, err := batchPreDeductFeatureCosts(userID, []string{taskID}, features)
+	if err != nil {
+		return nil, err
+	}
+	return usageRecordIDs[0], nil
+}
+
+// BatchPreDeductFeatureCostsForTasks 为 count 个使用相同扫描选项的任务一次性预扣功能使用成本
+// 所有任务在同一个事务中扣费：积分不足以支付全部任务时一个都不扣
+// 返回每个任务的 usageRecordIDs 映射，任务创建后通过 LinkUsageRecordsToTask 关联
+func BatchPreDeductFeatureCostsForTasks(userID string, count int, features []string) ([]map[string]string, error) {
+	return batchPreDeductFeatureCosts(userID, make([]string, count), features)
+}
+
+// batchPreDeductFeatureCosts 在单个事务中为每个任务（taskIDs 中的任务ID可以为空）预扣相同功能的使用成本
+func batchPreDeductFeatureCosts(userID string, taskIDs []string, features []string) ([]map[string]string, error) {
+	usageRecordIDs := make([]map[string]string, len(taskIDs))
+	for i := range usageRecordIDs {
+		usageRecordIDs[i] = make(map[string]string)
+	}
+	if userID == "" || len(taskIDs) == 0 {
 		// 匿名用户只能使用基础功能
-		return make(map[string]string), nil
+		return usageRecordIDs, nil
 	}
 
 	// 开始事务
@@ -424,11 +456,8 @@ func BatchPreDeductFeatureCosts(userID string, taskID string, features []string)
 		}
 	}
 
-	// 准备扣除请求列表
-	deductRequests := make([]FeatureDeductRequest, 0)
-	totalCreditsNeeded := 0
-	totalMonthlyCreditsNeeded := 0
-
+	// 需要扣费的功能列表
+	chargeable := make([]FeatureDeductRequest, 0)
 	for _, option := range features {
 		// 将选项名称映射到功能代码
 		featureCode := mapOptionToFeatureCode(option)
@@ -443,39 +472,54 @@ func BatchPreDeductFeatureCosts(userID string, taskID string, features []string)
 			continue
 		}
 
-		// 检查访问权限
-		canAccess, accessType, err := checkFeatureAccessInTx(tx, userID, featureCode, pricing, &credits, isSubscriptionUser, plan)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check access for feature %s: %w", featureCode, err)
-		}
-		if !canAccess {
-			// 返回详细的错误信息
-			var currentCredits int
-			if credits.Credits > 0 {
-				currentCredits = credits.Credits
-			}
-			if accessType == "not_logged_in" || accessType == "credits_required" {
-				return nil, fmt.Errorf("access denied for feature %s (%s): login required, need %d credits", featureCode, pricing.FeatureName, pricing.CreditsCost)
-			} else if accessType == "insufficient_credits" {
-				return nil, fmt.Errorf("access denied for feature %s (%s): insufficient credits, need %d, have %d", featureCode, pricing.FeatureName, pricing.CreditsCost, currentCredits)
-			} else {
-				return nil, fmt.Errorf("access denied for feature %s (%s): %s", featureCode, pricing.FeatureName, accessType)
-			}
-		}
-
-		deductRequests = append(deductRequests, FeatureDeductRequest{
+		chargeable = append(chargeable, FeatureDeductRequest{
 			Feature:     featureCode,
 			Pricing:     pricing,
-			AccessType:  accessType,
 			CreditsCost: pricing.CreditsCost,
 		})
+	}
 
-		// 累计需要的资源
-		switch accessType {
-		case "subscription", "credits":
-			totalCreditsNeeded += pricing.CreditsCost
-			if accessType == "subscription" {
-				totalMonthlyCreditsNeeded += pricing.CreditsCost
+	// 按任务逐个确定每个功能的扣费方式：前面的任务扣除后，后面的任务按剩余的积分余额和月度额度判断
+	// （如月度额度在批量中途用完时，之后的任务改为按积分扣费）
+	deductRequests := make([][]FeatureDeductRequest, len(taskIDs))
+	totalCreditsNeeded := 0
+	totalMonthlyCreditsNeeded := 0
+	remaining := credits
+
+	for i := range taskIDs {
+		for _, req := range chargeable {
+			// 检查访问权限
+			canAccess, accessType, err := checkFeatureAccessInTx(tx, userID, req.Feature, req.Pricing, &remaining, isSubscriptionUser, plan)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check access for feature %s: %w", req.Feature, err)
+			}
+			if !canAccess {
+				// 返回详细的错误信息
+				var currentCredits int
+				if remaining.Credits > 0 {
+					currentCredits = remaining.Credits
+				}
+				if accessType == "not_logged_in" || accessType == "credits_required" {
+					return nil, fmt.Errorf("access denied for feature %s (%s): login required, need %d credits", req.Feature, req.Pricing.FeatureName, req.CreditsCost)
+				} else if accessType == "insufficient_credits" {
+					return nil, fmt.Errorf("access denied for feature %s (%s): insufficient credits, need %d, have %d", req.Feature, req.Pricing.FeatureName, req.CreditsCost, currentCredits)
+				} else {
+					return nil, fmt.Errorf("access denied for feature %s (%s): %s", req.Feature, req.Pricing.FeatureName, accessType)
+				}
+			}
+
+			req.AccessType = accessType
+			deductRequests[i] = append(deductRequests[i], req)
+
+			// 累计需要的资源，并从剩余额度中扣除（供后续任务判断）
+			switch accessType {
+			case "subscription", "credits":
+				totalCreditsNeeded += req.CreditsCost
+				remaining.Credits -= req.CreditsCost
+				if accessType == "subscription" {
+					totalMonthlyCreditsNeeded += req.CreditsCost
+					remaining.MonthlyCreditsUsed += req.CreditsCost
+				}
 			}
 		}
 	}
@@ -492,46 +536,47 @@ func BatchPreDeductFeatureCosts(userID string, taskID string, features []string)
 	}
 
 	// 执行扣除操作
-	usageRecordIDs := make(map[string]string)
 	now := time.Now()
 	scanDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	for _, req := range deductRequests {
-		usageRecordID := uuid.New().String()
-		var creditsUsed int
-		isFree := false
+	for i, taskID := range taskIDs {
+		for _, req := range deductRequests[i] {
+			usageRecordID := uuid.New().String()
+			var creditsUsed int
+			isFree := false
+
+			switch req.AccessType {
+			case "subscription", "credits":
+				isFree = false
+				creditsUsed = req.CreditsCost
+			default:
+				return nil, fmt.Errorf("unknown access type: %s", req.AccessType)
+			}
 
-		switch req.AccessType {
-		case "subscription", "credits":
-			isFree = false
-			creditsUsed = req.CreditsCost
-		default:
-			return nil, fmt.Errorf("unknown access type: %s", req.AccessType)
-		}
+			// 创建使用记录（积分在本事务中扣除，标记为已扣费）
+			var taskIDPtr *string
+			if taskID != "" {
+				taskIDPtr = &taskID
+			}
+			_, err = tx.Exec(
+				`INSERT INTO usage_records (id, user_id, task_id, feature_type, credits_used, is_free, is_refunded, scan_date, created_at, deducted_at)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)`,
+				usageRecordID,
+				userID,
+				taskIDPtr,
+				req.Feature,
+				creditsUsed,
+				isFree,
+				false,
+				scanDate,
+				now,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create usage record for %s: %w", req.Feature, err)
+			}
 
-		// 创建使用记录
-		var taskIDPtr *string
-		if taskID != "" {
-			taskIDPtr = &taskID
+			usageRecordIDs[i][req.Feature] = usageRecordID
 		}
-		_, err = tx.Exec(
-			`INSERT INTO usage_records (id, user_id, task_id, feature_type, credits_used, is_free, is_refunded, scan_date, created_at)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-			usageRecordID,
-			userID,
-			taskIDPtr,
-			req.Feature,
-			creditsUsed,
-			isFree,
-			false,
-			scanDate,
-			now,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create usage record for %s: %w", req.Feature, err)
-		}
-
-		usageRecordIDs[req.Feature] = usageRecordID
 	}
 
 	// 更新用户积分
@@ -562,7 +607,7 @@ func BatchPreDeductFeatureCosts(userID string, taskID string, features []string)
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("[BatchPreDeduct] Successfully deducted costs for %d features for user %s", len(usageRecordIDs), userID)
+	log.Printf("[BatchPreDeduct] Successfully deducted costs for %d features x %d tasks for user %s", len(chargeable), len(taskIDs), userID)
 	return usageRecordIDs, nil
 }
 
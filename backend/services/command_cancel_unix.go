@@ -0,0 +1,25 @@
+//go:build !windows
+
+package services
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// configureCommandCancel 配置外部命令的取消行为
+// 命令在独立的进程组中运行，ctx 取消时终止整个进程组，
+// 确保 lighthouse 启动的 Chrome、testssl 调用的 openssl 等子进程一并退出
+func configureCommandCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		// 负的 pid 表示向整个进程组发送信号
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// 进程被终止后，最多再等待管道关闭 5 秒
+	cmd.WaitDelay = 5 * time.Second
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package services
+
+import (
+	"os/exec"
+	"time"
+)
+
+// configureCommandCancel 配置外部命令的取消行为
+// Windows 下使用 exec.CommandContext 默认的 Kill 行为，仅限制终止后等待管道关闭的时间
+func configureCommandCancel(cmd *exec.Cmd) {
+	cmd.WaitDelay = 5 * time.Second
+}
@@ -0,0 +1,516 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"web-checkly/models"
+)
+
+const (
+	cookieMaxPages     = 20               // 最多检查的页面数（含目标页面）
+	cookieWorkers      = 4                // 并发请求页面数
+	cookieFetchTimeout = 15 * time.Second // 单个页面的请求超时（含重定向）
+	cookiePageMaxBytes = 2 << 20          // 识别跟踪脚本时读取的页面最大字节数
+
+	// sessionCookieMaxLifetime 会话 Cookie 的有效期超过该值（24 小时）时提示
+	sessionCookieMaxLifetime = 24 * time.Hour
+	// trackerCookieMaxLifetime 跟踪 Cookie 的有效期超过该值（13 个月，CNIL 等监管机构建议的上限）时提示
+	trackerCookieMaxLifetime = 395 * 24 * time.Hour
+)
+
+var (
+	// sessionCookiePattern 会话或认证 Cookie 的名称（PHPSESSID、JSESSIONID、connect.sid、laravel_session、.ASPXAUTH 等）
+	sessionCookiePattern = regexp.MustCompile(`(?i)(sess|^sid$|[_.\-]sid$|^auth|[_.\-]auth|token|jwt|^remember|logged_in|^\.aspnetcore\.)`)
+	// csrfCookiePattern CSRF Cookie 需要被前端脚本读取（如 XSRF-TOKEN），不按会话 Cookie 处理
+	csrfCookiePattern = regexp.MustCompile(`(?i)(csrf|xsrf)`)
+)
+
+// cookieTrackerRule 已知的跟踪服务
+type cookieTrackerRule struct {
+	vendor   string
+	category string
+	names    *regexp.Regexp // 该服务设置的 Cookie 名称
+	scripts  []string       // 页面引用这些脚本（地址片段）时，推断会设置 inferred 中的 Cookie
+	inferred []string       // 脚本在目标网站上设置的第一方 Cookie（* 表示名称中包含站点或账号 ID）
+}
+
+// cookieTrackerRules 常见的分析和广告跟踪服务
+var cookieTrackerRules = []cookieTrackerRule{
+	{"Google Analytics", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(_ga|_ga_[A-Z0-9]+|_gid|_gat(_.+)?|__utm[abcztv])$`),
+		[]string{"google-analytics.com/analytics.js", "google-analytics.com/ga.js", "googletagmanager.com/gtag/js"}, []string{"_ga", "_ga_*", "_gid"}},
+	{"Google Ads", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(_gcl_(au|aw|dc|gb)|IDE|DSID|test_cookie|__gads|__gpi)$`),
+		[]string{"googleadservices.com", "googlesyndication.com", "doubleclick.net"}, []string{"_gcl_au"}},
+	{"Meta Pixel", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(_fbp|_fbc|fr)$`),
+		[]string{"connect.facebook.net"}, []string{"_fbp"}},
+	{"Microsoft Clarity", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(_clck|_clsk|CLID)$`),
+		[]string{"clarity.ms/tag"}, []string{"_clck", "_clsk"}},
+	{"Microsoft Advertising", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(_uetsid|_uetvid|MUID)$`),
+		[]string{"bat.bing.com"}, []string{"_uetsid", "_uetvid"}},
+	{"Hotjar", models.TrackerCategoryAnalytics, regexp.MustCompile(`^_hj`),
+		[]string{"static.hotjar.com"}, []string{"_hjSessionUser_*", "_hjSession_*"}},
+	{"HubSpot", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(__hstc|__hssc|__hssrc|hubspotutk)$`),
+		[]string{"js.hs-scripts.com", "js.hs-analytics.net"}, []string{"__hstc", "__hssc", "hubspotutk"}},
+	{"LinkedIn Insight", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(li_fat_id|li_sugr|lidc|bcookie|UserMatchHistory|AnalyticsSyncHistory)$`),
+		[]string{"snap.licdn.com"}, nil},
+	{"TikTok Pixel", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(_ttp|_tt_enable_cookie|ttcsid.*)$`),
+		[]string{"analytics.tiktok.com"}, []string{"_ttp"}},
+	{"X (Twitter) Ads", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(personalization_id|muc_ads|_twclid|guest_id)$`),
+		[]string{"static.ads-twitter.com"}, nil},
+	{"Pinterest Tag", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(_pin_unauth|_pinterest_ct_ua|_epik)$`),
+		[]string{"s.pinimg.com/ct/"}, []string{"_pin_unauth"}},
+	{"Snap Pixel", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(_scid|_sctr|sc_at)$`),
+		[]string{"sc-static.net/scevent"}, []string{"_scid"}},
+	{"Reddit Pixel", models.TrackerCategoryAdvertising, regexp.MustCompile(`^_rdt_uuid$`),
+		[]string{"redditstatic.com/ads"}, []string{"_rdt_uuid"}},
+	{"Criteo", models.TrackerCategoryAdvertising, regexp.MustCompile(`^(cto_bundle|cto_bidid)$`),
+		[]string{"static.criteo.net"}, []string{"cto_bundle"}},
+	{"Quantcast", models.TrackerCategoryAdvertising, regexp.MustCompile(`^__qca$`),
+		[]string{"quantserve.com"}, []string{"__qca"}},
+	{"Yandex Metrica", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(_ym_[a-z_]+|yandexuid|yuidss)$`),
+		[]string{"mc.yandex.ru/metrika", "mc.yandex.com/metrika"}, []string{"_ym_uid", "_ym_d"}},
+	{"Baidu Tongji", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(Hm_lvt_|Hm_lpvt_|HMACCOUNT)`),
+		[]string{"hm.baidu.com/hm.js"}, []string{"Hm_lvt_*", "Hm_lpvt_*"}},
+	{"CNZZ / Umeng", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(CNZZDATA\d+|UM_distinctid)$`),
+		[]string{"cnzz.com/", "umeng.com/"}, []string{"CNZZDATA*", "UM_distinctid"}},
+	{"Matomo", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(_pk_(id|ses|ref|cvar|hsr)[._].*|MATOMO_SESSID)$`),
+		[]string{"matomo.js", "piwik.js"}, []string{"_pk_id.*", "_pk_ses.*"}},
+	{"Adobe Analytics", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(s_cc|s_sq|s_vi|s_fid|AMCVS?_.+|demdex|dextp)$`),
+		[]string{"omtrdc.net", "demdex.net", "2o7.net"}, nil},
+	{"Mixpanel", models.TrackerCategoryAnalytics, regexp.MustCompile(`^mp_.+_mixpanel$`),
+		[]string{"cdn.mxpnl.com"}, []string{"mp_*_mixpanel"}},
+	{"Segment", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(ajs_anonymous_id|ajs_user_id|ajs_group_id)$`),
+		[]string{"cdn.segment.com"}, []string{"ajs_anonymous_id"}},
+	{"Amplitude", models.TrackerCategoryAnalytics, regexp.MustCompile(`^(amp_[0-9a-f]+|AMP_[0-9A-Fa-f]+.*)$`),
+		[]string{"cdn.amplitude.com"}, nil},
+}
+
+// cookieMessages 发现的说明文案（zh/en）
+var cookieMessages = map[string]map[string]string{
+	"zh": {
+		"cookie_no_secure":              "没有设置 Secure，Cookie 会通过明文 HTTP 发送，可能被窃听",
+		"cookie_no_httponly":            "没有设置 HttpOnly，页面脚本（包括注入的 XSS 脚本）可以读取该 Cookie",
+		"cookie_no_samesite":            "没有设置 SameSite，部分浏览器会在跨站请求中发送该 Cookie（CSRF）；建议显式设置 Lax 或 Strict",
+		"cookie_samesite_none_insecure": "SameSite=None 但没有设置 Secure，浏览器会拒绝该 Cookie",
+		"cookie_samesite_none":          "会话 Cookie 使用 SameSite=None，跨站请求会带上该 Cookie，需要确认有其他 CSRF 防护",
+		"cookie_samesite_invalid":       "SameSite 的值无法识别，浏览器会按默认策略处理",
+		"cookie_domain_invalid":         "Domain=%s 与设置它的主机 %s 不匹配，浏览器会拒绝该 Cookie",
+		"cookie_domain_broad":           "会话 Cookie 设置了 Domain=%s，会发送给所有子域名，任何子域名被攻破都可能窃取会话；建议去掉 Domain 属性或使用 __Host- 前缀",
+		"cookie_path_broad":             "会话 Cookie 由 %s 设置但 Path=/，会发送给整个网站的所有路径",
+		"cookie_session_long_lived":     "会话 Cookie 有效期为 %d 小时，被窃取后长期有效；建议不设置 Expires/Max-Age 或缩短有效期",
+		"cookie_tracker_long_lived":     "跟踪 Cookie 有效期为 %d 天，超过监管机构建议的 13 个月",
+		"cookie_prefix_secure":          "__Secure- 前缀要求通过 HTTPS 设置且带 Secure 属性，浏览器会拒绝该 Cookie",
+		"cookie_prefix_host":            "__Host- 前缀要求通过 HTTPS 设置、带 Secure、Path=/ 且不能设置 Domain，浏览器会拒绝该 Cookie",
+		"tracker_set_by_server":         "服务器在页面加载时直接设置了 %s 的跟踪 Cookie，在欧盟（GDPR/ePrivacy）需要先征得用户同意",
+		"tracker_script":                "页面引用了 %s 的跟踪脚本，会在浏览器中设置跟踪 Cookie%s，在欧盟（GDPR/ePrivacy）需要先征得用户同意",
+	},
+	"en": {
+		"cookie_no_secure":              "Secure is not set, so the cookie is sent over plain HTTP and can be intercepted",
+		"cookie_no_httponly":            "HttpOnly is not set, so page scripts (including injected XSS payloads) can read the cookie",
+		"cookie_no_samesite":            "SameSite is not set, so some browsers send the cookie on cross-site requests (CSRF); set Lax or Strict explicitly",
+		"cookie_samesite_none_insecure": "SameSite=None without Secure; browsers reject the cookie",
+		"cookie_samesite_none":          "The session cookie uses SameSite=None and is sent on cross-site requests; make sure other CSRF protection is in place",
+		"cookie_samesite_invalid":       "The SameSite value is not recognized; browsers apply their default policy",
+		"cookie_domain_invalid":         "Domain=%s does not match the host %s that set it; browsers reject the cookie",
+		"cookie_domain_broad":           "The session cookie sets Domain=%s and is sent to every subdomain, so any compromised subdomain can steal the session; drop the Domain attribute or use the __Host- prefix",
+		"cookie_path_broad":             "The session cookie is set by %s but uses Path=/, so it is sent to every path on the site",
+		"cookie_session_long_lived":     "The session cookie lives for %d hours and stays usable long after being stolen; omit Expires/Max-Age or shorten the lifetime",
+		"cookie_tracker_long_lived":     "The tracking cookie lives for %d days, longer than the 13 months recommended by regulators",
+		"cookie_prefix_secure":          "The __Secure- prefix requires the cookie to be set over HTTPS with the Secure attribute; browsers reject the cookie",
+		"cookie_prefix_host":            "The __Host- prefix requires the cookie to be set over HTTPS with Secure, Path=/ and no Domain; browsers reject the cookie",
+		"tracker_set_by_server":         "The server sets a %s tracking cookie on page load; in the EU (GDPR/ePrivacy) this requires prior user consent",
+		"tracker_script":                "The page loads the %s tracking script, which sets tracking cookies in the browser%s; in the EU (GDPR/ePrivacy) this requires prior user consent",
+	},
+}
+
+// observedCookie 响应中设置的一个 Cookie
+type observedCookie struct {
+	cookie *http.Cookie
+	setBy  *url.URL // 设置该 Cookie 的响应地址
+}
+
+// cookiePageResult 一个页面（含重定向）设置的 Cookie 和页面 HTML（用于识别跟踪脚本）
+type cookiePageResult struct {
+	cookies []observedCookie
+	html    string
+}
+
+// cookieAudit 一次 Cookie 审计的上下文
+type cookieAudit struct {
+	target *url.URL
+	lang   string
+	now    time.Time
+	result *models.CookieAuditResult
+}
+
+// CollectCookies 收集目标页面和爬虫发现的同站页面设置的 Cookie 并检查安全属性，识别已知的跟踪 Cookie
+// crawled 为爬虫（katana 模块）发现的链接，为空时只检查目标页面和它链接的同站页面；
+// 通过 JavaScript 设置的跟踪 Cookie 无法从响应中看到，根据页面引用的跟踪脚本推断
+func CollectCookies(ctx context.Context, targetURL string, lang string, crawled []KatanaResult, auth *ScanAuthSession) (*models.CookieAuditResult, error) {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	if len(crawled) == 0 {
+		discovered, err := discoverPageLinks(ctx, targetURL, "", nil, auth)
+		if err != nil {
+			// 只检查目标页面
+			log.Printf("[Cookies] %s failed, checking target page only: %v", CrawlerBackend(), err)
+		}
+		crawled = discovered
+	}
+	links := []string{targetURL}
+	for _, result := range crawled {
+		links = append(links, result.URL)
+	}
+	pages := filterPublicURLs(filterSitePages(target, links))
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("target URL is not a public page: %s", targetURL)
+	}
+	if len(pages) > cookieMaxPages {
+		log.Printf("[Cookies] Checking first %d of %d discovered pages", cookieMaxPages, len(pages))
+		pages = pages[:cookieMaxPages]
+	}
+
+	// 按页面顺序记录结果，同一 Cookie 以第一个设置它的页面为准
+	results := make([]*cookiePageResult, len(pages))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < cookieWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				page, err := fetchCookiePage(ctx, auth, pages[index])
+				if err != nil {
+					log.Printf("[Cookies] Failed to fetch %s: %v", pages[index], err)
+				}
+				results[index] = page
+			}
+		}()
+	}
+	for i := range pages {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if results[0] == nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	audit := &cookieAudit{
+		target: target,
+		lang:   lang,
+		now:    time.Now(),
+		result: &models.CookieAuditResult{
+			Cookies:  []models.CookieInfo{},
+			Findings: []models.CookieFinding{},
+		},
+	}
+	seen := make(map[string]bool)
+	for i, page := range results {
+		if page == nil {
+			continue
+		}
+		audit.result.PagesChecked++
+		for _, observed := range page.cookies {
+			key := cookieKey(observed)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			audit.checkCookie(observed)
+		}
+		audit.inferScriptTrackers(pages[i], page.html)
+	}
+
+	result := audit.result
+	sort.SliceStable(result.Cookies, func(i, j int) bool {
+		return strings.ToLower(result.Cookies[i].Name) < strings.ToLower(result.Cookies[j].Name)
+	})
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return severityRank[result.Findings[i].Severity] < severityRank[result.Findings[j].Severity]
+	})
+	for _, cookie := range result.Cookies {
+		if cookie.Tracker != nil {
+			result.Trackers++
+		}
+	}
+
+	log.Printf("[Cookies] Checked %d pages of %s: %d cookies (%d tracking), %d findings",
+		result.PagesChecked, targetURL, len(result.Cookies), result.Trackers, len(result.Findings))
+	return result, nil
+}
+
+// fetchCookiePage 请求页面并收集所有响应（含重定向）设置的 Cookie
+func fetchCookiePage(ctx context.Context, auth *ScanAuthSession, pageURL string) (*cookiePageResult, error) {
+	page := &cookiePageResult{}
+	record := func(resp *http.Response) {
+		for _, cookie := range resp.Cookies() {
+			page.cookies = append(page.cookies, observedCookie{cookie: cookie, setBy: resp.Request.URL})
+		}
+	}
+
+	client := auth.HTTPClient(cookieFetchTimeout)
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// req.Response 为触发本次重定向的响应
+		if req.Response != nil {
+			record(req.Response)
+		}
+		return checkRedirect(req, via)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	auth.Apply(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// 重定向过程中设置的 Cookie 仍然有效
+		if len(page.cookies) > 0 {
+			return page, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	record(resp)
+
+	if strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html") {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, cookiePageMaxBytes))
+		page.html = string(body)
+	}
+	return page, nil
+}
+
+// cookieKey Cookie 的去重键：名称、Domain（没有时为设置它的主机）和 Path
+func cookieKey(observed observedCookie) string {
+	domain := strings.ToLower(strings.TrimPrefix(observed.cookie.Domain, "."))
+	if domain == "" {
+		domain = "host:" + strings.ToLower(observed.setBy.Hostname())
+	}
+	return observed.cookie.Name + "|" + domain + "|" + observed.cookie.Path
+}
+
+// addFinding 记录一个发现，args 用于填充说明文案中的占位符
+func (a *cookieAudit) addFinding(cookie, id, severity string, args ...interface{}) {
+	a.result.Findings = append(a.result.Findings, models.CookieFinding{
+		Cookie:   cookie,
+		ID:       id,
+		Severity: severity,
+		Message:  findingMessage(cookieMessages, a.lang, id, args...),
+	})
+}
+
+// checkCookie 记录响应设置的 Cookie 并检查安全属性（删除 Cookie 的响应不检查）
+func (a *cookieAudit) checkCookie(observed observedCookie) {
+	c := observed.cookie
+	lifetime := time.Duration(0)
+	switch {
+	case c.MaxAge < 0:
+		return
+	case c.MaxAge > 0:
+		lifetime = time.Duration(c.MaxAge) * time.Second
+	case !c.Expires.IsZero():
+		if lifetime = c.Expires.Sub(a.now); lifetime <= 0 {
+			return
+		}
+	}
+
+	host := strings.ToLower(observed.setBy.Hostname())
+	https := observed.setBy.Scheme == "https"
+	info := models.CookieInfo{
+		Name:       c.Name,
+		Domain:     strings.ToLower(strings.TrimPrefix(c.Domain, ".")),
+		Path:       c.Path,
+		SetBy:      observed.setBy.String(),
+		Source:     models.CookieSourceHeader,
+		Secure:     c.Secure,
+		HttpOnly:   c.HttpOnly,
+		Persistent: lifetime > 0,
+		MaxAge:     int64(lifetime / time.Second),
+		ThirdParty: organizationalDomain(host) != organizationalDomain(strings.ToLower(a.target.Hostname())),
+	}
+	switch c.SameSite {
+	case http.SameSiteStrictMode:
+		info.SameSite = "Strict"
+	case http.SameSiteLaxMode:
+		info.SameSite = "Lax"
+	case http.SameSiteNoneMode:
+		info.SameSite = "None"
+	}
+	tracker := cookieTrackerFor(c.Name)
+	if tracker != nil {
+		info.Tracker = &models.CookieTracker{Vendor: tracker.vendor, Category: tracker.category}
+	} else {
+		info.SessionIdentifier = sessionCookiePattern.MatchString(c.Name) && !csrfCookiePattern.MatchString(c.Name)
+	}
+	a.result.Cookies = append(a.result.Cookies, info)
+
+	session := info.SessionIdentifier
+	sensitive := func(high, other string) string {
+		if session {
+			return high
+		}
+		return other
+	}
+
+	// 前缀：不满足要求时浏览器直接拒绝该 Cookie，其他属性不再检查
+	if strings.HasPrefix(c.Name, "__Secure-") && (!c.Secure || !https) {
+		a.addFinding(c.Name, "cookie_prefix_secure", models.SeverityHigh)
+		return
+	}
+	if strings.HasPrefix(c.Name, "__Host-") && (!c.Secure || !https || c.Path != "/" || info.Domain != "") {
+		a.addFinding(c.Name, "cookie_prefix_host", models.SeverityHigh)
+		return
+	}
+	if info.Domain != "" && info.Domain != host && !strings.HasSuffix(host, "."+info.Domain) {
+		a.addFinding(c.Name, "cookie_domain_invalid", models.SeverityLow, info.Domain, host)
+		return
+	}
+
+	// SameSite=None 缺少 Secure 由下面单独报告
+	if !c.Secure && c.SameSite != http.SameSiteNoneMode {
+		a.addFinding(c.Name, "cookie_no_secure", sensitive(models.SeverityHigh, models.SeverityLow))
+	}
+	if !c.HttpOnly && info.Tracker == nil {
+		a.addFinding(c.Name, "cookie_no_httponly", sensitive(models.SeverityHigh, models.SeverityInfo))
+	}
+	switch c.SameSite {
+	case 0:
+		a.addFinding(c.Name, "cookie_no_samesite", sensitive(models.SeverityMedium, models.SeverityLow))
+	case http.SameSiteDefaultMode:
+		a.addFinding(c.Name, "cookie_samesite_invalid", models.SeverityLow)
+	case http.SameSiteNoneMode:
+		if !c.Secure {
+			a.addFinding(c.Name, "cookie_samesite_none_insecure", models.SeverityHigh)
+		} else if session {
+			a.addFinding(c.Name, "cookie_samesite_none", models.SeverityMedium)
+		}
+	}
+
+	if session {
+		if info.Domain != "" {
+			a.addFinding(c.Name, "cookie_domain_broad", models.SeverityMedium, info.Domain)
+		}
+		// __Host- 前缀要求 Path=/
+		if (c.Path == "/" || c.Path == "") && !strings.HasPrefix(c.Name, "__Host-") && path.Dir(observed.setBy.Path) != "/" && path.Dir(observed.setBy.Path) != "." {
+			a.addFinding(c.Name, "cookie_path_broad", models.SeverityLow, observed.setBy.Path)
+		}
+		if lifetime > sessionCookieMaxLifetime {
+			a.addFinding(c.Name, "cookie_session_long_lived", models.SeverityMedium, int64(lifetime/time.Hour))
+		}
+	}
+
+	if info.Tracker != nil {
+		a.addFinding(c.Name, "tracker_set_by_server", models.SeverityLow, info.Tracker.Vendor)
+		if lifetime > trackerCookieMaxLifetime {
+			a.addFinding(c.Name, "cookie_tracker_long_lived", models.SeverityLow, int64(lifetime/(24*time.Hour)))
+		}
+	}
+}
+
+// inferScriptTrackers 根据页面引用的跟踪脚本推断由 JavaScript 设置的跟踪 Cookie（每个服务只记录一次）
+func (a *cookieAudit) inferScriptTrackers(pageURL, html string) {
+	if html == "" {
+		return
+	}
+	lower := strings.ToLower(html)
+	for _, rule := range cookieTrackerRules {
+		if a.hasTrackerFinding(rule.vendor) || !containsAny(lower, rule.scripts) {
+			continue
+		}
+
+		var names []string
+		for _, name := range rule.inferred {
+			// 服务器已经设置过同名 Cookie 时不重复记录
+			if a.hasCookie(name) {
+				continue
+			}
+			names = append(names, name)
+			a.result.Cookies = append(a.result.Cookies, models.CookieInfo{
+				Name:       name,
+				SetBy:      pageURL,
+				Source:     models.CookieSourceScript,
+				Persistent: true,
+				Tracker:    &models.CookieTracker{Vendor: rule.vendor, Category: rule.category},
+			})
+		}
+		list := ""
+		if len(names) > 0 {
+			list = " (" + strings.Join(names, ", ") + ")"
+			if a.lang != "en" {
+				list = "（" + strings.Join(names, ", ") + "）"
+			}
+		}
+		a.addFinding(rule.vendor, "tracker_script", models.SeverityInfo, rule.vendor, list)
+	}
+}
+
+// hasTrackerFinding 是否已经记录了该服务的脚本推断结果
+func (a *cookieAudit) hasTrackerFinding(vendor string) bool {
+	for _, f := range a.result.Findings {
+		if f.ID == "tracker_script" && f.Cookie == vendor {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCookie 是否已经记录了同名 Cookie
+func (a *cookieAudit) hasCookie(name string) bool {
+	for _, cookie := range a.result.Cookies {
+		if cookie.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieTrackerVendors 发现的跟踪服务（去重，按出现顺序）
+func cookieTrackerVendors(result *models.CookieAuditResult) []string {
+	var vendors []string
+	for _, cookie := range result.Cookies {
+		if cookie.Tracker != nil && !containsString(vendors, cookie.Tracker.Vendor) {
+			vendors = append(vendors, cookie.Tracker.Vendor)
+		}
+	}
+	return vendors
+}
+
+// cookieTrackerFor 返回 Cookie 名称对应的跟踪服务，不是已知的跟踪 Cookie 时返回 nil
+func cookieTrackerFor(name string) *cookieTrackerRule {
+	for i := range cookieTrackerRules {
+		if cookieTrackerRules[i].names.MatchString(name) {
+			return &cookieTrackerRules[i]
+		}
+	}
+	return nil
+}
+
+// containsAny s 是否包含任意一个子串
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,714 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"web-checkly/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 全站爬取后端（环境变量 CRAWLER_BACKEND）
+const (
+	CrawlerNative = "native" // 进程内 Go 爬虫（默认）
+	CrawlerKatana = "katana" // ProjectDiscovery katana 命令行工具
+)
+
+// 爬取预算的默认值和上限
+const (
+	defaultCrawlMaxPages = 200
+	maxCrawlMaxPages     = 1000
+	defaultCrawlMaxDepth = 3
+	maxCrawlMaxDepth     = 10
+	defaultCrawlDuration = 90 * time.Second
+	maxCrawlDuration     = 110 * time.Second // katana 插件超时为 120 秒
+	defaultCrawlDelay    = 200 * time.Millisecond
+	maxCrawlDelay        = 10 * time.Second
+	maxCrawlPatterns     = 20      // include/exclude 各自最多的正则数
+	maxCrawlPatternLen   = 500     // 单个正则的最大长度
+	maxCrawlLinks        = 2000    // 最多检查的非页面链接数（资源、外部链接、超出预算的页面）
+	maxCrawlPageBytes    = 5 << 20 // 解析页面的大小上限
+	crawlWorkers         = 4       // 同一深度的页面并发爬取数
+
+	crawlResultFlushSize     = 50          // 实时推送：缓冲满多少条结果时推送一批
+	crawlResultFlushInterval = time.Second // 实时推送：缓冲的结果最长等待时间
+)
+
+// crawlSources 会被当作页面继续爬取的链接来源（其余来源如 img、css 只检查不爬取）
+var crawlSources = map[string]bool{
+	"link":    true,
+	"iframe":  true,
+	"sitemap": true,
+}
+
+// ErrInvalidCrawlConfig 爬取配置无效
+var ErrInvalidCrawlConfig = errors.New("invalid crawl config")
+
+// CrawlerBackend 当前使用的全站爬取后端
+func CrawlerBackend() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("CRAWLER_BACKEND")), CrawlerKatana) {
+		return CrawlerKatana
+	}
+	return CrawlerNative
+}
+
+// ValidateCrawlConfig 校验爬取配置（正则能否编译、预算是否超出上限）
+func ValidateCrawlConfig(cfg *models.CrawlConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	for name, patterns := range map[string][]string{"include": cfg.Include, "exclude": cfg.Exclude} {
+		if len(patterns) > maxCrawlPatterns {
+			return fmt.Errorf("%w: at most %d %s patterns allowed", ErrInvalidCrawlConfig, maxCrawlPatterns, name)
+		}
+		for _, pattern := range patterns {
+			if len(pattern) > maxCrawlPatternLen {
+				return fmt.Errorf("%w: %s pattern must be at most %d characters", ErrInvalidCrawlConfig, name, maxCrawlPatternLen)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%w: invalid %s pattern %q: %v", ErrInvalidCrawlConfig, name, pattern, err)
+			}
+		}
+	}
+	switch {
+	case cfg.MaxPages < 0 || cfg.MaxPages > maxCrawlMaxPages:
+		return fmt.Errorf("%w: max_pages must be between 1 and %d", ErrInvalidCrawlConfig, maxCrawlMaxPages)
+	case cfg.MaxDepth < 0 || cfg.MaxDepth > maxCrawlMaxDepth:
+		return fmt.Errorf("%w: max_depth must be between 1 and %d", ErrInvalidCrawlConfig, maxCrawlMaxDepth)
+	case cfg.MaxDuration < 0 || time.Duration(cfg.MaxDuration)*time.Second > maxCrawlDuration:
+		return fmt.Errorf("%w: max_duration must be between 1 and %d seconds", ErrInvalidCrawlConfig, int(maxCrawlDuration.Seconds()))
+	case cfg.DelayMs < 0 || time.Duration(cfg.DelayMs)*time.Millisecond > maxCrawlDelay:
+		return fmt.Errorf("%w: delay_ms must be between 0 and %d", ErrInvalidCrawlConfig, maxCrawlDelay.Milliseconds())
+	}
+	return nil
+}
+
+// CrawlSite 全站爬取：发现页面和资源并检查其状态，发现的链接实时推送给 taskManager
+// 根据 CRAWLER_BACKEND 选择进程内爬虫或 katana（katana 只支持 max_depth）
+func CrawlSite(ctx context.Context, targetURL string, taskID string, taskManager interface{}, cfg *models.CrawlConfig, auth *ScanAuthSession) ([]KatanaResult, error) {
+	if CrawlerBackend() == CrawlerKatana {
+		depth := 0
+		if cfg != nil {
+			depth = cfg.MaxDepth
+		}
+		return RunKatana(ctx, targetURL, taskID, taskManager, auth, depth)
+	}
+
+	crawler, err := newSiteCrawler(targetURL, cfg, true, auth)
+	if err != nil {
+		return nil, err
+	}
+	return crawler.run(ctx, taskID, taskManager)
+}
+
+// discoverPageLinks 只发现目标页面上的链接和资源，不爬取其他页面也不检查链接（链接由 link-health 统一检查）
+// 只请求目标页面本身，因此不读取 robots.txt 和 sitemap
+func discoverPageLinks(ctx context.Context, targetURL string, taskID string, taskManager interface{}, auth *ScanAuthSession) ([]KatanaResult, error) {
+	if CrawlerBackend() == CrawlerKatana {
+		return RunKatana(ctx, targetURL, taskID, taskManager, auth, 1)
+	}
+
+	crawler, err := newSiteCrawler(targetURL, &models.CrawlConfig{MaxPages: 1, MaxDepth: 1, IgnoreRobots: true, SkipSitemap: true}, false, auth)
+	if err != nil {
+		return nil, err
+	}
+	return crawler.run(ctx, taskID, taskManager)
+}
+
+// siteCrawler 进程内爬虫
+// 按深度逐层爬取同站页面（下载并解析链接），其余链接（资源、外部链接、超出页面预算的页面）只检查状态不爬取；
+// 同一页面（按规范化地址和 rel=canonical 去重）只爬取一次
+type siteCrawler struct {
+	target        *url.URL
+	maxPages      int
+	maxDepth      int
+	duration      time.Duration
+	delay         time.Duration
+	include       []*regexp.Regexp
+	exclude       []*regexp.Regexp
+	respectRobots bool
+	useSitemap    bool
+	checkLinks    bool // false 时只发现链接不检查（供 link-health 使用，由链接检查器统一检查）
+
+	auth   *ScanAuthSession // 认证扫描的认证信息（只附加到同站请求）
+	client *http.Client
+	stream *crawlResultStream // 实时推送发现的链接（执行 run 期间有效）
+
+	mu             sync.Mutex
+	seen           map[string]bool // 已发现的地址（规范化后）
+	crawled        map[string]bool // 已爬取页面的规范化地址和 canonical 地址
+	scheduled      int             // 已安排爬取的页面数
+	next           []crawlLink     // 下一层待爬取的页面
+	links          []crawlLink     // 只检查不爬取的链接
+	results        []KatanaResult
+	excludedCount  int
+	robotsBlocked  int
+	duplicateCount int
+
+	hostsMu sync.Mutex
+	hosts   map[string]*hostLimiter
+
+	robotsMu sync.Mutex
+	robots   map[string]*crawlRobots
+}
+
+// crawlLink 待爬取或待检查的链接
+type crawlLink struct {
+	url    string
+	source string
+	depth  int
+}
+
+// crawlRobots 单个主机的 robots.txt 规则（首次使用时读取）
+type crawlRobots struct {
+	once  sync.Once
+	rules *robotsRules
+}
+
+// newSiteCrawler 按爬取配置创建爬虫，未设置的字段使用默认值
+func newSiteCrawler(targetURL string, cfg *models.CrawlConfig, checkLinks bool, auth *ScanAuthSession) (*siteCrawler, error) {
+	if cfg == nil {
+		cfg = &models.CrawlConfig{}
+	}
+	if err := ValidateCrawlConfig(cfg); err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(targetURL)
+	if err != nil || target.Hostname() == "" {
+		return nil, fmt.Errorf("invalid target url: %s", targetURL)
+	}
+
+	c := &siteCrawler{
+		target:        target,
+		maxPages:      defaultCrawlMaxPages,
+		maxDepth:      defaultCrawlMaxDepth,
+		duration:      defaultCrawlDuration,
+		delay:         defaultCrawlDelay,
+		respectRobots: !cfg.IgnoreRobots,
+		useSitemap:    !cfg.SkipSitemap,
+		checkLinks:    checkLinks,
+		auth:          auth,
+		client:        auth.HTTPClient(15 * time.Second),
+		seen:          make(map[string]bool),
+		crawled:       make(map[string]bool),
+		hosts:         make(map[string]*hostLimiter),
+		robots:        make(map[string]*crawlRobots),
+	}
+	if cfg.MaxPages > 0 {
+		c.maxPages = cfg.MaxPages
+	}
+	if cfg.MaxDepth > 0 {
+		c.maxDepth = cfg.MaxDepth
+	}
+	if cfg.MaxDuration > 0 {
+		c.duration = time.Duration(cfg.MaxDuration) * time.Second
+	}
+	if cfg.DelayMs > 0 {
+		c.delay = time.Duration(cfg.DelayMs) * time.Millisecond
+	}
+	for _, pattern := range cfg.Include {
+		c.include = append(c.include, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range cfg.Exclude {
+		c.exclude = append(c.exclude, regexp.MustCompile(pattern))
+	}
+	return c, nil
+}
+
+// run 执行爬取并返回全部结果；目标页面请求失败时返回错误，超出时间预算时返回已有结果
+func (c *siteCrawler) run(ctx context.Context, taskID string, taskManager interface{}) ([]KatanaResult, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, c.duration)
+	defer cancel()
+	c.stream = newCrawlResultStream(taskID, taskManager, "[Crawler]")
+	defer c.stream.Close()
+
+	// 需要检查链接时，爬取页面最多使用 2/3 的时间，剩余时间用于检查链接
+	crawlCtx := ctx
+	if c.checkLinks {
+		var crawlCancel context.CancelFunc
+		crawlCtx, crawlCancel = context.WithTimeout(ctx, c.duration*2/3)
+		defer crawlCancel()
+	}
+
+	seed := c.target.String()
+	c.seen[crawlKey(c.target)] = true
+	c.scheduled = 1
+	level := []crawlLink{{url: seed, source: "seed", depth: 1}}
+
+	for len(level) > 0 && crawlCtx.Err() == nil {
+		c.crawlLevel(crawlCtx, level)
+
+		if level[0].depth == 1 {
+			if len(c.results) == 0 || c.results[0].Status == 0 {
+				return c.results, fmt.Errorf("failed to fetch %s", seed)
+			}
+			if c.useSitemap && c.maxDepth > 1 {
+				c.seedFromSitemap(crawlCtx)
+			}
+		}
+
+		c.mu.Lock()
+		level, c.next = c.next, nil
+		c.mu.Unlock()
+	}
+	// 超出时间预算未爬取的页面只检查状态
+	c.links = append(c.links, level...)
+
+	if c.checkLinks {
+		c.checkPendingLinks(ctx)
+	} else {
+		for _, link := range c.links {
+			c.emit(KatanaResult{URL: link.url, Source: link.source, Method: http.MethodGet})
+		}
+	}
+
+	log.Printf("[Crawler] Native crawl of %s finished in %s: %d results (%d pages crawled, %d excluded by scope, %d blocked by robots.txt, %d duplicates)",
+		seed, time.Since(start).Round(time.Millisecond), len(c.results), len(c.crawled), c.excludedCount, c.robotsBlocked, c.duplicateCount)
+	return c.results, nil
+}
+
+// crawlLevel 并发爬取同一深度的页面
+func (c *siteCrawler) crawlLevel(ctx context.Context, level []crawlLink) {
+	jobs := make(chan crawlLink)
+	var wg sync.WaitGroup
+	for i := 0; i < crawlWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				c.crawlPage(ctx, link)
+			}
+		}()
+	}
+	for i, link := range level {
+		if ctx.Err() != nil {
+			// 超出时间预算未爬取的页面只检查状态
+			c.mu.Lock()
+			c.links = append(c.links, level[i:]...)
+			c.mu.Unlock()
+			break
+		}
+		jobs <- link
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// crawlPage 下载页面，记录结果并提取链接
+func (c *siteCrawler) crawlPage(ctx context.Context, link crawlLink) {
+	start := time.Now()
+	result := KatanaResult{URL: link.url, Source: link.source, Method: http.MethodGet}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.url, nil)
+	if err != nil {
+		c.emit(result)
+		return
+	}
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	c.auth.Apply(req)
+
+	limiter := c.hostLimiter(req.URL.Host)
+	if err := limiter.acquire(ctx, c.hostDelay(ctx, req.URL)); err != nil {
+		c.mu.Lock()
+		c.links = append(c.links, link)
+		c.mu.Unlock()
+		return
+	}
+	resp, err := c.client.Do(req)
+	limiter.release()
+	if err != nil && ctx.Err() != nil {
+		// 请求因超出时间预算被取消，不是页面本身的错误：与未爬取的页面一样只检查状态
+		c.mu.Lock()
+		c.links = append(c.links, link)
+		c.mu.Unlock()
+		return
+	}
+	if err != nil {
+		log.Printf("[Crawler] Failed to fetch %s: %v", link.url, err)
+		result.Response = fmt.Sprintf("%dms", time.Since(start).Milliseconds())
+		c.emit(result)
+		return
+	}
+	defer resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.Type = parseContentType(resp.Header.Get("Content-Type"))
+	result.Length = resp.ContentLength
+
+	if resp.StatusCode != http.StatusOK || result.Type != "html" {
+		result.Response = fmt.Sprintf("%dms", time.Since(start).Milliseconds())
+		c.emit(result)
+		return
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxCrawlPageBytes))
+	result.Response = fmt.Sprintf("%dms", time.Since(start).Milliseconds())
+	if err != nil {
+		c.emit(result)
+		return
+	}
+	result.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	c.emit(result)
+
+	// 重定向到了其他网站（如第三方登录页）时不提取链接
+	final := resp.Request.URL
+	if !sameSite(c.target, final) {
+		return
+	}
+	base := final
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if parsed, err := final.Parse(href); err == nil {
+			base = parsed
+		}
+	}
+
+	// 同一内容（rel=canonical 相同）的页面只提取一次链接
+	keys := []string{crawlKey(final)}
+	if href, ok := doc.Find("link[rel='canonical']").First().Attr("href"); ok {
+		if canonical, err := final.Parse(href); err == nil && sameSite(c.target, canonical) {
+			keys = append(keys, crawlKey(canonical))
+		}
+	}
+	c.mu.Lock()
+	duplicate := false
+	for _, key := range keys {
+		if c.crawled[key] && key != crawlKey(req.URL) {
+			duplicate = true
+		}
+		c.crawled[key] = true
+		c.seen[key] = true
+	}
+	if duplicate {
+		c.duplicateCount++
+	}
+	c.mu.Unlock()
+	if duplicate {
+		return
+	}
+
+	// 遵守 robots.txt 时也遵守页面的 <meta name="robots" content="nofollow">：链接只检查不爬取
+	follow := link.depth < c.maxDepth
+	if c.respectRobots {
+		if content, ok := doc.Find("meta[name='robots']").First().Attr("content"); ok && strings.Contains(strings.ToLower(content), "nofollow") {
+			follow = false
+		}
+	}
+
+	extractDocumentURLs(doc, base, func(rawURL string, source string) {
+		if rawURL == "" || strings.HasPrefix(rawURL, "data:") {
+			return
+		}
+		cleaned := cleanURL(rawURL, base)
+		if valid, _ := isValidURL(cleaned); !valid {
+			return
+		}
+		c.addLink(ctx, cleaned, source, link.depth+1, follow)
+	})
+}
+
+// addLink 处理新发现的链接：按范围规则和 robots.txt 过滤，同站页面在预算内安排到下一层爬取，其余只检查
+func (c *siteCrawler) addLink(ctx context.Context, rawURL string, source string, depth int, follow bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	key := crawlKey(parsed)
+
+	c.mu.Lock()
+	if c.seen[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.seen[key] = true
+	if !c.inScope(rawURL) {
+		c.excludedCount++
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	isSite := sameSite(c.target, parsed)
+	if isSite && c.respectRobots && !c.robotsFor(ctx, parsed).Allowed(parsed) {
+		c.mu.Lock()
+		c.robotsBlocked++
+		c.mu.Unlock()
+		return
+	}
+
+	link := crawlLink{url: rawURL, source: source, depth: depth}
+	_, isPage := sitePageURL(c.target, rawURL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if follow && isPage && crawlSources[source] && c.scheduled < c.maxPages {
+		c.scheduled++
+		c.next = append(c.next, link)
+		return
+	}
+	c.links = append(c.links, link)
+}
+
+// seedFromSitemap 将 sitemap 中的同站页面加入下一层（排在首页链接之后）
+func (c *siteCrawler) seedFromSitemap(ctx context.Context) {
+	pages, sitemaps := discoverSitemapPages(ctx, c.target)
+	if len(pages) == 0 {
+		return
+	}
+	log.Printf("[Crawler] Seeding %d pages from %d sitemaps", len(pages), len(sitemaps))
+	for _, page := range pages {
+		c.mu.Lock()
+		full := c.scheduled >= c.maxPages
+		c.mu.Unlock()
+		if full {
+			return
+		}
+		c.addLink(ctx, page, "sitemap", 2, true)
+	}
+}
+
+// checkPendingLinks 检查未爬取的链接（最多 maxCrawlLinks 个），结果转换为 KatanaResult
+func (c *siteCrawler) checkPendingLinks(ctx context.Context) {
+	links := c.links
+	if len(links) > maxCrawlLinks {
+		log.Printf("[Crawler] Checking first %d of %d discovered links", maxCrawlLinks, len(links))
+		links = links[:maxCrawlLinks]
+	}
+	if len(links) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	sources := make(map[string]string, len(links))
+	urls := make([]string, 0, len(links))
+	for _, link := range links {
+		sources[link.url] = link.source
+		urls = append(urls, link.url)
+	}
+	urls = filterPublicURLs(urls)
+
+	cfg := DefaultLinkCheckConfig()
+	cfg.Auth = c.auth
+	if c.delay > cfg.PerHostInterval {
+		cfg.PerHostInterval = c.delay
+	}
+	results := make(chan models.HttpxResult, 100)
+	go NewLinkChecker(cfg).Run(ctx, urls, results)
+	for r := range results {
+		// 超时未检查的链接没有状态，不输出（KatanaResult 没有对应字段，状态 0 会被当作失效）
+		if r.Skipped {
+			continue
+		}
+		c.emit(KatanaResult{
+			URL:      r.URL,
+			Source:   sources[r.URL],
+			Method:   r.Method,
+			Status:   r.StatusCode,
+			Title:    r.Title,
+			Response: fmt.Sprintf("%dms", r.ResponseTime),
+		})
+	}
+}
+
+// emit 记录结果并实时推送给 taskManager
+func (c *siteCrawler) emit(result KatanaResult) {
+	c.mu.Lock()
+	c.results = append(c.results, result)
+	c.mu.Unlock()
+	c.stream.Add(result)
+}
+
+// inScope URL 是否在 include/exclude 规则允许的范围内（调用方持有 c.mu）
+func (c *siteCrawler) inScope(rawURL string) bool {
+	for _, re := range c.exclude {
+		if re.MatchString(rawURL) {
+			return false
+		}
+	}
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, re := range c.include {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsFor 获取主机的 robots.txt 规则（每个主机只读取一次）
+func (c *siteCrawler) robotsFor(ctx context.Context, u *url.URL) *robotsRules {
+	host := strings.ToLower(u.Host)
+	c.robotsMu.Lock()
+	entry, ok := c.robots[host]
+	if !ok {
+		entry = &crawlRobots{}
+		c.robots[host] = entry
+	}
+	c.robotsMu.Unlock()
+
+	entry.once.Do(func() {
+		entry.rules = fetchRobotsRules(ctx, &url.URL{Scheme: u.Scheme, Host: u.Host})
+	})
+	return entry.rules
+}
+
+// hostDelay 同一主机相邻请求的间隔：配置的间隔和 robots.txt 的 Crawl-delay 中较大者
+func (c *siteCrawler) hostDelay(ctx context.Context, u *url.URL) time.Duration {
+	delay := c.delay
+	if c.respectRobots && sameSite(c.target, u) {
+		if robotsDelay := c.robotsFor(ctx, u).crawlDelay; robotsDelay > delay {
+			delay = robotsDelay
+		}
+	}
+	return delay
+}
+
+// hostLimiter 获取主机对应的限速器
+func (c *siteCrawler) hostLimiter(host string) *hostLimiter {
+	host = strings.ToLower(host)
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+	limiter, ok := c.hosts[host]
+	if !ok {
+		limiter = &hostLimiter{sem: make(chan struct{}, crawlWorkers)}
+		c.hosts[host] = limiter
+	}
+	return limiter
+}
+
+// crawlResultStream 将发现的链接批量推送给 taskManager（用于实时展示）
+// 由单个 goroutine 在缓冲满 crawlResultFlushSize 条或每隔 crawlResultFlushInterval 时追加一批结果，
+// 避免每个链接单独写一次数据库
+type crawlResultStream struct {
+	taskID    string
+	appender  katanaResultAppender
+	logPrefix string
+
+	mu      sync.Mutex
+	pending []KatanaResult
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// katanaResultAppender 接收实时推送结果的 taskManager（使用接口避免循环依赖）
+type katanaResultAppender interface {
+	AppendKatanaResults(taskID string, results []interface{}) error
+}
+
+// newCrawlResultStream 创建结果推送器；没有任务或 taskManager 不支持实时推送时返回 nil（Add/Close 可安全调用）
+func newCrawlResultStream(taskID string, taskManager interface{}, logPrefix string) *crawlResultStream {
+	if taskID == "" || taskManager == nil {
+		return nil
+	}
+	appender, ok := taskManager.(katanaResultAppender)
+	if !ok {
+		return nil
+	}
+	s := &crawlResultStream{
+		taskID:    taskID,
+		appender:  appender,
+		logPrefix: logPrefix,
+		notify:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Add 缓冲一条结果（不阻塞调用方）
+func (s *crawlResultStream) Add(result KatanaResult) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.pending = append(s.pending, result)
+	full := len(s.pending) >= crawlResultFlushSize
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close 推送剩余的结果并等待推送 goroutine 退出（之后不能再调用 Add）
+func (s *crawlResultStream) Close() {
+	if s == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
+
+// run 推送 goroutine：缓冲满或定时触发时推送一批
+func (s *crawlResultStream) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(crawlResultFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.notify:
+		case <-ticker.C:
+		case <-s.stop:
+			s.flush()
+			return
+		}
+		s.flush()
+	}
+}
+
+// flush 取出缓冲的结果并一次追加到任务
+func (s *crawlResultStream) flush() {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	items := make([]interface{}, len(batch))
+	for i, result := range batch {
+		items[i] = result
+	}
+	if err := s.appender.AppendKatanaResults(s.taskID, items); err != nil {
+		log.Printf("%s Error appending %d results to task manager: %v", s.logPrefix, len(batch), err)
+	}
+}
+
+// sameSite 两个地址是否属于同一网站（忽略 www. 前缀）
+func sameSite(target, u *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") == strings.TrimPrefix(strings.ToLower(target.Hostname()), "www.")
+}
+
+// crawlKey 用于去重的规范化地址：协议和主机小写，去掉默认端口、fragment 和末尾斜杠，查询参数排序
+func crawlKey(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	key := strings.ToLower(u.Scheme) + "://" + host + path
+	if u.RawQuery != "" {
+		// Encode 按参数名排序
+		key += "?" + u.Query().Encode()
+	}
+	return key
+}
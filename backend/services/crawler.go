@@ -11,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"web-checkly/utils"
+
 	"github.com/PuerkitoBio/goquery"
 )
 
@@ -235,15 +237,8 @@ func extractURLsFromJS(jsContent string, baseURL *url.URL, addURL func(string, s
 	}
 }
 
-// 创建复用的HTTP客户端
-var httpClient = &http.Client{
-	Timeout: 15 * time.Second,
-	Transport: &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-	},
-}
+// 创建复用的HTTP客户端（连接时拒绝内网地址，防止 DNS 重绑定）
+var httpClient = utils.NewSafeHTTPClient(15 * time.Second)
 
 func ExtractPageUrls(target string, report *FullLighthouseReport) ([]string, error) {
 	urls := make(map[string]bool)
@@ -291,7 +286,7 @@ func ExtractPageUrls(target string, report *FullLighthouseReport) ([]string, err
 	}
 
 	// 2. 同时使用 goquery 提取 DOM 中的链接（确保不遗漏未加载的 <a> 标签）
-	domUrls, err := ExtractPageUrlsGoQuery(target)
+	domUrls, err := ExtractPageUrlsGoQuery(target, nil)
 	if err == nil {
 		for _, u := range domUrls {
 			addURL(u, "goquery-dom")
@@ -312,8 +307,8 @@ func ExtractPageUrls(target string, report *FullLighthouseReport) ([]string, err
 	return result, nil
 }
 
-// ExtractPageUrlsGoQuery 是原有的基于 goquery 的提取逻辑
-func ExtractPageUrlsGoQuery(target string) ([]string, error) {
+// ExtractPageUrlsGoQuery 是原有的基于 goquery 的提取逻辑（auth 不为 nil 时以认证身份请求页面）
+func ExtractPageUrlsGoQuery(target string, auth *ScanAuthSession) ([]string, error) {
 	req, err := http.NewRequest("GET", target, nil)
 	if err != nil {
 		log.Printf("[Crawler] Error creating request: %v", err)
@@ -323,8 +318,13 @@ func ExtractPageUrlsGoQuery(target string) ([]string, error) {
 	// 设置User-Agent
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	auth.Apply(req)
 
-	resp, err := httpClient.Do(req)
+	client := httpClient
+	if auth != nil {
+		client = auth.HTTPClient(15 * time.Second)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("[Crawler] Error fetching page: %v", err)
 		return nil, err
@@ -354,7 +354,6 @@ func ExtractPageUrlsGoQuery(target string) ([]string, error) {
 	}
 
 	urls := make(map[string]bool)
-	linkCount := 0
 	skippedURLs := make(map[string]string) // 记录跳过的URL及原因
 
 	// 获取base域名的hostname，用于日志对比
@@ -388,6 +387,68 @@ func ExtractPageUrlsGoQuery(target string) ([]string, error) {
 		}
 	}
 
+	linkCount := extractDocumentURLs(doc, base, addURL)
+
+	log.Printf("[Crawler] Total links found: %d, Valid HTTP(S) URLs: %d", linkCount, len(urls))
+
+	// 输出所有跳过的URL统计
+	log.Printf("[Crawler] ========== SKIPPED URLs SUMMARY ==========")
+	log.Printf("[Crawler] Total skipped URLs: %d", len(skippedURLs))
+	if len(skippedURLs) > 0 {
+		skipReasons := make(map[string]int)
+		for urlStr, reason := range skippedURLs {
+			log.Printf("[Crawler] SKIPPED: %s", urlStr)
+			log.Printf("[Crawler]   Reason: %s", reason)
+			// 提取原因类型（简化）
+			reasonType := reason
+			if idx := strings.Index(reason, " ("); idx > 0 {
+				reasonType = reason[:idx]
+			}
+			skipReasons[reasonType]++
+		}
+		log.Printf("[Crawler] Skip reasons breakdown:")
+		for reason, count := range skipReasons {
+			log.Printf("[Crawler]   %s: %d", reason, count)
+		}
+	}
+	log.Printf("[Crawler] ==========================================")
+
+	result := []string{}
+	sameDomainCount := 0
+	crossDomainCount := 0
+
+	for u := range urls {
+		result = append(result, u)
+		// 统计同域和跨域URL数量
+		parsedURL, err := url.Parse(u)
+		if err == nil {
+			urlHostname := parsedURL.Hostname()
+			if urlHostname == baseHostname {
+				sameDomainCount++
+			} else {
+				crossDomainCount++
+			}
+		}
+	}
+
+	log.Printf("[Crawler] Extracted %d unique URLs (Same-domain: %d, Cross-domain: %d)",
+		len(result), sameDomainCount, crossDomainCount)
+
+	// 详细列出所有提取的URL
+	log.Printf("[Crawler] ========== EXTRACTED URLs LIST ==========")
+	for i, u := range result {
+		log.Printf("[Crawler] URL %d/%d: %s", i+1, len(result), u)
+	}
+	log.Printf("[Crawler] =========================================")
+
+	return result, nil
+}
+
+// extractDocumentURLs 提取 HTML 文档中的链接和资源地址（addURL 接收原始地址和来源），返回找到的链接数
+// 供 ExtractPageUrlsGoQuery 和进程内爬虫共用
+func extractDocumentURLs(doc *goquery.Document, base *url.URL, addURL func(string, string)) int {
+	linkCount := 0
+
 	// 1. 提取 <a href=""> 链接
 	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
 		linkCount++
@@ -552,57 +613,5 @@ func ExtractPageUrlsGoQuery(target string) ([]string, error) {
 		}
 	})
 
-	log.Printf("[Crawler] Total links found: %d, Valid HTTP(S) URLs: %d", linkCount, len(urls))
-
-	// 输出所有跳过的URL统计
-	log.Printf("[Crawler] ========== SKIPPED URLs SUMMARY ==========")
-	log.Printf("[Crawler] Total skipped URLs: %d", len(skippedURLs))
-	if len(skippedURLs) > 0 {
-		skipReasons := make(map[string]int)
-		for urlStr, reason := range skippedURLs {
-			log.Printf("[Crawler] SKIPPED: %s", urlStr)
-			log.Printf("[Crawler]   Reason: %s", reason)
-			// 提取原因类型（简化）
-			reasonType := reason
-			if idx := strings.Index(reason, " ("); idx > 0 {
-				reasonType = reason[:idx]
-			}
-			skipReasons[reasonType]++
-		}
-		log.Printf("[Crawler] Skip reasons breakdown:")
-		for reason, count := range skipReasons {
-			log.Printf("[Crawler]   %s: %d", reason, count)
-		}
-	}
-	log.Printf("[Crawler] ==========================================")
-
-	result := []string{}
-	sameDomainCount := 0
-	crossDomainCount := 0
-
-	for u := range urls {
-		result = append(result, u)
-		// 统计同域和跨域URL数量
-		parsedURL, err := url.Parse(u)
-		if err == nil {
-			urlHostname := parsedURL.Hostname()
-			if urlHostname == baseHostname {
-				sameDomainCount++
-			} else {
-				crossDomainCount++
-			}
-		}
-	}
-
-	log.Printf("[Crawler] Extracted %d unique URLs (Same-domain: %d, Cross-domain: %d)",
-		len(result), sameDomainCount, crossDomainCount)
-
-	// 详细列出所有提取的URL
-	log.Printf("[Crawler] ========== EXTRACTED URLs LIST ==========")
-	for i, u := range result {
-		log.Printf("[Crawler] URL %d/%d: %s", i+1, len(result), u)
-	}
-	log.Printf("[Crawler] =========================================")
-
-	return result, nil
+	return linkCount
 }
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"log"
 	"time"
 	"web-checkly/database"
 	"web-checkly/models"
@@ -131,7 +132,7 @@ func GetUsageStats(userID string, startDate, endDate time.Time) (*models.UsageSt
 	return &stats, nil
 }
 
-// CreateUsageRecord 创建使用记录
+// CreateUsageRecord 创建使用记录（调用方已扣除积分，记录标记为已扣费）
 func CreateUsageRecord(userID, taskID, featureType string, creditsUsed int, isFree bool) (string, error) {
 	usageRecordID := uuid.New().String()
 	now := time.Now()
@@ -139,8 +140,8 @@ func CreateUsageRecord(userID, taskID, featureType string, creditsUsed int, isFr
 
 	query := `
 		INSERT INTO usage_records (
-			id, user_id, task_id, feature_type, credits_used, is_free, is_refunded, scan_date, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			id, user_id, task_id, feature_type, credits_used, is_free, is_refunded, scan_date, created_at, deducted_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
 	`
 
 	var taskIDPtr *string
@@ -188,6 +189,21 @@ func DeleteUsageRecord(usageRecordID string) error {
 	return nil
 }
 
+// LinkUsageRecordsToTask 将预先创建的使用记录关联到任务（任务完成时据此扣除积分）
+func LinkUsageRecordsToTask(taskID string, usageRecordIDs map[string]string) {
+	for feature, urID := range usageRecordIDs {
+		if urID == "" {
+			continue // 跳过匿名用户使用基础功能的情况
+		}
+		query := `UPDATE usage_records SET task_id = $1 WHERE id = $2`
+		if _, err := database.GetDB().Exec(query, taskID, urID); err != nil {
+			log.Printf("[Credits] Warning: Failed to link usage record %s to task %s: %v", urID, taskID, err)
+		} else {
+			log.Printf("[Credits] Linked usage record %s to task %s for feature %s", urID, taskID, feature)
+		}
+	}
+}
+
 // GetUsageRecords 获取使用记录列表
 func GetUsageRecords(userID string, limit, offset int) ([]*models.UsageRecord, error) {
 	query := `
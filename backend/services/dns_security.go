@@ -0,0 +1,669 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// maxCNAMEChain CNAME 链最大长度
+	maxCNAMEChain = 10
+	// maxParentServers 查询委派时最多尝试的父区域服务器数
+	maxParentServers = 3
+	// takeoverFetchTimeout 读取 CNAME 目标页面（检测未认领资源的特征）的超时时间
+	takeoverFetchTimeout = 10 * time.Second
+	// takeoverMaxBody 读取 CNAME 目标页面的最大字节数
+	takeoverMaxBody = 256 << 10
+)
+
+// takeoverService 可能被接管的云服务：CNAME 指向这些服务但资源未被认领时，他人可以注册同名资源接管域名
+type takeoverService struct {
+	name        string
+	pattern     *regexp.Regexp
+	fingerprint string // 资源未被认领时页面中的特征文本（为空时只通过 NXDOMAIN 判断）
+}
+
+// takeoverServices 常见的可接管云服务（特征参考 can-i-take-over-xyz）
+var takeoverServices = []takeoverService{
+	{"AWS S3", regexp.MustCompile(`\.s3[.-]([a-z0-9-]+\.)*amazonaws\.com$|\.s3-website[.-]([a-z0-9-]+\.)*amazonaws\.com$`), "NoSuchBucket"},
+	{"AWS Elastic Beanstalk", regexp.MustCompile(`\.elasticbeanstalk\.com$`), ""},
+	{"Azure", regexp.MustCompile(`\.(azurewebsites\.net|cloudapp\.net|cloudapp\.azure\.com|trafficmanager\.net|blob\.core\.windows\.net|azureedge\.net|azure-api\.net|azurefd\.net|azurecontainer\.io|azurestaticapps\.net)$`), ""},
+	{"GitHub Pages", regexp.MustCompile(`\.github\.io$`), "There isn't a GitHub Pages site here"},
+	{"Heroku", regexp.MustCompile(`\.(herokuapp|herokudns|herokussl)\.com$`), "No such app"},
+	{"Shopify", regexp.MustCompile(`\.myshopify\.com$`), "Sorry, this shop is currently unavailable"},
+	{"Fastly", regexp.MustCompile(`\.fastly\.net$`), "Fastly error: unknown domain"},
+	{"Netlify", regexp.MustCompile(`\.netlify\.(app|com)$`), "Not Found - Request ID"},
+	{"Surge.sh", regexp.MustCompile(`\.surge\.sh$`), "project not found"},
+	{"Bitbucket", regexp.MustCompile(`\.bitbucket\.io$`), "Repository not found"},
+	{"Pantheon", regexp.MustCompile(`\.pantheonsite\.io$`), "The gods are wise"},
+	{"Zendesk", regexp.MustCompile(`\.zendesk\.com$`), "Help Center Closed"},
+	{"Ghost", regexp.MustCompile(`\.ghost\.io$`), "Site unavailable"},
+	{"Fly.io", regexp.MustCompile(`\.fly\.dev$`), ""},
+}
+
+// caaIssuerDomains 证书颁发者名称中的关键字 -> CA 在 CAA issue 中使用的域名
+var caaIssuerDomains = []struct {
+	keyword string
+	domains []string
+}{
+	{"let's encrypt", []string{"letsencrypt.org"}},
+	{"google trust services", []string{"pki.goog"}},
+	{"digicert", []string{"digicert.com", "symantec.com", "geotrust.com", "rapidssl.com", "thawte.com", "digitalcertvalidation.com"}},
+	{"geotrust", []string{"digicert.com", "geotrust.com", "symantec.com"}},
+	{"rapidssl", []string{"digicert.com", "rapidssl.com", "symantec.com"}},
+	{"thawte", []string{"digicert.com", "thawte.com", "symantec.com"}},
+	{"encryption everywhere", []string{"digicert.com"}},
+	{"zerossl", []string{"sectigo.com", "zerossl.com"}},
+	{"sectigo", []string{"sectigo.com", "comodoca.com", "comodo.com", "usertrust.com", "trust-provider.com"}},
+	{"comodo", []string{"sectigo.com", "comodoca.com", "comodo.com", "usertrust.com"}},
+	{"usertrust", []string{"sectigo.com", "usertrust.com", "comodoca.com"}},
+	{"globalsign", []string{"globalsign.com"}},
+	{"amazon", []string{"amazon.com", "amazontrust.com", "awstrust.com", "amazonaws.com"}},
+	{"godaddy", []string{"godaddy.com", "starfieldtech.com"}},
+	{"starfield", []string{"starfieldtech.com", "godaddy.com"}},
+	{"entrust", []string{"entrust.net", "affirmtrust.com"}},
+	{"buypass", []string{"buypass.com", "buypass.no"}},
+	{"ssl.com", []string{"ssl.com"}},
+	{"microsoft", []string{"microsoft.com"}},
+	{"certum", []string{"certum.pl", "certum.eu"}},
+	{"actalis", []string{"actalis.it"}},
+	{"harica", []string{"harica.gr"}},
+	{"trustwave", []string{"trustwave.com"}},
+	{"apple", []string{"apple.com"}},
+}
+
+// dnsSecurityMessages 发现的说明文案（zh/en）
+var dnsSecurityMessages = map[string]map[string]string{
+	"zh": {
+		"dnssec_unsigned":                 "域名没有启用 DNSSEC，DNS 应答可能被伪造（缓存投毒）",
+		"dnssec_no_dnskey":                "父区域有 DS 记录但区域没有发布 DNSKEY，启用验证的解析器将无法解析该域名",
+		"dnssec_no_ds":                    "区域已签名但父区域没有 DS 记录，DNSSEC 没有生效（需要在注册商处提交 DS）",
+		"dnssec_ds_mismatch":              "父区域的 DS 记录与区域的 DNSKEY 不匹配，启用验证的解析器将无法解析该域名",
+		"dnssec_dnskey_signature_invalid": "DNSKEY 记录的签名无效：%s",
+		"dnssec_rrsig_invalid":            "记录签名无效：%s",
+		"dnssec_signature_expiring":       "DNSSEC 签名将于 %s 过期，请确认区域会自动重新签名",
+		"dnssec_weak_algorithm":           "DNSSEC 使用已废弃的算法 %s，部分解析器会视为未签名",
+		"dnssec_sha1_algorithm":           "DNSSEC 使用基于 SHA-1 的算法 %s，建议迁移到 ECDSAP256SHA256 或 ED25519",
+		"dnssec_sha1_digest":              "DS 记录只使用 SHA-1 摘要，建议改用 SHA-256",
+		"dnssec_resolver_servfail":        "解析器验证 DNSSEC 失败（SERVFAIL），启用验证的用户将无法访问该域名",
+		"caa_missing":                     "没有 CAA 记录，任何证书颁发机构都可以为该域名签发证书",
+		"caa_issuer_not_allowed":          "当前证书的颁发者（%s）不在 CAA 允许的列表中（%s），续期证书将会失败",
+		"caa_issuer_unknown":              "无法识别当前证书颁发者（%s）对应的 CAA 域名，未比较 CAA 记录",
+		"caa_unknown_critical":            "CAA 记录包含无法识别的关键标签 %s，CA 将拒绝签发证书",
+		"cname_dangling":                  "%s 的 CNAME 指向不存在的名称 %s（悬空 CNAME），应删除该记录",
+		"cname_takeover":                  "%s 的 CNAME 指向 %s 上未被认领的资源 %s，他人可以注册该资源接管此域名",
+		"ns_single":                       "只有 %d 个权威域名服务器，建议至少 2 个并分布在不同网络",
+		"ns_delegation_mismatch":          "父区域委派的 NS（%s）与区域自身声明的 NS（%s）不一致",
+		"ns_lame":                         "域名服务器 %s 没有对该区域返回权威应答：%s",
+		"ns_unresolvable":                 "域名服务器 %s 无法解析到 IP 地址，如果其域名可被注册，可能导致域名被接管",
+		"ns_serial_mismatch":              "各权威服务器的 SOA 序列号不一致（%s），区域数据可能没有同步",
+		"ns_same_network":                 "所有权威域名服务器都在同一个 /24 网段，单点故障会导致域名无法解析",
+	},
+	"en": {
+		"dnssec_unsigned":                 "DNSSEC is not enabled; DNS answers for this domain can be forged (cache poisoning)",
+		"dnssec_no_dnskey":                "The parent zone has DS records but the zone publishes no DNSKEY; validating resolvers cannot resolve this domain",
+		"dnssec_no_ds":                    "The zone is signed but the parent has no DS record, so DNSSEC is not in effect (submit the DS at your registrar)",
+		"dnssec_ds_mismatch":              "The DS records at the parent do not match any DNSKEY; validating resolvers cannot resolve this domain",
+		"dnssec_dnskey_signature_invalid": "Invalid DNSKEY signature: %s",
+		"dnssec_rrsig_invalid":            "Invalid record signature: %s",
+		"dnssec_signature_expiring":       "DNSSEC signatures expire at %s; make sure the zone is re-signed automatically",
+		"dnssec_weak_algorithm":           "DNSSEC uses the deprecated algorithm %s; some resolvers treat the zone as unsigned",
+		"dnssec_sha1_algorithm":           "DNSSEC uses the SHA-1 based algorithm %s; migrate to ECDSAP256SHA256 or ED25519",
+		"dnssec_sha1_digest":              "DS records only use SHA-1 digests; publish a SHA-256 DS instead",
+		"dnssec_resolver_servfail":        "The resolver fails DNSSEC validation (SERVFAIL); users behind validating resolvers cannot reach this domain",
+		"caa_missing":                     "No CAA records; any certificate authority may issue certificates for this domain",
+		"caa_issuer_not_allowed":          "The current certificate issuer (%s) is not allowed by CAA (%s); certificate renewal will fail",
+		"caa_issuer_unknown":              "Cannot map the current certificate issuer (%s) to a CAA domain; CAA was not compared",
+		"caa_unknown_critical":            "CAA contains the unknown critical tag %s; CAs will refuse to issue certificates",
+		"cname_dangling":                  "The CNAME of %s points to %s, which does not exist (dangling CNAME); remove the record",
+		"cname_takeover":                  "The CNAME of %s points to an unclaimed %s resource (%s); anyone can claim it and take over this name",
+		"ns_single":                       "Only %d authoritative name server(s); use at least 2 on different networks",
+		"ns_delegation_mismatch":          "The NS set delegated by the parent (%s) differs from the NS set in the zone (%s)",
+		"ns_lame":                         "Name server %s does not answer authoritatively for the zone: %s",
+		"ns_unresolvable":                 "Name server %s does not resolve to an IP address; if its domain can be registered, the zone can be hijacked",
+		"ns_serial_mismatch":              "SOA serials differ between authoritative servers (%s); zone data may be out of sync",
+		"ns_same_network":                 "All authoritative name servers are in the same /24 network, a single point of failure",
+	},
+}
+
+// dnsAudit 一次域名 DNS 检查的上下文
+type dnsAudit struct {
+	ctx    context.Context
+	client *utils.DNSClient
+	lang   string
+	info   *models.DomainInfo
+	mu     sync.Mutex // 保护 info.Findings（各检查项并发执行）
+}
+
+// newDNSAudit 创建 DNS 检查（使用 DNS_RESOLVER 配置的解析器）
+func newDNSAudit(ctx context.Context, lang string, info *models.DomainInfo) *dnsAudit {
+	return &dnsAudit{
+		ctx:    ctx,
+		client: utils.NewDNSClient(),
+		lang:   lang,
+		info:   info,
+	}
+}
+
+// addFinding 记录一个发现（按任务语言生成说明）
+func (d *dnsAudit) addFinding(check, id, severity string, args ...interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.info.Findings = append(d.info.Findings, models.DNSFinding{
+		Check:    check,
+		ID:       id,
+		Severity: severity,
+		Message:  findingMessage(dnsSecurityMessages, d.lang, id, args...),
+	})
+}
+
+// lookup 查询记录，返回应答中指定类型的记录（跟随 CNAME 后的记录也包含在内）
+func (d *dnsAudit) lookup(name string, rrType dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	resp, err := d.query(name, rrType, false)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("%s %s: %s", name, rrType, resp.Header.RCode)
+	}
+	var records []dnsmessage.Resource
+	for _, rr := range resp.Answers {
+		if rr.Header.Type == rrType {
+			records = append(records, rr)
+		}
+	}
+	return records, nil
+}
+
+// lookupIPv4 查询主机名的 IPv4 地址
+func (d *dnsAudit) lookupIPv4(name string) []string {
+	records, err := d.lookup(name, dnsmessage.TypeA)
+	if err != nil {
+		return nil
+	}
+	var ips []string
+	for _, rr := range records {
+		if a, ok := rr.Body.(*dnsmessage.AResource); ok {
+			ips = append(ips, net.IP(a.A[:]).String())
+		}
+	}
+	return ips
+}
+
+// lookupNSNames 查询区域的 NS 主机名（小写，不带末尾的点，已排序）
+func (d *dnsAudit) lookupNSNames(zone string) []string {
+	records, err := d.lookup(zone, dnsmessage.TypeNS)
+	if err != nil {
+		return nil
+	}
+	return nsNames(records, zone)
+}
+
+// nsNames 提取指定区域的 NS 主机名
+func nsNames(records []dnsmessage.Resource, zone string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, rr := range records {
+		ns, ok := rr.Body.(*dnsmessage.NSResource)
+		if !ok || !strings.EqualFold(rr.Header.Name.String(), fqdnName(zone)) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(ns.NS.String(), "."))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parentName 去掉第一级标签（"www.example.com." -> "example.com."）
+func parentName(name string) string {
+	name = fqdnName(name)
+	if i := strings.Index(name, "."); i >= 0 && i < len(name)-1 {
+		return name[i+1:]
+	}
+	return "."
+}
+
+// isTopLevel 是否为顶级域名或根（不再向上查找）
+func isTopLevel(name string) bool {
+	return strings.Count(fqdnName(name), ".") <= 1
+}
+
+// findZone 查找主机名所在的 DNS 区域（SOA 所在的名称）
+func (d *dnsAudit) findZone(host string) (string, error) {
+	name := strings.ToLower(fqdnName(host))
+	for !isTopLevel(name) {
+		resp, err := d.query(name, dnsmessage.TypeSOA, true)
+		if err != nil {
+			return "", err
+		}
+		alias := false
+		for _, rr := range resp.Answers {
+			if !strings.EqualFold(rr.Header.Name.String(), name) {
+				continue
+			}
+			switch rr.Header.Type {
+			case dnsmessage.TypeSOA:
+				return name, nil
+			case dnsmessage.TypeCNAME:
+				alias = true
+			}
+		}
+		// CNAME 的 SOA 属于目标所在的区域，需要继续向上查找
+		if !alias {
+			for _, rr := range resp.Authorities {
+				if rr.Header.Type == dnsmessage.TypeSOA && strings.HasSuffix(name, strings.ToLower(rr.Header.Name.String())) {
+					return strings.ToLower(rr.Header.Name.String()), nil
+				}
+			}
+		}
+		name = parentName(name)
+	}
+	return name, nil
+}
+
+// runSecurityChecks 并发执行 DNSSEC、CAA、悬空 CNAME 和 NS 一致性检查
+func (d *dnsAudit) runSecurityChecks(host string, sslInfo *models.SSLInfo) {
+	zone, err := d.findZone(host)
+	if err != nil {
+		log.Printf("[DomainInfo] Warning: Failed to find DNS zone for %s: %v", host, err)
+		return
+	}
+	if isTopLevel(zone) {
+		log.Printf("[DomainInfo] Warning: %s is not in a delegated zone, skipping DNS security checks", host)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, check := range []func(){
+		func() { d.checkDNSSEC(zone, host) },
+		func() { d.checkCAA(host, sslInfo) },
+		func() { d.checkCNAME(host) },
+		func() { d.checkNameservers(zone) },
+	} {
+		wg.Add(1)
+		go func(check func()) {
+			defer wg.Done()
+			check()
+		}(check)
+	}
+	wg.Wait()
+
+	sort.SliceStable(d.info.Findings, func(i, j int) bool {
+		return severityRank[d.info.Findings[i].Severity] < severityRank[d.info.Findings[j].Severity]
+	})
+}
+
+// checkCAA 查找 CAA 记录（从主机名逐级向上，使用第一个非空的记录集合），并与当前证书的颁发者比较
+func (d *dnsAudit) checkCAA(host string, sslInfo *models.SSLInfo) {
+	info := &models.CAAInfo{}
+	d.info.CAA = info
+
+	for name := strings.ToLower(fqdnName(host)); !isTopLevel(name); name = parentName(name) {
+		resp, err := d.query(name, dnsTypeCAA, false)
+		if err != nil {
+			log.Printf("[DomainInfo] Warning: Failed to lookup CAA for %s: %v", name, err)
+			return
+		}
+		// NXDOMAIN 时继续查找上级域名；其它错误（如 SERVFAIL）无法判断，停止检查
+		if resp.Header.RCode != dnsmessage.RCodeSuccess && resp.Header.RCode != dnsmessage.RCodeNameError {
+			log.Printf("[DomainInfo] Warning: CAA lookup for %s returned %s", name, resp.Header.RCode)
+			return
+		}
+		for _, rr := range resp.Answers {
+			if unknown, ok := rr.Body.(*dnsmessage.UnknownResource); ok && rr.Header.Type == dnsTypeCAA {
+				if record, ok := parseCAA(unknown.Data); ok {
+					info.Records = append(info.Records, record)
+				}
+			}
+		}
+		if len(info.Records) > 0 {
+			info.Domain = strings.TrimSuffix(name, ".")
+			break
+		}
+	}
+
+	if len(info.Records) == 0 {
+		d.addFinding(models.DNSCheckCAA, "caa_missing", models.SeverityLow)
+		return
+	}
+
+	hasIssue, hasIssueWild := false, false
+	for _, record := range info.Records {
+		switch record.Tag {
+		case "issue":
+			hasIssue = true
+			info.Issuers = append(info.Issuers, caaIssuerDomain(record.Value))
+		case "issuewild":
+			hasIssueWild = true
+			info.WildcardIssuers = append(info.WildcardIssuers, caaIssuerDomain(record.Value))
+		case "iodef":
+			info.IODEF = append(info.IODEF, record.Value)
+		default:
+			if record.Flags&0x80 != 0 {
+				d.addFinding(models.DNSCheckCAA, "caa_unknown_critical", models.SeverityMedium, record.Tag)
+			}
+		}
+	}
+
+	if sslInfo == nil || sslInfo.Issuer == "" {
+		return
+	}
+	info.CertificateIssuer = sslInfo.Issuer
+
+	// 通配符证书优先使用 issuewild（RFC 8659 4.3）
+	allowed, restricted := info.Issuers, hasIssue
+	for _, name := range sslInfo.DNSNames {
+		if strings.HasPrefix(name, "*.") && hasIssueWild {
+			allowed, restricted = info.WildcardIssuers, true
+			break
+		}
+	}
+	if !restricted {
+		return
+	}
+
+	candidates := caaDomainsForIssuer(sslInfo.Issuer)
+	if len(candidates) == 0 {
+		d.addFinding(models.DNSCheckCAA, "caa_issuer_unknown", models.SeverityInfo, sslInfo.Issuer)
+		return
+	}
+	ok := false
+	for _, domain := range allowed {
+		for _, candidate := range candidates {
+			if domain == candidate {
+				ok = true
+			}
+		}
+	}
+	info.IssuerAllowed = &ok
+	if !ok {
+		d.addFinding(models.DNSCheckCAA, "caa_issuer_not_allowed", models.SeverityHigh, sslInfo.Issuer, strings.Join(nonEmptyStrings(allowed...), ", "))
+	}
+}
+
+// parseCAA 解析 CAA 记录（flags、tag 长度、tag、value）
+func parseCAA(rdata []byte) (models.CAARecord, bool) {
+	if len(rdata) < 2 || len(rdata) < 2+int(rdata[1]) {
+		return models.CAARecord{}, false
+	}
+	tagLen := int(rdata[1])
+	return models.CAARecord{
+		Flags: int(rdata[0]),
+		Tag:   strings.ToLower(string(rdata[2 : 2+tagLen])),
+		Value: string(rdata[2+tagLen:]),
+	}, true
+}
+
+// caaIssuerDomain 提取 issue 值中的 CA 域名（"letsencrypt.org; validationmethods=dns-01" -> "letsencrypt.org"）
+func caaIssuerDomain(value string) string {
+	domain, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(domain))
+}
+
+// caaDomainsForIssuer 根据证书颁发者名称推断 CA 的 CAA 域名
+func caaDomainsForIssuer(issuer string) []string {
+	lower := strings.ToLower(issuer)
+	for _, ca := range caaIssuerDomains {
+		if strings.Contains(lower, ca.keyword) {
+			return ca.domains
+		}
+	}
+	return nil
+}
+
+// checkCNAME 检查目标主机名（及 www 子域名）的 CNAME 是否悬空或指向未认领的云资源
+func (d *dnsAudit) checkCNAME(host string) {
+	hosts := []string{host}
+	if !strings.HasPrefix(host, "www.") {
+		hosts = append(hosts, "www."+host)
+	}
+
+	for _, name := range hosts {
+		chain, resolved, nxdomain, err := d.resolveCNAMEChain(name)
+		if err != nil {
+			log.Printf("[DomainInfo] Warning: Failed to resolve CNAME chain for %s: %v", name, err)
+			continue
+		}
+		if name == host {
+			d.mu.Lock()
+			d.info.CNAME = chain
+			d.mu.Unlock()
+		}
+		if len(chain) == 0 {
+			continue
+		}
+
+		target := chain[len(chain)-1]
+		service := matchTakeoverService(target)
+		switch {
+		case nxdomain && service != nil:
+			d.addFinding(models.DNSCheckCNAME, "cname_takeover", models.SeverityCritical, name, service.name, target)
+		case nxdomain:
+			d.addFinding(models.DNSCheckCNAME, "cname_dangling", models.SeverityHigh, name, target)
+		case resolved && service != nil && service.fingerprint != "":
+			if d.pageContains(name, service.fingerprint) {
+				d.addFinding(models.DNSCheckCNAME, "cname_takeover", models.SeverityCritical, name, service.name, target)
+			}
+		}
+	}
+}
+
+// resolveCNAMEChain 解析主机名的 CNAME 链，返回链上的名称、最终是否解析到地址、最终名称是否不存在
+func (d *dnsAudit) resolveCNAMEChain(host string) ([]string, bool, bool, error) {
+	resp, err := d.query(host, dnsmessage.TypeA, false)
+	if err != nil {
+		return nil, false, false, err
+	}
+	var chain []string
+	name := strings.ToLower(fqdnName(host))
+	for len(chain) < maxCNAMEChain {
+		next := ""
+		for _, rr := range resp.Answers {
+			if cname, ok := rr.Body.(*dnsmessage.CNAMEResource); ok && strings.EqualFold(rr.Header.Name.String(), name) {
+				next = strings.ToLower(cname.CNAME.String())
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		chain = append(chain, strings.TrimSuffix(next, "."))
+		name = next
+	}
+
+	resolved := false
+	for _, rr := range resp.Answers {
+		if rr.Header.Type == dnsmessage.TypeA && strings.EqualFold(rr.Header.Name.String(), name) {
+			resolved = true
+		}
+	}
+	return chain, resolved, resp.Header.RCode == dnsmessage.RCodeNameError, nil
+}
+
+// matchTakeoverService 匹配 CNAME 目标所属的可接管云服务
+func matchTakeoverService(target string) *takeoverService {
+	for i := range takeoverServices {
+		if takeoverServices[i].pattern.MatchString(strings.ToLower(target)) {
+			return &takeoverServices[i]
+		}
+	}
+	return nil
+}
+
+// pageContains 读取主机名的首页，判断是否包含未认领资源的特征文本
+func (d *dnsAudit) pageContains(host, fingerprint string) bool {
+	client := utils.NewSafeHTTPClient(takeoverFetchTimeout)
+	for _, scheme := range []string{"https", "http"} {
+		req, err := http.NewRequestWithContext(d.ctx, http.MethodGet, scheme+"://"+host+"/", nil)
+		if err != nil {
+			return false
+		}
+		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; WebCheckly/1.0)")
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, takeoverMaxBody))
+		resp.Body.Close()
+		return strings.Contains(string(body), fingerprint)
+	}
+	return false
+}
+
+// checkNameservers 比较父区域委派与区域自身的 NS，并逐个查询权威服务器的 SOA
+func (d *dnsAudit) checkNameservers(zone string) {
+	result := &models.NSConsistency{Zone: strings.TrimSuffix(zone, ".")}
+	d.info.Nameservers = result
+
+	result.ZoneNS = d.lookupNSNames(zone)
+	result.Delegation = d.parentDelegation(zone)
+
+	hosts := append([]string{}, result.Delegation...)
+	for _, host := range result.ZoneNS {
+		if !containsString(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	if len(hosts) == 0 {
+		return
+	}
+
+	result.Servers = make([]models.NSServer, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			result.Servers[i] = d.checkNameserver(zone, host)
+		}(i, host)
+	}
+	wg.Wait()
+
+	result.Consistent = true
+	if len(hosts) < 2 {
+		result.Consistent = false
+		d.addFinding(models.DNSCheckNS, "ns_single", models.SeverityMedium, len(hosts))
+	}
+	if len(result.Delegation) > 0 && len(result.ZoneNS) > 0 &&
+		strings.Join(result.Delegation, ",") != strings.Join(result.ZoneNS, ",") {
+		result.Consistent = false
+		d.addFinding(models.DNSCheckNS, "ns_delegation_mismatch", models.SeverityMedium,
+			strings.Join(result.Delegation, ", "), strings.Join(result.ZoneNS, ", "))
+	}
+
+	serials := make(map[uint32][]string)
+	networks := make(map[string]bool)
+	for _, server := range result.Servers {
+		switch {
+		case len(server.IPs) == 0:
+			result.Consistent = false
+			d.addFinding(models.DNSCheckNS, "ns_unresolvable", models.SeverityHigh, server.Host)
+		case !server.Authoritative:
+			result.Consistent = false
+			d.addFinding(models.DNSCheckNS, "ns_lame", models.SeverityHigh, server.Host, server.Error)
+		default:
+			serials[server.Serial] = append(serials[server.Serial], server.Host)
+		}
+		for _, ip := range server.IPs {
+			if parsed := net.ParseIP(ip).To4(); parsed != nil {
+				networks[parsed.Mask(net.CIDRMask(24, 32)).String()] = true
+			}
+		}
+	}
+	if len(serials) > 1 {
+		result.Consistent = false
+		parts := make([]string, 0, len(serials))
+		for serial, servers := range serials {
+			parts = append(parts, fmt.Sprintf("%d: %s", serial, strings.Join(servers, ", ")))
+		}
+		sort.Strings(parts)
+		d.addFinding(models.DNSCheckNS, "ns_serial_mismatch", models.SeverityLow, strings.Join(parts, "; "))
+	}
+	if len(hosts) >= 2 && len(networks) == 1 {
+		d.addFinding(models.DNSCheckNS, "ns_same_network", models.SeverityLow)
+	}
+}
+
+// parentDelegation 直接向父区域的权威服务器查询区域的委派（NS）
+func (d *dnsAudit) parentDelegation(zone string) []string {
+	parent := parentName(zone)
+	if parent == "." {
+		return nil
+	}
+	parentServers := d.lookupNSNames(parent)
+	tried := 0
+	for _, server := range parentServers {
+		if tried >= maxParentServers {
+			break
+		}
+		ips := d.lookupIPv4(server)
+		if len(ips) == 0 || utils.IsPrivateIP(ips[0]) {
+			continue
+		}
+		tried++
+		resp, err := d.client.Exchange(d.ctx, net.JoinHostPort(ips[0], "53"), utils.DNSQuery{Name: zone, Type: dnsmessage.TypeNS})
+		if err != nil {
+			log.Printf("[DomainInfo] Warning: Failed to query parent server %s for %s: %v", server, zone, err)
+			continue
+		}
+		// 委派在权威部分（referral）；父区域同时托管子区域时在应答部分
+		names := nsNames(append(append([]dnsmessage.Resource{}, resp.Answers...), resp.Authorities...), zone)
+		if len(names) > 0 {
+			return names
+		}
+	}
+	return nil
+}
+
+// checkNameserver 查询单个权威服务器的 SOA（不递归），判断是否权威应答
+func (d *dnsAudit) checkNameserver(zone, host string) models.NSServer {
+	server := models.NSServer{Host: host, IPs: d.lookupIPv4(host)}
+	if len(server.IPs) == 0 {
+		server.Error = "no IPv4 address"
+		return server
+	}
+	ip := server.IPs[0]
+	if utils.IsPrivateIP(ip) {
+		// 不向内网地址发送查询（防止利用 NS 记录探测内网）
+		server.Error = fmt.Sprintf("resolves to private address %s", ip)
+		return server
+	}
+
+	resp, err := d.client.Exchange(d.ctx, net.JoinHostPort(ip, "53"), utils.DNSQuery{Name: zone, Type: dnsmessage.TypeSOA})
+	if err != nil {
+		server.Error = err.Error()
+		return server
+	}
+	if resp.Header.RCode != dnsmessage.RCodeSuccess {
+		server.Error = resp.Header.RCode.String()
+		return server
+	}
+	for _, rr := range resp.Answers {
+		if soa, ok := rr.Body.(*dnsmessage.SOAResource); ok && strings.EqualFold(rr.Header.Name.String(), zone) {
+			server.Serial = soa.Serial
+			server.Authoritative = resp.Header.Authoritative
+		}
+	}
+	if !server.Authoritative {
+		server.Error = "non-authoritative answer"
+	}
+	return server
+}
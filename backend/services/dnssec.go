@@ -0,0 +1,624 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsmessage 没有定义的记录类型
+const (
+	dnsTypeDS     = dnsmessage.Type(43)
+	dnsTypeRRSIG  = dnsmessage.Type(46)
+	dnsTypeDNSKEY = dnsmessage.Type(48)
+	dnsTypeCAA    = dnsmessage.Type(257)
+)
+
+// dnssecExpiryWarning 签名剩余有效期少于该值时提示（区域签名可能没有自动续签）
+const dnssecExpiryWarning = 7 * 24 * time.Hour
+
+// dnssecAlgorithms DNSSEC 算法编号（RFC 8624）
+var dnssecAlgorithms = map[uint8]string{
+	1:  "RSAMD5",
+	3:  "DSA",
+	5:  "RSASHA1",
+	6:  "DSA-NSEC3-SHA1",
+	7:  "RSASHA1-NSEC3-SHA1",
+	8:  "RSASHA256",
+	10: "RSASHA512",
+	12: "ECC-GOST",
+	13: "ECDSAP256SHA256",
+	14: "ECDSAP384SHA384",
+	15: "ED25519",
+	16: "ED448",
+}
+
+// dsDigestTypes DS 摘要算法编号
+var dsDigestTypes = map[uint8]string{
+	1: "SHA-1",
+	2: "SHA-256",
+	3: "GOST",
+	4: "SHA-384",
+}
+
+// dnskeyRecord DNSKEY 记录
+type dnskeyRecord struct {
+	flags     uint16
+	algorithm uint8
+	publicKey []byte
+	rdata     []byte
+	keyTag    uint16
+}
+
+// dsRecord DS 记录
+type dsRecord struct {
+	keyTag     uint16
+	algorithm  uint8
+	digestType uint8
+	digest     []byte
+}
+
+// rrsigRecord RRSIG 记录
+type rrsigRecord struct {
+	typeCovered dnsmessage.Type
+	algorithm   uint8
+	labels      uint8
+	originalTTL uint32
+	expiration  uint32
+	inception   uint32
+	keyTag      uint16
+	signerName  string
+	header      []byte // 签名数据中的 RRSIG 部分（不含签名，签名者名称为规范格式）
+	signature   []byte
+}
+
+// parseDNSKEY 解析 DNSKEY 记录
+func parseDNSKEY(rdata []byte) (dnskeyRecord, error) {
+	if len(rdata) < 4 {
+		return dnskeyRecord{}, errors.New("DNSKEY record too short")
+	}
+	return dnskeyRecord{
+		flags:     binary.BigEndian.Uint16(rdata),
+		algorithm: rdata[3],
+		publicKey: rdata[4:],
+		rdata:     rdata,
+		keyTag:    dnskeyTag(rdata),
+	}, nil
+}
+
+// dnskeyTag 计算 key tag（RFC 4034 附录 B）
+func dnskeyTag(rdata []byte) uint16 {
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 1 {
+			ac += uint32(b)
+		} else {
+			ac += uint32(b) << 8
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
+
+// parseDS 解析 DS 记录
+func parseDS(rdata []byte) (dsRecord, error) {
+	if len(rdata) < 5 {
+		return dsRecord{}, errors.New("DS record too short")
+	}
+	return dsRecord{
+		keyTag:     binary.BigEndian.Uint16(rdata),
+		algorithm:  rdata[2],
+		digestType: rdata[3],
+		digest:     rdata[4:],
+	}, nil
+}
+
+// parseRRSIG 解析 RRSIG 记录（签名者名称不允许压缩，可以直接从 RDATA 读取）
+func parseRRSIG(rdata []byte) (rrsigRecord, error) {
+	if len(rdata) < 19 {
+		return rrsigRecord{}, errors.New("RRSIG record too short")
+	}
+	signer, n, err := readWireName(rdata[18:])
+	if err != nil {
+		return rrsigRecord{}, fmt.Errorf("invalid RRSIG signer name: %w", err)
+	}
+	header := append(append([]byte{}, rdata[:18]...), canonicalNameWire(signer)...)
+	return rrsigRecord{
+		typeCovered: dnsmessage.Type(binary.BigEndian.Uint16(rdata)),
+		algorithm:   rdata[2],
+		labels:      rdata[3],
+		originalTTL: binary.BigEndian.Uint32(rdata[4:]),
+		expiration:  binary.BigEndian.Uint32(rdata[8:]),
+		inception:   binary.BigEndian.Uint32(rdata[12:]),
+		keyTag:      binary.BigEndian.Uint16(rdata[16:]),
+		signerName:  signer,
+		header:      header,
+		signature:   rdata[18+n:],
+	}, nil
+}
+
+// readWireName 读取未压缩的线路格式域名，返回小写域名（末尾带点）和占用的字节数
+func readWireName(data []byte) (string, int, error) {
+	var labels []string
+	offset := 0
+	for {
+		if offset >= len(data) {
+			return "", 0, errors.New("name exceeds record")
+		}
+		length := int(data[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length > 63 || offset+length > len(data) {
+			return "", 0, errors.New("invalid label")
+		}
+		labels = append(labels, strings.ToLower(string(data[offset:offset+length])))
+		offset += length
+	}
+	return strings.Join(labels, ".") + ".", offset, nil
+}
+
+// canonicalNameWire 规范格式（小写、不压缩）的线路格式域名
+func canonicalNameWire(name string) []byte {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	var buf []byte
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			buf = append(buf, byte(len(label)))
+			buf = append(buf, label...)
+		}
+	}
+	return append(buf, 0)
+}
+
+// canonicalRData 记录的规范格式 RDATA（RFC 4034 6.2：RDATA 中的域名小写且不压缩）
+func canonicalRData(body dnsmessage.ResourceBody) ([]byte, error) {
+	switch r := body.(type) {
+	case *dnsmessage.AResource:
+		return r.A[:], nil
+	case *dnsmessage.AAAAResource:
+		return r.AAAA[:], nil
+	case *dnsmessage.CNAMEResource:
+		return canonicalNameWire(r.CNAME.String()), nil
+	case *dnsmessage.NSResource:
+		return canonicalNameWire(r.NS.String()), nil
+	case *dnsmessage.MXResource:
+		buf := binary.BigEndian.AppendUint16(nil, r.Pref)
+		return append(buf, canonicalNameWire(r.MX.String())...), nil
+	case *dnsmessage.SOAResource:
+		buf := append(canonicalNameWire(r.NS.String()), canonicalNameWire(r.MBox.String())...)
+		for _, v := range []uint32{r.Serial, r.Refresh, r.Retry, r.Expire, r.MinTTL} {
+			buf = binary.BigEndian.AppendUint32(buf, v)
+		}
+		return buf, nil
+	case *dnsmessage.TXTResource:
+		var buf []byte
+		for _, s := range r.TXT {
+			buf = append(buf, byte(len(s)))
+			buf = append(buf, s...)
+		}
+		return buf, nil
+	case *dnsmessage.UnknownResource:
+		// DNSKEY、DS、CAA 等记录的 RDATA 中没有域名
+		return r.Data, nil
+	default:
+		return nil, fmt.Errorf("unsupported record type %T", body)
+	}
+}
+
+// rrset 同名同类型的记录集合及覆盖它的签名
+type rrset struct {
+	name   string
+	rrType dnsmessage.Type
+	rdata  [][]byte
+	sigs   []rrsigRecord
+}
+
+// extractRRset 从响应的应答部分提取指定名称和类型的记录集合及其 RRSIG
+func extractRRset(resources []dnsmessage.Resource, name string, rrType dnsmessage.Type) (*rrset, error) {
+	set := &rrset{name: strings.ToLower(fqdnName(name)), rrType: rrType}
+	for _, rr := range resources {
+		if !strings.EqualFold(rr.Header.Name.String(), set.name) {
+			continue
+		}
+		switch rr.Header.Type {
+		case rrType:
+			rdata, err := canonicalRData(rr.Body)
+			if err != nil {
+				return nil, err
+			}
+			set.rdata = append(set.rdata, rdata)
+		case dnsTypeRRSIG:
+			if unknown, ok := rr.Body.(*dnsmessage.UnknownResource); ok {
+				sig, err := parseRRSIG(unknown.Data)
+				if err == nil && sig.typeCovered == rrType {
+					set.sigs = append(set.sigs, sig)
+				}
+			}
+		}
+	}
+	return set, nil
+}
+
+// fqdnName 补全末尾的点
+func fqdnName(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// signedData 构造 RRSIG 签名覆盖的数据（RFC 4034 3.1.8.1）
+func (s *rrset) signedData(sig rrsigRecord) []byte {
+	owner := s.name
+	labels := strings.Split(strings.TrimSuffix(owner, "."), ".")
+	if int(sig.labels) < len(labels) {
+		// 通配符展开的记录：使用 *.<最后 labels 级> 作为所有者名称
+		owner = "*." + strings.Join(labels[len(labels)-int(sig.labels):], ".") + "."
+	}
+	ownerWire := canonicalNameWire(owner)
+
+	sorted := append([][]byte{}, s.rdata...)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+
+	data := append([]byte{}, sig.header...)
+	var previous []byte
+	for _, rdata := range sorted {
+		if previous != nil && bytes.Equal(previous, rdata) {
+			continue // 重复记录只计算一次
+		}
+		previous = rdata
+		data = append(data, ownerWire...)
+		data = binary.BigEndian.AppendUint16(data, uint16(s.rrType))
+		data = binary.BigEndian.AppendUint16(data, uint16(dnsmessage.ClassINET))
+		data = binary.BigEndian.AppendUint32(data, sig.originalTTL)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(rdata)))
+		data = append(data, rdata...)
+	}
+	return data
+}
+
+// verify 用区域的 DNSKEY 验证记录集合，返回通过验证的签名
+func (s *rrset) verify(zone string, keys []dnskeyRecord, now time.Time) (*rrsigRecord, error) {
+	if len(s.rdata) == 0 {
+		return nil, fmt.Errorf("no %s records for %s", s.rrType, s.name)
+	}
+	if len(s.sigs) == 0 {
+		return nil, fmt.Errorf("no RRSIG covering %s %s", s.name, s.rrType)
+	}
+	var lastErr error
+	for i := range s.sigs {
+		sig := s.sigs[i]
+		if sig.signerName != strings.ToLower(fqdnName(zone)) {
+			lastErr = fmt.Errorf("RRSIG for %s %s is signed by %s, expected %s", s.name, s.rrType, sig.signerName, fqdnName(zone))
+			continue
+		}
+		if now.Unix() > int64(sig.expiration) {
+			lastErr = fmt.Errorf("RRSIG for %s %s expired at %s", s.name, s.rrType, time.Unix(int64(sig.expiration), 0).UTC().Format(time.RFC3339))
+			continue
+		}
+		if now.Unix() < int64(sig.inception) {
+			lastErr = fmt.Errorf("RRSIG for %s %s is not yet valid", s.name, s.rrType)
+			continue
+		}
+		for _, key := range keys {
+			if key.keyTag != sig.keyTag || key.algorithm != sig.algorithm {
+				continue
+			}
+			if err := verifyDNSSECSignature(key, sig.signature, s.signedData(sig)); err != nil {
+				lastErr = fmt.Errorf("RRSIG for %s %s (key tag %d): %w", s.name, s.rrType, sig.keyTag, err)
+				continue
+			}
+			return &sig, nil
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no DNSKEY with key tag %d for RRSIG over %s %s", sig.keyTag, s.name, s.rrType)
+		}
+	}
+	return nil, lastErr
+}
+
+// verifyDNSSECSignature 按算法验证签名
+func verifyDNSSECSignature(key dnskeyRecord, signature, data []byte) error {
+	switch key.algorithm {
+	case 5, 7, 8, 10:
+		pub, err := parseDNSSECRSAKey(key.publicKey)
+		if err != nil {
+			return err
+		}
+		hash := crypto.SHA1
+		switch key.algorithm {
+		case 8:
+			hash = crypto.SHA256
+		case 10:
+			hash = crypto.SHA512
+		}
+		h := hash.New()
+		h.Write(data)
+		return rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), signature)
+	case 13, 14:
+		curve, hash, size := elliptic.P256(), crypto.SHA256, 32
+		if key.algorithm == 14 {
+			curve, hash, size = elliptic.P384(), crypto.SHA384, 48
+		}
+		if len(key.publicKey) != 2*size || len(signature) != 2*size {
+			return errors.New("invalid ECDSA key or signature length")
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(key.publicKey[:size]),
+			Y:     new(big.Int).SetBytes(key.publicKey[size:]),
+		}
+		h := hash.New()
+		h.Write(data)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, h.Sum(nil), r, s) {
+			return errors.New("ECDSA signature verification failed")
+		}
+		return nil
+	case 15:
+		if len(key.publicKey) != ed25519.PublicKeySize {
+			return errors.New("invalid Ed25519 key length")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key.publicKey), data, signature) {
+			return errors.New("Ed25519 signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %s", dnssecAlgorithmName(key.algorithm))
+	}
+}
+
+// parseDNSSECRSAKey 解析 DNSKEY 中的 RSA 公钥（RFC 3110：指数长度 + 指数 + 模数）
+func parseDNSSECRSAKey(data []byte) (*rsa.PublicKey, error) {
+	if len(data) < 3 {
+		return nil, errors.New("RSA key too short")
+	}
+	expLen, offset := int(data[0]), 1
+	if expLen == 0 {
+		expLen, offset = int(binary.BigEndian.Uint16(data[1:])), 3
+	}
+	if expLen == 0 || expLen > 4 || offset+expLen >= len(data) {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	exponent := 0
+	for _, b := range data[offset : offset+expLen] {
+		exponent = exponent<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(data[offset+expLen:]), E: exponent}, nil
+}
+
+// dsMatches 判断 DS 是否与 DNSKEY 匹配（摘要 = hash(所有者名称 | DNSKEY RDATA)）
+func dsMatches(ds dsRecord, zone string, key dnskeyRecord) bool {
+	if ds.keyTag != key.keyTag || ds.algorithm != key.algorithm {
+		return false
+	}
+	data := append(canonicalNameWire(zone), key.rdata...)
+	var digest []byte
+	switch ds.digestType {
+	case 1:
+		sum := sha1.Sum(data)
+		digest = sum[:]
+	case 2:
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case 4:
+		sum := sha512.Sum384(data)
+		digest = sum[:]
+	default:
+		return false
+	}
+	return bytes.Equal(digest, ds.digest)
+}
+
+// dnssecAlgorithmName 算法名称
+func dnssecAlgorithmName(algorithm uint8) string {
+	if name, ok := dnssecAlgorithms[algorithm]; ok {
+		return name
+	}
+	return fmt.Sprintf("ALG%d", algorithm)
+}
+
+// checkDNSSEC 检查区域的 DNSSEC：DS 与 DNSKEY 的链接、DNSKEY 和目标记录的签名
+// 查询设置 CD 标志，避免启用验证的解析器在签名错误时只返回 SERVFAIL
+func (d *dnsAudit) checkDNSSEC(zone, host string) {
+	info := &models.DNSSECInfo{Zone: strings.TrimSuffix(zone, ".")}
+	d.info.DNSSEC = info
+	now := time.Now()
+
+	dnskeyResp, err := d.query(zone, dnsTypeDNSKEY, true)
+	if err != nil {
+		info.Errors = append(info.Errors, err.Error())
+		return
+	}
+	dsResp, err := d.query(zone, dnsTypeDS, true)
+	if err != nil {
+		info.Errors = append(info.Errors, err.Error())
+		return
+	}
+
+	keySet, _ := extractRRset(dnskeyResp.Answers, zone, dnsTypeDNSKEY)
+	var keys []dnskeyRecord
+	algorithms := make(map[string]bool)
+	for _, rdata := range keySet.rdata {
+		key, err := parseDNSKEY(rdata)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+		name := dnssecAlgorithmName(key.algorithm)
+		if !algorithms[name] {
+			algorithms[name] = true
+			info.Algorithms = append(info.Algorithms, name)
+		}
+	}
+	dsSet, _ := extractRRset(dsResp.Answers, zone, dnsTypeDS)
+	var dsRecords []dsRecord
+	digests := make(map[string]bool)
+	for _, rdata := range dsSet.rdata {
+		ds, err := parseDS(rdata)
+		if err != nil {
+			continue
+		}
+		dsRecords = append(dsRecords, ds)
+		name := dsDigestTypes[ds.digestType]
+		if name == "" {
+			name = fmt.Sprintf("DIGEST%d", ds.digestType)
+		}
+		if !digests[name] {
+			digests[name] = true
+			info.DigestTypes = append(info.DigestTypes, name)
+		}
+	}
+	info.Signed = len(keys) > 0
+	info.DSPresent = len(dsRecords) > 0
+
+	// 解析器是否验证通过（不设置 CD）
+	if resp, err := d.query(host, dnsmessage.TypeA, false); err == nil {
+		info.ResolverValidated = resp.Header.AuthenticData
+		if resp.Header.RCode == dnsmessage.RCodeServerFailure && info.DSPresent {
+			d.addFinding(models.DNSCheckDNSSEC, "dnssec_resolver_servfail", models.SeverityCritical)
+		}
+	}
+
+	switch {
+	case !info.Signed && !info.DSPresent:
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_unsigned", models.SeverityLow)
+		return
+	case !info.Signed:
+		info.Errors = append(info.Errors, "DS records exist but the zone publishes no DNSKEY")
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_no_dnskey", models.SeverityCritical)
+		return
+	case !info.DSPresent:
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_no_ds", models.SeverityMedium)
+	}
+
+	// 算法强度（RFC 8624）：只报告最弱的一类
+	var weakAlgorithm, sha1Algorithm string
+	for _, key := range keys {
+		switch key.algorithm {
+		case 1, 3, 6, 12:
+			weakAlgorithm = dnssecAlgorithmName(key.algorithm)
+		case 5, 7:
+			sha1Algorithm = dnssecAlgorithmName(key.algorithm)
+		}
+	}
+	if weakAlgorithm != "" {
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_weak_algorithm", models.SeverityHigh, weakAlgorithm)
+	} else if sha1Algorithm != "" {
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_sha1_algorithm", models.SeverityMedium, sha1Algorithm)
+	}
+	if info.DSPresent && len(digests) == 1 && digests["SHA-1"] {
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_sha1_digest", models.SeverityLow)
+	}
+
+	// DS -> DNSKEY：找到与 DS 匹配的密钥
+	var anchors []dnskeyRecord
+	for _, key := range keys {
+		for _, ds := range dsRecords {
+			if dsMatches(ds, zone, key) {
+				anchors = append(anchors, key)
+				info.KeyTags = append(info.KeyTags, int(key.keyTag))
+				break
+			}
+		}
+	}
+	if info.DSPresent && len(anchors) == 0 {
+		info.Errors = append(info.Errors, "no DNSKEY matches the DS records at the parent")
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_ds_mismatch", models.SeverityCritical)
+		return
+	}
+	if !info.DSPresent {
+		// 没有 DS 时仍然验证区域内部的签名，帮助发现签名本身的问题
+		anchors = keys
+	}
+
+	var earliest *rrsigRecord
+	track := func(sig *rrsigRecord) {
+		if earliest == nil || sig.expiration < earliest.expiration {
+			earliest = sig
+		}
+	}
+
+	// 已匹配的 KSK -> DNSKEY 记录集合
+	sig, err := keySet.verify(zone, anchors, now)
+	if err != nil {
+		info.Errors = append(info.Errors, err.Error())
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_dnskey_signature_invalid", models.SeverityCritical, err.Error())
+		return
+	}
+	track(sig)
+
+	// DNSKEY -> 目标记录：主机名的 A（或 CNAME）记录，没有时使用区域的 SOA
+	target, err := d.signedTargetRRset(host, zone)
+	if err != nil {
+		info.Errors = append(info.Errors, err.Error())
+		return
+	}
+	sig, err = target.verify(zone, keys, now)
+	if err != nil {
+		info.Errors = append(info.Errors, err.Error())
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_rrsig_invalid", models.SeverityCritical, err.Error())
+		return
+	}
+	track(sig)
+
+	info.Valid = info.DSPresent
+	expires := time.Unix(int64(earliest.expiration), 0).UTC()
+	info.SignatureExpires = expires.Format(time.RFC3339)
+	if expires.Sub(now) < dnssecExpiryWarning {
+		d.addFinding(models.DNSCheckDNSSEC, "dnssec_signature_expiring", models.SeverityMedium, info.SignatureExpires)
+	}
+}
+
+// signedTargetRRset 获取用于验证区域签名的记录集合
+func (d *dnsAudit) signedTargetRRset(host, zone string) (*rrset, error) {
+	resp, err := d.query(host, dnsmessage.TypeA, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, rrType := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeCNAME} {
+		set, err := extractRRset(resp.Answers, host, rrType)
+		if err != nil {
+			return nil, err
+		}
+		if len(set.rdata) > 0 {
+			return set, nil
+		}
+	}
+
+	resp, err = d.query(zone, dnsmessage.TypeSOA, true)
+	if err != nil {
+		return nil, err
+	}
+	return extractRRset(resp.Answers, zone, dnsmessage.TypeSOA)
+}
+
+// query 通过递归解析器查询（总是设置 DO 标志以获取 RRSIG 和 AD 标志；checkingDisabled 为 true 时设置 CD 标志）
+func (d *dnsAudit) query(name string, rrType dnsmessage.Type, checkingDisabled bool) (*dnsmessage.Message, error) {
+	return d.client.Query(d.ctx, utils.DNSQuery{
+		Name:             name,
+		Type:             rrType,
+		DNSSEC:           true,
+		CheckingDisabled: checkingDisabled,
+	})
+}
@@ -1,19 +1,25 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 	"web-checkly/models"
+	"web-checkly/utils"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
-// CollectDomainInfo 收集域名信息
-func CollectDomainInfo(targetURL string) (*models.DomainInfo, error) {
+// CollectDomainInfo 收集域名信息，并检查 DNSSEC、CAA、悬空 CNAME 和 NS 一致性
+// DNS 查询使用 DNS_RESOLVER 配置的解析器；sslInfo 为 ssl-info 模块的结果（可为 nil，为 nil 时不比较 CAA 与证书颁发者）
+func CollectDomainInfo(ctx context.Context, targetURL string, lang string, sslInfo *models.SSLInfo) (*models.DomainInfo, error) {
 	log.Printf("[DomainInfo] Collecting domain info for: %s", targetURL)
 
 	parsedURL, err := url.Parse(targetURL)
@@ -30,60 +36,26 @@ func CollectDomainInfo(targetURL string) (*models.DomainInfo, error) {
 		Domain: domain,
 	}
 
-	// 解析IP地址
-	ips, err := net.LookupIP(domain)
-	if err != nil {
-		log.Printf("[DomainInfo] Warning: Failed to lookup IPs: %v", err)
-	} else {
-		for _, ip := range ips {
-			ipStr := ip.String()
-			if ip.To4() != nil {
-				info.IPv4 = append(info.IPv4, ipStr)
-				if info.IP == "" {
-					info.IP = ipStr
-				}
-			} else {
-				info.IPv6 = append(info.IPv6, ipStr)
-			}
-		}
-	}
-
-	// MX记录
-	mxRecords, err := net.LookupMX(domain)
-	if err != nil {
-		log.Printf("[DomainInfo] Warning: Failed to lookup MX records: %v", err)
-	} else {
-		for _, mx := range mxRecords {
-			info.MX = append(info.MX, strings.TrimSuffix(mx.Host, "."))
-		}
-	}
-
-	// NS记录
-	nsRecords, err := net.LookupNS(domain)
-	if err != nil {
-		log.Printf("[DomainInfo] Warning: Failed to lookup NS records: %v", err)
-	} else {
-		for _, ns := range nsRecords {
-			info.NS = append(info.NS, strings.TrimSuffix(ns.Host, "."))
+	// 目标是 IP 地址时没有 DNS 记录可查
+	if ip := net.ParseIP(domain); ip != nil {
+		if ip.To4() != nil {
+			info.IPv4 = []string{domain}
+		} else {
+			info.IPv6 = []string{domain}
 		}
-	}
-
-	// TXT记录
-	txtRecords, err := net.LookupTXT(domain)
-	if err != nil {
-		log.Printf("[DomainInfo] Warning: Failed to lookup TXT records: %v", err)
+		info.IP = domain
 	} else {
-		info.TXT = txtRecords
+		audit := newDNSAudit(ctx, lang, info)
+		audit.collectRecords(domain)
+		audit.runSecurityChecks(domain, sslInfo)
 	}
 
 	// 获取ASN和地理位置信息（可选，通过第三方API）
 	// 如果主IP存在，尝试获取ASN和地理位置
 	// 注意：此功能使用免费API，有速率限制，失败时不影响主要功能
 	if info.IP != "" {
-		// 使用带超时的HTTP客户端
-		client := &http.Client{
-			Timeout: 5 * time.Second,
-		}
+		// 使用带超时的安全HTTP客户端
+		client := utils.NewSafeHTTPClient(5 * time.Second)
 
 		asnInfo, err := getASNInfo(client, info.IP)
 		if err == nil && asnInfo != nil {
@@ -98,12 +70,68 @@ func CollectDomainInfo(targetURL string) (*models.DomainInfo, error) {
 		}
 	}
 
-	log.Printf("[DomainInfo] Collected info: Domain=%s, IP=%s, IPv4=%d, IPv6=%d, ASN=%s",
-		info.Domain, info.IP, len(info.IPv4), len(info.IPv6), info.ASN)
+	log.Printf("[DomainInfo] Collected info: Domain=%s, IP=%s, IPv4=%d, IPv6=%d, ASN=%s, DNS findings=%d",
+		info.Domain, info.IP, len(info.IPv4), len(info.IPv6), info.ASN, len(info.Findings))
 
 	return info, nil
 }
 
+// collectRecords 查询 A、AAAA、MX、NS、TXT 记录
+func (d *dnsAudit) collectRecords(domain string) {
+	// 解析IP地址
+	for _, rrType := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		records, err := d.lookup(domain, rrType)
+		if err != nil {
+			log.Printf("[DomainInfo] Warning: Failed to lookup IPs: %v", err)
+			continue
+		}
+		for _, rr := range records {
+			switch body := rr.Body.(type) {
+			case *dnsmessage.AResource:
+				d.info.IPv4 = append(d.info.IPv4, net.IP(body.A[:]).String())
+			case *dnsmessage.AAAAResource:
+				d.info.IPv6 = append(d.info.IPv6, net.IP(body.AAAA[:]).String())
+			}
+		}
+	}
+	if len(d.info.IPv4) > 0 {
+		d.info.IP = d.info.IPv4[0]
+	}
+
+	// MX记录
+	mxRecords, err := d.lookup(domain, dnsmessage.TypeMX)
+	if err != nil {
+		log.Printf("[DomainInfo] Warning: Failed to lookup MX records: %v", err)
+	} else {
+		sort.SliceStable(mxRecords, func(i, j int) bool {
+			return mxRecords[i].Body.(*dnsmessage.MXResource).Pref < mxRecords[j].Body.(*dnsmessage.MXResource).Pref
+		})
+		for _, rr := range mxRecords {
+			d.info.MX = append(d.info.MX, strings.TrimSuffix(rr.Body.(*dnsmessage.MXResource).MX.String(), "."))
+		}
+	}
+
+	// NS记录
+	nsRecords, err := d.lookup(domain, dnsmessage.TypeNS)
+	if err != nil {
+		log.Printf("[DomainInfo] Warning: Failed to lookup NS records: %v", err)
+	} else {
+		for _, rr := range nsRecords {
+			d.info.NS = append(d.info.NS, strings.TrimSuffix(rr.Body.(*dnsmessage.NSResource).NS.String(), "."))
+		}
+	}
+
+	// TXT记录（一条记录的多个字符串按 RFC 7208 3.3 直接拼接）
+	txtRecords, err := d.lookup(domain, dnsmessage.TypeTXT)
+	if err != nil {
+		log.Printf("[DomainInfo] Warning: Failed to lookup TXT records: %v", err)
+	} else {
+		for _, rr := range txtRecords {
+			d.info.TXT = append(d.info.TXT, strings.Join(rr.Body.(*dnsmessage.TXTResource).TXT, ""))
+		}
+	}
+}
+
 // ASNInfo ASN和地理位置信息
 type ASNInfo struct {
 	ASN     string
@@ -0,0 +1,1057 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// maxSPFLookups RFC 7208 4.6.4：需要 DNS 查询的机制和修饰符最多 10 个
+	maxSPFLookups = 10
+	// maxSPFVoidLookups RFC 7208 4.6.4：没有结果的 DNS 查询最多 2 个
+	maxSPFVoidLookups = 2
+	// maxMTASTSPolicySize MTA-STS 策略文件最大读取字节数
+	maxMTASTSPolicySize = 64 << 10
+	// mtaSTSFetchTimeout 获取 MTA-STS 策略文件的超时时间
+	mtaSTSFetchTimeout = 10 * time.Second
+)
+
+// dkimSelectors 常见邮件服务商使用的 DKIM 选择器（DKIM 选择器无法枚举，只能逐个尝试）
+var dkimSelectors = []string{
+	"default", "dkim", "mail", "smtp", "k1", "k2", "k3", "s1", "s2", "key1", "key2",
+	"google", "selector1", "selector2", // Google Workspace、Microsoft 365
+	"fm1", "fm2", "fm3", // Fastmail
+	"protonmail", "protonmail2", "protonmail3",
+	"zoho", "zmail", "mandrill", "mxvault", "pm", "mailjet", "sendgrid", "smtpapi", "amazonses",
+	"everlytickey1", "everlytickey2", "dkim1024", "sig1",
+}
+
+// emailSeverityPenalty 每个发现按严重程度扣分
+var emailSeverityPenalty = map[string]int{
+	models.SeverityCritical: 40,
+	models.SeverityHigh:     25,
+	models.SeverityMedium:   10,
+	models.SeverityLow:      5,
+}
+
+// severityRank 严重程度排序（越小越严重）
+var severityRank = map[string]int{
+	models.SeverityCritical: 0,
+	models.SeverityHigh:     1,
+	models.SeverityMedium:   2,
+	models.SeverityLow:      3,
+	models.SeverityInfo:     4,
+}
+
+// emailDNSResolver 邮件安全检测使用的 DNS 查询接口
+type emailDNSResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// emailResolver 默认使用系统解析器
+var emailResolver emailDNSResolver = net.DefaultResolver
+
+// emailSecurityMessages 发现的说明文案（zh/en），%s 等占位符由 addFinding 填充
+var emailSecurityMessages = map[string]map[string]string{
+	"zh": {
+		"no_mx":                           "域名没有 MX 记录，不接收邮件；如果也不发送邮件，建议发布 \"v=spf1 -all\" 和 \"v=DMARC1; p=reject\" 防止被冒用",
+		"null_mx":                         "域名声明了空 MX（RFC 7505），不接收邮件",
+		"spf_missing":                     "没有 SPF 记录，任何服务器都可以冒充该域名发送邮件",
+		"spf_multiple":                    "存在 %d 条 SPF 记录，接收方会按 permerror 处理（SPF 失效）",
+		"spf_plus_all":                    "SPF 以 +all 结尾，允许任何服务器以该域名发送邮件",
+		"spf_neutral_all":                 "SPF 以 ?all 结尾，对未授权的服务器不做判断，无法防止冒用",
+		"spf_softfail_all":                "SPF 以 ~all 结尾（软失败），确认发信来源完整后建议改为 -all",
+		"spf_no_all":                      "SPF 没有 all 机制也没有 redirect，未列出的服务器默认为 neutral",
+		"spf_too_many_lookups":            "SPF 需要 %d 次 DNS 查询，超过 10 次的上限，接收方会按 permerror 处理",
+		"spf_void_lookups":                "SPF 有 %d 次 DNS 查询没有结果，超过 2 次的上限，接收方会按 permerror 处理",
+		"spf_include_error":               "SPF 引用的 %s 无法解析：%s",
+		"spf_ptr":                         "SPF 使用了 ptr 机制，RFC 7208 不建议使用（慢且不可靠，部分接收方会忽略）",
+		"spf_syntax":                      "SPF 记录有语法错误：%s",
+		"dmarc_missing":                   "没有 DMARC 记录，接收方无法按策略处理冒用该域名的邮件",
+		"dmarc_multiple":                  "存在 %d 条 DMARC 记录，接收方会忽略 DMARC",
+		"dmarc_invalid":                   "DMARC 记录无效：%s",
+		"dmarc_policy_none":               "DMARC 策略为 p=none，只监控不拦截，冒用的邮件仍会被投递",
+		"dmarc_policy_quarantine":         "DMARC 策略为 p=quarantine，冒用的邮件会进入垃圾箱；确认无误后建议改为 p=reject",
+		"dmarc_subdomain_none":            "DMARC 子域名策略为 sp=none，可以冒用子域名发送邮件",
+		"dmarc_pct":                       "DMARC 策略只对 %d%% 的邮件生效（pct）",
+		"dmarc_no_rua":                    "DMARC 没有配置聚合报告地址（rua），无法了解谁在以该域名发送邮件",
+		"dmarc_external_rua_unauthorized": "DMARC 报告地址 %s 属于外部域名，但该域名没有发布授权记录（%s），报告不会被发送",
+		"dmarc_inherited":                 "子域名没有 DMARC 记录，使用组织域名 %s 的记录",
+		"dkim_not_found":                  "在常见的 DKIM 选择器中没有找到公钥（DKIM 选择器无法枚举，可能使用了其他选择器）",
+		"dkim_weak_key":                   "DKIM 选择器 %s 使用 %d 位 RSA 密钥，可以被破解，接收方可能不接受",
+		"dkim_1024":                       "DKIM 选择器 %s 使用 1024 位 RSA 密钥，建议升级到 2048 位",
+		"dkim_revoked":                    "DKIM 选择器 %s 的公钥已撤销（p= 为空）",
+		"dkim_testing":                    "DKIM 选择器 %s 处于测试模式（t=y），接收方可能不会按签名结果处理",
+		"dkim_invalid":                    "DKIM 选择器 %s 的公钥无法解析：%s",
+		"mta_sts_missing":                 "没有 MTA-STS 记录，发往该域名的邮件可能被降级为明文传输",
+		"mta_sts_policy_error":            "MTA-STS 策略文件无法使用：%s",
+		"mta_sts_testing":                 "MTA-STS 处于 testing 模式，TLS 失败时邮件仍会投递",
+		"mta_sts_none":                    "MTA-STS 策略为 mode: none，已停用",
+		"mta_sts_mx_mismatch":             "MX 主机 %s 不在 MTA-STS 策略的 mx 列表中，发送方会拒绝投递",
+		"tls_rpt_missing":                 "没有 TLS-RPT 记录，收不到 SMTP TLS 失败报告",
+		"tls_rpt_no_rua":                  "TLS-RPT 记录没有有效的报告地址（rua）",
+		"bimi_missing":                    "没有 BIMI 记录（可选，可以在支持的邮箱客户端中显示品牌标识）",
+		"bimi_requires_dmarc":             "BIMI 要求 DMARC 策略为 quarantine 或 reject 且 pct=100，当前配置下品牌标识不会显示",
+		"bimi_no_logo":                    "BIMI 记录没有有效的 HTTPS SVG 标识地址（l=）",
+		"bimi_no_vmc":                     "BIMI 没有配置标记证书（a=），Gmail 等邮箱不会显示品牌标识",
+	},
+	"en": {
+		"no_mx":                           "The domain has no MX records and does not receive mail; if it does not send mail either, publish \"v=spf1 -all\" and \"v=DMARC1; p=reject\" to prevent spoofing",
+		"null_mx":                         "The domain publishes a null MX (RFC 7505) and does not receive mail",
+		"spf_missing":                     "No SPF record; any server can send mail as this domain",
+		"spf_multiple":                    "%d SPF records found; receivers treat this as a permerror (SPF is ineffective)",
+		"spf_plus_all":                    "SPF ends with +all, which authorizes every server on the internet to send mail as this domain",
+		"spf_neutral_all":                 "SPF ends with ?all (neutral) and does not protect against spoofing",
+		"spf_softfail_all":                "SPF ends with ~all (softfail); switch to -all once all legitimate senders are listed",
+		"spf_no_all":                      "SPF has neither an all mechanism nor a redirect; unlisted servers default to neutral",
+		"spf_too_many_lookups":            "SPF requires %d DNS lookups, above the limit of 10; receivers treat this as a permerror",
+		"spf_void_lookups":                "SPF has %d DNS lookups with no result, above the limit of 2; receivers treat this as a permerror",
+		"spf_include_error":               "SPF reference %s cannot be resolved: %s",
+		"spf_ptr":                         "SPF uses the ptr mechanism, which RFC 7208 discourages (slow, unreliable and ignored by some receivers)",
+		"spf_syntax":                      "SPF record has a syntax error: %s",
+		"dmarc_missing":                   "No DMARC record; receivers have no policy for mail spoofing this domain",
+		"dmarc_multiple":                  "%d DMARC records found; receivers will ignore DMARC",
+		"dmarc_invalid":                   "Invalid DMARC record: %s",
+		"dmarc_policy_none":               "DMARC policy is p=none (monitor only); spoofed mail is still delivered",
+		"dmarc_policy_quarantine":         "DMARC policy is p=quarantine; spoofed mail goes to spam. Move to p=reject once reports look clean",
+		"dmarc_subdomain_none":            "DMARC subdomain policy is sp=none; subdomains can be spoofed",
+		"dmarc_pct":                       "DMARC policy only applies to %d%% of messages (pct)",
+		"dmarc_no_rua":                    "DMARC has no aggregate report address (rua); you cannot see who sends mail as this domain",
+		"dmarc_external_rua_unauthorized": "DMARC report address %s is on an external domain that does not authorize it (%s); reports will not be sent",
+		"dmarc_inherited":                 "The subdomain has no DMARC record and inherits the record of the organizational domain %s",
+		"dkim_not_found":                  "No DKIM key found under common selectors (selectors cannot be enumerated; a custom selector may be in use)",
+		"dkim_weak_key":                   "DKIM selector %s uses a %d-bit RSA key, which can be broken and may be rejected by receivers",
+		"dkim_1024":                       "DKIM selector %s uses a 1024-bit RSA key; upgrade to 2048 bits",
+		"dkim_revoked":                    "DKIM selector %s has a revoked key (empty p=)",
+		"dkim_testing":                    "DKIM selector %s is in testing mode (t=y); receivers may ignore signature results",
+		"dkim_invalid":                    "DKIM selector %s has an unparsable public key: %s",
+		"mta_sts_missing":                 "No MTA-STS record; mail to this domain can be downgraded to plaintext",
+		"mta_sts_policy_error":            "MTA-STS policy cannot be used: %s",
+		"mta_sts_testing":                 "MTA-STS is in testing mode; mail is still delivered when TLS fails",
+		"mta_sts_none":                    "MTA-STS policy is mode: none (disabled)",
+		"mta_sts_mx_mismatch":             "MX host %s is not covered by the MTA-STS policy mx list; senders will refuse delivery",
+		"tls_rpt_missing":                 "No TLS-RPT record; SMTP TLS failure reports are not received",
+		"tls_rpt_no_rua":                  "TLS-RPT record has no valid report address (rua)",
+		"bimi_missing":                    "No BIMI record (optional; shows the brand logo in supporting mail clients)",
+		"bimi_requires_dmarc":             "BIMI requires a DMARC policy of quarantine or reject with pct=100; the logo will not be shown",
+		"bimi_no_logo":                    "BIMI record has no valid HTTPS SVG logo location (l=)",
+		"bimi_no_vmc":                     "BIMI has no mark certificate (a=); Gmail and others will not show the logo",
+	},
+}
+
+// emailAudit 一次邮件安全检测的上下文
+type emailAudit struct {
+	ctx    context.Context
+	lang   string
+	result *models.EmailSecurityResult
+}
+
+// addFinding 记录一个发现（按任务语言生成说明）
+func (a *emailAudit) addFinding(check, id, severity string, args ...interface{}) {
+	a.result.Findings = append(a.result.Findings, models.EmailSecurityFinding{
+		Check:    check,
+		ID:       id,
+		Severity: severity,
+		Message:  findingMessage(emailSecurityMessages, a.lang, id, args...),
+	})
+}
+
+// findingMessage 按语言取发现的说明文案并填充参数（不支持的语言使用中文）
+func findingMessage(messages map[string]map[string]string, lang, id string, args ...interface{}) string {
+	table, ok := messages[lang]
+	if !ok {
+		table = messages["zh"]
+	}
+	message := table[id]
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// CollectEmailSecurity 检测目标域名的邮件安全配置（SPF、DMARC、DKIM、MTA-STS、TLS-RPT、BIMI）
+func CollectEmailSecurity(ctx context.Context, targetURL string, lang string) (*models.EmailSecurityResult, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+	domain := strings.TrimPrefix(strings.ToLower(parsedURL.Hostname()), "www.")
+	if domain == "" {
+		return nil, fmt.Errorf("empty hostname")
+	}
+	if net.ParseIP(domain) != nil {
+		return nil, fmt.Errorf("email security checks require a domain name, got IP address %s", domain)
+	}
+
+	log.Printf("[EmailSecurity] Checking email security for: %s", domain)
+
+	audit := &emailAudit{
+		ctx:  ctx,
+		lang: lang,
+		result: &models.EmailSecurityResult{
+			Domain:   domain,
+			Findings: []models.EmailSecurityFinding{},
+		},
+	}
+
+	mxErr := audit.checkMX(domain)
+	spfErr := audit.checkSPF(domain)
+	if mxErr != nil && spfErr != nil {
+		// 基础记录都查不到（不是"不存在"），多半是 DNS 不可用，结果没有意义
+		return nil, fmt.Errorf("DNS lookup failed: %w", spfErr)
+	}
+	audit.checkDMARC(domain)
+	audit.checkDKIM(domain)
+	audit.checkMTASTS(domain)
+	audit.checkTLSRPT(domain)
+	audit.checkBIMI(domain)
+
+	result := audit.result
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return severityRank[result.Findings[i].Severity] < severityRank[result.Findings[j].Severity]
+	})
+	result.Score = 100
+	for _, f := range result.Findings {
+		result.Score -= emailSeverityPenalty[f.Severity]
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	result.Grade = scoreGrade(result.Score)
+
+	log.Printf("[EmailSecurity] Finished %s: score %d (%s), %d findings", domain, result.Score, result.Grade, len(result.Findings))
+	return result, nil
+}
+
+// scoreGrade 将 0-100 评分换算为 A-F 等级
+func scoreGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// isDNSNotFound 判断 DNS 错误是否为"记录不存在"（NXDOMAIN 或没有该类型的记录）
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// lookupTXT 查询 TXT 记录，记录不存在时返回空结果而不是错误
+func (a *emailAudit) lookupTXT(name string) ([]string, error) {
+	records, err := emailResolver.LookupTXT(a.ctx, name)
+	if err != nil {
+		if isDNSNotFound(err) {
+			return nil, nil
+		}
+		log.Printf("[EmailSecurity] Warning: Failed to lookup TXT %s: %v", name, err)
+		return nil, err
+	}
+	return records, nil
+}
+
+// filterTagRecords 筛选以指定版本标签开头的记录（如 v=spf1、v=DMARC1），不区分大小写
+func filterTagRecords(records []string, version string) []string {
+	var matched []string
+	for _, record := range records {
+		record = strings.TrimSpace(record)
+		lower := strings.ToLower(record)
+		prefix := strings.ToLower(version)
+		if !strings.HasPrefix(lower, prefix) {
+			continue
+		}
+		rest := lower[len(prefix):]
+		if rest == "" || rest[0] == ' ' || rest[0] == ';' || rest[0] == '\t' {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// parseTagList 解析 "k=v; k2=v2" 格式的标签列表（DMARC、DKIM、MTA-STS 记录等），键统一为小写
+func parseTagList(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		if _, exists := tags[key]; !exists {
+			tags[key] = strings.TrimSpace(value)
+		}
+	}
+	return tags
+}
+
+// checkMX 查询 MX 记录，识别空 MX
+func (a *emailAudit) checkMX(domain string) error {
+	records, err := emailResolver.LookupMX(a.ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		log.Printf("[EmailSecurity] Warning: Failed to lookup MX records: %v", err)
+		return err
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+	for _, mx := range records {
+		host := strings.ToLower(strings.TrimSuffix(mx.Host, "."))
+		if host == "" {
+			continue
+		}
+		a.result.MX = append(a.result.MX, host)
+	}
+
+	switch {
+	case len(records) == 1 && len(a.result.MX) == 0:
+		a.result.NullMX = true
+		a.addFinding(models.EmailCheckMX, "null_mx", models.SeverityInfo)
+	case len(a.result.MX) == 0:
+		a.addFinding(models.EmailCheckMX, "no_mx", models.SeverityInfo)
+	}
+	return nil
+}
+
+// receivesMail 域名是否接收邮件（有非空 MX）
+func (a *emailAudit) receivesMail() bool {
+	return len(a.result.MX) > 0
+}
+
+// checkSPF 解析并评估 SPF 记录（含嵌套 include 和 redirect 的查询计数）
+func (a *emailAudit) checkSPF(domain string) error {
+	txt, err := a.lookupTXT(domain)
+	if err != nil {
+		return err
+	}
+	records := filterTagRecords(txt, "v=spf1")
+	if len(records) == 0 {
+		a.addFinding(models.EmailCheckSPF, "spf_missing", models.SeverityHigh)
+		return nil
+	}
+	if len(records) > 1 {
+		a.addFinding(models.EmailCheckSPF, "spf_multiple", models.SeverityHigh, len(records))
+	}
+
+	spf := &models.SPFRecord{Record: records[0]}
+	a.result.SPF = spf
+
+	eval := &spfEvaluator{audit: a, visited: map[string]bool{domain: true}}
+	top := eval.evaluate(domain, records[0])
+	spf.All = top.all
+	spf.Redirect = top.redirect
+	spf.Includes = top.includes
+	spf.DNSLookups = eval.lookups
+	spf.VoidLookups = eval.voids
+	spf.Errors = eval.errors
+	spf.Valid = len(records) == 1 && !eval.permerror &&
+		eval.lookups <= maxSPFLookups && eval.voids <= maxSPFVoidLookups
+
+	if eval.lookups > maxSPFLookups {
+		a.addFinding(models.EmailCheckSPF, "spf_too_many_lookups", models.SeverityHigh, eval.lookups)
+	}
+	if eval.voids > maxSPFVoidLookups {
+		a.addFinding(models.EmailCheckSPF, "spf_void_lookups", models.SeverityMedium, eval.voids)
+	}
+	for _, includeErr := range eval.includeErrors {
+		a.addFinding(models.EmailCheckSPF, "spf_include_error", models.SeverityMedium, includeErr[0], includeErr[1])
+	}
+	for _, syntaxErr := range eval.syntaxErrors {
+		a.addFinding(models.EmailCheckSPF, "spf_syntax", models.SeverityMedium, syntaxErr)
+	}
+	if eval.usesPTR {
+		a.addFinding(models.EmailCheckSPF, "spf_ptr", models.SeverityLow)
+	}
+
+	// 最终生效的 all：顶层没有 all 时使用 redirect 目标的 all
+	all := top.all
+	if all == "" && top.redirect != "" {
+		all = eval.redirectAll
+	}
+	switch all {
+	case "+all":
+		a.addFinding(models.EmailCheckSPF, "spf_plus_all", models.SeverityCritical)
+	case "?all":
+		a.addFinding(models.EmailCheckSPF, "spf_neutral_all", models.SeverityMedium)
+	case "~all":
+		a.addFinding(models.EmailCheckSPF, "spf_softfail_all", models.SeverityLow)
+	case "-all":
+	default:
+		if top.redirect == "" {
+			a.addFinding(models.EmailCheckSPF, "spf_no_all", models.SeverityMedium)
+		}
+	}
+	return nil
+}
+
+// spfEvaluator 递归评估 SPF 记录，统计 DNS 查询次数
+type spfEvaluator struct {
+	audit         *emailAudit
+	visited       map[string]bool
+	lookups       int
+	voids         int
+	permerror     bool
+	usesPTR       bool
+	redirectAll   string
+	errors        []string
+	includeErrors [][2]string
+	syntaxErrors  []string
+}
+
+// spfTerms 一条 SPF 记录的顶层信息
+type spfTerms struct {
+	all      string
+	redirect string
+	includes []string
+}
+
+// fail 记录导致 permerror 的错误
+func (e *spfEvaluator) fail(format string, args ...interface{}) {
+	e.permerror = true
+	e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
+
+// fetch 查询被引用域名的 SPF 记录，不存在或无效时记录错误并返回空字符串
+func (e *spfEvaluator) fetch(mechanism, target string) string {
+	txt, err := e.audit.lookupTXT(target)
+	if err != nil {
+		e.fail("%s:%s lookup failed: %v", mechanism, target, err)
+		e.includeErrors = append(e.includeErrors, [2]string{mechanism + ":" + target, err.Error()})
+		return ""
+	}
+	if len(txt) == 0 {
+		e.voids++
+	}
+	records := filterTagRecords(txt, "v=spf1")
+	if len(records) != 1 {
+		reason := "no SPF record"
+		if len(records) > 1 {
+			reason = "multiple SPF records"
+		}
+		e.fail("%s:%s has %s", mechanism, target, reason)
+		e.includeErrors = append(e.includeErrors, [2]string{mechanism + ":" + target, reason})
+		return ""
+	}
+	return records[0]
+}
+
+// evaluate 评估一条 SPF 记录
+func (e *spfEvaluator) evaluate(domain, record string) spfTerms {
+	var terms spfTerms
+	fields := strings.Fields(record)
+	for _, term := range fields[1:] {
+		lower := strings.ToLower(term)
+
+		// 修饰符：name=value
+		if name, value, ok := strings.Cut(lower, "="); ok && isSPFModifierName(name) {
+			// exp= 只影响拒绝说明，不需要处理
+			if name == "redirect" {
+				terms.redirect = value
+			}
+			continue
+		}
+
+		qualifier := "+"
+		if strings.ContainsAny(lower[:1], "+-~?") {
+			qualifier = lower[:1]
+			lower = lower[1:]
+		}
+		name, arg, _ := strings.Cut(lower, ":")
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+		}
+
+		switch name {
+		case "all":
+			terms.all = qualifier + "all"
+		case "include":
+			if arg == "" {
+				e.syntaxErrors = append(e.syntaxErrors, term)
+				e.fail("include without domain")
+				continue
+			}
+			terms.includes = append(terms.includes, arg)
+			e.lookups++
+			e.follow("include", arg)
+		case "a", "mx":
+			e.lookups++
+			target := domain
+			if arg != "" {
+				target, _, _ = strings.Cut(arg, "/")
+			}
+			if !strings.Contains(target, "%") {
+				e.countVoid(name, target)
+			}
+		case "ptr":
+			e.lookups++
+			e.usesPTR = true
+		case "exists":
+			e.lookups++
+		case "ip4", "ip6":
+			if !validSPFNetwork(name, arg) {
+				e.syntaxErrors = append(e.syntaxErrors, term)
+				e.fail("invalid %s address %q", name, arg)
+			}
+		default:
+			e.syntaxErrors = append(e.syntaxErrors, term)
+			e.fail("unknown mechanism %q", term)
+		}
+	}
+
+	// all 存在时忽略 redirect（RFC 7208 6.1）
+	if terms.redirect != "" && terms.all == "" {
+		e.lookups++
+		if redirected, ok := e.follow("redirect", terms.redirect); ok && e.redirectAll == "" {
+			e.redirectAll = redirected.all
+		}
+	}
+	return terms
+}
+
+// follow 递归评估 include 或 redirect 引用的记录
+func (e *spfEvaluator) follow(mechanism, target string) (spfTerms, bool) {
+	// 含宏的域名需要发送方信息才能展开，已超限时不再继续查询
+	if strings.Contains(target, "%") || e.lookups > maxSPFLookups {
+		return spfTerms{}, false
+	}
+	if e.visited[target] {
+		e.fail("%s:%s creates a loop", mechanism, target)
+		return spfTerms{}, false
+	}
+	e.visited[target] = true
+	defer delete(e.visited, target)
+
+	record := e.fetch(mechanism, target)
+	if record == "" {
+		return spfTerms{}, false
+	}
+	return e.evaluate(target, record), true
+}
+
+// countVoid 统计 a/mx 机制没有结果的查询
+func (e *spfEvaluator) countVoid(mechanism, target string) {
+	var err error
+	var found bool
+	if mechanism == "mx" {
+		var records []*net.MX
+		records, err = emailResolver.LookupMX(e.audit.ctx, target)
+		found = len(records) > 0
+	} else {
+		var hosts []string
+		hosts, err = emailResolver.LookupHost(e.audit.ctx, target)
+		found = len(hosts) > 0
+	}
+	if (err == nil && !found) || isDNSNotFound(err) {
+		e.voids++
+	}
+}
+
+// isSPFModifierName 判断是否为修饰符名称（字母开头，由字母、数字、-、_、. 组成）
+func isSPFModifierName(name string) bool {
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z') {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// validSPFNetwork 校验 ip4/ip6 机制的地址或网段
+func validSPFNetwork(mechanism, value string) bool {
+	if value == "" {
+		return false
+	}
+	var ip net.IP
+	if strings.Contains(value, "/") {
+		parsed, _, err := net.ParseCIDR(value)
+		if err != nil {
+			return false
+		}
+		ip = parsed
+	} else {
+		ip = net.ParseIP(value)
+	}
+	if ip == nil {
+		return false
+	}
+	if mechanism == "ip4" {
+		return ip.To4() != nil
+	}
+	return ip.To4() == nil
+}
+
+// organizationalDomain 按公共后缀列表计算组织域名（可注册域名，如 a.b.example.co.uk -> example.co.uk）
+// IP 地址、公共后缀本身等无法计算时返回原值
+func organizationalDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if net.ParseIP(domain) != nil {
+		return domain
+	}
+	org, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return domain
+	}
+	return org
+}
+
+// checkDMARC 解析 DMARC 记录（子域名没有记录时回退到组织域名）
+func (a *emailAudit) checkDMARC(domain string) {
+	recordDomain := domain
+	txt, err := a.lookupTXT("_dmarc." + domain)
+	if err != nil {
+		return
+	}
+	records := filterTagRecords(txt, "v=DMARC1")
+	if len(records) == 0 {
+		if org := organizationalDomain(domain); org != domain {
+			txt, err = a.lookupTXT("_dmarc." + org)
+			if err != nil {
+				return
+			}
+			records = filterTagRecords(txt, "v=DMARC1")
+			recordDomain = org
+		}
+	}
+	if len(records) == 0 {
+		a.addFinding(models.EmailCheckDMARC, "dmarc_missing", models.SeverityHigh)
+		return
+	}
+	if len(records) > 1 {
+		a.addFinding(models.EmailCheckDMARC, "dmarc_multiple", models.SeverityHigh, len(records))
+		return
+	}
+	if recordDomain != domain {
+		a.addFinding(models.EmailCheckDMARC, "dmarc_inherited", models.SeverityInfo, recordDomain)
+	}
+
+	tags := parseTagList(records[0])
+	dmarc := &models.DMARCRecord{
+		Record:          records[0],
+		Domain:          recordDomain,
+		Policy:          strings.ToLower(tags["p"]),
+		SubdomainPolicy: strings.ToLower(tags["sp"]),
+		Percent:         100,
+		RUA:             splitReportURIs(tags["rua"]),
+		RUF:             splitReportURIs(tags["ruf"]),
+		ADKIM:           strings.ToLower(tags["adkim"]),
+		ASPF:            strings.ToLower(tags["aspf"]),
+	}
+	a.result.DMARC = dmarc
+
+	switch dmarc.Policy {
+	case "reject":
+	case "quarantine":
+		a.addFinding(models.EmailCheckDMARC, "dmarc_policy_quarantine", models.SeverityLow)
+	case "none":
+		a.addFinding(models.EmailCheckDMARC, "dmarc_policy_none", models.SeverityMedium)
+	case "":
+		a.addFinding(models.EmailCheckDMARC, "dmarc_invalid", models.SeverityHigh, "missing p= tag")
+	default:
+		a.addFinding(models.EmailCheckDMARC, "dmarc_invalid", models.SeverityHigh, fmt.Sprintf("unknown policy p=%s", dmarc.Policy))
+	}
+	// 子域名策略只对组织域名的记录有意义
+	if dmarc.SubdomainPolicy == "none" && dmarc.Policy != "none" {
+		a.addFinding(models.EmailCheckDMARC, "dmarc_subdomain_none", models.SeverityLow)
+	}
+	if pctValue, ok := tags["pct"]; ok {
+		pct, err := strconv.Atoi(pctValue)
+		if err != nil || pct < 0 || pct > 100 {
+			a.addFinding(models.EmailCheckDMARC, "dmarc_invalid", models.SeverityMedium, fmt.Sprintf("invalid pct=%s", pctValue))
+		} else {
+			dmarc.Percent = pct
+			if pct < 100 && dmarc.Policy != "none" {
+				a.addFinding(models.EmailCheckDMARC, "dmarc_pct", models.SeverityLow, pct)
+			}
+		}
+	}
+
+	if len(dmarc.RUA) == 0 {
+		a.addFinding(models.EmailCheckDMARC, "dmarc_no_rua", models.SeverityLow)
+	}
+	a.checkDMARCReportAuthorization(recordDomain, append(append([]string{}, dmarc.RUA...), dmarc.RUF...))
+}
+
+// splitReportURIs 拆分 rua/ruf 地址列表
+func splitReportURIs(value string) []string {
+	var uris []string
+	for _, uri := range strings.Split(value, ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// checkDMARCReportAuthorization 外部报告地址需要对方发布 <domain>._report._dmarc.<外部域名> 授权（RFC 7489 7.1）
+func (a *emailAudit) checkDMARCReportAuthorization(recordDomain string, uris []string) {
+	checked := make(map[string]bool)
+	for _, uri := range uris {
+		if !strings.HasPrefix(strings.ToLower(uri), "mailto:") {
+			continue
+		}
+		address, _, _ := strings.Cut(uri[len("mailto:"):], "!")
+		at := strings.LastIndex(address, "@")
+		if at < 0 {
+			continue
+		}
+		reportDomain := strings.ToLower(address[at+1:])
+		if reportDomain == "" || checked[reportDomain] ||
+			organizationalDomain(reportDomain) == organizationalDomain(recordDomain) {
+			continue
+		}
+		checked[reportDomain] = true
+
+		authName := recordDomain + "._report._dmarc." + reportDomain
+		txt, err := a.lookupTXT(authName)
+		if err != nil {
+			continue
+		}
+		if len(filterTagRecords(txt, "v=DMARC1")) == 0 {
+			a.addFinding(models.EmailCheckDMARC, "dmarc_external_rua_unauthorized", models.SeverityLow, address, authName)
+		}
+	}
+}
+
+// checkDKIM 并发查询常见选择器的 DKIM 公钥
+func (a *emailAudit) checkDKIM(domain string) {
+	type selectorResult struct {
+		record *models.DKIMRecord
+		err    string
+	}
+	results := make([]selectorResult, len(dkimSelectors))
+
+	var wg sync.WaitGroup
+	for i, selector := range dkimSelectors {
+		wg.Add(1)
+		go func(i int, selector string) {
+			defer wg.Done()
+			txt, err := a.lookupTXT(selector + "._domainkey." + domain)
+			if err != nil || len(txt) == 0 {
+				return
+			}
+			// DKIM 记录可能被拆分为多个字符串，LookupTXT 已合并同一条记录
+			for _, record := range txt {
+				if parsed, parseErr := parseDKIMRecord(selector, record); parsed != nil {
+					results[i] = selectorResult{record: parsed}
+					return
+				} else if parseErr != nil {
+					results[i] = selectorResult{record: &models.DKIMRecord{Selector: selector}, err: parseErr.Error()}
+				}
+			}
+		}(i, selector)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r.record == nil {
+			continue
+		}
+		if r.err != "" {
+			a.addFinding(models.EmailCheckDKIM, "dkim_invalid", models.SeverityMedium, dkimSelectors[i], r.err)
+			continue
+		}
+		record := r.record
+		a.result.DKIM = append(a.result.DKIM, *record)
+		switch {
+		case record.Revoked:
+			a.addFinding(models.EmailCheckDKIM, "dkim_revoked", models.SeverityInfo, record.Selector)
+		case record.KeyType == "rsa" && record.KeyBits > 0 && record.KeyBits < 1024:
+			a.addFinding(models.EmailCheckDKIM, "dkim_weak_key", models.SeverityHigh, record.Selector, record.KeyBits)
+		case record.KeyType == "rsa" && record.KeyBits == 1024:
+			a.addFinding(models.EmailCheckDKIM, "dkim_1024", models.SeverityLow, record.Selector)
+		}
+		if record.Testing {
+			a.addFinding(models.EmailCheckDKIM, "dkim_testing", models.SeverityLow, record.Selector)
+		}
+	}
+
+	// 只检测发信域名（有 SPF 或 MX）；没有找到不代表没有配置，所以严重程度较低
+	if len(a.result.DKIM) == 0 && (a.result.SPF != nil || a.receivesMail()) {
+		a.addFinding(models.EmailCheckDKIM, "dkim_not_found", models.SeverityLow)
+	}
+}
+
+// parseDKIMRecord 解析 DKIM 公钥记录；不是 DKIM 记录时返回 nil, nil
+func parseDKIMRecord(selector, record string) (*models.DKIMRecord, error) {
+	tags := parseTagList(record)
+	publicKey, hasKey := tags["p"]
+	if !hasKey {
+		return nil, nil
+	}
+	if v, ok := tags["v"]; ok && !strings.EqualFold(v, "DKIM1") {
+		return nil, nil
+	}
+
+	dkim := &models.DKIMRecord{
+		Selector: selector,
+		KeyType:  strings.ToLower(tags["k"]),
+	}
+	if dkim.KeyType == "" {
+		dkim.KeyType = "rsa"
+	}
+	for _, flag := range strings.Split(tags["t"], ":") {
+		if strings.TrimSpace(strings.ToLower(flag)) == "y" {
+			dkim.Testing = true
+		}
+	}
+
+	publicKey = strings.Join(strings.Fields(publicKey), "")
+	if publicKey == "" {
+		dkim.Revoked = true
+		return dkim, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	switch dkim.KeyType {
+	case "rsa":
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			// 少数记录直接发布 PKCS#1 格式的 RSA 公钥
+			rsaKey, pkcs1Err := x509.ParsePKCS1PublicKey(der)
+			if pkcs1Err != nil {
+				return nil, fmt.Errorf("invalid RSA public key: %w", err)
+			}
+			key = rsaKey
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("k=rsa but key is %T", key)
+		}
+		dkim.KeyBits = rsaKey.N.BitLen()
+	case "ed25519":
+		if len(der) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key length %d", len(der))
+		}
+	}
+	return dkim, nil
+}
+
+// checkMTASTS 检查 MTA-STS 记录并读取策略文件（只对接收邮件的域名检测）
+func (a *emailAudit) checkMTASTS(domain string) {
+	if !a.receivesMail() {
+		return
+	}
+	txt, err := a.lookupTXT("_mta-sts." + domain)
+	if err != nil {
+		return
+	}
+	records := filterTagRecords(txt, "v=STSv1")
+	if len(records) == 0 {
+		a.addFinding(models.EmailCheckMTASTS, "mta_sts_missing", models.SeverityLow)
+		return
+	}
+
+	mtaSTS := &models.MTASTSRecord{
+		Record:    records[0],
+		PolicyURL: "https://mta-sts." + domain + "/.well-known/mta-sts.txt",
+	}
+	a.result.MTASTS = mtaSTS
+
+	if len(records) > 1 {
+		mtaSTS.Error = fmt.Sprintf("%d STSv1 records", len(records))
+	} else if err := a.fetchMTASTSPolicy(mtaSTS); err != nil {
+		mtaSTS.Error = err.Error()
+	}
+	if mtaSTS.Error != "" {
+		a.addFinding(models.EmailCheckMTASTS, "mta_sts_policy_error", models.SeverityMedium, mtaSTS.Error)
+		return
+	}
+
+	switch mtaSTS.Mode {
+	case "testing":
+		a.addFinding(models.EmailCheckMTASTS, "mta_sts_testing", models.SeverityLow)
+	case "none":
+		a.addFinding(models.EmailCheckMTASTS, "mta_sts_none", models.SeverityLow)
+		return
+	}
+	for _, host := range a.result.MX {
+		if !mtaSTSMatchesMX(mtaSTS.MX, host) {
+			severity := models.SeverityMedium
+			if mtaSTS.Mode == "enforce" {
+				severity = models.SeverityHigh
+			}
+			a.addFinding(models.EmailCheckMTASTS, "mta_sts_mx_mismatch", severity, host)
+		}
+	}
+}
+
+// fetchMTASTSPolicy 下载并解析 MTA-STS 策略文件（RFC 8461 3.3：必须 HTTPS 且不能跟随重定向）
+func (a *emailAudit) fetchMTASTSPolicy(mtaSTS *models.MTASTSRecord) error {
+	client := utils.NewSafeHTTPClient(mtaSTSFetchTimeout)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, mtaSTS.PolicyURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; WebCheckly/1.0)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch policy: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("policy returned HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMTASTSPolicySize))
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %w", err)
+	}
+	var version string
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "version":
+			version = value
+		case "mode":
+			mtaSTS.Mode = strings.ToLower(value)
+		case "mx":
+			mtaSTS.MX = append(mtaSTS.MX, strings.ToLower(value))
+		case "max_age":
+			mtaSTS.MaxAge, _ = strconv.Atoi(value)
+		}
+	}
+
+	if version != "STSv1" {
+		return fmt.Errorf("policy version is %q, expected STSv1", version)
+	}
+	switch mtaSTS.Mode {
+	case "enforce", "testing", "none":
+	default:
+		return fmt.Errorf("invalid policy mode %q", mtaSTS.Mode)
+	}
+	if mtaSTS.Mode != "none" && len(mtaSTS.MX) == 0 {
+		return errors.New("policy has no mx entries")
+	}
+	if mtaSTS.MaxAge <= 0 {
+		return errors.New("policy has no valid max_age")
+	}
+	return nil
+}
+
+// mtaSTSMatchesMX 判断 MX 主机是否匹配策略中的 mx 模式（*. 通配符只匹配一级标签）
+func mtaSTSMatchesMX(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, ".")
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") {
+			suffix := pattern[1:]
+			if strings.HasSuffix(host, suffix) && !strings.Contains(strings.TrimSuffix(host, suffix), ".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkTLSRPT 检查 SMTP TLS 报告记录（只对接收邮件的域名检测）
+func (a *emailAudit) checkTLSRPT(domain string) {
+	if !a.receivesMail() {
+		return
+	}
+	txt, err := a.lookupTXT("_smtp._tls." + domain)
+	if err != nil {
+		return
+	}
+	records := filterTagRecords(txt, "v=TLSRPTv1")
+	if len(records) == 0 {
+		severity := models.SeverityInfo
+		if a.result.MTASTS != nil {
+			// 部署了 MTA-STS 却收不到失败报告，策略出问题时无法及时发现
+			severity = models.SeverityLow
+		}
+		a.addFinding(models.EmailCheckTLSRPT, "tls_rpt_missing", severity)
+		return
+	}
+
+	tlsRPT := &models.TLSRPTRecord{Record: records[0]}
+	for _, uri := range splitReportURIs(parseTagList(records[0])["rua"]) {
+		lower := strings.ToLower(uri)
+		if strings.HasPrefix(lower, "mailto:") || strings.HasPrefix(lower, "https:") {
+			tlsRPT.RUA = append(tlsRPT.RUA, uri)
+		}
+	}
+	a.result.TLSRPT = tlsRPT
+	if len(tlsRPT.RUA) == 0 {
+		a.addFinding(models.EmailCheckTLSRPT, "tls_rpt_no_rua", models.SeverityLow)
+	}
+}
+
+// checkBIMI 检查 BIMI 记录（BIMI 是可选项，缺失只作为提示）
+func (a *emailAudit) checkBIMI(domain string) {
+	txt, err := a.lookupTXT("default._bimi." + domain)
+	if err != nil {
+		return
+	}
+	records := filterTagRecords(txt, "v=BIMI1")
+	if len(records) == 0 {
+		if a.result.SPF != nil || a.receivesMail() {
+			a.addFinding(models.EmailCheckBIMI, "bimi_missing", models.SeverityInfo)
+		}
+		return
+	}
+
+	tags := parseTagList(records[0])
+	bimi := &models.BIMIRecord{
+		Record:   records[0],
+		Logo:     tags["l"],
+		Evidence: tags["a"],
+	}
+	a.result.BIMI = bimi
+
+	if dmarc := a.result.DMARC; dmarc == nil || dmarc.Percent < 100 ||
+		(dmarc.Policy != "quarantine" && dmarc.Policy != "reject") {
+		a.addFinding(models.EmailCheckBIMI, "bimi_requires_dmarc", models.SeverityLow)
+	}
+	if logo := strings.ToLower(bimi.Logo); !strings.HasPrefix(logo, "https://") || !strings.HasSuffix(logo, ".svg") {
+		a.addFinding(models.EmailCheckBIMI, "bimi_no_logo", models.SeverityLow)
+	}
+	if bimi.Evidence == "" {
+		a.addFinding(models.EmailCheckBIMI, "bimi_no_vmc", models.SeverityInfo)
+	}
+}
@@ -0,0 +1,767 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-checkly/fingerprints"
+	"web-checkly/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// techSignaturesEnv 外部签名库路径（Wappalyzer 格式的 JSON 文件或目录）
+	techSignaturesEnv = "TECH_SIGNATURES_PATH"
+	// techPageMaxBytes 参与指纹识别的页面最大字节数
+	techPageMaxBytes = 2 << 20
+	// techMinListedConfidence 置信度低于该值的技术只出现在 detections 中，不列入 TechStack 分类
+	techMinListedConfidence = 50
+)
+
+// TechStack 分类字段，签名的分类按 Wappalyzer 分类 ID 映射到这些字段
+const (
+	techFieldCMS        = "cms"
+	techFieldFramework  = "framework"
+	techFieldLanguage   = "language"
+	techFieldJavaScript = "javascript_lib"
+	techFieldAnalytics  = "analytics"
+	techFieldCDN        = "cdn"
+	techFieldCache      = "cache"
+	techFieldDatabase   = "database"
+	techFieldServer     = "server"
+	techFieldOS         = "os"
+)
+
+// techCategoryFields Wappalyzer 分类 ID -> TechStack 字段（未列出的分类只出现在 technologies 中）
+var techCategoryFields = map[int]string{
+	1:   techFieldCMS,        // CMS
+	2:   techFieldCMS,        // Message boards
+	6:   techFieldCMS,        // Ecommerce
+	8:   techFieldCMS,        // Wikis
+	11:  techFieldCMS,        // Blogs
+	51:  techFieldCMS,        // Page builders
+	12:  techFieldFramework,  // JavaScript frameworks
+	18:  techFieldFramework,  // Web frameworks
+	26:  techFieldFramework,  // Mobile frameworks
+	57:  techFieldFramework,  // Static site generator
+	66:  techFieldFramework,  // UI frameworks
+	108: techFieldFramework,  // Ecommerce frontends
+	27:  techFieldLanguage,   // Programming languages
+	25:  techFieldJavaScript, // JavaScript graphics
+	59:  techFieldJavaScript, // JavaScript libraries
+	10:  techFieldAnalytics,  // Analytics
+	42:  techFieldAnalytics,  // Tag managers
+	74:  techFieldAnalytics,  // A/B Testing
+	31:  techFieldCDN,        // CDN
+	23:  techFieldCache,      // Caching
+	34:  techFieldDatabase,   // Databases
+	22:  techFieldServer,     // Web servers
+	28:  techFieldOS,         // Operating systems
+}
+
+var (
+	// techVersionTernary 版本模板中的三元表达式 \1?a:b（第 1 组非空取 a，否则取 b）
+	techVersionTernary = regexp.MustCompile(`\\(\d+)\?([^:]*):(.*)`)
+	// techVersionRef 版本模板中的分组引用 \1
+	techVersionRef = regexp.MustCompile(`\\(\d+)`)
+	// techNameKey 技术名称比较时忽略的字符
+	techNameKey = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// techSignatureFile Wappalyzer 格式的签名文件（旧版 apps.json 使用 apps 代替 technologies）
+type techSignatureFile struct {
+	Categories   map[string]techCategoryJSON  `json:"categories"`
+	Technologies map[string]techSignatureJSON `json:"technologies"`
+	Apps         map[string]techSignatureJSON `json:"apps"`
+}
+
+type techCategoryJSON struct {
+	Name string `json:"name"`
+}
+
+// techSignatureJSON 单个技术的签名（只支持可以在服务端匹配的字段，js 等需要执行脚本的字段忽略）
+type techSignatureJSON struct {
+	Cats      []int                  `json:"cats"`
+	Website   string                 `json:"website"`
+	Headers   map[string]techStrings `json:"headers"`
+	Cookies   map[string]techStrings `json:"cookies"`
+	Meta      map[string]techStrings `json:"meta"`
+	ScriptSrc techStrings            `json:"scriptSrc"`
+	Script    techStrings            `json:"script"` // 旧版 apps.json 的 script 即 scriptSrc
+	Scripts   techStrings            `json:"scripts"`
+	HTML      techStrings            `json:"html"`
+	URL       techStrings            `json:"url"`
+	DOM       json.RawMessage        `json:"dom"`
+	Implies   techStrings            `json:"implies"`
+	Excludes  techStrings            `json:"excludes"`
+	Requires  techStrings            `json:"requires"`
+}
+
+// techStrings 字符串或字符串数组
+type techStrings []string
+
+func (s *techStrings) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = techStrings{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// techPattern 签名模式：正则（不区分大小写）\;version:模板\;confidence:置信度
+type techPattern struct {
+	regex      *regexp.Regexp // nil 表示只要求存在（如响应头、Cookie）
+	version    string
+	confidence int
+}
+
+// techDOMRule dom 签名：CSS 选择器存在，或其文本/属性匹配
+type techDOMRule struct {
+	selector   string
+	text       []*techPattern
+	attributes map[string][]*techPattern
+}
+
+// techImplied 隐含的技术（如 WordPress 隐含 PHP）
+type techImplied struct {
+	name       string
+	confidence int
+}
+
+// techSignature 编译后的技术签名
+type techSignature struct {
+	name       string
+	website    string
+	categories []int
+	headers    map[string][]*techPattern
+	cookies    map[string][]*techPattern
+	meta       map[string][]*techPattern
+	scriptSrc  []*techPattern
+	scripts    []*techPattern
+	html       []*techPattern
+	url        []*techPattern
+	dom        []techDOMRule
+	implies    []techImplied
+	excludes   []string
+	requires   []string
+}
+
+// techSignatureDB 编译后的签名库
+type techSignatureDB struct {
+	categories   map[int]string
+	technologies []*techSignature
+	byName       map[string]*techSignature // 小写名称
+	byKey        map[string]*techSignature // 只保留字母数字的小写名称
+	skipped      int                       // 无法编译的模式数（Go 正则不支持的 JS 语法，如零宽断言）
+}
+
+// techPage 参与指纹识别的页面
+type techPage struct {
+	URL     string
+	Headers http.Header
+	Cookies []*http.Cookie
+	HTML    string
+	Doc     *goquery.Document
+}
+
+var (
+	builtinTechOnce sync.Once
+	builtinTechDB   *techSignatureDB
+
+	techSignatureCache struct {
+		sync.Mutex
+		db      *techSignatureDB
+		path    string
+		modTime time.Time
+	}
+)
+
+// builtinTechSignatures 内置签名库
+func builtinTechSignatures() *techSignatureDB {
+	builtinTechOnce.Do(func() {
+		var file techSignatureFile
+		if err := json.Unmarshal(fingerprints.Technologies, &file); err != nil {
+			log.Printf("[Fingerprint] Failed to parse built-in signatures: %v", err)
+		}
+		builtinTechDB = compileTechSignatures(file)
+	})
+	return builtinTechDB
+}
+
+// techSignatures 当前使用的签名库
+// 设置 TECH_SIGNATURES_PATH 时从磁盘加载，文件修改后下次识别自动重新加载；
+// 加载失败时继续使用上一次加载成功的签名库（从未成功时使用内置签名库）
+func techSignatures() *techSignatureDB {
+	path := strings.TrimSpace(os.Getenv(techSignaturesEnv))
+	if path == "" {
+		return builtinTechSignatures()
+	}
+
+	cache := &techSignatureCache
+	cache.Lock()
+	defer cache.Unlock()
+
+	modTime, err := techSignaturesModTime(path)
+	if err == nil && cache.db != nil && cache.path == path && modTime.Equal(cache.modTime) {
+		return cache.db
+	}
+
+	var db *techSignatureDB
+	if err == nil {
+		db, err = loadTechSignatures(path)
+	}
+	if err != nil {
+		log.Printf("[Fingerprint] Failed to load signatures from %s: %v", path, err)
+		if cache.db != nil {
+			return cache.db
+		}
+		return builtinTechSignatures()
+	}
+
+	cache.db, cache.path, cache.modTime = db, path, modTime
+	log.Printf("[Fingerprint] Loaded %d technologies from %s (%d unsupported patterns skipped)", len(db.technologies), path, db.skipped)
+	return db
+}
+
+// techSignaturesModTime 签名文件（或目录中所有 JSON 文件）的最后修改时间
+func techSignaturesModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !info.IsDir() {
+		return info.ModTime(), nil
+	}
+
+	latest := info.ModTime()
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".json" {
+			return err
+		}
+		fileInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if fileInfo.ModTime().After(latest) {
+			latest = fileInfo.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// loadTechSignatures 从磁盘加载签名库
+// path 可以是包含 categories 和 technologies 的单个 JSON 文件，
+// 也可以是 Wappalyzer 仓库结构的目录：categories.json + technologies/*.json（每个文件为 名称 -> 签名）
+func loadTechSignatures(path string) (*techSignatureDB, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file techSignatureFile
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else {
+		if data, err := os.ReadFile(filepath.Join(path, "categories.json")); err == nil {
+			if err := json.Unmarshal(data, &file.Categories); err != nil {
+				return nil, fmt.Errorf("failed to parse categories.json: %w", err)
+			}
+		}
+
+		dir := filepath.Join(path, "technologies")
+		if _, err := os.Stat(dir); err != nil {
+			dir = path
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		file.Technologies = make(map[string]techSignatureJSON)
+		for _, name := range files {
+			if filepath.Base(name) == "categories.json" {
+				continue
+			}
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			var technologies map[string]techSignatureJSON
+			if err := json.Unmarshal(data, &technologies); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(name), err)
+			}
+			for techName, tech := range technologies {
+				file.Technologies[techName] = tech
+			}
+		}
+	}
+
+	db := compileTechSignatures(file)
+	if len(db.technologies) == 0 {
+		return nil, fmt.Errorf("no technologies found")
+	}
+	return db, nil
+}
+
+// compileTechSignatures 编译签名（无法编译的模式跳过并计数）
+func compileTechSignatures(file techSignatureFile) *techSignatureDB {
+	db := &techSignatureDB{
+		categories: make(map[int]string),
+		byName:     make(map[string]*techSignature),
+		byKey:      make(map[string]*techSignature),
+	}
+	for id, category := range file.Categories {
+		if n, err := strconv.Atoi(id); err == nil {
+			db.categories[n] = category.Name
+		}
+	}
+
+	technologies := file.Technologies
+	if len(technologies) == 0 {
+		technologies = file.Apps
+	}
+	for name, raw := range technologies {
+		tech := &techSignature{
+			name:       name,
+			website:    raw.Website,
+			categories: raw.Cats,
+			headers:    db.compileMap(raw.Headers),
+			cookies:    db.compileMap(raw.Cookies),
+			meta:       db.compileMap(raw.Meta),
+			scriptSrc:  db.compileList(append(raw.ScriptSrc, raw.Script...)),
+			scripts:    db.compileList(raw.Scripts),
+			html:       db.compileList(raw.HTML),
+			url:        db.compileList(raw.URL),
+			dom:        db.compileDOM(raw.DOM),
+			excludes:   raw.Excludes,
+			requires:   raw.Requires,
+		}
+		for _, implied := range raw.Implies {
+			pattern := parseTechPatternOptions(implied)
+			tech.implies = append(tech.implies, techImplied{name: pattern.name, confidence: pattern.confidence})
+		}
+		db.technologies = append(db.technologies, tech)
+		db.byName[strings.ToLower(name)] = tech
+		db.byKey[techNameKey.ReplaceAllString(strings.ToLower(name), "")] = tech
+	}
+	sort.Slice(db.technologies, func(i, j int) bool { return db.technologies[i].name < db.technologies[j].name })
+	return db
+}
+
+// techPatternParts 拆分后的签名模式
+type techPatternParts struct {
+	name       string // 正则（或 implies 中的技术名称）
+	version    string
+	confidence int
+}
+
+// parseTechPatternOptions 拆分 "正则\;version:\1\;confidence:50"
+func parseTechPatternOptions(value string) techPatternParts {
+	parts := strings.Split(value, `\;`)
+	pattern := techPatternParts{name: parts[0], confidence: 100}
+	for _, part := range parts[1:] {
+		key, val, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "version":
+			pattern.version = val
+		case "confidence":
+			if n, err := strconv.Atoi(val); err == nil {
+				pattern.confidence = n
+			}
+		}
+	}
+	return pattern
+}
+
+// compilePattern 编译单个模式，Go 正则不支持的语法返回 nil
+func (db *techSignatureDB) compilePattern(value string) *techPattern {
+	parts := parseTechPatternOptions(value)
+	pattern := &techPattern{version: parts.version, confidence: parts.confidence}
+	if parts.name != "" {
+		re, err := regexp.Compile("(?i)" + parts.name)
+		if err != nil {
+			db.skipped++
+			return nil
+		}
+		pattern.regex = re
+	}
+	return pattern
+}
+
+func (db *techSignatureDB) compileList(values []string) []*techPattern {
+	var patterns []*techPattern
+	for _, value := range values {
+		if pattern := db.compilePattern(value); pattern != nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func (db *techSignatureDB) compileMap(values map[string]techStrings) map[string][]*techPattern {
+	if len(values) == 0 {
+		return nil
+	}
+	patterns := make(map[string][]*techPattern, len(values))
+	for key, list := range values {
+		if compiled := db.compileList(list); len(compiled) > 0 {
+			patterns[strings.ToLower(key)] = compiled
+		}
+	}
+	return patterns
+}
+
+// compileDOM 编译 dom 签名：选择器字符串、选择器数组，或 选择器 -> {exists, text, attributes}
+func (db *techSignatureDB) compileDOM(raw json.RawMessage) []techDOMRule {
+	if len(raw) == 0 {
+		return nil
+	}
+	var selectors techStrings
+	if err := json.Unmarshal(raw, &selectors); err == nil {
+		rules := make([]techDOMRule, 0, len(selectors))
+		for _, selector := range selectors {
+			rules = append(rules, techDOMRule{selector: selector})
+		}
+		return rules
+	}
+
+	var objects map[string]struct {
+		Text       techStrings            `json:"text"`
+		Attributes map[string]techStrings `json:"attributes"`
+	}
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		db.skipped++
+		return nil
+	}
+	var rules []techDOMRule
+	for selector, object := range objects {
+		rules = append(rules, techDOMRule{
+			selector:   selector,
+			text:       db.compileList(object.Text),
+			attributes: db.compileMap(object.Attributes),
+		})
+	}
+	return rules
+}
+
+// lookup 按名称查找技术（不区分大小写，忽略空格和标点）
+func (db *techSignatureDB) lookup(name string) *techSignature {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if tech, ok := db.byName[name]; ok {
+		return tech
+	}
+	return db.byKey[techNameKey.ReplaceAllString(name, "")]
+}
+
+// match 用模式匹配 value，返回是否匹配和提取的版本
+func (p *techPattern) match(value string) (bool, string) {
+	if p.regex == nil {
+		return true, ""
+	}
+	groups := p.regex.FindStringSubmatch(value)
+	if groups == nil {
+		return false, ""
+	}
+	return true, resolveTechVersion(p.version, groups)
+}
+
+// resolveTechVersion 按版本模板（\1、\1?a:b）从分组中提取版本
+func resolveTechVersion(template string, groups []string) string {
+	if template == "" {
+		return ""
+	}
+	group := func(ref string) string {
+		i, err := strconv.Atoi(ref)
+		if err != nil || i >= len(groups) {
+			return ""
+		}
+		return groups[i]
+	}
+	version := techVersionTernary.ReplaceAllStringFunc(template, func(expr string) string {
+		m := techVersionTernary.FindStringSubmatch(expr)
+		if group(m[1]) != "" {
+			return m[2]
+		}
+		return m[3]
+	})
+	version = techVersionRef.ReplaceAllStringFunc(version, func(ref string) string {
+		return group(ref[1:])
+	})
+	version = strings.TrimSpace(version)
+	if len(version) > 32 {
+		return "" // 过长的通常是误匹配
+	}
+	return version
+}
+
+// techMatch 单个技术的匹配结果
+type techMatch struct {
+	tech       *techSignature
+	confidence int
+	version    string
+}
+
+// detect 识别页面使用的技术（含隐含的技术），按名称排序
+func (db *techSignatureDB) detect(page *techPage) []models.TechDetection {
+	meta := make(map[string][]string)
+	var scriptSrc, scripts []string
+	if page.Doc != nil {
+		page.Doc.Find("meta").Each(func(i int, s *goquery.Selection) {
+			content := s.AttrOr("content", "")
+			for _, attr := range []string{"name", "property", "http-equiv"} {
+				if key := strings.ToLower(s.AttrOr(attr, "")); key != "" {
+					meta[key] = append(meta[key], content)
+				}
+			}
+		})
+		page.Doc.Find("script").Each(func(i int, s *goquery.Selection) {
+			if src := s.AttrOr("src", ""); src != "" {
+				scriptSrc = append(scriptSrc, src)
+			} else if text := s.Text(); text != "" {
+				scripts = append(scripts, text)
+			}
+		})
+	}
+
+	matches := make(map[string]*techMatch)
+	add := func(tech *techSignature, confidence int, version string) {
+		m, ok := matches[tech.name]
+		if !ok {
+			m = &techMatch{tech: tech}
+			matches[tech.name] = m
+		}
+		m.confidence = min(m.confidence+confidence, 100)
+		if len(version) > len(m.version) {
+			m.version = version
+		}
+	}
+	// matchAny 模式只要匹配任一值即计一次置信度
+	matchAny := func(tech *techSignature, patterns []*techPattern, values []string) {
+		for _, pattern := range patterns {
+			for _, value := range values {
+				if ok, version := pattern.match(value); ok {
+					add(tech, pattern.confidence, version)
+					break
+				}
+			}
+		}
+	}
+
+	for _, tech := range db.technologies {
+		for name, patterns := range tech.headers {
+			matchAny(tech, patterns, page.Headers.Values(name))
+		}
+		for name, patterns := range tech.cookies {
+			var values []string
+			for _, cookie := range page.Cookies {
+				if strings.EqualFold(cookie.Name, name) {
+					values = append(values, cookie.Value)
+				}
+			}
+			matchAny(tech, patterns, values)
+		}
+		for name, patterns := range tech.meta {
+			matchAny(tech, patterns, meta[name])
+		}
+		matchAny(tech, tech.scriptSrc, scriptSrc)
+		matchAny(tech, tech.scripts, scripts)
+		matchAny(tech, tech.html, []string{page.HTML})
+		matchAny(tech, tech.url, []string{page.URL})
+		if page.Doc != nil {
+			for _, rule := range tech.dom {
+				db.matchDOM(page.Doc, tech, rule, add)
+			}
+		}
+	}
+
+	// 隐含的技术（可传递，置信度不超过来源技术）
+	for changed := true; changed; {
+		changed = false
+		for _, m := range matches {
+			for _, implied := range m.tech.implies {
+				tech := db.lookup(implied.name)
+				if tech == nil {
+					continue
+				}
+				confidence := m.confidence * implied.confidence / 100
+				if existing, ok := matches[tech.name]; !ok || existing.confidence < confidence {
+					if !ok {
+						matches[tech.name] = &techMatch{tech: tech}
+					}
+					matches[tech.name].confidence = confidence
+					changed = true
+				}
+			}
+		}
+	}
+
+	// requires：依赖的技术未识别到时丢弃；excludes：互斥的技术丢弃
+	for name, m := range matches {
+		for _, required := range m.tech.requires {
+			if tech := db.lookup(required); tech == nil || matches[tech.name] == nil {
+				delete(matches, name)
+				break
+			}
+		}
+	}
+	for _, m := range matches {
+		for _, excluded := range m.tech.excludes {
+			if tech := db.lookup(excluded); tech != nil {
+				delete(matches, tech.name)
+			}
+		}
+	}
+
+	detections := make([]models.TechDetection, 0, len(matches))
+	for _, m := range matches {
+		detection := models.TechDetection{
+			Name:       m.tech.name,
+			Version:    m.version,
+			Confidence: m.confidence,
+			Website:    m.tech.website,
+		}
+		for _, id := range m.tech.categories {
+			if name, ok := db.categories[id]; ok {
+				detection.Categories = append(detection.Categories, name)
+			}
+		}
+		detections = append(detections, detection)
+	}
+	sort.Slice(detections, func(i, j int) bool { return detections[i].Name < detections[j].Name })
+	return detections
+}
+
+// matchDOM 匹配 dom 签名：没有 text/attributes 条件时选择器存在即匹配
+func (db *techSignatureDB) matchDOM(doc *goquery.Document, tech *techSignature, rule techDOMRule, add func(*techSignature, int, string)) {
+	selection, err := safeFind(doc, rule.selector)
+	if err != nil || selection.Length() == 0 {
+		return
+	}
+	if len(rule.text) == 0 && len(rule.attributes) == 0 {
+		add(tech, 100, "")
+		return
+	}
+	selection.EachWithBreak(func(i int, s *goquery.Selection) bool {
+		matched := false
+		for _, pattern := range rule.text {
+			if ok, version := pattern.match(s.Text()); ok {
+				add(tech, pattern.confidence, version)
+				matched = true
+			}
+		}
+		for attr, patterns := range rule.attributes {
+			value, exists := s.Attr(attr)
+			if !exists {
+				continue
+			}
+			for _, pattern := range patterns {
+				if ok, version := pattern.match(value); ok {
+					add(tech, pattern.confidence, version)
+					matched = true
+				}
+			}
+		}
+		return !matched
+	})
+}
+
+// safeFind 执行签名中的 CSS 选择器（外部签名的选择器可能不被 goquery 支持，解析失败时 goquery 会 panic）
+func safeFind(doc *goquery.Document, selector string) (selection *goquery.Selection, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid selector %q: %v", selector, r)
+		}
+	}()
+	return doc.Find(selector), nil
+}
+
+// fields 技术对应的 TechStack 分类字段
+func (t *techSignature) fields() []string {
+	var fields []string
+	for _, id := range t.categories {
+		if field, ok := techCategoryFields[id]; ok && !containsString(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// applyTechDetections 把识别结果写入 TechStack 的各分类（名称带版本，如 "jQuery 3.6.0"）
+// 置信度低于 techMinListedConfidence 的技术只保留在 Detections 中
+func applyTechDetections(stack *models.TechStack, db *techSignatureDB) {
+	for _, detection := range stack.Detections {
+		if detection.Confidence < techMinListedConfidence {
+			continue
+		}
+		label := detection.Name
+		if detection.Version != "" {
+			label += " " + detection.Version
+		}
+		if !contains(stack.Technologies, label) {
+			stack.Technologies = append(stack.Technologies, label)
+		}
+
+		tech := db.lookup(detection.Name)
+		if tech == nil {
+			continue
+		}
+		for _, field := range tech.fields() {
+			var list *[]string
+			switch field {
+			case techFieldCMS:
+				list = &stack.CMS
+			case techFieldFramework:
+				list = &stack.Framework
+			case techFieldLanguage:
+				list = &stack.Language
+			case techFieldJavaScript:
+				list = &stack.JavaScriptLib
+			case techFieldAnalytics:
+				list = &stack.Analytics
+			case techFieldCDN:
+				list = &stack.CDN
+			case techFieldCache:
+				list = &stack.Cache
+			case techFieldDatabase:
+				list = &stack.Database
+			case techFieldServer:
+				if stack.Server == "" {
+					stack.Server = label
+				}
+			case techFieldOS:
+				if stack.OS == "" {
+					stack.OS = label
+				}
+			}
+			if list != nil && !contains(*list, label) {
+				*list = append(*list, label)
+			}
+		}
+	}
+}
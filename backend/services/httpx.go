@@ -6,13 +6,48 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os/exec"
 	"strings"
 	"sync"
 
 	"web-checkly/models"
+	"web-checkly/utils"
 )
 
+// BuildScanSummary 根据链接检查结果计算扫描摘要
+// 超时未检查（Skipped）的链接既不算可用也不算失效，存在时 Timeout 为 true
+func BuildScanSummary(results []models.HttpxResult) models.ScanSummary {
+	alive := 0
+	skipped := 0
+	totalResponse := 0
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+			continue
+		case !isBrokenLink(r):
+			alive++
+		}
+		if r.ResponseTime > 0 {
+			totalResponse += r.ResponseTime
+		}
+	}
+	checked := len(results) - skipped
+	avgResponse := 0
+	if checked > 0 {
+		avgResponse = totalResponse / checked
+	}
+	return models.ScanSummary{
+		Total:       len(results),
+		Alive:       alive,
+		Dead:        checked - alive,
+		Skipped:     skipped,
+		AvgResponse: avgResponse,
+		Timeout:     skipped > 0,
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -20,10 +55,41 @@ func min(a, b int) int {
 	return b
 }
 
-func RunHttpx(ctx context.Context, urls []string, output chan models.HttpxResult) error {
-	cmd := exec.CommandContext(
-		ctx,
-		"httpx",
+// filterPublicURLs 过滤掉主机为内网地址或非 http/https 的链接
+// 页面中提取的链接（含 Katana 发现的链接）可能指向任意主机，httpx 是外部程序，无法在连接时检查，因此在检查前过滤；
+// 同一主机只解析一次
+func filterPublicURLs(urls []string) []string {
+	allowedHosts := make(map[string]bool)
+	filtered := make([]string, 0, len(urls))
+	skipped := 0
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+			skipped++
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		allowed, ok := allowedHosts[host]
+		if !ok {
+			allowed = !utils.IsPrivateIP(host)
+			allowedHosts[host] = allowed
+		}
+		if !allowed {
+			skipped++
+			continue
+		}
+		filtered = append(filtered, u)
+	}
+	if skipped > 0 {
+		log.Printf("[Httpx] Skipped %d URLs with private or unsupported hosts", skipped)
+	}
+	return filtered
+}
+
+// RunHttpx 使用 httpx 命令行工具检查链接（LINK_CHECKER_BACKEND=httpx），通过 RunLinkCheck 调用
+// extraArgs 为附加的命令行参数（如认证扫描的 -H 请求头）
+func RunHttpx(ctx context.Context, urls []string, output chan models.HttpxResult, extraArgs ...string) error {
+	args := []string{
 		"-json",
 		"-status-code",
 		"-title",
@@ -36,7 +102,10 @@ func RunHttpx(ctx context.Context, urls []string, output chan models.HttpxResult
 		"-threads", "30", // 增加并发线程数
 		"-rate-limit", "100", // 增加速率限制
 		"-max-host-error", "30", // 最大主机错误数
-	)
+	}
+	args = append(args, extraArgs...)
+	cmd := exec.CommandContext(ctx, "httpx", args...)
+	configureCommandCancel(cmd)
 
 	// 使用 sync.Once 确保 channel 只关闭一次
 	var once sync.Once
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterPublicURLsDropsPrivateHosts(t *testing.T) {
+	urls := []string{
+		"https://93.184.216.34/",
+		"http://127.0.0.1:8080/admin",
+		"http://localhost/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/internal",
+		"http://192.168.1.1/",
+		"http://[::1]/",
+		"http://[fe80::1]/",
+		"ftp://93.184.216.34/file",
+		"javascript:alert(1)",
+		"https://93.184.216.34/about",
+	}
+	want := []string{"https://93.184.216.34/", "https://93.184.216.34/about"}
+
+	if got := filterPublicURLs(urls); !reflect.DeepEqual(got, want) {
+		t.Fatalf("filterPublicURLs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,644 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-checkly/fingerprints"
+	"web-checkly/models"
+)
+
+const (
+	// retireRepositoryEnv 外部漏洞库路径（retire.js 格式的 jsrepository.json）
+	retireRepositoryEnv = "RETIRE_JS_REPOSITORY"
+	// retireVersionPlaceholder 漏洞库正则中的版本号占位符
+	retireVersionPlaceholder = "§§version§§"
+	// retireVersionPattern 版本号占位符对应的正则（与 retire.js 相同）
+	retireVersionPattern = `[0-9][0-9.a-z_\-]+`
+	// retireDontCheck 漏洞库中列出不需要检测的脚本地址的组件名
+	retireDontCheck = "dont check"
+
+	jsLibMaxScripts     = 100     // 最多检测的脚本数
+	jsLibMaxScriptBytes = 5 << 20 // 单个脚本参与内容匹配和哈希的最大字节数
+	jsLibWorkers        = 4       // 并发下载脚本数
+)
+
+// 版本识别方式
+const (
+	jsLibDetectionURI         = "uri"
+	jsLibDetectionFilename    = "filename"
+	jsLibDetectionFileContent = "filecontent"
+	jsLibDetectionHash        = "hash"
+)
+
+var (
+	// retireReplacePattern filecontentreplace 的格式：/正则/替换模板/
+	retireReplacePattern = regexp.MustCompile(`^/(.*[^\\])/([^/]+)/$`)
+	// retireVersionSeparator 比较版本时的分段分隔符
+	retireVersionSeparator = regexp.MustCompile(`[.\-]`)
+	// jsLibVersionSuffixes 文件名中紧跟版本号、会被版本正则一并匹配的后缀（如 jquery-1.12.4.min.js）
+	jsLibVersionSuffixes = []string{".min", "-min", ".slim", ".custom", ".runtime", ".bundle"}
+)
+
+// jsLibSeverityPenalty 未选择 security（Lighthouse）时，每个存在漏洞的库按最高严重程度扣除的安全评分
+var jsLibSeverityPenalty = map[string]int{
+	models.SeverityCritical: 40,
+	models.SeverityHigh:     25,
+	models.SeverityMedium:   10,
+	models.SeverityLow:      5,
+}
+
+// retireComponentJSON retire.js 漏洞库中的单个组件（func、ast 等需要执行脚本的提取方式忽略）
+type retireComponentJSON struct {
+	Vulnerabilities []retireVulnerabilityJSON `json:"vulnerabilities"`
+	Extractors      struct {
+		URI                []string          `json:"uri"`
+		Filename           []string          `json:"filename"`
+		FileContent        []string          `json:"filecontent"`
+		FileContentReplace []string          `json:"filecontentreplace"`
+		Hashes             map[string]string `json:"hashes"`
+	} `json:"extractors"`
+}
+
+// retireVulnerabilityJSON 受影响的版本范围 [atOrAbove, below) 及漏洞信息
+type retireVulnerabilityJSON struct {
+	AtOrAbove   string `json:"atOrAbove"`
+	Below       string `json:"below"`
+	Severity    string `json:"severity"`
+	Identifiers struct {
+		Summary  string   `json:"summary"`
+		CVE      []string `json:"CVE"`
+		GithubID string   `json:"githubID"`
+	} `json:"identifiers"`
+	Info []string `json:"info"`
+}
+
+// jsLibReplace filecontentreplace 规则：匹配后按模板展开得到版本号
+type jsLibReplace struct {
+	regex    *regexp.Regexp
+	template string
+}
+
+// jsLibComponent 编译后的组件
+type jsLibComponent struct {
+	name            string
+	vulnerabilities []retireVulnerabilityJSON
+	uri             []*regexp.Regexp
+	filename        []*regexp.Regexp
+	content         []*regexp.Regexp
+	contentReplace  []jsLibReplace
+	hashes          map[string]string // SHA-1 -> 版本
+}
+
+// jsLibRepository 编译后的漏洞库
+type jsLibRepository struct {
+	components []*jsLibComponent
+	dontCheck  []*regexp.Regexp
+	skipped    int // Go 正则不支持（如前瞻断言）而跳过的模式数
+}
+
+// jsLibDetection 在脚本中识别到的库版本
+type jsLibDetection struct {
+	component *jsLibComponent
+	version   string
+	detection string
+}
+
+var (
+	builtinJSLibOnce sync.Once
+	builtinJSLibRepo *jsLibRepository
+
+	jsLibRepositoryCache struct {
+		sync.Mutex
+		repo    *jsLibRepository
+		path    string
+		modTime time.Time
+	}
+)
+
+// builtinJSLibRepository 内置漏洞库
+func builtinJSLibRepository() *jsLibRepository {
+	builtinJSLibOnce.Do(func() {
+		var file map[string]retireComponentJSON
+		if err := json.Unmarshal(fingerprints.JSRepository, &file); err != nil {
+			log.Printf("[JSLibraries] Failed to parse built-in repository: %v", err)
+		}
+		builtinJSLibRepo = compileJSLibRepository(file)
+	})
+	return builtinJSLibRepo
+}
+
+// jsLibRepositoryCurrent 当前使用的漏洞库
+// 设置 RETIRE_JS_REPOSITORY 时从磁盘加载，文件修改后下次检测自动重新加载；
+// 加载失败时继续使用上一次加载成功的漏洞库（从未成功时使用内置漏洞库）
+func jsLibRepositoryCurrent() *jsLibRepository {
+	path := strings.TrimSpace(os.Getenv(retireRepositoryEnv))
+	if path == "" {
+		return builtinJSLibRepository()
+	}
+
+	cache := &jsLibRepositoryCache
+	cache.Lock()
+	defer cache.Unlock()
+
+	info, err := os.Stat(path)
+	if err == nil && cache.repo != nil && cache.path == path && info.ModTime().Equal(cache.modTime) {
+		return cache.repo
+	}
+
+	var repo *jsLibRepository
+	if err == nil {
+		repo, err = loadJSLibRepository(path)
+	}
+	if err != nil {
+		log.Printf("[JSLibraries] Failed to load repository from %s: %v", path, err)
+		if cache.repo != nil {
+			return cache.repo
+		}
+		return builtinJSLibRepository()
+	}
+
+	cache.repo, cache.path, cache.modTime = repo, path, info.ModTime()
+	log.Printf("[JSLibraries] Loaded %d components from %s (%d unsupported patterns skipped)", len(repo.components), path, repo.skipped)
+	return repo
+}
+
+// loadJSLibRepository 从磁盘加载 retire.js 格式的漏洞库
+func loadJSLibRepository(path string) (*jsLibRepository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file map[string]retireComponentJSON
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	repo := compileJSLibRepository(file)
+	if len(repo.components) == 0 {
+		return nil, fmt.Errorf("no components found")
+	}
+	return repo, nil
+}
+
+// compileJSLibRepository 编译漏洞库（无法编译的模式跳过并计数），组件按名称排序以保证结果稳定
+func compileJSLibRepository(file map[string]retireComponentJSON) *jsLibRepository {
+	repo := &jsLibRepository{}
+	names := make([]string, 0, len(file))
+	for name := range file {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := file[name]
+		if name == retireDontCheck {
+			repo.dontCheck = repo.compileList(entry.Extractors.URI)
+			continue
+		}
+		component := &jsLibComponent{
+			name:            name,
+			vulnerabilities: entry.Vulnerabilities,
+			uri:             repo.compileList(entry.Extractors.URI),
+			filename:        repo.compileList(entry.Extractors.Filename),
+			content:         repo.compileList(entry.Extractors.FileContent),
+			hashes:          entry.Extractors.Hashes,
+		}
+		for _, value := range entry.Extractors.FileContentReplace {
+			parts := retireReplacePattern.FindStringSubmatch(value)
+			if parts == nil {
+				repo.skipped++
+				continue
+			}
+			if re := repo.compilePattern(parts[1]); re != nil {
+				component.contentReplace = append(component.contentReplace, jsLibReplace{regex: re, template: parts[2]})
+			}
+		}
+		repo.components = append(repo.components, component)
+	}
+	return repo
+}
+
+// compilePattern 编译模式（替换版本号占位符）
+func (r *jsLibRepository) compilePattern(value string) *regexp.Regexp {
+	re, err := regexp.Compile(strings.ReplaceAll(value, retireVersionPlaceholder, retireVersionPattern))
+	if err != nil {
+		r.skipped++
+		return nil
+	}
+	return re
+}
+
+func (r *jsLibRepository) compileList(values []string) []*regexp.Regexp {
+	var list []*regexp.Regexp
+	for _, value := range values {
+		if re := r.compilePattern(value); re != nil {
+			list = append(list, re)
+		}
+	}
+	return list
+}
+
+// ignored 脚本是否在漏洞库的 "dont check" 列表中
+func (r *jsLibRepository) ignored(rawURL string) bool {
+	for _, re := range r.dontCheck {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectURL 根据脚本地址（完整地址和文件名）识别库版本
+func (r *jsLibRepository) detectURL(rawURL string) []jsLibDetection {
+	filename := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		filename = path.Base(parsed.Path)
+	}
+
+	var found []jsLibDetection
+	for _, component := range r.components {
+		if version := matchJSLibVersion(component.uri, rawURL); version != "" {
+			found = append(found, jsLibDetection{component: component, version: version, detection: jsLibDetectionURI})
+		} else if version := matchJSLibVersion(component.filename, filename); version != "" {
+			found = append(found, jsLibDetection{component: component, version: version, detection: jsLibDetectionFilename})
+		}
+	}
+	return found
+}
+
+// detectContent 根据脚本内容（版权声明等特征字符串）和 SHA-1 识别库版本
+func (r *jsLibRepository) detectContent(content []byte) []jsLibDetection {
+	sum := sha1.Sum(content)
+	hash := hex.EncodeToString(sum[:])
+	text := string(content)
+
+	var found []jsLibDetection
+	for _, component := range r.components {
+		if version, ok := component.hashes[hash]; ok {
+			found = append(found, jsLibDetection{component: component, version: version, detection: jsLibDetectionHash})
+			continue
+		}
+		if version := matchJSLibVersion(component.content, text); version != "" {
+			found = append(found, jsLibDetection{component: component, version: version, detection: jsLibDetectionFileContent})
+			continue
+		}
+		for _, rule := range component.contentReplace {
+			match := rule.regex.FindStringSubmatchIndex(text)
+			if match == nil {
+				continue
+			}
+			version := cleanJSLibVersion(string(rule.regex.ExpandString(nil, rule.template, text, match)))
+			if version != "" {
+				found = append(found, jsLibDetection{component: component, version: version, detection: jsLibDetectionFileContent})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// matchJSLibVersion 返回第一个匹配的模式提取到的版本号（第 1 个分组）
+func matchJSLibVersion(patterns []*regexp.Regexp, value string) string {
+	for _, re := range patterns {
+		if match := re.FindStringSubmatch(value); len(match) > 1 {
+			if version := cleanJSLibVersion(match[1]); version != "" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+// cleanJSLibVersion 去掉被版本正则一并匹配的文件名后缀
+func cleanJSLibVersion(version string) string {
+	version = strings.TrimSpace(version)
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range jsLibVersionSuffixes {
+			if strings.HasSuffix(version, suffix) {
+				version = strings.TrimSuffix(version, suffix)
+				trimmed = true
+			}
+		}
+	}
+	return version
+}
+
+// retireAtOrAbove 按 retire.js 的规则判断 v1 >= v2：按 . 和 - 分段，都是数字的段按数值比较，
+// 数字段大于非数字段（3.0.0 > 3.0.0-beta1），其余按字符串比较，缺失的段视为 0
+func retireAtOrAbove(v1, v2 string) bool {
+	parts1 := retireVersionSeparator.Split(v1, -1)
+	parts2 := retireVersionSeparator.Split(v2, -1)
+	n := len(parts1)
+	if len(parts2) > n {
+		n = len(parts2)
+	}
+	for i := 0; i < n; i++ {
+		s1, num1, isNum1 := retireVersionPart(parts1, i)
+		s2, num2, isNum2 := retireVersionPart(parts2, i)
+		if isNum1 != isNum2 {
+			return isNum1
+		}
+		if isNum1 {
+			if num1 != num2 {
+				return num1 > num2
+			}
+			continue
+		}
+		if s1 != s2 {
+			return s1 > s2
+		}
+	}
+	return true
+}
+
+// retireVersionPart 版本的第 i 段：数字段返回数值，缺失或空的段视为数字 0
+func retireVersionPart(parts []string, i int) (string, int, bool) {
+	if i >= len(parts) || parts[i] == "" {
+		return "", 0, true
+	}
+	if num, err := strconv.Atoi(parts[i]); err == nil {
+		return parts[i], num, true
+	}
+	return parts[i], 0, false
+}
+
+// vulnerabilitiesFor 版本命中的漏洞（受影响范围为 [atOrAbove, below)）
+func (c *jsLibComponent) vulnerabilitiesFor(version string) []models.LibraryVulnerability {
+	var vulns []models.LibraryVulnerability
+	seen := make(map[string]bool)
+	for _, v := range c.vulnerabilities {
+		if v.Below == "" || retireAtOrAbove(version, v.Below) {
+			continue
+		}
+		if v.AtOrAbove != "" && !retireAtOrAbove(version, v.AtOrAbove) {
+			continue
+		}
+
+		identifiers := append([]string{}, v.Identifiers.CVE...)
+		if len(identifiers) == 0 && v.Identifiers.GithubID != "" {
+			identifiers = append(identifiers, v.Identifiers.GithubID)
+		}
+		key := strings.Join(identifiers, ",") + "|" + v.Identifiers.Summary
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		severity := strings.ToLower(v.Severity)
+		if _, ok := jsLibSeverityPenalty[severity]; !ok {
+			severity = models.SeverityMedium
+		}
+		vulns = append(vulns, models.LibraryVulnerability{
+			Identifiers: identifiers,
+			Severity:    severity,
+			Summary:     strings.TrimSpace(v.Identifiers.Summary),
+			FixedIn:     v.Below,
+			Info:        v.Info,
+		})
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		return severityRank[vulns[i].Severity] < severityRank[vulns[j].Severity]
+	})
+	return vulns
+}
+
+// jsLibScriptURLs 从爬虫发现的链接中筛选脚本地址（去重，最多 jsLibMaxScripts 个）
+func jsLibScriptURLs(results []KatanaResult) []string {
+	seen := make(map[string]bool)
+	var scripts []string
+	for _, result := range results {
+		parsed, err := url.Parse(result.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(parsed.Path))
+		isScript := result.Source == "js" || result.Type == "js" ||
+			strings.Contains(strings.ToLower(result.Source), "script") || ext == ".js" || ext == ".mjs"
+		if !isScript || seen[result.URL] {
+			continue
+		}
+		seen[result.URL] = true
+		scripts = append(scripts, result.URL)
+	}
+	if len(scripts) > jsLibMaxScripts {
+		log.Printf("[JSLibraries] Checking first %d of %d discovered scripts", jsLibMaxScripts, len(scripts))
+		scripts = scripts[:jsLibMaxScripts]
+	}
+	return scripts
+}
+
+// CollectJSLibraries 检测页面引用的 JavaScript 库版本，与 retire.js 格式的漏洞库比对，返回存在已知漏洞的库
+// crawled 为爬虫（katana 模块）发现的链接，为空时只发现目标页面引用的脚本；
+// 版本从脚本地址、文件名、脚本内容中的版权声明和文件 SHA-1 中识别
+func CollectJSLibraries(ctx context.Context, targetURL string, crawled []KatanaResult, auth *ScanAuthSession) ([]models.VulnerableLibrary, error) {
+	if len(crawled) == 0 {
+		discovered, err := discoverPageLinks(ctx, targetURL, "", nil, auth)
+		if err != nil {
+			return nil, fmt.Errorf("%s failed: %w", CrawlerBackend(), err)
+		}
+		crawled = discovered
+	}
+
+	repo := jsLibRepositoryCurrent()
+	var scripts []string
+	for _, script := range filterPublicURLs(jsLibScriptURLs(crawled)) {
+		if !repo.ignored(script) {
+			scripts = append(scripts, script)
+		}
+	}
+
+	// 按脚本顺序记录识别结果，同一库版本出现在多个脚本中时取第一个脚本
+	detections := make([][]jsLibDetection, len(scripts))
+	client := auth.HTTPClient(15 * time.Second)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jsLibWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				detections[index] = detectScriptLibraries(ctx, client, auth, repo, scripts[index])
+			}
+		}()
+	}
+	for i := range scripts {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	libraries := make([]models.VulnerableLibrary, 0)
+	seen := make(map[string]bool)
+	detected := 0
+	for i, found := range detections {
+		for _, d := range found {
+			key := d.component.name + "@" + d.version
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			detected++
+
+			vulns := d.component.vulnerabilitiesFor(d.version)
+			if len(vulns) == 0 {
+				continue
+			}
+			libraries = append(libraries, models.VulnerableLibrary{
+				Library:         d.component.name,
+				Version:         d.version,
+				URL:             scripts[i],
+				Detection:       d.detection,
+				Severity:        vulns[0].Severity,
+				Vulnerabilities: vulns,
+			})
+		}
+	}
+	sort.SliceStable(libraries, func(i, j int) bool {
+		if libraries[i].Severity != libraries[j].Severity {
+			return severityRank[libraries[i].Severity] < severityRank[libraries[j].Severity]
+		}
+		return libraries[i].Library < libraries[j].Library
+	})
+
+	log.Printf("[JSLibraries] Checked %d scripts of %s: %d library versions detected, %d vulnerable",
+		len(scripts), targetURL, detected, len(libraries))
+	return libraries, nil
+}
+
+// detectScriptLibraries 识别单个脚本中的库版本：先按地址识别，再下载脚本按内容和哈希识别
+// 下载失败时只返回按地址识别的结果
+func detectScriptLibraries(ctx context.Context, client *http.Client, auth *ScanAuthSession, repo *jsLibRepository, scriptURL string) []jsLibDetection {
+	found := repo.detectURL(scriptURL)
+
+	content, err := fetchJSLibScript(ctx, client, auth, scriptURL)
+	if err != nil {
+		log.Printf("[JSLibraries] Failed to fetch %s: %v", scriptURL, err)
+		return found
+	}
+	// 同一库以地址识别的版本为准（打包文件中的版权声明可能属于其他依赖的版本）
+	known := make(map[string]bool, len(found))
+	for _, d := range found {
+		known[d.component.name] = true
+	}
+	for _, d := range repo.detectContent(content) {
+		if !known[d.component.name] {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+// fetchJSLibScript 下载脚本（最多 jsLibMaxScriptBytes 字节）
+func fetchJSLibScript(ctx context.Context, client *http.Client, auth *ScanAuthSession, scriptURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scriptURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+	req.Header.Set("Accept", "*/*")
+	auth.Apply(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, jsLibMaxScriptBytes))
+}
+
+// MergeVulnerableLibraries 将 js-libraries 模块的结果合并到安全风险结果中（替换之前的库检测结果）
+// risk 为已有的安全风险结果（未选择 security 时为 nil）；
+// hasLighthouse 为 false 时安全评分按存在漏洞的库计算，否则保留 Lighthouse 的评分
+func MergeVulnerableLibraries(risk *models.SecurityRisk, libraries []models.VulnerableLibrary, hasLighthouse bool) *models.SecurityRisk {
+	merged := &models.SecurityRisk{SecurityHeaders: make(map[string]string)}
+	if risk != nil {
+		copied := *risk
+		merged = &copied
+	}
+	merged.VulnerableLibraries = libraries
+	if !hasLighthouse {
+		merged.Score = jsLibrarySecurityScore(libraries)
+	}
+	return merged
+}
+
+// jsLibrarySecurityScore 只检测了 JavaScript 库时的安全评分：每个存在漏洞的库按最高严重程度扣分
+func jsLibrarySecurityScore(libraries []models.VulnerableLibrary) int {
+	score := 100
+	for _, lib := range libraries {
+		score -= jsLibSeverityPenalty[lib.Severity]
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// jsLibraryFixedVersion 修复该库全部已知漏洞所需的最低版本
+func jsLibraryFixedVersion(lib models.VulnerableLibrary) string {
+	fixed := ""
+	for _, v := range lib.Vulnerabilities {
+		if fixed == "" || (v.FixedIn != "" && !retireAtOrAbove(fixed, v.FixedIn)) {
+			fixed = v.FixedIn
+		}
+	}
+	return fixed
+}
+
+// jsLibraryIdentifiers 库命中的漏洞编号（去重）
+func jsLibraryIdentifiers(lib models.VulnerableLibrary) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, v := range lib.Vulnerabilities {
+		for _, id := range v.Identifiers {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// vulnerableLibraryIssue 存在漏洞的库的一行描述（用于门禁详情和 SARIF）
+func vulnerableLibraryIssue(lib models.VulnerableLibrary) string {
+	issue := fmt.Sprintf("Vulnerable JavaScript library %s %s (%s)", lib.Library, lib.Version, lib.Severity)
+	if ids := jsLibraryIdentifiers(lib); len(ids) > 0 {
+		issue += ": " + strings.Join(ids, ", ")
+	}
+	if fixed := jsLibraryFixedVersion(lib); fixed != "" {
+		issue += "; upgrade to " + fixed + " or later"
+	}
+	return issue
+}
+
+// securityIssues 安全问题列表：Lighthouse 发现的问题和存在已知漏洞的 JavaScript 库
+func securityIssues(risk *models.SecurityRisk) []string {
+	issues := append([]string{}, risk.Vulnerabilities...)
+	for _, lib := range risk.VulnerableLibraries {
+		issues = append(issues, vulnerableLibraryIssue(lib))
+	}
+	return issues
+}
@@ -5,9 +5,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/user"
@@ -35,8 +37,9 @@ type KatanaResult struct {
 
 // RunKatana 使用katana进行页面和资源发现
 // 如果提供了taskID和taskManager，会实时推送发现的链接
+// auth: 认证扫描的认证信息（通过 -H 传入，为 nil 时匿名爬取）
 // depth: 爬取深度，1=只爬取当前页面，3=深度爬取（默认3，保持向后兼容）
-func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager interface{}, depth ...int) ([]KatanaResult, error) {
+func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager interface{}, auth *ScanAuthSession, depth ...int) ([]KatanaResult, error) {
 	// 默认深度为3（深度爬取），如果指定了depth参数则使用指定值
 	crawlDepth := 3
 	if len(depth) > 0 && depth[0] > 0 {
@@ -65,9 +68,7 @@ func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager
 		return nil, fmt.Errorf("katana command not found: %w. Please ensure katana is installed and in PATH", err)
 	}
 
-	cmd := exec.CommandContext(
-		ctx,
-		katanaPath,
+	args := []string{
 		"-u", targetURL,
 		"-j",           // JSON Lines 输出格式
 		"-or",          // 省略原始请求/响应数据
@@ -80,7 +81,16 @@ func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager
 		"-c", "20", // 并发数（增加到20以提高发现速度）
 		// 注意：-rd 只接受整数秒，100ms 延迟太小，使用默认行为（无延迟）或设置为 0
 		// "-rd", "0", // 请求延迟（秒，只接受整数）
-	)
+	}
+	if auth != nil {
+		// 认证扫描：-H 附加到所有请求，用 -cs 把爬取范围限制为认证信息适用的地址（协议和端口与目标相同），
+		// 避免凭据以明文发往 http 地址或被同一主机上其他端口的服务收到；不爬取退出登录地址，避免会话失效
+		args = append(args, auth.HeaderArgs("-H")...)
+		args = append(args, "-cs", auth.ScopePattern())
+		args = append(args, "-cos", logoutURLPattern.String())
+	}
+	cmd := exec.CommandContext(ctx, katanaPath, args...)
+	configureCommandCancel(cmd)
 
 	// 设置环境变量（Katana 需要 HOME 环境变量）
 	cmd.Env = os.Environ()
@@ -117,6 +127,10 @@ func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager
 	var results []KatanaResult
 	var stderrLines []string
 
+	// 实时推送发现的链接（批量写入）
+	stream := newCrawlResultStream(taskID, taskManager, "[Katana]")
+	defer stream.Close()
+
 	// 读取 stderr（错误信息和日志）
 	go func() {
 		stderrScanner := bufio.NewScanner(stderr)
@@ -344,20 +358,7 @@ func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager
 				results = append(results, result)
 
 				// 如果提供了taskManager，实时推送发现的链接
-				if taskID != "" && taskManager != nil {
-					// 使用接口类型断言（避免循环依赖）
-					type TaskManagerInterface interface {
-						AppendKatanaResult(taskID string, result interface{}) error
-					}
-					if tm, ok := taskManager.(TaskManagerInterface); ok {
-						// 异步推送，不阻塞主流程
-						go func() {
-							if err := tm.AppendKatanaResult(taskID, result); err != nil {
-								log.Printf("[Katana] Error appending result to task manager: %v", err)
-							}
-						}()
-					}
-				}
+				stream.Add(result)
 			}
 		}
 	}
@@ -405,7 +406,7 @@ func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager
 			if hasRealError {
 				errMsg += fmt.Sprintf(" (errors: %s)", strings.Join(errorMessages, "; "))
 			}
-			return results, fmt.Errorf(errMsg)
+			return results, errors.New(errMsg)
 		}
 		// 即使有错误，如果有部分结果，也返回结果（部分成功）
 		if len(results) > 0 {
@@ -426,7 +427,7 @@ func RunKatana(ctx context.Context, targetURL string, taskID string, taskManager
 			if waitErr != nil {
 				errMsg += fmt.Sprintf(" (exit error: %v)", waitErr)
 			}
-			return results, fmt.Errorf(errMsg)
+			return results, errors.New(errMsg)
 		}
 		// 如果没有错误，只是没有发现结果，这是正常情况（可能网站没有链接或需要登录）
 		log.Printf("[Katana] No results found, but no errors detected. This may be normal if the site has no crawlable links.")
@@ -684,13 +685,80 @@ func extractCharsetFromContentType(contentType string) string {
 	return ""
 }
 
-// CollectKatanaResults 收集katana发现的页面和资源（用于插件）
-func CollectKatanaResults(targetURL string, taskID string, taskManager interface{}) ([]KatanaResult, error) {
+// CollectKatanaResults 全站爬取发现的页面和资源（用于插件），后端见 CrawlSite
+// ctx 取消（如任务被取消）时爬取停止（katana 进程会被终止）
+func CollectKatanaResults(ctx context.Context, targetURL string, taskID string, taskManager interface{}, cfg *models.CrawlConfig, auth *ScanAuthSession) ([]KatanaResult, error) {
 	// 增加超时时间到120秒，因为增加了爬取深度和并发数
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
+
+	return CrawlSite(ctx, targetURL, taskID, taskManager, cfg, auth)
+}
+
+// pageLinkDiscoveryTimeout DiscoverPageURLs 的最长时间：link-health 插件共 60 秒，其余时间留给链接检查
+const pageLinkDiscoveryTimeout = 20 * time.Second
+
+// DiscoverPageURLs 提取当前页面的链接和资源（depth=1，不跟随链接到其他页面），后端见 CrawlerBackend
+// 返回去重后的 URL 列表，包括外部链接（跨域链接），供 link-health 检查使用
+func DiscoverPageURLs(ctx context.Context, targetURL string, taskID string, taskManager interface{}, auth *ScanAuthSession) ([]string, error) {
+	// 创建子context用于爬取，最多占用 pageLinkDiscoveryTimeout
+	ctx, cancel := context.WithTimeout(ctx, pageLinkDiscoveryTimeout)
 	defer cancel()
 
-	return RunKatana(ctx, targetURL, taskID, taskManager)
+	katanaResults, err := discoverPageLinks(ctx, targetURL, taskID, taskManager, auth)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", CrawlerBackend(), err)
+	}
+
+	urlMap := make(map[string]bool)
+	urls := make([]string, 0)
+	skippedCount := 0
+	sameDomainCount := 0
+	crossDomainCount := 0
+
+	// 解析目标URL的域名，用于统计（但不用于过滤）
+	var targetHostname string
+	if targetURLParsed, err := url.Parse(targetURL); err == nil {
+		targetHostname = strings.ToLower(targetURLParsed.Hostname())
+	}
+
+	for _, result := range katanaResults {
+		if result.URL == "" {
+			skippedCount++
+			continue
+		}
+
+		resultURLParsed, err := url.Parse(result.URL)
+		if err != nil {
+			skippedCount++
+			continue
+		}
+
+		// 统计同域和跨域链接
+		resultHostname := strings.ToLower(resultURLParsed.Hostname())
+		if targetHostname != "" && resultHostname == targetHostname {
+			sameDomainCount++
+		} else if resultHostname != "" {
+			crossDomainCount++
+		}
+
+		// 规范化URL用于去重（保留查询参数和路径，只对没有查询参数的URL移除尾部斜杠）
+		// 注意：/path?query 和 /path/?query 是不同的
+		normalized := strings.ToLower(result.URL)
+		if !strings.Contains(normalized, "?") && !strings.Contains(normalized, "#") {
+			normalized = strings.TrimRight(normalized, "/")
+		}
+
+		if !urlMap[normalized] {
+			urlMap[normalized] = true
+			urls = append(urls, result.URL) // 保留原始URL格式
+		}
+	}
+
+	log.Printf("[Katana] Extracted %d unique URLs from current page (from %d results, skipped %d invalid, same-domain: %d, cross-domain: %d)",
+		len(urls), len(katanaResults), skippedCount, sameDomainCount, crossDomainCount)
+
+	return urls, nil
 }
 
 // ConvertKatanaToHttpxResults 将Katana结果转换为HttpxResult格式（用于兼容现有系统）
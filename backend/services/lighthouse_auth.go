@@ -0,0 +1,373 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// chromeStartTimeout 等待 Chrome 输出 DevTools 地址的最长时间
+	chromeStartTimeout = 30 * time.Second
+	// cdpOrigin 连接 DevTools 时使用的 Origin（Chrome 111+ 需要通过 --remote-allow-origins 显式允许）
+	cdpOrigin = "http://127.0.0.1"
+)
+
+// chromeCandidates 没有设置 CHROME_PATH 时依次查找的 Chrome/Chromium 命令
+var chromeCandidates = []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome"}
+
+// devToolsURLPattern Chrome 启动后在 stderr 输出的 DevTools 地址
+var devToolsURLPattern = regexp.MustCompile(`DevTools listening on (ws://\S+)`)
+
+// lighthouseAuthBrowser 认证扫描时供 Lighthouse 连接（--port）的 Chrome
+// Lighthouse 的 --extra-headers 会附加到页面发出的所有请求，任务的 Cookie 和 Authorization 会被发送给第三方 CDN、统计和广告服务。
+// 这里自己启动 Chrome，通过 DevTools 协议自动附加到每个新页面并开启 Fetch 拦截，只为 ScanAuthSession 适用的（同站）请求附加认证请求头；
+// Cookie 写入浏览器的 Cookie 存储（只属于目标主机的协议和端口），因为浏览器发送请求时会用存储中的 Cookie 覆盖拦截时设置的 Cookie 请求头
+type lighthouseAuthBrowser struct {
+	Port int // DevTools 端口
+
+	auth    *ScanAuthSession
+	cmd     *exec.Cmd
+	conn    *websocket.Conn
+	dataDir string
+
+	writeMu sync.Mutex
+	nextID  int64
+	done    chan struct{} // 事件循环退出时关闭（事件循环没有启动时为 nil）
+}
+
+// cdpMessage DevTools 协议消息（命令响应或事件）
+type cdpMessage struct {
+	ID        int64           `json:"id,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Error     *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// cdpHeader Fetch.continueRequest 的请求头
+type cdpHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// findChrome 查找 Chrome 可执行文件：优先使用 CHROME_PATH（与 Lighthouse 相同）
+func findChrome() (string, error) {
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		return path, nil
+	}
+	for _, name := range chromeCandidates {
+		if path, err := findCommand(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("chrome not found, set CHROME_PATH to the Chrome/Chromium executable")
+}
+
+// startLighthouseAuthBrowser 启动无头 Chrome 并开启按请求地址附加认证信息的拦截
+// ctx 取消时终止 Chrome；调用方必须在 Lighthouse 结束后调用 Close
+func startLighthouseAuthBrowser(ctx context.Context, auth *ScanAuthSession) (*lighthouseAuthBrowser, error) {
+	chromePath, err := findChrome()
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := os.MkdirTemp("", "webcheckly-chrome-")
+	if err != nil {
+		return nil, err
+	}
+
+	// 与 Lighthouse（chrome-launcher）默认启动参数保持一致，避免后台任务影响性能指标
+	args := []string{
+		"--headless",
+		"--remote-debugging-port=0",
+		"--remote-allow-origins=" + cdpOrigin,
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-background-networking",
+		"--disable-background-timer-throttling",
+		"--disable-backgrounding-occluded-windows",
+		"--disable-renderer-backgrounding",
+		"--disable-default-apps",
+		"--disable-extensions",
+		"--disable-sync",
+		"--metrics-recording-only",
+		"--mute-audio",
+		"--password-store=basic",
+		"--use-mock-keychain",
+		// Cookie 默认不区分端口和协议，开启后写入的认证 Cookie 只发送给目标的协议和端口（与 appliesTo 一致）
+		"--enable-features=EnablePortBoundCookies,EnableSchemeBoundCookies",
+	}
+	// 以 root 运行（如容器内）时 Chrome 必须关闭沙箱才能启动
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, "about:blank")
+
+	cmd := exec.CommandContext(ctx, chromePath, args...)
+	configureCommandCancel(cmd)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("failed to start chrome: %w", err)
+	}
+
+	b := &lighthouseAuthBrowser{auth: auth, cmd: cmd, dataDir: dataDir}
+
+	// 读取 DevTools 地址，之后继续读取 stderr 避免 Chrome 因管道写满而阻塞
+	wsURL := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if m := devToolsURLPattern.FindStringSubmatch(scanner.Text()); m != nil {
+				select {
+				case wsURL <- m[1]:
+				default:
+				}
+			}
+		}
+	}()
+
+	var browserURL string
+	select {
+	case browserURL = <-wsURL:
+	case <-time.After(chromeStartTimeout):
+		b.Close()
+		return nil, errors.New("timed out waiting for chrome devtools endpoint")
+	case <-ctx.Done():
+		b.Close()
+		return nil, ctx.Err()
+	}
+
+	parsed, err := url.Parse(browserURL)
+	if err == nil {
+		b.Port, err = strconv.Atoi(parsed.Port())
+	}
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("invalid devtools endpoint %q", browserURL)
+	}
+	if b.conn, err = websocket.Dial(browserURL, "", cdpOrigin); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to connect to chrome devtools: %w", err)
+	}
+
+	// 自动附加到所有页面（包括 Lighthouse 之后创建的页面），新页面在开启拦截之前保持暂停
+	id, err := b.send("", "Target.setAutoAttach", map[string]interface{}{
+		"autoAttach":             true,
+		"waitForDebuggerOnStart": true,
+		"flatten":                true,
+	})
+	if err == nil {
+		err = b.waitResponse(id)
+	}
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to enable auto attach: %w", err)
+	}
+	if cookies := b.sessionCookies(); len(cookies) > 0 {
+		id, err := b.send("", "Storage.setCookies", map[string]interface{}{"cookies": cookies})
+		if err == nil {
+			err = b.waitResponse(id)
+		}
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("failed to set cookies: %w", err)
+		}
+	}
+
+	b.done = make(chan struct{})
+	go b.handleEvents()
+	log.Printf("[Lighthouse] Started chrome for authenticated scan on port %d", b.Port)
+	return b, nil
+}
+
+// send 发送命令（sessionID 为空时发送给浏览器），返回命令 ID
+func (b *lighthouseAuthBrowser) send(sessionID, method string, params interface{}) (int64, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	b.nextID++
+	message := map[string]interface{}{"id": b.nextID, "method": method, "params": params}
+	if sessionID != "" {
+		message["sessionId"] = sessionID
+	}
+	return b.nextID, websocket.JSON.Send(b.conn, message)
+}
+
+// waitResponse 等待命令的响应（只在启动阶段、事件循环开始之前使用）
+func (b *lighthouseAuthBrowser) waitResponse(id int64) error {
+	b.conn.SetReadDeadline(time.Now().Add(chromeStartTimeout))
+	defer b.conn.SetReadDeadline(time.Time{})
+	for {
+		var message cdpMessage
+		if err := websocket.JSON.Receive(b.conn, &message); err != nil {
+			return err
+		}
+		if message.ID != id {
+			continue
+		}
+		if message.Error != nil {
+			return errors.New(message.Error.Message)
+		}
+		return nil
+	}
+}
+
+// handleEvents 处理页面附加和请求拦截事件，连接关闭时退出
+func (b *lighthouseAuthBrowser) handleEvents() {
+	defer close(b.done)
+	for {
+		var message cdpMessage
+		if err := websocket.JSON.Receive(b.conn, &message); err != nil {
+			return
+		}
+		switch message.Method {
+		case "Target.attachedToTarget":
+			var params struct {
+				SessionID  string `json:"sessionId"`
+				TargetInfo struct {
+					Type string `json:"type"`
+				} `json:"targetInfo"`
+			}
+			if json.Unmarshal(message.Params, &params) != nil {
+				continue
+			}
+			// 同一会话上的命令按顺序执行：拦截开启后页面才会继续加载
+			if params.TargetInfo.Type == "page" {
+				b.send(params.SessionID, "Fetch.enable", map[string]interface{}{
+					"patterns": b.interceptPatterns(),
+				})
+			}
+			b.send(params.SessionID, "Runtime.runIfWaitingForDebugger", map[string]interface{}{})
+		case "Fetch.requestPaused":
+			var params struct {
+				RequestID string `json:"requestId"`
+				Request   struct {
+					URL     string            `json:"url"`
+					Headers map[string]string `json:"headers"`
+				} `json:"request"`
+			}
+			if json.Unmarshal(message.Params, &params) != nil {
+				continue
+			}
+			continueParams := map[string]interface{}{"requestId": params.RequestID}
+			if headers := b.requestHeaders(params.Request.URL, params.Request.Headers); headers != nil {
+				continueParams["headers"] = headers
+			}
+			b.send(message.SessionID, "Fetch.continueRequest", continueParams)
+		}
+	}
+}
+
+// interceptPatterns 只拦截目标主机（含 www. 前缀的变体）的请求，减少对性能指标的影响
+// 模式只用于缩小拦截范围，是否附加认证信息仍由 requestHeaders 按完整地址判断
+func (b *lighthouseAuthBrowser) interceptPatterns() []map[string]string {
+	host := strings.TrimPrefix(strings.ToLower(b.auth.target.Hostname()), "www.")
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return []map[string]string{
+		{"urlPattern": "*://" + host + "*", "requestStage": "Request"},
+		{"urlPattern": "*://www." + host + "*", "requestStage": "Request"},
+	}
+}
+
+// sessionCookies 认证 Cookie 对应的 Storage.setCookies 参数：只设置 url 不设置 domain，
+// 浏览器把它们保存为只发送给该主机的 Cookie；与 appliesTo 一致，同时为 www. 前缀的变体设置，
+// 并绑定目标的协议和端口（https 目标的 Cookie 带 Secure，不会以明文发送）
+func (b *lighthouseAuthBrowser) sessionCookies() []map[string]interface{} {
+	header := b.auth.header.Get("Cookie")
+	if header == "" {
+		return nil
+	}
+	// IP 地址没有 www. 变体
+	hosts := []string{b.auth.target.Host}
+	if hostname := strings.ToLower(b.auth.target.Hostname()); net.ParseIP(hostname) == nil {
+		apex := strings.TrimPrefix(hostname, "www.")
+		hosts = []string{apex, "www." + apex}
+		if port := b.auth.target.Port(); port != "" {
+			hosts = []string{apex + ":" + port, "www." + apex + ":" + port}
+		}
+	}
+
+	// sourceScheme/sourcePort 配合启动参数中的 EnableSchemeBoundCookies/EnablePortBoundCookies 生效
+	secure := strings.EqualFold(b.auth.target.Scheme, "https")
+	sourceScheme := "NonSecure"
+	if secure {
+		sourceScheme = "Secure"
+	}
+	port, _ := strconv.Atoi(effectivePort(b.auth.target))
+
+	req := &http.Request{Header: http.Header{"Cookie": {header}}}
+	var cookies []map[string]interface{}
+	for _, host := range hosts {
+		origin := b.auth.target.Scheme + "://" + host + "/"
+		for _, cookie := range req.Cookies() {
+			cookies = append(cookies, map[string]interface{}{
+				"name": cookie.Name, "value": cookie.Value, "url": origin,
+				"secure": secure, "sourceScheme": sourceScheme, "sourcePort": port,
+			})
+		}
+	}
+	return cookies
+}
+
+// requestHeaders 被拦截请求应使用的请求头：ScanAuthSession 不适用于该地址时返回 nil（原样继续）
+// 认证请求头覆盖页面设置的同名请求头；Cookie 已经写入浏览器的 Cookie 存储，这里不再设置
+func (b *lighthouseAuthBrowser) requestHeaders(rawURL string, original map[string]string) []cdpHeader {
+	u, err := url.Parse(rawURL)
+	if err != nil || !b.auth.appliesTo(u) {
+		return nil
+	}
+
+	headers := make([]cdpHeader, 0, len(original)+len(b.auth.header))
+	for name, value := range original {
+		if _, ok := b.auth.header[http.CanonicalHeaderKey(name)]; !ok {
+			headers = append(headers, cdpHeader{Name: name, Value: value})
+		}
+	}
+	for _, name := range b.auth.headerNames() {
+		if name != "Cookie" {
+			headers = append(headers, cdpHeader{Name: name, Value: strings.Join(b.auth.header[name], ", ")})
+		}
+	}
+	return headers
+}
+
+// Close 断开 DevTools 连接、终止 Chrome 并删除临时用户目录
+func (b *lighthouseAuthBrowser) Close() {
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	if b.done != nil {
+		<-b.done
+	}
+	if b.cmd.Cancel != nil {
+		b.cmd.Cancel()
+	} else if b.cmd.Process != nil {
+		b.cmd.Process.Kill()
+	}
+	b.cmd.Wait()
+	os.RemoveAll(b.dataDir)
+}
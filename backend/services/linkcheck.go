@@ -0,0 +1,585 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 链接检查后端（环境变量 LINK_CHECKER_BACKEND）
+const (
+	LinkCheckerNative = "native" // 进程内 Go 实现（默认）
+	LinkCheckerHttpx  = "httpx"  // ProjectDiscovery httpx 命令行工具
+)
+
+// linkCheckUserAgent 链接检查使用的 User-Agent
+const linkCheckUserAgent = "Mozilla/5.0 (compatible; WebCheckly-LinkChecker/1.0)"
+
+// linkCheckSkippedError 到达截止时间前没有检查完的链接的说明
+const linkCheckSkippedError = "not checked: link check time limit reached"
+
+// soft404TitlePattern 页面标题或主标题包含“未找到”类文字时视为软 404
+var soft404TitlePattern = regexp.MustCompile(`(?i)\b404\b|not\s+found|page\s+(does\s+not|doesn't)\s+exist|no\s+longer\s+available|页面不存在|找不到|未找到|页面已删除`)
+
+// LinkCheckerBackend 当前使用的链接检查后端
+func LinkCheckerBackend() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("LINK_CHECKER_BACKEND")), LinkCheckerHttpx) {
+		return LinkCheckerHttpx
+	}
+	return LinkCheckerNative
+}
+
+// RunLinkCheck 检查链接健康状态，结果逐条写入 output，结束后关闭 output
+// 根据 LINK_CHECKER_BACKEND 选择进程内检查器或 httpx；指向内网地址的链接在检查前过滤；
+// auth 不为 nil 时同站链接携带认证信息检查
+func RunLinkCheck(ctx context.Context, urls []string, output chan models.HttpxResult, auth *ScanAuthSession) error {
+	urls = filterPublicURLs(urls)
+
+	if LinkCheckerBackend() == LinkCheckerHttpx {
+		return runHttpxWithAuth(ctx, urls, output, auth)
+	}
+	cfg := DefaultLinkCheckConfig()
+	cfg.Auth = auth
+	return NewLinkChecker(cfg).Run(ctx, urls, output)
+}
+
+// runHttpxWithAuth 认证扫描时分两批运行 httpx：同站链接携带认证请求头，其余链接匿名检查
+// httpx 的 -H 会附加到所有请求，不能把认证信息发送给外部网站
+func runHttpxWithAuth(ctx context.Context, urls []string, output chan models.HttpxResult, auth *ScanAuthSession) error {
+	if auth == nil {
+		return RunHttpx(ctx, urls, output)
+	}
+	defer close(output)
+
+	var siteURLs, otherURLs []string
+	for _, rawURL := range urls {
+		if u, err := url.Parse(rawURL); err == nil && auth.appliesTo(u) {
+			siteURLs = append(siteURLs, rawURL)
+		} else {
+			otherURLs = append(otherURLs, rawURL)
+		}
+	}
+
+	var firstErr error
+	for _, batch := range []struct {
+		urls []string
+		args []string
+	}{
+		{siteURLs, auth.HeaderArgs("-H")},
+		{otherURLs, nil},
+	} {
+		if len(batch.urls) == 0 || ctx.Err() != nil {
+			continue
+		}
+		results := make(chan models.HttpxResult, 100)
+		done := make(chan error, 1)
+		go func() {
+			done <- RunHttpx(ctx, batch.urls, results, batch.args...)
+		}()
+		for result := range results {
+			output <- result
+		}
+		if err := <-done; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// LinkCheckConfig 进程内链接检查器配置
+type LinkCheckConfig struct {
+	Concurrency        int               // 全局并发数
+	PerHostConcurrency int               // 单个主机的并发数
+	PerHostInterval    time.Duration     // 同一主机相邻两次请求的最小间隔
+	Timeout            time.Duration     // 单次请求超时
+	Retries            int               // 网络错误和 429/5xx 的重试次数
+	RetryBackoff       time.Duration     // 首次重试前的等待时间（之后每次翻倍）
+	MaxRedirects       int               // 最多跟随的重定向次数
+	MaxBodyBytes       int64             // 读取 HTML 的大小上限（用于标题、软 404 和锚点检查）
+	Transport          http.RoundTripper // 为空时使用 utils.SafeTransport（连接时拒绝内网地址）
+	Auth               *ScanAuthSession  // 认证扫描的认证信息（只附加到同站请求，每次重定向单独判断）
+}
+
+// DefaultLinkCheckConfig 默认配置，单主机并发和速率可通过环境变量
+// LINK_CHECK_HOST_CONCURRENCY 和 LINK_CHECK_HOST_RATE（每秒请求数）调整
+// 同一主机每秒最多 20 个请求：常见页面（数百个链接，大部分指向本站）在 link-health 插件的时间内（60 秒，含链接提取）可以检查完
+func DefaultLinkCheckConfig() LinkCheckConfig {
+	cfg := LinkCheckConfig{
+		Concurrency:        30,
+		PerHostConcurrency: 6,
+		PerHostInterval:    50 * time.Millisecond,
+		Timeout:            10 * time.Second,
+		Retries:            1,
+		RetryBackoff:       500 * time.Millisecond,
+		MaxRedirects:       10,
+		MaxBodyBytes:       2 << 20,
+	}
+	if value, err := strconv.Atoi(os.Getenv("LINK_CHECK_HOST_CONCURRENCY")); err == nil && value > 0 {
+		cfg.PerHostConcurrency = value
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("LINK_CHECK_HOST_RATE"), 64); err == nil && value > 0 {
+		cfg.PerHostInterval = time.Duration(float64(time.Second) / value)
+	}
+	return cfg
+}
+
+// LinkChecker 进程内链接检查器，输出与 httpx 后端相同的 models.HttpxResult
+// 资源链接先发 HEAD，失败或是 HTML 时再发 GET；页面链接需要读取内容，直接发 GET；手动跟随重定向以记录重定向链；
+// 同一页面（忽略 #fragment）只请求一次，再逐个校验锚点
+type LinkChecker struct {
+	cfg    LinkCheckConfig
+	client *http.Client
+
+	hostsMu sync.Mutex
+	hosts   map[string]*hostLimiter
+
+	pagesMu sync.Mutex
+	pages   map[string]*linkPage
+}
+
+// hostLimiter 单个主机的并发和速率限制
+type hostLimiter struct {
+	sem  chan struct{}
+	mu   sync.Mutex
+	next time.Time
+}
+
+// linkPage 一个页面（不含 fragment）的检查结果，同一页面的多个锚点链接共享
+type linkPage struct {
+	once    sync.Once
+	result  models.HttpxResult
+	isHTML  bool
+	anchors map[string]bool
+}
+
+// NewLinkChecker 创建进程内链接检查器
+func NewLinkChecker(cfg LinkCheckConfig) *LinkChecker {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.PerHostConcurrency <= 0 {
+		cfg.PerHostConcurrency = 1
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = utils.SafeTransport
+	}
+	return &LinkChecker{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			// 重定向由 followRedirects 手动处理，以记录完整的重定向链
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		hosts: make(map[string]*hostLimiter),
+		pages: make(map[string]*linkPage),
+	}
+}
+
+// Run 检查全部链接，结果逐条写入 output，结束后关闭 output（与 RunHttpx 的约定一致）
+// ctx 到达截止时间时不再发起新的检查，已经得到的结果照常输出，其余链接输出为 Skipped 并返回 nil（部分结果）；
+// ctx 被取消时同样输出，但返回 ctx.Err()
+func (lc *LinkChecker) Run(ctx context.Context, urls []string, output chan models.HttpxResult) error {
+	defer close(output)
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	workers := lc.cfg.Concurrency
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rawURL := range jobs {
+				output <- lc.Check(ctx, rawURL)
+			}
+		}()
+	}
+
+	sent := 0
+feed:
+	for _, rawURL := range urls {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- rawURL:
+			sent++
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, rawURL := range urls[sent:] {
+		output <- models.HttpxResult{URL: rawURL, Skipped: true, Error: linkCheckSkippedError}
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("[LinkCheck] Time limit reached, dispatched %d of %d URLs (%d pages), returning partial results", sent, len(urls), len(lc.pages))
+		return nil
+	}
+	log.Printf("[LinkCheck] Checked %d URLs (%d pages)", len(urls), len(lc.pages))
+	return ctx.Err()
+}
+
+// Check 检查单个链接
+func (lc *LinkChecker) Check(ctx context.Context, rawURL string) models.HttpxResult {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return models.HttpxResult{URL: rawURL, Error: fmt.Sprintf("invalid url: %v", err)}
+	}
+	fragment := parsed.Fragment
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	pageURL := parsed.String()
+
+	lc.pagesMu.Lock()
+	page, ok := lc.pages[pageURL]
+	if !ok {
+		page = &linkPage{}
+		lc.pages[pageURL] = page
+	}
+	lc.pagesMu.Unlock()
+
+	page.once.Do(func() {
+		lc.checkPage(ctx, pageURL, isLikelyPage(parsed), page)
+	})
+
+	result := page.result
+	result.URL = rawURL
+	if fragment != "" && page.isHTML && result.StatusCode > 0 && result.StatusCode < 400 && !anchorExists(page.anchors, fragment) {
+		result.AnchorMissing = true
+	}
+	return result
+}
+
+// checkPage 请求页面：likelyPage 为 false 时先发 HEAD，成功且不是 HTML 时直接返回，否则用 GET 获取状态和页面内容
+// 请求因 ctx 结束而中断时结果为 Skipped
+func (lc *LinkChecker) checkPage(ctx context.Context, pageURL string, likelyPage bool, page *linkPage) {
+	start := time.Now()
+
+	var (
+		resp     *http.Response
+		chain    []string
+		remoteIP string
+		err      error
+	)
+	method := http.MethodGet
+	if !likelyPage {
+		resp, chain, remoteIP, err = lc.followRedirects(ctx, http.MethodHead, pageURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		// HEAD 失败（部分服务器对 HEAD 返回 403/404/405）或需要读取页面内容时改用 GET
+		if err == nil && resp.StatusCode < 400 && !isHTMLResponse(resp) {
+			method = http.MethodHead
+		}
+	}
+	if method == http.MethodGet {
+		resp, chain, remoteIP, err = lc.followRedirects(ctx, http.MethodGet, pageURL)
+	}
+	if err != nil && ctx.Err() != nil {
+		page.result = models.HttpxResult{URL: pageURL, Skipped: true, Error: linkCheckSkippedError}
+		return
+	}
+
+	result := models.HttpxResult{
+		URL:           pageURL,
+		Method:        method,
+		RedirectChain: chain,
+		IP:            remoteIP,
+		ResponseTime:  int(time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		page.result = result
+		return
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.TLS = resp.Request.URL.Scheme == "https"
+	result.CDN = isCDNResponse(resp.Header)
+	if len(chain) > 0 {
+		result.FinalURL = resp.Request.URL.String()
+	}
+
+	if method == http.MethodGet && isHTMLResponse(resp) {
+		doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, lc.cfg.MaxBodyBytes))
+		if err == nil {
+			page.isHTML = true
+			page.anchors = collectAnchors(doc)
+			result.Title = strings.TrimSpace(doc.Find("title").First().Text())
+			if resp.StatusCode == http.StatusOK {
+				result.Soft404 = isSoft404(resp.Request.URL, pageURL, chain, result.Title, strings.TrimSpace(doc.Find("h1").First().Text()))
+			}
+		}
+	}
+	page.result = result
+}
+
+// isLikelyPage 链接是否像页面（路径没有扩展名或是常见的页面扩展名）
+func isLikelyPage(u *url.URL) bool {
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case "", ".html", ".htm", ".xhtml", ".shtml", ".php", ".asp", ".aspx", ".jsp":
+		return true
+	}
+	return false
+}
+
+// followRedirects 发送请求并手动跟随重定向，返回最终响应、重定向链（不含起始地址）和最终连接的 IP
+func (lc *LinkChecker) followRedirects(ctx context.Context, method, rawURL string) (*http.Response, []string, string, error) {
+	var chain []string
+	current := rawURL
+	for {
+		resp, remoteIP, err := lc.doWithRetry(ctx, method, current)
+		if err != nil {
+			return nil, chain, remoteIP, err
+		}
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			return resp, chain, remoteIP, nil
+		}
+		resp.Body.Close()
+
+		if len(chain) >= lc.cfg.MaxRedirects {
+			return nil, chain, remoteIP, fmt.Errorf("stopped after %d redirects", lc.cfg.MaxRedirects)
+		}
+		next, err := resp.Request.URL.Parse(location)
+		if err != nil {
+			return nil, chain, remoteIP, fmt.Errorf("invalid redirect location %q: %w", location, err)
+		}
+		if next.Scheme != "http" && next.Scheme != "https" {
+			return nil, chain, remoteIP, fmt.Errorf("redirect to unsupported scheme %q", next.Scheme)
+		}
+		next.Fragment = ""
+		current = next.String()
+		chain = append(chain, current)
+	}
+}
+
+// doWithRetry 发送单个请求，网络错误和 429/502/503/504 按指数退避重试
+func (lc *LinkChecker) doWithRetry(ctx context.Context, method, rawURL string) (*http.Response, string, error) {
+	remoteIP := ""
+	for attempt := 0; ; attempt++ {
+		resp, ip, err := lc.do(ctx, method, rawURL)
+		if ip != "" {
+			remoteIP = ip
+		}
+		retryable := err != nil && !errors.Is(err, utils.ErrPrivateAddress) && ctx.Err() == nil
+		if err == nil {
+			retryable = isRetryableStatus(resp.StatusCode)
+		}
+		if !retryable || attempt >= lc.cfg.Retries {
+			return resp, remoteIP, err
+		}
+
+		wait := lc.cfg.RetryBackoff << attempt
+		if err == nil {
+			if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > wait {
+				wait = retryAfter
+			}
+			resp.Body.Close()
+		}
+		// 加入随机抖动，避免同一主机的并发请求同时重试
+		wait += time.Duration(rand.Int63n(int64(wait)/4 + 1))
+		select {
+		case <-ctx.Done():
+			return nil, remoteIP, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// do 在主机限速下发送单个请求，返回响应和连接的 IP
+func (lc *LinkChecker) do(ctx context.Context, method, rawURL string) (*http.Response, string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	lc.cfg.Auth.Apply(req)
+
+	remoteIP := ""
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if addr := info.Conn.RemoteAddr(); addr != nil {
+				remoteIP, _, _ = net.SplitHostPort(addr.String())
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	limiter := lc.hostLimiter(req.URL.Host)
+	if err := limiter.acquire(ctx, lc.cfg.PerHostInterval); err != nil {
+		return nil, "", err
+	}
+	defer limiter.release()
+
+	resp, err := lc.client.Do(req)
+	return resp, remoteIP, err
+}
+
+// hostLimiter 获取主机对应的限速器
+func (lc *LinkChecker) hostLimiter(host string) *hostLimiter {
+	host = strings.ToLower(host)
+	lc.hostsMu.Lock()
+	defer lc.hostsMu.Unlock()
+	limiter, ok := lc.hosts[host]
+	if !ok {
+		limiter = &hostLimiter{sem: make(chan struct{}, lc.cfg.PerHostConcurrency)}
+		lc.hosts[host] = limiter
+	}
+	return limiter
+}
+
+// acquire 占用一个主机并发名额，并等待到与上一次请求间隔 interval 之后
+func (h *hostLimiter) acquire(ctx context.Context, interval time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case h.sem <- struct{}{}:
+	}
+
+	h.mu.Lock()
+	now := time.Now()
+	if h.next.Before(now) {
+		h.next = now
+	}
+	wait := h.next.Sub(now)
+	h.next = h.next.Add(interval)
+	h.mu.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-ctx.Done():
+			<-h.sem
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil
+}
+
+// release 释放主机并发名额
+func (h *hostLimiter) release() {
+	<-h.sem
+}
+
+// isRetryableStatus 是否为可重试的状态码
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter 解析 Retry-After（秒数或 HTTP 日期），最长等待 5 秒
+func parseRetryAfter(value string) time.Duration {
+	const maxRetryAfter = 5 * time.Second
+	if value == "" {
+		return 0
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		wait = time.Until(at)
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait
+}
+
+// isHTMLResponse 响应是否为 HTML 页面
+func isHTMLResponse(resp *http.Response) bool {
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	return strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml")
+}
+
+// isCDNResponse 根据常见 CDN 的响应头判断是否经过 CDN
+func isCDNResponse(header http.Header) bool {
+	for _, name := range []string{"CF-Ray", "X-Amz-Cf-Id", "X-Akamai-Transformed", "X-Fastly-Request-ID", "X-Azure-Ref", "X-Cdn", "Cdn-Cache"} {
+		if header.Get(name) != "" {
+			return true
+		}
+	}
+	server := strings.ToLower(header.Get("Server"))
+	via := strings.ToLower(header.Get("Via"))
+	return strings.Contains(server, "cloudflare") || strings.Contains(server, "akamai") ||
+		strings.Contains(via, "cloudfront") || strings.Contains(via, "varnish") || strings.Contains(header.Get("X-Served-By"), "cache-")
+}
+
+// isSoft404 返回 200 但实际是“页面不存在”的页面：
+// 标题或主标题包含“未找到”类文字，或非首页的链接被重定向到了网站首页
+func isSoft404(finalURL *url.URL, pageURL string, chain []string, title, heading string) bool {
+	if soft404TitlePattern.MatchString(title) || soft404TitlePattern.MatchString(heading) {
+		return true
+	}
+	if len(chain) == 0 {
+		return false
+	}
+	original, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	isRoot := func(u *url.URL) bool { return (u.Path == "" || u.Path == "/") && u.RawQuery == "" }
+	return !isRoot(original) && isRoot(finalURL)
+}
+
+// collectAnchors 收集页面中可作为 #fragment 目标的 id 和 <a name>
+func collectAnchors(doc *goquery.Document) map[string]bool {
+	anchors := make(map[string]bool)
+	doc.Find("[id]").Each(func(_ int, s *goquery.Selection) {
+		if id, ok := s.Attr("id"); ok && id != "" {
+			anchors[id] = true
+		}
+	})
+	doc.Find("a[name]").Each(func(_ int, s *goquery.Selection) {
+		if name, ok := s.Attr("name"); ok && name != "" {
+			anchors[name] = true
+		}
+	})
+	return anchors
+}
+
+// anchorExists 锚点是否存在于页面中
+// #top 始终有效；#! 和 #/ 开头的是单页应用路由而非锚点，不做检查
+func anchorExists(anchors map[string]bool, fragment string) bool {
+	if strings.EqualFold(fragment, "top") || strings.HasPrefix(fragment, "!") || strings.HasPrefix(fragment, "/") {
+		return true
+	}
+	if anchors[fragment] {
+		return true
+	}
+	if decoded, err := url.PathUnescape(fragment); err == nil && anchors[decoded] {
+		return true
+	}
+	return false
+}
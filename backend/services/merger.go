@@ -42,7 +42,7 @@ func IsDeepCheckMode(pluginNames []string) bool {
 			hasKatana = true
 		}
 		// 检查是否有其他网站链接深度检查工具（排除基础工具）
-		if name != "katana" && name != "website-info" && name != "domain-info" && name != "ssl-info" && name != "tech-stack" && name != "link-health" {
+		if name != "katana" && name != "website-info" && name != "domain-info" && name != "ssl-info" && name != "tech-stack" && name != "email-security" && name != "js-libraries" && name != "cookies" && name != "link-health" {
 			hasOtherDeepTools = true
 		}
 	}
@@ -0,0 +1,468 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"web-checkly/models"
+	"web-checkly/utils"
+)
+
+// 多页面审计参数
+const (
+	defaultPageAuditBudget = 5                 // 默认最多审计的页面数（含首页）
+	pageAuditPageTimeout   = 120 * time.Second // 单个页面的 Lighthouse 超时（剩余时间不足时按剩余页面平均分配）
+	pageAuditMinPageTime   = 30 * time.Second  // 每个审计页面至少预留的时间（按剩余时间限制页面预算）
+	pageAuditDiscoveryTime = 60 * time.Second  // 读取 sitemap 预留的时间
+	maxSitemapFiles        = 10                // 最多请求的 sitemap 文件数（含 sitemap 索引）
+	maxSitemapURLs         = 5000              // 最多收集的页面数
+	maxSitemapBytes        = 10 << 20          // 单个 sitemap（解压后）的大小上限
+
+	// maxPageAuditBudget PAGE_AUDIT_MAX_PAGES 的上限：单个任务的最长执行时间内，读取 sitemap 后每个页面至少有 pageAuditMinPageTime
+	maxPageAuditBudget = int((taskExecutionTimeout - pageAuditDiscoveryTime) / pageAuditMinPageTime)
+)
+
+// sitemapClient 读取 robots.txt 和 sitemap 的 HTTP 客户端（连接和重定向到内网地址时拒绝）
+var sitemapClient = utils.NewSafeHTTPClient(15 * time.Second)
+
+// sitemapDocument sitemap 文件（urlset 或 sitemapindex，不区分命名空间）
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// pageExtensions 可以作为页面审计的路径扩展名（无扩展名的路径也视为页面）
+var pageExtensions = map[string]bool{
+	".html": true, ".htm": true, ".shtml": true, ".php": true,
+	".asp": true, ".aspx": true, ".jsp": true,
+}
+
+// PageAuditBudget 多页面审计最多审计的页面数（环境变量 PAGE_AUDIT_MAX_PAGES，默认 5，最多 maxPageAuditBudget）
+func PageAuditBudget() int {
+	if value, err := strconv.Atoi(os.Getenv("PAGE_AUDIT_MAX_PAGES")); err == nil && value > 0 {
+		if value > maxPageAuditBudget {
+			return maxPageAuditBudget
+		}
+		return value
+	}
+	return defaultPageAuditBudget
+}
+
+// PageAuditTimeout 多页面审计插件的超时：读取 sitemap 的时间加每个页面的 Lighthouse 超时，不超过单个任务的最长执行时间
+func PageAuditTimeout() time.Duration {
+	timeout := pageAuditDiscoveryTime + time.Duration(PageAuditBudget())*pageAuditPageTimeout
+	if timeout > taskExecutionTimeout {
+		timeout = taskExecutionTimeout
+	}
+	return timeout
+}
+
+// pageAuditBudgetFor 按 ctx 的剩余时间限制页面预算，保证每个页面至少有 pageAuditMinPageTime（至少审计首页）
+func pageAuditBudgetFor(ctx context.Context) int {
+	budget := PageAuditBudget()
+	if deadline, ok := ctx.Deadline(); ok {
+		if fit := int(time.Until(deadline) / pageAuditMinPageTime); fit < budget {
+			budget = fit
+		}
+	}
+	if budget < 1 {
+		budget = 1
+	}
+	return budget
+}
+
+// pageAuditTimeoutFor 单个页面的 Lighthouse 超时：ctx 的剩余时间按未审计的页面平均分配，不超过 pageAuditPageTimeout
+func pageAuditTimeoutFor(ctx context.Context, remainingPages int) time.Duration {
+	timeout := pageAuditPageTimeout
+	if deadline, ok := ctx.Deadline(); ok && remainingPages > 0 {
+		if share := time.Until(deadline) / time.Duration(remainingPages); share < timeout {
+			timeout = share
+		}
+	}
+	return timeout
+}
+
+// RunPageAudits 多页面审计：从 sitemap（没有时从首页链接）中按 URL 模板抽样，逐个运行 Lighthouse
+// 首页总是第一个审计的页面；部分页面审计失败时返回其余页面的结果，全部失败时返回错误；
+// auth 不为 nil 时以认证身份提取首页链接和运行 Lighthouse（sitemap 仍匿名读取）
+func RunPageAudits(ctx context.Context, target string, lang string, taskID string, taskManager interface{}, auth *ScanAuthSession) (*models.PageAuditResult, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Hostname() == "" {
+		return nil, fmt.Errorf("invalid target url: %s", target)
+	}
+
+	result := &models.PageAuditResult{
+		Source: models.PageAuditSourceSitemap,
+	}
+
+	pages, sitemaps := discoverSitemapPages(ctx, targetURL)
+	result.Sitemaps = sitemaps
+	if len(pages) == 0 {
+		log.Printf("[PageAudit] No sitemap pages found for %s, sampling homepage links", target)
+		result.Source = models.PageAuditSourceLinks
+		links, err := ExtractPageUrlsGoQuery(target, auth)
+		if err != nil {
+			log.Printf("[PageAudit] Failed to extract homepage links for %s: %v", target, err)
+		}
+		pages = filterSitePages(targetURL, links)
+	}
+	result.DiscoveredURLs = len(pages)
+
+	// 页面预算按读取 sitemap 后的剩余时间计算，避免抽样的页面超出任务的执行时间
+	result.PageBudget = pageAuditBudgetFor(ctx)
+	result.Pages, result.Templates = samplePagesByTemplate(targetURL, pages, result.PageBudget)
+	log.Printf("[PageAudit] Auditing %d pages (%d templates, %d discovered URLs) for %s",
+		len(result.Pages), result.Templates, result.DiscoveredURLs, target)
+
+	// 实时更新模块进度（已审计页面数 / 计划审计页面数）
+	type progressUpdater interface {
+		UpdateModuleProgress(taskID, moduleName string, current, total int) error
+	}
+	tm, _ := taskManager.(progressUpdater)
+
+	for i := range result.Pages {
+		page := &result.Pages[i]
+		if ctx.Err() != nil {
+			page.Error = "skipped: " + ctx.Err().Error()
+			continue
+		}
+		auditPage(ctx, page, lang, auth, pageAuditTimeoutFor(ctx, len(result.Pages)-i))
+		if tm != nil && taskID != "" {
+			if err := tm.UpdateModuleProgress(taskID, "sitemap-audit", i+1, len(result.Pages)); err != nil {
+				log.Printf("[PageAudit] Failed to update progress for task %s: %v", taskID, err)
+			}
+		}
+	}
+
+	result.Aggregate = aggregatePageAudits(result.Pages)
+	if result.Aggregate.Audited == 0 {
+		return nil, fmt.Errorf("all %d page audits failed: %s", len(result.Pages), result.Pages[0].Error)
+	}
+	return result, nil
+}
+
+// auditPage 对单个页面运行 Lighthouse 并记录评分
+func auditPage(ctx context.Context, page *models.PageAudit, lang string, auth *ScanAuthSession, timeout time.Duration) {
+	pageCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report, err := RunLighthouseWithAuth(pageCtx, page.URL, lang, auth)
+	if err != nil {
+		log.Printf("[PageAudit] Lighthouse failed for %s: %v", page.URL, err)
+		page.Error = err.Error()
+		return
+	}
+
+	performance := ParsePerformanceMetrics(report)
+	page.Performance = performance.Score
+	page.LCP = performance.LCP
+	page.CLS = performance.CLS
+	page.TBT = performance.TBT
+	page.SEO = ParseSEOCompliance(report, "").Score
+	page.Accessibility = ParseAccessibilityInfo(report, lang).Score
+}
+
+// aggregatePageAudits 汇总审计成功的页面评分（平均、最低、最高及得分最低的页面）
+func aggregatePageAudits(pages []models.PageAudit) models.PageAuditAggregate {
+	aggregate := models.PageAuditAggregate{}
+	var performance, seo, accessibility []scoredPage
+	for _, page := range pages {
+		if page.Error != "" {
+			aggregate.Failed++
+			continue
+		}
+		aggregate.Audited++
+		performance = append(performance, scoredPage{page.URL, page.Performance})
+		seo = append(seo, scoredPage{page.URL, page.SEO})
+		accessibility = append(accessibility, scoredPage{page.URL, page.Accessibility})
+	}
+
+	aggregate.Performance = aggregateScores(performance)
+	aggregate.SEO = aggregateScores(seo)
+	aggregate.Accessibility = aggregateScores(accessibility)
+	return aggregate
+}
+
+type scoredPage struct {
+	url   string
+	score int
+}
+
+func aggregateScores(pages []scoredPage) *models.ScoreAggregate {
+	if len(pages) == 0 {
+		return nil
+	}
+	aggregate := &models.ScoreAggregate{Min: pages[0].score, Max: pages[0].score, WorstURL: pages[0].url}
+	total := 0
+	for _, page := range pages {
+		total += page.score
+		if page.score < aggregate.Min {
+			aggregate.Min = page.score
+			aggregate.WorstURL = page.url
+		}
+		if page.score > aggregate.Max {
+			aggregate.Max = page.score
+		}
+	}
+	aggregate.Average = (total + len(pages)/2) / len(pages)
+	return aggregate
+}
+
+// discoverSitemapPages 读取 robots.txt 中声明的 sitemap（没有声明时尝试 /sitemap.xml），展开 sitemap 索引，
+// 返回同站页面（按 sitemap 中的顺序去重）和读取过的 sitemap 地址
+func discoverSitemapPages(ctx context.Context, target *url.URL) ([]string, []string) {
+	root := &url.URL{Scheme: target.Scheme, Host: target.Host}
+
+	queue := robotsSitemaps(ctx, root)
+	if len(queue) == 0 {
+		queue = []string{root.String() + "/sitemap.xml"}
+	}
+
+	seenSitemaps := make(map[string]bool)
+	seenPages := make(map[string]bool)
+	var sitemaps, pages []string
+	for len(queue) > 0 && len(seenSitemaps) < maxSitemapFiles && len(pages) < maxSitemapURLs {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+		if seenSitemaps[sitemapURL] {
+			continue
+		}
+		seenSitemaps[sitemapURL] = true
+
+		doc, err := fetchSitemap(ctx, sitemapURL)
+		if err != nil {
+			log.Printf("[PageAudit] Failed to read sitemap %s: %v", sitemapURL, err)
+			continue
+		}
+		sitemaps = append(sitemaps, sitemapURL)
+
+		for _, child := range doc.Sitemaps {
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				queue = append(queue, loc)
+			}
+		}
+		for _, entry := range doc.URLs {
+			page, ok := sitePageURL(target, strings.TrimSpace(entry.Loc))
+			if !ok || seenPages[page] {
+				continue
+			}
+			seenPages[page] = true
+			pages = append(pages, page)
+			if len(pages) >= maxSitemapURLs {
+				break
+			}
+		}
+	}
+	return pages, sitemaps
+}
+
+// robotsSitemaps 读取 robots.txt 中的 Sitemap 声明
+func robotsSitemaps(ctx context.Context, root *url.URL) []string {
+	body, err := fetchSitemapBody(ctx, root.String()+"/robots.txt")
+	if err != nil {
+		return nil
+	}
+
+	var sitemaps []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > len("sitemap:") && strings.EqualFold(line[:len("sitemap:")], "sitemap:") {
+			if loc := strings.TrimSpace(line[len("sitemap:"):]); loc != "" {
+				sitemaps = append(sitemaps, loc)
+			}
+		}
+	}
+	return sitemaps
+}
+
+// fetchSitemap 下载并解析 sitemap（支持 gzip 压缩）
+func fetchSitemap(ctx context.Context, sitemapURL string) (*sitemapDocument, error) {
+	body, err := fetchSitemapBody(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip sitemap: %w", err)
+		}
+		body, err = io.ReadAll(io.LimitReader(reader, maxSitemapBytes))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip sitemap: %w", err)
+		}
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid sitemap xml: %w", err)
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("unexpected sitemap root element: %s", doc.XMLName.Local)
+	}
+	return &doc, nil
+}
+
+// fetchSitemapBody 下载 robots.txt 或 sitemap（只允许公网地址）
+func fetchSitemapBody(ctx context.Context, rawURL string) ([]byte, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid url: %s", rawURL)
+	}
+	if utils.IsPrivateIP(parsed.Hostname()) {
+		return nil, errors.New("private IP not allowed")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+
+	resp, err := sitemapClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSitemapBytes))
+}
+
+// filterSitePages 从链接中筛选同站页面（去重，保持顺序）
+func filterSitePages(target *url.URL, links []string) []string {
+	seen := make(map[string]bool)
+	pages := make([]string, 0, len(links))
+	for _, link := range links {
+		page, ok := sitePageURL(target, link)
+		if !ok || seen[page] {
+			continue
+		}
+		seen[page] = true
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// sitePageURL 判断链接是否为目标网站的页面（忽略 www. 前缀），返回去掉 fragment 的地址
+func sitePageURL(target *url.URL, link string) (string, bool) {
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", false
+	}
+	if strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.") != strings.TrimPrefix(strings.ToLower(target.Hostname()), "www.") {
+		return "", false
+	}
+	ext := strings.ToLower(path.Ext(parsed.Path))
+	if ext != "" && !pageExtensions[ext] {
+		return "", false
+	}
+	parsed.Fragment = ""
+	return parsed.String(), true
+}
+
+// pageURLTemplate 计算页面的 URL 模板：保留第一级路径（语言前缀如 /en/ 时保留前两级），
+// 更深的路径段和包含数字的路径段替换为 *，如 /blog/2024/hello → /blog/*/*、/products/123 → /products/*
+func pageURLTemplate(u *url.URL) string {
+	trimmed := strings.Trim(u.Path, "/")
+	if trimmed == "" {
+		return "/"
+	}
+
+	segments := strings.Split(trimmed, "/")
+	literal := 1
+	if isLocaleSegment(segments[0]) {
+		literal = 2
+	}
+	for i, segment := range segments {
+		if i >= literal || strings.ContainsAny(segment, "0123456789") {
+			segments[i] = "*"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// isLocaleSegment 判断路径段是否为语言前缀（如 en、zh-cn、pt_BR）
+func isLocaleSegment(segment string) bool {
+	segment = strings.ToLower(segment)
+	if len(segment) == 2 {
+		return segment[0] >= 'a' && segment[0] <= 'z' && segment[1] >= 'a' && segment[1] <= 'z'
+	}
+	if len(segment) == 5 && (segment[2] == '-' || segment[2] == '_') {
+		return isLocaleSegment(segment[:2]) && isLocaleSegment(segment[3:])
+	}
+	return false
+}
+
+// samplePagesByTemplate 按 URL 模板抽样：首页在前，其余模板按页面数从多到少各取第一个页面，总数不超过 budget
+// 返回抽样的页面和模板总数
+func samplePagesByTemplate(target *url.URL, pages []string, budget int) ([]models.PageAudit, int) {
+	type templateGroup struct {
+		template string
+		first    string
+		count    int
+	}
+
+	homeTemplate := pageURLTemplate(target)
+	groups := make(map[string]*templateGroup)
+	order := make([]*templateGroup, 0)
+	for _, page := range pages {
+		parsed, err := url.Parse(page)
+		if err != nil {
+			continue
+		}
+		template := pageURLTemplate(parsed)
+		group, ok := groups[template]
+		if !ok {
+			group = &templateGroup{template: template, first: page}
+			groups[template] = group
+			order = append(order, group)
+		}
+		group.count++
+	}
+
+	homePages := 1
+	if group, ok := groups[homeTemplate]; ok {
+		homePages = group.count
+	}
+	sampled := []models.PageAudit{{URL: target.String(), Template: homeTemplate, TemplatePages: homePages}}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].count > order[j].count
+	})
+	for _, group := range order {
+		if len(sampled) >= budget {
+			break
+		}
+		if group.template == homeTemplate {
+			continue
+		}
+		sampled = append(sampled, models.PageAudit{URL: group.first, Template: group.template, TemplatePages: group.count})
+	}
+
+	templates := len(groups)
+	if _, ok := groups[homeTemplate]; !ok {
+		templates++
+	}
+	return sampled, templates
+}
@@ -14,9 +14,10 @@ type BasePlugin struct {
 	timeout      time.Duration
 	isAsync      bool
 	dependencies []string
+	options      []string
 }
 
-// NewBasePlugin 创建基础插件
+// NewBasePlugin 创建基础插件（默认由与插件同名的任务选项启用，见 WithOptions）
 func NewBasePlugin(name string, timeout time.Duration, isAsync bool, dependencies []string) *BasePlugin {
 	if timeout <= 0 {
 		timeout = 60 * time.Second // 默认超时60秒
@@ -26,9 +27,16 @@ func NewBasePlugin(name string, timeout time.Duration, isAsync bool, dependencie
 		timeout:      timeout,
 		isAsync:      isAsync,
 		dependencies: dependencies,
+		options:      []string{name},
 	}
 }
 
+// WithOptions 设置启用插件的任务选项（不传选项表示插件不由任务选项启用）
+func (p *BasePlugin) WithOptions(options ...string) *BasePlugin {
+	p.options = options
+	return p
+}
+
 // Name 返回插件名称
 func (p *BasePlugin) Name() string {
 	return p.name
@@ -49,6 +57,21 @@ func (p *BasePlugin) Dependencies() []string {
 	return p.dependencies
 }
 
+// Options 返回启用插件的任务选项
+func (p *BasePlugin) Options() []string {
+	return p.options
+}
+
+// Selected 任务选中了任意一个启用插件的选项时执行插件
+func (p *BasePlugin) Selected(options []string) bool {
+	for _, option := range p.options {
+		if HasOption(options, option) {
+			return true
+		}
+	}
+	return false
+}
+
 // ExecuteWithTimeout 带超时控制的执行包装
 func ExecuteWithTimeout(ctx context.Context, plugin Plugin, input *PluginInput, executeFunc func(context.Context, *PluginInput) (*PluginOutput, error)) (*PluginOutput, error) {
 	// 创建带超时的上下文
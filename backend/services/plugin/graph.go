@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDependencyCycle 插件依赖存在环
+var ErrDependencyCycle = errors.New("plugin dependency cycle detected")
+
+// upstreamOptionPrefix 上游插件输出在下游 PluginInput.Options 中的键前缀
+const upstreamOptionPrefix = "upstream:"
+
+// UpstreamOptionKey 返回上游插件输出数据在 PluginInput.Options 中的键
+// 执行器会把已成功的依赖插件的 PluginOutput.Data 以该键写入下游插件的 Options
+func UpstreamOptionKey(pluginName string) string {
+	return upstreamOptionPrefix + pluginName
+}
+
+// ExecutionGraph 插件执行图（有向无环图）
+// 只包含本次任务选中的插件；依赖未被选中时忽略该依赖（依赖均为可选依赖）
+type ExecutionGraph struct {
+	order      []string            // 拓扑序
+	deps       map[string][]string // 插件 -> 本次执行中生效的依赖
+	dependents map[string][]string // 插件 -> 依赖它的插件
+}
+
+// BuildExecutionGraph 根据注册表中插件声明的 Dependencies() 构建执行图
+// 未注册的插件作为无依赖节点保留（执行时会失败），存在环时返回 ErrDependencyCycle
+func BuildExecutionGraph(names []string) (*ExecutionGraph, error) {
+	graph := &ExecutionGraph{
+		deps:       make(map[string][]string),
+		dependents: make(map[string][]string),
+	}
+
+	selected := make(map[string]bool, len(names))
+	nodes := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "" || selected[name] {
+			continue
+		}
+		selected[name] = true
+		nodes = append(nodes, name)
+	}
+
+	inDegree := make(map[string]int, len(nodes))
+	for _, name := range nodes {
+		inDegree[name] = 0
+		p, err := GetPlugin(name)
+		if err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, dep := range p.Dependencies() {
+			if !selected[dep] || seen[dep] {
+				continue
+			}
+			if dep == name {
+				return nil, fmt.Errorf("%w: %s -> %s", ErrDependencyCycle, name, name)
+			}
+			seen[dep] = true
+			graph.deps[name] = append(graph.deps[name], dep)
+			graph.dependents[dep] = append(graph.dependents[dep], name)
+			inDegree[name]++
+		}
+	}
+
+	// Kahn 拓扑排序，同一层级保持输入顺序
+	queue := make([]string, 0, len(nodes))
+	for _, name := range nodes {
+		if inDegree[name] == 0 {
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		graph.order = append(graph.order, name)
+		for _, next := range graph.dependents[name] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if len(graph.order) != len(nodes) {
+		cyclic := make([]string, 0)
+		for _, name := range nodes {
+			if inDegree[name] > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cyclic, ", "))
+	}
+
+	return graph, nil
+}
+
+// Order 返回插件的拓扑序（依赖总是排在被依赖者之后）
+func (g *ExecutionGraph) Order() []string {
+	return g.order
+}
+
+// Dependencies 返回插件在本次执行中生效的依赖
+func (g *ExecutionGraph) Dependencies(name string) []string {
+	return g.deps[name]
+}
+
+// Dependents 返回依赖指定插件的下游插件
+func (g *ExecutionGraph) Dependents(name string) []string {
+	return g.dependents[name]
+}
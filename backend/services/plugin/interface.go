@@ -43,6 +43,18 @@ type Plugin interface {
 	Timeout() time.Duration
 
 	// Dependencies 返回插件依赖的其他插件名称列表
-	// 执行器会确保依赖的插件先执行
+	// 执行器会确保依赖的插件先执行，并将依赖插件的输出数据以 UpstreamOptionKey(依赖名) 为键传入 Options
+	// 依赖未被任务选中或执行失败时，插件仍会执行（对应的键不存在）
 	Dependencies() []string
+
+	// Options 返回启用该插件的任务选项，任务选中其中任意一个时执行该插件
+	// 与插件名不同的选项是插件的子模块（如 lighthouse 的 performance、seo），在任务中有独立的模块状态
+	Options() []string
+
+	// Selected 根据本次执行的任务选项判断是否执行该插件
+	Selected(options []string) bool
+
+	// StoreResult 将插件成功执行的输出数据写入任务结果
+	// results 可能已包含之前执行或其他插件保存的结果，需要与之合并的插件在这里处理
+	StoreResult(task *models.Task, data interface{}, results *models.TaskResults)
 }
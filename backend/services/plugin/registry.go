@@ -2,13 +2,16 @@ package plugin
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
 var (
 	// pluginRegistry 插件注册表
 	pluginRegistry = make(map[string]Plugin)
-	registryMu     sync.RWMutex
+	// registryOrder 插件注册顺序（选择插件和聚合结果时按该顺序遍历）
+	registryOrder []string
+	registryMu    sync.RWMutex
 )
 
 // RegisterPlugin 注册插件
@@ -30,6 +33,7 @@ func RegisterPlugin(plugin Plugin) error {
 	}
 
 	pluginRegistry[name] = plugin
+	registryOrder = append(registryOrder, name)
 	return nil
 }
 
@@ -46,25 +50,61 @@ func GetPlugin(name string) (Plugin, error) {
 	return plugin, nil
 }
 
-// ListPlugins 列出所有已注册的插件
+// ListPlugins 按注册顺序列出所有已注册的插件
 func ListPlugins() []string {
 	registryMu.RLock()
 	defer registryMu.RUnlock()
 
-	names := make([]string, 0, len(pluginRegistry))
-	for name := range pluginRegistry {
-		names = append(names, name)
-	}
+	return append([]string{}, registryOrder...)
+}
 
+// SelectPlugins 按注册顺序返回任务选项启用的插件（见 Plugin.Selected）
+func SelectPlugins(options []string) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := []string{}
+	for _, name := range registryOrder {
+		if pluginRegistry[name].Selected(options) {
+			names = append(names, name)
+		}
+	}
 	return names
 }
 
+// SubModules 返回插件的子模块（Options 中与插件名不同的选项）
+func SubModules(plugin Plugin) []string {
+	modules := []string{}
+	for _, option := range plugin.Options() {
+		if option != plugin.Name() {
+			modules = append(modules, option)
+		}
+	}
+	return modules
+}
+
+// HasOption 检查任务选项中是否包含指定选项（不区分大小写）
+func HasOption(options []string, name string) bool {
+	for _, option := range options {
+		if strings.EqualFold(option, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // UnregisterPlugin 取消注册插件（主要用于测试）
 func UnregisterPlugin(name string) {
 	registryMu.Lock()
 	defer registryMu.Unlock()
 
 	delete(pluginRegistry, name)
+	for i, registered := range registryOrder {
+		if registered == name {
+			registryOrder = append(registryOrder[:i], registryOrder[i+1:]...)
+			break
+		}
+	}
 }
 
 // ClearRegistry 清空注册表（主要用于测试）
@@ -73,4 +113,5 @@ func ClearRegistry() {
 	defer registryMu.Unlock()
 
 	pluginRegistry = make(map[string]Plugin)
+	registryOrder = nil
 }
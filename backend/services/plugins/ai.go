@@ -10,7 +10,7 @@ import (
 )
 
 // AIPlugin AI 分析插件
-// 依赖其他模块的结果
+// 依赖其他模块的结果（由执行器通过 plugin.UpstreamOptionKey 传入）
 type AIPlugin struct {
 	*plugin.BasePlugin
 }
@@ -27,7 +27,12 @@ func NewAIPlugin() *AIPlugin {
 				"domain-info",
 				"ssl-info",
 				"tech-stack",
+				"email-security",
+				"js-libraries",
+				"cookies",
 				"link-health",
+				"lighthouse",
+				"katana",
 			},
 		),
 	}
@@ -59,38 +64,55 @@ func (p *AIPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*plu
 			aiInput.Mode = "balanced"
 		}
 
-		// 提取各模块结果
-		if websiteInfo, ok := options["website_info"].(*models.WebsiteInfo); ok {
+		// 提取各上游模块结果（未选中或执行失败的模块不会出现在选项中）
+		if websiteInfo, ok := options[plugin.UpstreamOptionKey("website-info")].(*models.WebsiteInfo); ok {
 			aiInput.WebsiteInfo = websiteInfo
 		}
-		if domainInfo, ok := options["domain_info"].(*models.DomainInfo); ok {
+		if domainInfo, ok := options[plugin.UpstreamOptionKey("domain-info")].(*models.DomainInfo); ok {
 			aiInput.DomainInfo = domainInfo
 		}
-		if sslInfo, ok := options["ssl_info"].(*models.SSLInfo); ok {
+		if sslInfo, ok := options[plugin.UpstreamOptionKey("ssl-info")].(*models.SSLInfo); ok {
 			aiInput.SSLInfo = sslInfo
 		}
-		if techStack, ok := options["tech_stack"].(*models.TechStack); ok {
+		if techStack, ok := options[plugin.UpstreamOptionKey("tech-stack")].(*models.TechStack); ok {
 			aiInput.TechStack = techStack
 		}
-		if results, ok := options["link_health"].([]models.HttpxResult); ok {
-			aiInput.Results = results
+		if emailSecurity, ok := options[plugin.UpstreamOptionKey("email-security")].(*models.EmailSecurityResult); ok {
+			aiInput.EmailSecurity = emailSecurity
 		}
-		if performance, ok := options["performance"].(*models.PerformanceMetrics); ok {
-			aiInput.Performance = performance
+		if cookies, ok := options[plugin.UpstreamOptionKey("cookies")].(*models.CookieAuditResult); ok {
+			aiInput.Cookies = cookies
 		}
-		if seo, ok := options["seo"].(*models.SEOCompliance); ok {
-			aiInput.SEO = seo
+
+		// 链接检查结果：优先使用 link-health，全站检查模式下使用 katana 的结果
+		if results, ok := options[plugin.UpstreamOptionKey("link-health")].([]models.HttpxResult); ok {
+			aiInput.Results = results
+		} else if katanaResults, ok := options[plugin.UpstreamOptionKey("katana")].([]services.KatanaResult); ok {
+			aiInput.Results = services.ConvertKatanaToHttpxResults(katanaResults)
 		}
-		if security, ok := options["security"].(*models.SecurityRisk); ok {
-			aiInput.Security = security
+		if aiInput.Results != nil {
+			aiInput.Summary = services.BuildScanSummary(aiInput.Results)
 		}
-		if accessibility, ok := options["accessibility"].(*models.AccessibilityInfo); ok {
-			aiInput.Accessibility = accessibility
+
+		// Lighthouse 子任务结果
+		if lighthouseData, ok := options[plugin.UpstreamOptionKey("lighthouse")].(map[string]interface{}); ok {
+			if performance, ok := lighthouseData["performance"].(*models.PerformanceMetrics); ok {
+				aiInput.Performance = performance
+			}
+			if seo, ok := lighthouseData["seo"].(*models.SEOCompliance); ok {
+				aiInput.SEO = seo
+			}
+			if security, ok := lighthouseData["security"].(*models.SecurityRisk); ok {
+				aiInput.Security = security
+			}
+			if accessibility, ok := lighthouseData["accessibility"].(*models.AccessibilityInfo); ok {
+				aiInput.Accessibility = accessibility
+			}
 		}
 
-		// 构建摘要
-		if summary, ok := options["summary"].(models.ScanSummary); ok {
-			aiInput.Summary = summary
+		// 存在已知漏洞的 JavaScript 库合并到安全风险中
+		if libraries, ok := options[plugin.UpstreamOptionKey("js-libraries")].([]models.VulnerableLibrary); ok {
+			aiInput.Security = services.MergeVulnerableLibraries(aiInput.Security, libraries, aiInput.Security != nil)
 		}
 
 		// 过滤重要指标，减少传递给AI的数据量
@@ -110,3 +132,10 @@ func (p *AIPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*plu
 		return plugin.CreateSuccessOutput(analysis, nil), nil
 	})
 }
+
+// StoreResult 将AI 分析结果写入任务结果
+func (p *AIPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.AIAnalysis); ok {
+		results.AIAnalysis = info
+	}
+}
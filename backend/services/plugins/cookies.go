@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"context"
+	"time"
+	"web-checkly/models"
+	"web-checkly/services"
+	"web-checkly/services/plugin"
+)
+
+// CookiesPlugin Cookie 安全审计插件
+// 同时选择 katana 时检查全站爬取发现的页面，否则只检查目标页面和它链接的同站页面
+type CookiesPlugin struct {
+	*plugin.BasePlugin
+}
+
+// NewCookiesPlugin 创建 Cookie 安全审计插件
+func NewCookiesPlugin() *CookiesPlugin {
+	return &CookiesPlugin{
+		BasePlugin: plugin.NewBasePlugin(
+			"cookies",
+			60*time.Second,     // 60秒超时（页面发现 + 请求页面）
+			false,              // 同步执行
+			[]string{"katana"}, // 可选依赖：使用全站爬取发现的页面
+		),
+	}
+}
+
+// Execute 执行 Cookie 安全审计
+func (p *CookiesPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+	if err := plugin.ValidateInput(input); err != nil {
+		return plugin.HandleError(p.Name(), err), err
+	}
+
+	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+		// 认证扫描时使用 options["auth"] 的认证信息
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		crawled, _ := input.Options[plugin.UpstreamOptionKey("katana")].([]services.KatanaResult)
+
+		result, err := services.CollectCookies(ctx, input.TargetURL, input.Language, crawled, auth)
+		if err != nil {
+			return plugin.HandleError(p.Name(), err), err
+		}
+
+		return plugin.CreateSuccessOutput(result, nil), nil
+	})
+}
+
+// StoreResult 将Cookie 审计结果写入任务结果
+func (p *CookiesPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.CookieAuditResult); ok {
+		results.Cookies = info
+	}
+}
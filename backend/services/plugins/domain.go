@@ -3,11 +3,13 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
 
 // DomainPlugin 域名信息插件
+// 可选依赖 ssl-info：用于比较 CAA 记录与当前证书的颁发者
 type DomainPlugin struct {
 	*plugin.BasePlugin
 }
@@ -17,9 +19,9 @@ func NewDomainPlugin() *DomainPlugin {
 	return &DomainPlugin{
 		BasePlugin: plugin.NewBasePlugin(
 			"domain-info",
-			10*time.Second, // 10秒超时
-			false,          // 同步执行
-			nil,            // 无依赖
+			45*time.Second,       // 45秒超时（DNSSEC、NS 一致性检查需要查询多个服务器）
+			false,                // 同步执行
+			[]string{"ssl-info"}, // 依赖 SSL 信息（可选）
 		),
 	}
 }
@@ -31,7 +33,8 @@ func (p *DomainPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (
 	}
 
 	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
-		info, err := services.CollectDomainInfo(input.TargetURL)
+		sslInfo, _ := input.Options[plugin.UpstreamOptionKey("ssl-info")].(*models.SSLInfo)
+		info, err := services.CollectDomainInfo(ctx, input.TargetURL, input.Language, sslInfo)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -39,3 +42,10 @@ func (p *DomainPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (
 		return plugin.CreateSuccessOutput(info, nil), nil
 	})
 }
+
+// StoreResult 将域名信息写入任务结果
+func (p *DomainPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.DomainInfo); ok {
+		results.DomainInfo = info
+	}
+}
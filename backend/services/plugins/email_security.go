@@ -0,0 +1,49 @@
+package plugins
+
+import (
+	"context"
+	"time"
+	"web-checkly/models"
+	"web-checkly/services"
+	"web-checkly/services/plugin"
+)
+
+// EmailSecurityPlugin 邮件安全插件（SPF、DMARC、DKIM、MTA-STS、TLS-RPT、BIMI）
+type EmailSecurityPlugin struct {
+	*plugin.BasePlugin
+}
+
+// NewEmailSecurityPlugin 创建邮件安全插件
+func NewEmailSecurityPlugin() *EmailSecurityPlugin {
+	return &EmailSecurityPlugin{
+		BasePlugin: plugin.NewBasePlugin(
+			"email-security",
+			30*time.Second, // 30秒超时（DNS 查询 + MTA-STS 策略文件）
+			false,          // 同步执行
+			nil,            // 无依赖
+		),
+	}
+}
+
+// Execute 执行邮件安全检测
+func (p *EmailSecurityPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+	if err := plugin.ValidateInput(input); err != nil {
+		return plugin.HandleError(p.Name(), err), err
+	}
+
+	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+		result, err := services.CollectEmailSecurity(ctx, input.TargetURL, input.Language)
+		if err != nil {
+			return plugin.HandleError(p.Name(), err), err
+		}
+
+		return plugin.CreateSuccessOutput(result, nil), nil
+	})
+}
+
+// StoreResult 将邮件安全检测结果写入任务结果
+func (p *EmailSecurityPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.EmailSecurityResult); ok {
+		results.EmailSecurity = info
+	}
+}
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 	"web-checkly/models"
@@ -9,7 +10,8 @@ import (
 	"web-checkly/services/plugin"
 )
 
-// HttpxPlugin Httpx 链接健康检查插件
+// HttpxPlugin 链接健康检查插件（进程内检查器或 httpx，见 services.RunLinkCheck）
+// 未通过 options["urls"] 指定 URL 列表时，先用 Katana 提取目标页面上的链接（depth=1，最多 20 秒）
 type HttpxPlugin struct {
 	*plugin.BasePlugin
 }
@@ -39,16 +41,11 @@ func (p *HttpxPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*
 			return plugin.HandleError(p.Name(), plugin.ErrInvalidInput), plugin.ErrInvalidInput
 		}
 
-		urlsInterface, ok := options["urls"]
-		if !ok {
-			return plugin.HandleError(p.Name(), plugin.ErrInvalidInput), plugin.ErrInvalidInput
-		}
-
 		// 转换 URL 列表
 		urls := []string{}
-		if urlList, ok := urlsInterface.([]string); ok {
+		if urlList, ok := options["urls"].([]string); ok {
 			urls = urlList
-		} else if urlList, ok := urlsInterface.([]interface{}); ok {
+		} else if urlList, ok := options["urls"].([]interface{}); ok {
 			for _, u := range urlList {
 				if urlStr, ok := u.(string); ok {
 					urls = append(urls, urlStr)
@@ -56,8 +53,20 @@ func (p *HttpxPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*
 			}
 		}
 
+		// 认证扫描的认证信息（由executor传递，为 nil 时匿名检查）
+		auth, _ := options["auth"].(*services.ScanAuthSession)
+
+		// 未指定 URL 列表：使用 Katana 提取当前页面的链接和资源（仅限当前页面，不进行深度爬取）
 		if len(urls) == 0 {
-			return plugin.HandleError(p.Name(), plugin.ErrInvalidInput), plugin.ErrInvalidInput
+			discovered, err := services.DiscoverPageURLs(ctx, input.TargetURL, input.TaskID, options["taskManager"], auth)
+			if err != nil {
+				return plugin.HandleError(p.Name(), err), err
+			}
+			if len(discovered) == 0 {
+				err := fmt.Errorf("no URLs found by Katana")
+				return plugin.HandleError(p.Name(), err), err
+			}
+			urls = discovered
 		}
 
 		// 创建结果 channel
@@ -73,23 +82,30 @@ func (p *HttpxPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*
 			done <- nil
 		}()
 
-		// 运行 Httpx（RunHttpx 内部会负责关闭 channel）
-		err := services.RunHttpx(ctx, urls, resultsChan)
+		// 运行链接检查（RunLinkCheck 内部会负责关闭 channel，后端由 LINK_CHECKER_BACKEND 决定）
+		// 进程内检查器到达插件超时时返回部分结果，未检查的链接标记为 Skipped
+		err := services.RunLinkCheck(ctx, urls, resultsChan, auth)
 
 		// 等待结果收集完成（等待 channel 被关闭）
 		<-done
 
 		if err != nil {
-			log.Printf("[Plugin:link-health] RunHttpx returned error: %v", err)
+			log.Printf("[Plugin:link-health] RunLinkCheck returned error: %v", err)
 			return plugin.HandleError(p.Name(), err), err
 		}
 
 		// 记录收集到的结果数量
-		log.Printf("[Plugin:link-health] Collected %d results from httpx scan", len(results))
+		log.Printf("[Plugin:link-health] Collected %d results from link check", len(results))
 
-		// 创建进度信息
+		// 创建进度信息（超时未检查的链接不计入已完成）
+		checked := 0
+		for _, result := range results {
+			if !result.Skipped {
+				checked++
+			}
+		}
 		progress := &models.TaskProgress{
-			Current: len(results),
+			Current: checked,
 			Total:   len(urls),
 		}
 
@@ -97,3 +113,18 @@ func (p *HttpxPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*
 		return plugin.CreateSuccessOutput(results, progress), nil
 	})
 }
+
+// Selected 同时选择了 katana（全站链接检查）时只执行 katana，跳过 link-health
+func (p *HttpxPlugin) Selected(options []string) bool {
+	return p.BasePlugin.Selected(options) && !plugin.HasOption(options, "katana")
+}
+
+// StoreResult 将链接健康检查结果写入任务结果
+func (p *HttpxPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	linkResults, ok := data.([]models.HttpxResult)
+	if !ok {
+		log.Printf("[Plugin:%s] Failed to store results: type assertion failed, got %T", p.Name(), data)
+		return
+	}
+	results.LinkHealth = linkResults
+}
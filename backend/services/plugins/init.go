@@ -13,7 +13,11 @@ func InitPlugins() {
 		NewDomainPlugin(),
 		NewSSLPlugin(),
 		NewTechStackPlugin(),
+		NewEmailSecurityPlugin(),
+		NewJSLibrariesPlugin(),
+		NewCookiesPlugin(),
 		NewLighthousePlugin(),
+		NewPageAuditPlugin(),
 		NewHttpxPlugin(),
 		NewAIPlugin(),
 		NewKatanaPlugin(),
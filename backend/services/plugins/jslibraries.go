@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"context"
+	"time"
+	"web-checkly/models"
+	"web-checkly/services"
+	"web-checkly/services/plugin"
+)
+
+// JSLibrariesPlugin 已知漏洞 JavaScript 库检测插件
+// 同时选择 katana 时检测全站爬取发现的脚本，否则只检测目标页面引用的脚本；结果合并到安全风险（security_risk）中
+type JSLibrariesPlugin struct {
+	*plugin.BasePlugin
+}
+
+// NewJSLibrariesPlugin 创建已知漏洞 JavaScript 库检测插件
+func NewJSLibrariesPlugin() *JSLibrariesPlugin {
+	return &JSLibrariesPlugin{
+		BasePlugin: plugin.NewBasePlugin(
+			"js-libraries",
+			90*time.Second,     // 90秒超时（页面发现 + 下载脚本）
+			false,              // 同步执行
+			[]string{"katana"}, // 可选依赖：使用全站爬取发现的脚本
+		),
+	}
+}
+
+// Execute 执行已知漏洞 JavaScript 库检测
+func (p *JSLibrariesPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+	if err := plugin.ValidateInput(input); err != nil {
+		return plugin.HandleError(p.Name(), err), err
+	}
+
+	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+		// 认证扫描时使用 options["auth"] 的认证信息
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		crawled, _ := input.Options[plugin.UpstreamOptionKey("katana")].([]services.KatanaResult)
+
+		libraries, err := services.CollectJSLibraries(ctx, input.TargetURL, crawled, auth)
+		if err != nil {
+			return plugin.HandleError(p.Name(), err), err
+		}
+
+		return plugin.CreateSuccessOutput(libraries, nil), nil
+	})
+}
+
+// StoreResult 将检测结果合并到安全风险结果中（与 Lighthouse 的安全检测结果共存）
+func (p *JSLibrariesPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if libraries, ok := data.([]models.VulnerableLibrary); ok {
+		results.SecurityRisk = services.MergeVulnerableLibraries(results.SecurityRisk, libraries, plugin.HasOption(task.Options, "security"))
+	}
+}
@@ -3,11 +3,13 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
 
-// KatanaPlugin 页面和资源发现插件
+// KatanaPlugin 全站页面和资源发现插件（进程内爬虫或 katana，见 services.CrawlSite）
+// 爬取配置由 options["crawl"]（*models.CrawlConfig）传入，认证扫描的认证信息由 options["auth"] 传入
 type KatanaPlugin struct {
 	*plugin.BasePlugin
 }
@@ -17,9 +19,9 @@ func NewKatanaPlugin() *KatanaPlugin {
 	return &KatanaPlugin{
 		BasePlugin: plugin.NewBasePlugin(
 			"katana",
-			60*time.Second, // 60秒超时
-			false,          // 同步执行
-			nil,            // 无依赖
+			120*time.Second, // 120秒超时（与 CollectKatanaResults 的爬取超时一致）
+			false,           // 同步执行
+			nil,             // 无依赖
 		),
 	}
 }
@@ -36,7 +38,9 @@ func (p *KatanaPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (
 		if tm, ok := input.Options["taskManager"].(interface{}); ok {
 			taskManager = tm
 		}
-		results, err := services.CollectKatanaResults(input.TargetURL, input.TaskID, taskManager)
+		crawlConfig, _ := input.Options["crawl"].(*models.CrawlConfig)
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		results, err := services.CollectKatanaResults(ctx, input.TargetURL, input.TaskID, taskManager, crawlConfig, auth)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -44,3 +48,8 @@ func (p *KatanaPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (
 		return plugin.CreateSuccessOutput(results, nil), nil
 	})
 }
+
+// StoreResult 将爬取结果写入任务结果（执行过程中已通过 taskManager 实时追加，这里写入完整结果）
+func (p *KatanaPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	results.KatanaResults = data
+}
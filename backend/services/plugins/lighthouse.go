@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
@@ -21,7 +22,7 @@ func NewLighthousePlugin() *LighthousePlugin {
 			120*time.Second, // 120秒超时（Lighthouse 执行较慢）
 			false,           // 同步执行
 			nil,             // 无依赖
-		),
+		).WithOptions("performance", "seo", "security", "accessibility"), // 各子模块在任务中有独立的模块状态
 	}
 }
 
@@ -38,8 +39,9 @@ func (p *LighthousePlugin) Execute(ctx context.Context, input *plugin.PluginInpu
 			lang = "zh" // 默认中文
 		}
 
-		// 运行 Lighthouse
-		report, err := services.RunLighthouse(input.TargetURL, lang)
+		// 运行 Lighthouse（认证扫描时只为同站请求附加认证信息）
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		report, err := services.RunLighthouseWithAuth(ctx, input.TargetURL, lang, auth)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -85,3 +87,27 @@ func (p *LighthousePlugin) Execute(ctx context.Context, input *plugin.PluginInpu
 		return plugin.CreateSuccessOutput(result, nil), nil
 	})
 }
+
+// StoreResult 将各子模块的结果写入任务结果
+// 只重新执行 security 或 js-libraries 先完成时，保留已保存的 JavaScript 库检测结果
+func (p *LighthousePlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	lighthouseData, ok := data.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if perf, ok := lighthouseData["performance"].(*models.PerformanceMetrics); ok {
+		results.Performance = perf
+	}
+	if seo, ok := lighthouseData["seo"].(*models.SEOCompliance); ok {
+		results.SEOCompliance = seo
+	}
+	if sec, ok := lighthouseData["security"].(*models.SecurityRisk); ok {
+		if results.SecurityRisk != nil && len(results.SecurityRisk.VulnerableLibraries) > 0 {
+			sec = services.MergeVulnerableLibraries(sec, results.SecurityRisk.VulnerableLibraries, true)
+		}
+		results.SecurityRisk = sec
+	}
+	if acc, ok := lighthouseData["accessibility"].(*models.AccessibilityInfo); ok {
+		results.Accessibility = acc
+	}
+}
@@ -0,0 +1,62 @@
+package plugins
+
+import (
+	"context"
+	"web-checkly/models"
+	"web-checkly/services"
+	"web-checkly/services/plugin"
+)
+
+// PageAuditPlugin 多页面审计插件
+// 按 sitemap 中的 URL 模板抽样页面，逐个运行 Lighthouse
+type PageAuditPlugin struct {
+	*plugin.BasePlugin
+}
+
+// NewPageAuditPlugin 创建多页面审计插件
+func NewPageAuditPlugin() *PageAuditPlugin {
+	return &PageAuditPlugin{
+		BasePlugin: plugin.NewBasePlugin(
+			"sitemap-audit",
+			services.PageAuditTimeout(), // 按页面预算计算，不超过单个任务的最长执行时间
+			false,                       // 同步执行
+			nil,                         // 无依赖
+		),
+	}
+}
+
+// Execute 执行多页面审计
+func (p *PageAuditPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+	if err := plugin.ValidateInput(input); err != nil {
+		return plugin.HandleError(p.Name(), err), err
+	}
+
+	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
+		lang := input.Language
+		if lang == "" {
+			lang = "zh" // 默认中文
+		}
+
+		// 从options中获取taskManager（由executor传递），用于实时更新已审计页面数
+		var taskManager interface{}
+		if input.Options != nil {
+			taskManager = input.Options["taskManager"]
+		}
+
+		// 认证扫描的认证信息（为 nil 时匿名审计）
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		result, err := services.RunPageAudits(ctx, input.TargetURL, lang, input.TaskID, taskManager, auth)
+		if err != nil {
+			return plugin.HandleError(p.Name(), err), err
+		}
+
+		return plugin.CreateSuccessOutput(result, nil), nil
+	})
+}
+
+// StoreResult 将多页面审计结果写入任务结果
+func (p *PageAuditPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.PageAuditResult); ok {
+		results.PageAudits = info
+	}
+}
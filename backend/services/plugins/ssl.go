@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
@@ -17,7 +18,7 @@ func NewSSLPlugin() *SSLPlugin {
 	return &SSLPlugin{
 		BasePlugin: plugin.NewBasePlugin(
 			"ssl-info",
-			10*time.Second, // 10秒超时
+			30*time.Second, // 30秒超时（可能需要下载中间证书、查询 OCSP 或下载 CRL）
 			false,          // 同步执行
 			nil,            // 无依赖
 		),
@@ -31,7 +32,7 @@ func (p *SSLPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*pl
 	}
 
 	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
-		info, err := services.CollectSSLInfo(input.TargetURL)
+		info, err := services.CollectSSLInfo(ctx, input.TargetURL, input.Language)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -39,3 +40,10 @@ func (p *SSLPlugin) Execute(ctx context.Context, input *plugin.PluginInput) (*pl
 		return plugin.CreateSuccessOutput(info, nil), nil
 	})
 }
+
+// StoreResult 将SSL 证书信息写入任务结果
+func (p *SSLPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.SSLInfo); ok {
+		results.SSLInfo = info
+	}
+}
@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
@@ -31,7 +32,9 @@ func (p *TechStackPlugin) Execute(ctx context.Context, input *plugin.PluginInput
 	}
 
 	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
-		info, err := services.CollectTechStack(input.TargetURL)
+		// 认证扫描时使用 options["auth"] 的认证信息
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		info, err := services.CollectTechStack(ctx, input.TargetURL, input.Language, auth)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -39,3 +42,10 @@ func (p *TechStackPlugin) Execute(ctx context.Context, input *plugin.PluginInput
 		return plugin.CreateSuccessOutput(info, nil), nil
 	})
 }
+
+// StoreResult 将技术栈检测结果写入任务结果
+func (p *TechStackPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.TechStack); ok {
+		results.TechStack = info
+	}
+}
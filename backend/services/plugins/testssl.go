@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
@@ -17,10 +18,10 @@ func NewTestSSLPlugin() *TestSSLPlugin {
 	return &TestSSLPlugin{
 		BasePlugin: plugin.NewBasePlugin(
 			"testssl",
-			120*time.Second, // 120秒超时（testssl可能需要更长时间）
+			120*time.Second, // 120秒超时（逐个枚举协议和加密套件，testssl可能需要更长时间）
 			false,           // 同步执行
 			nil,             // 无依赖
-		),
+		).WithOptions(), // 未接入扫描选项，不由任务选项启用
 	}
 }
 
@@ -31,7 +32,7 @@ func (p *TestSSLPlugin) Execute(ctx context.Context, input *plugin.PluginInput)
 	}
 
 	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
-		result, err := services.CollectTestSSLInfo(input.TargetURL)
+		result, err := services.CollectTestSSLInfo(ctx, input.TargetURL, input.Language)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -39,3 +40,7 @@ func (p *TestSSLPlugin) Execute(ctx context.Context, input *plugin.PluginInput)
 		return plugin.CreateSuccessOutput(result, nil), nil
 	})
 }
+
+// StoreResult 检测结果没有对应的任务结果字段，不写入
+func (p *TestSSLPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+}
@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
@@ -33,8 +34,9 @@ func (p *WebsitePlugin) Execute(ctx context.Context, input *plugin.PluginInput)
 
 	// 使用带超时的执行包装
 	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
-		// 调用现有服务
-		info, err := services.CollectWebsiteInfo(input.TargetURL)
+		// 调用现有服务（认证扫描时使用 options["auth"] 的认证信息）
+		auth, _ := input.Options["auth"].(*services.ScanAuthSession)
+		info, err := services.CollectWebsiteInfo(input.TargetURL, auth)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -42,3 +44,10 @@ func (p *WebsitePlugin) Execute(ctx context.Context, input *plugin.PluginInput)
 		return plugin.CreateSuccessOutput(info, nil), nil
 	})
 }
+
+// StoreResult 将网站基础信息写入任务结果
+func (p *WebsitePlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+	if info, ok := data.(*models.WebsiteInfo); ok {
+		results.WebsiteInfo = info
+	}
+}
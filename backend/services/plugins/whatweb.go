@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"time"
+	"web-checkly/models"
 	"web-checkly/services"
 	"web-checkly/services/plugin"
 )
@@ -20,7 +21,7 @@ func NewWhatWebPlugin() *WhatWebPlugin {
 			30*time.Second, // 30秒超时
 			false,          // 同步执行
 			nil,            // 无依赖
-		),
+		).WithOptions(), // 未接入扫描选项，不由任务选项启用
 	}
 }
 
@@ -31,7 +32,7 @@ func (p *WhatWebPlugin) Execute(ctx context.Context, input *plugin.PluginInput)
 	}
 
 	return plugin.ExecuteWithTimeout(ctx, p, input, func(ctx context.Context, input *plugin.PluginInput) (*plugin.PluginOutput, error) {
-		result, err := services.CollectWhatWebInfo(input.TargetURL)
+		result, err := services.CollectWhatWebInfo(ctx, input.TargetURL)
 		if err != nil {
 			return plugin.HandleError(p.Name(), err), err
 		}
@@ -39,3 +40,7 @@ func (p *WhatWebPlugin) Execute(ctx context.Context, input *plugin.PluginInput)
 		return plugin.CreateSuccessOutput(result, nil), nil
 	})
 }
+
+// StoreResult 检测结果没有对应的任务结果字段，不写入
+func (p *WhatWebPlugin) StoreResult(task *models.Task, data interface{}, results *models.TaskResults) {
+}
@@ -0,0 +1,1498 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"web-checkly/models"
+)
+
+// 报告格式
+const (
+	ReportFormatPDF  = "pdf"
+	ReportFormatHTML = "html"
+)
+
+// reportMaxLinkRows 报告中最多列出的链接数（失效链接优先）
+const reportMaxLinkRows = 300
+
+// taskReport 与输出格式无关的报告内容，由 renderReportHTML / renderReportPDF 渲染
+type taskReport struct {
+	Lang        string
+	Title       string
+	TargetURL   string
+	GeneratedAt string
+	Overview    []reportField
+	Scores      []reportScore
+	Sections    []reportSection
+	Footer      string
+}
+
+// reportSection 报告章节
+type reportSection struct {
+	Title  string
+	Blocks []reportBlock
+}
+
+// reportBlock 章节中的内容块（按 Kind 渲染为字段列表、评分、表格、列表或段落）
+type reportBlock struct {
+	Kind   string // fields / scores / table / list / text
+	Title  string
+	Fields []reportField
+	Scores []reportScore
+	Table  *reportTable
+	Items  []string
+	Text   string
+}
+
+// reportField 名称-值字段
+type reportField struct {
+	Label string
+	Value string
+	Level string // 值的状态：good / warn / bad，为空时不着色
+}
+
+// reportScore 0-100 评分
+type reportScore struct {
+	Label string
+	Score int
+}
+
+// Level 评分等级（与 Lighthouse 一致：>= 90 良好，>= 50 需改进）
+func (s reportScore) Level() string {
+	switch {
+	case s.Score >= 90:
+		return "good"
+	case s.Score >= 50:
+		return "warn"
+	default:
+		return "bad"
+	}
+}
+
+// reportTable 表格，Widths 为各列宽度占比（PDF 使用）
+type reportTable struct {
+	Headers []string
+	Widths  []float64
+	Rows    []reportRow
+	Note    string
+}
+
+// reportRow 表格行
+type reportRow struct {
+	Cells []string
+	Level string
+}
+
+// reportMessages 报告文案（zh/en）
+var reportMessages = map[string]map[string]string{
+	"zh": {
+		"title":            "网站检测报告",
+		"footer":           "由 WebCheckly 生成",
+		"generated_at":     "生成时间",
+		"overview":         "概览",
+		"target_url":       "目标网址",
+		"task_id":          "任务ID",
+		"status":           "任务状态",
+		"created_at":       "创建时间",
+		"completed_at":     "完成时间",
+		"options":          "检测项目",
+		"error":            "错误信息",
+		"verdict":          "门禁结论",
+		"verdict_passed":   "通过",
+		"verdict_failed":   "未通过",
+		"scores":           "评分",
+		"modules":          "模块状态",
+		"module":           "模块",
+		"website_info":     "网站信息",
+		"page_title":       "标题",
+		"description":      "描述",
+		"keywords":         "关键词",
+		"language":         "语言",
+		"charset":          "字符集",
+		"author":           "作者",
+		"generator":        "生成器",
+		"viewport":         "Viewport",
+		"robots":           "Robots",
+		"domain_info":      "域名信息",
+		"domain":           "域名",
+		"ip":               "IP",
+		"ipv4":             "IPv4",
+		"ipv6":             "IPv6",
+		"mx":               "MX 记录",
+		"ns":               "NS 记录",
+		"txt":              "TXT 记录",
+		"asn":              "ASN",
+		"location":         "位置",
+		"isp":              "ISP",
+		"organization":     "组织",
+		"cname":            "CNAME",
+		"dnssec":           "DNSSEC",
+		"dnssec_valid":     "已签名并验证通过",
+		"dnssec_unsigned":  "未签名",
+		"dnssec_no_ds":     "已签名，但父区域没有 DS 记录",
+		"dnssec_invalid":   "验证失败",
+		"caa":              "CAA",
+		"caa_issuewild":    "CAA（通配符）",
+		"ns_consistency":   "NS 一致性",
+		"consistent":       "一致",
+		"inconsistent":     "不一致",
+		"ssl_info":         "SSL 证书",
+		"issuer":           "颁发者",
+		"subject":          "主题",
+		"valid_from":       "生效时间",
+		"valid_to":         "到期时间",
+		"is_valid":         "证书有效",
+		"days_remaining":   "剩余天数",
+		"signature_alg":    "签名算法",
+		"public_key":       "公钥",
+		"serial_number":    "序列号",
+		"dns_names":        "SAN 域名",
+		"chain_trusted":    "证书链可信",
+		"chain_complete":   "证书链完整",
+		"ocsp_stapled":     "OCSP 装订",
+		"sct_count":        "CT 证明（SCT）数",
+		"revocation":       "吊销状态",
+		"cert_chain":       "证书链",
+		"tech_stack":       "技术栈",
+		"server":           "服务器",
+		"powered_by":       "X-Powered-By",
+		"content_type":     "Content-Type",
+		"os":               "操作系统",
+		"technologies":     "技术",
+		"tech_detections":  "识别到的技术",
+		"name":             "名称",
+		"version":          "版本",
+		"confidence":       "置信度",
+		"category":         "分类",
+		"framework":        "框架",
+		"cms":              "CMS",
+		"prog_language":    "编程语言",
+		"javascript_lib":   "JavaScript 库",
+		"analytics":        "分析工具",
+		"cdn":              "CDN",
+		"cache":            "缓存",
+		"database":         "数据库",
+		"email_security":   "邮件安全",
+		"grade":            "等级",
+		"spf":              "SPF",
+		"dmarc":            "DMARC",
+		"dkim_selectors":   "DKIM 选择器",
+		"mta_sts":          "MTA-STS",
+		"tls_rpt":          "TLS-RPT",
+		"bimi":             "BIMI",
+		"null_mx":          "空 MX（不接收邮件）",
+		"severity":         "严重程度",
+		"check":            "检查项",
+		"message":          "说明",
+		"no_findings":      "未发现问题",
+		"sev_critical":     "严重",
+		"sev_high":         "高",
+		"sev_medium":       "中",
+		"sev_low":          "低",
+		"sev_info":         "提示",
+		"security_headers": "安全响应头",
+		"header":           "响应头",
+		"value":            "值",
+		"no_headers":       "未检测到安全响应头",
+		"header_analysis":  "安全响应头分析",
+		"csp":              "CSP",
+		"hsts":             "HSTS",
+		"hsts_summary":     "max-age=%d 秒，includeSubDomains: %s，可预加载: %s",
+		"remediation":      "修复建议",
+		"cookies":          "Cookie 安全",
+		"pages_checked":    "检查页面数",
+		"cookie_count":     "Cookie 数量",
+		"trackers":         "跟踪 Cookie",
+		"tracker_vendors":  "跟踪服务",
+		"cookie":           "Cookie",
+		"scope":            "范围",
+		"attributes":       "属性",
+		"lifetime":         "有效期",
+		"tracker":          "跟踪服务",
+		"session_only":     "会话（浏览器关闭时删除）",
+		"hours":            "%d 小时",
+		"set_by_script":    "由脚本设置（属性未知）",
+		"performance":      "性能",
+		"seo":              "SEO",
+		"security":         "安全",
+		"accessibility":    "可访问性",
+		"metric":           "指标",
+		"score":            "评分",
+		"lcp_element":      "LCP 元素",
+		"has_title":        "包含 Title",
+		"has_description":  "包含 Description",
+		"has_viewport":     "包含 Viewport",
+		"has_robots_txt":   "包含 robots.txt",
+		"has_canonical":    "包含 canonical 标签",
+		"indexable":        "可被索引",
+		"spa_visibility":   "SPA 可见性",
+		"script_count":     "脚本数量",
+		"third_party":      "第三方脚本来源",
+		"vulnerabilities":  "潜在问题",
+		"vulnerable_libs":  "存在已知漏洞的 JavaScript 库",
+		"identifiers":      "漏洞编号",
+		"fixed_in":         "修复版本",
+		"findings":         "主要发现",
+		"page_audits":      "多页面审计",
+		"page_source":      "页面来源",
+		"source_sitemap":   "Sitemap",
+		"source_links":     "首页链接（未找到 sitemap）",
+		"discovered_urls":  "发现页面数",
+		"url_templates":    "URL 模板数",
+		"audited_pages":    "已审计页面",
+		"average_score":    "%s（平均）",
+		"worst_page":       "%s最差页面",
+		"template":         "模板",
+		"link_health":      "链接健康",
+		"total_links":      "链接总数",
+		"alive_links":      "可用",
+		"dead_links":       "失效",
+		"skipped_links":    "超时未检查",
+		"avg_response":     "平均响应时间",
+		"scan_timeout":     "扫描超时",
+		"url":              "URL",
+		"http_status":      "状态码",
+		"response_time":    "响应时间",
+		"rows_truncated":   "仅列出前 %d 条（共 %d 条，失效链接优先）",
+		"ai_analysis":      "AI 分析",
+		"ai_summary":       "总结",
+		"risk_level":       "风险等级",
+		"availability":     "可用性",
+		"highlights":       "关键发现",
+		"recommendations":  "优化建议",
+		"yes":              "是",
+		"no":               "否",
+		"none":             "无",
+		"ms":               "%d 毫秒",
+		"days":             "%d 天",
+	},
+	"en": {
+		"title":            "Website Scan Report",
+		"footer":           "Generated by WebCheckly",
+		"generated_at":     "Generated at",
+		"overview":         "Overview",
+		"target_url":       "Target URL",
+		"task_id":          "Task ID",
+		"status":           "Status",
+		"created_at":       "Created at",
+		"completed_at":     "Completed at",
+		"options":          "Checks",
+		"error":            "Error",
+		"verdict":          "Policy verdict",
+		"verdict_passed":   "Passed",
+		"verdict_failed":   "Failed",
+		"scores":           "Scores",
+		"modules":          "Module status",
+		"module":           "Module",
+		"website_info":     "Website Information",
+		"page_title":       "Title",
+		"description":      "Description",
+		"keywords":         "Keywords",
+		"language":         "Language",
+		"charset":          "Charset",
+		"author":           "Author",
+		"generator":        "Generator",
+		"viewport":         "Viewport",
+		"robots":           "Robots",
+		"domain_info":      "Domain Information",
+		"domain":           "Domain",
+		"ip":               "IP",
+		"ipv4":             "IPv4",
+		"ipv6":             "IPv6",
+		"mx":               "MX records",
+		"ns":               "NS records",
+		"txt":              "TXT records",
+		"asn":              "ASN",
+		"location":         "Location",
+		"isp":              "ISP",
+		"organization":     "Organization",
+		"cname":            "CNAME",
+		"dnssec":           "DNSSEC",
+		"dnssec_valid":     "Signed and validated",
+		"dnssec_unsigned":  "Unsigned",
+		"dnssec_no_ds":     "Signed, but no DS record at the parent",
+		"dnssec_invalid":   "Validation failed",
+		"caa":              "CAA",
+		"caa_issuewild":    "CAA (wildcard)",
+		"ns_consistency":   "NS consistency",
+		"consistent":       "Consistent",
+		"inconsistent":     "Inconsistent",
+		"ssl_info":         "SSL Certificate",
+		"issuer":           "Issuer",
+		"subject":          "Subject",
+		"valid_from":       "Valid from",
+		"valid_to":         "Valid to",
+		"is_valid":         "Valid",
+		"days_remaining":   "Days remaining",
+		"signature_alg":    "Signature algorithm",
+		"public_key":       "Public key",
+		"serial_number":    "Serial number",
+		"dns_names":        "SAN names",
+		"chain_trusted":    "Chain trusted",
+		"chain_complete":   "Chain complete",
+		"ocsp_stapled":     "OCSP stapling",
+		"sct_count":        "CT SCTs",
+		"revocation":       "Revocation status",
+		"cert_chain":       "Certificate Chain",
+		"tech_stack":       "Technology Stack",
+		"server":           "Server",
+		"powered_by":       "X-Powered-By",
+		"content_type":     "Content-Type",
+		"os":               "Operating system",
+		"technologies":     "Technologies",
+		"tech_detections":  "Detected Technologies",
+		"name":             "Name",
+		"version":          "Version",
+		"confidence":       "Confidence",
+		"category":         "Category",
+		"framework":        "Frameworks",
+		"cms":              "CMS",
+		"prog_language":    "Languages",
+		"javascript_lib":   "JavaScript libraries",
+		"analytics":        "Analytics",
+		"cdn":              "CDN",
+		"cache":            "Cache",
+		"database":         "Database",
+		"email_security":   "Email Security",
+		"grade":            "Grade",
+		"spf":              "SPF",
+		"dmarc":            "DMARC",
+		"dkim_selectors":   "DKIM selectors",
+		"mta_sts":          "MTA-STS",
+		"tls_rpt":          "TLS-RPT",
+		"bimi":             "BIMI",
+		"null_mx":          "Null MX (does not receive mail)",
+		"severity":         "Severity",
+		"check":            "Check",
+		"message":          "Details",
+		"no_findings":      "No issues found",
+		"sev_critical":     "Critical",
+		"sev_high":         "High",
+		"sev_medium":       "Medium",
+		"sev_low":          "Low",
+		"sev_info":         "Info",
+		"security_headers": "Security headers",
+		"header":           "Header",
+		"value":            "Value",
+		"no_headers":       "No security headers detected",
+		"header_analysis":  "Security headers analysis",
+		"csp":              "CSP",
+		"hsts":             "HSTS",
+		"hsts_summary":     "max-age=%d s, includeSubDomains: %s, preload eligible: %s",
+		"remediation":      "Remediation",
+		"cookies":          "Cookie Security",
+		"pages_checked":    "Pages checked",
+		"cookie_count":     "Cookies",
+		"trackers":         "Tracking cookies",
+		"tracker_vendors":  "Tracking services",
+		"cookie":           "Cookie",
+		"scope":            "Scope",
+		"attributes":       "Attributes",
+		"lifetime":         "Lifetime",
+		"tracker":          "Tracker",
+		"session_only":     "Session (deleted when the browser closes)",
+		"hours":            "%d hours",
+		"set_by_script":    "Set by script (attributes unknown)",
+		"performance":      "Performance",
+		"seo":              "SEO",
+		"security":         "Security",
+		"accessibility":    "Accessibility",
+		"metric":           "Metric",
+		"score":            "Score",
+		"lcp_element":      "LCP element",
+		"has_title":        "Has title",
+		"has_description":  "Has meta description",
+		"has_viewport":     "Has viewport",
+		"has_robots_txt":   "Has robots.txt",
+		"has_canonical":    "Has canonical link",
+		"indexable":        "Indexable",
+		"spa_visibility":   "SPA visibility",
+		"script_count":     "Scripts",
+		"third_party":      "Third-party script origins",
+		"vulnerabilities":  "Potential issues",
+		"vulnerable_libs":  "Vulnerable JavaScript libraries",
+		"identifiers":      "Identifiers",
+		"fixed_in":         "Fixed in",
+		"findings":         "Findings",
+		"page_audits":      "Multi-page Audit",
+		"page_source":      "Page source",
+		"source_sitemap":   "Sitemap",
+		"source_links":     "Homepage links (no sitemap found)",
+		"discovered_urls":  "Discovered pages",
+		"url_templates":    "URL templates",
+		"audited_pages":    "Audited pages",
+		"average_score":    "%s (average)",
+		"worst_page":       "Worst %s page",
+		"template":         "Template",
+		"link_health":      "Link Health",
+		"total_links":      "Total links",
+		"alive_links":      "Alive",
+		"dead_links":       "Broken",
+		"skipped_links":    "Not checked (time limit)",
+		"avg_response":     "Average response time",
+		"scan_timeout":     "Scan timed out",
+		"url":              "URL",
+		"http_status":      "Status",
+		"response_time":    "Response time",
+		"rows_truncated":   "Showing the first %d of %d links (broken links first)",
+		"ai_analysis":      "AI Analysis",
+		"ai_summary":       "Summary",
+		"risk_level":       "Risk level",
+		"availability":     "Availability",
+		"highlights":       "Highlights",
+		"recommendations":  "Recommendations",
+		"yes":              "Yes",
+		"no":               "No",
+		"none":             "None",
+		"ms":               "%d ms",
+		"days":             "%d days",
+	},
+}
+
+// reportStatusMessages 任务/模块状态文案
+var reportStatusMessages = map[string]map[models.TaskStatus]string{
+	"zh": {
+		models.TaskStatusPending:   "等待中",
+		models.TaskStatusRunning:   "执行中",
+		models.TaskStatusCompleted: "已完成",
+		models.TaskStatusFailed:    "失败",
+		models.TaskStatusCanceled:  "已取消",
+	},
+	"en": {
+		models.TaskStatusPending:   "Pending",
+		models.TaskStatusRunning:   "Running",
+		models.TaskStatusCompleted: "Completed",
+		models.TaskStatusFailed:    "Failed",
+		models.TaskStatusCanceled:  "Canceled",
+	},
+}
+
+// reportBuilder 构建报告内容
+type reportBuilder struct {
+	lang string
+	task *models.Task
+}
+
+// t 获取当前语言的文案
+func (b *reportBuilder) t(key string) string {
+	if msg, ok := reportMessages[b.lang][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// tf 获取并格式化当前语言的文案
+func (b *reportBuilder) tf(key string, args ...interface{}) string {
+	return fmt.Sprintf(b.t(key), args...)
+}
+
+// RenderTaskReport 将任务结果渲染为报告，返回文件内容、Content-Type 和建议的文件名
+// PDF/HTML 报告语言跟随任务的语言设置（zh/en）；SARIF/JUnit 供 CI 使用，门禁结论取自 task.Verdict
+func RenderTaskReport(task *models.Task, format string) ([]byte, string, string, error) {
+	switch format {
+	case ReportFormatHTML:
+		content, err := renderReportHTML(buildTaskReport(task))
+		return content, "text/html; charset=utf-8", reportFilename(task, "html"), err
+	case ReportFormatPDF:
+		content, err := renderReportPDF(buildTaskReport(task))
+		return content, "application/pdf", reportFilename(task, "pdf"), err
+	case ReportFormatSARIF:
+		content, err := renderReportSARIF(task)
+		return content, "application/sarif+json", reportFilename(task, "sarif"), err
+	case ReportFormatJUnit:
+		content, err := renderReportJUnit(task)
+		return content, "application/xml; charset=utf-8", reportFilename(task, "xml"), err
+	default:
+		return nil, "", "", fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+// reportFilename 生成报告文件名，如 webcheckly-example.com-20240101.pdf
+func reportFilename(task *models.Task, extension string) string {
+	host := "report"
+	if parsedURL, err := url.Parse(task.TargetURL); err == nil && parsedURL.Hostname() != "" {
+		host = parsedURL.Hostname()
+	}
+	return fmt.Sprintf("webcheckly-%s-%s.%s", host, task.CreatedAt.Format("20060102"), extension)
+}
+
+// buildTaskReport 根据任务结果构建报告内容
+func buildTaskReport(task *models.Task) *taskReport {
+	lang := task.Language
+	if _, ok := reportMessages[lang]; !ok {
+		lang = "zh"
+	}
+	b := &reportBuilder{lang: lang, task: task}
+
+	report := &taskReport{
+		Lang:        lang,
+		Title:       b.t("title"),
+		TargetURL:   task.TargetURL,
+		GeneratedAt: b.formatTime(time.Now()),
+		Footer:      b.t("footer"),
+	}
+	report.Overview = b.overviewFields()
+
+	results := task.Results
+	if results == nil {
+		results = &models.TaskResults{}
+	}
+	report.Scores = b.overviewScores(results)
+
+	if section := b.modulesSection(); section != nil {
+		report.Sections = append(report.Sections, *section)
+	}
+	if results.WebsiteInfo != nil {
+		report.Sections = append(report.Sections, b.websiteInfoSection(results.WebsiteInfo))
+	}
+	if results.DomainInfo != nil {
+		report.Sections = append(report.Sections, b.domainInfoSection(results.DomainInfo))
+	}
+	if results.SSLInfo != nil {
+		report.Sections = append(report.Sections, b.sslSection(results.SSLInfo))
+	}
+	if results.TechStack != nil {
+		report.Sections = append(report.Sections, b.techStackSection(results.TechStack))
+		if results.TechStack.HeaderAnalysis != nil {
+			report.Sections = append(report.Sections, b.headerAnalysisSection(results.TechStack.HeaderAnalysis))
+		}
+	}
+	if results.EmailSecurity != nil {
+		report.Sections = append(report.Sections, b.emailSecuritySection(results.EmailSecurity))
+	}
+	if results.Cookies != nil {
+		report.Sections = append(report.Sections, b.cookiesSection(results.Cookies))
+	}
+	if results.Performance != nil {
+		report.Sections = append(report.Sections, b.performanceSection(results.Performance))
+	}
+	if results.SEOCompliance != nil {
+		report.Sections = append(report.Sections, b.seoSection(results.SEOCompliance))
+	}
+	if results.SecurityRisk != nil {
+		report.Sections = append(report.Sections, b.securitySection(results.SecurityRisk))
+	}
+	if results.Accessibility != nil {
+		report.Sections = append(report.Sections, b.accessibilitySection(results.Accessibility))
+	}
+	if results.PageAudits != nil {
+		report.Sections = append(report.Sections, b.pageAuditSection(results.PageAudits))
+	}
+	if len(results.LinkHealth) > 0 {
+		report.Sections = append(report.Sections, b.linkHealthSection(results))
+	}
+	if results.AIAnalysis != nil {
+		report.Sections = append(report.Sections, b.aiSection(results.AIAnalysis))
+	}
+	return report
+}
+
+// overviewFields 概览字段
+func (b *reportBuilder) overviewFields() []reportField {
+	task := b.task
+	fields := []reportField{
+		{Label: b.t("target_url"), Value: task.TargetURL},
+		{Label: b.t("task_id"), Value: task.ID},
+		{Label: b.t("status"), Value: b.statusText(task.Status), Level: statusLevel(task.Status)},
+		{Label: b.t("created_at"), Value: b.formatTime(task.CreatedAt)},
+	}
+	if task.CompletedAt != nil {
+		fields = append(fields, reportField{Label: b.t("completed_at"), Value: b.formatTime(*task.CompletedAt)})
+	}
+	fields = append(fields, reportField{Label: b.t("options"), Value: b.join(task.Options)})
+	if task.Error != "" {
+		fields = append(fields, reportField{Label: b.t("error"), Value: task.Error, Level: "bad"})
+	}
+	if task.Verdict != nil {
+		verdict := reportField{Label: b.t("verdict"), Value: b.t("verdict_passed"), Level: "good"}
+		if !task.Verdict.Passed {
+			verdict.Value, verdict.Level = b.t("verdict_failed"), "bad"
+			for _, check := range task.Verdict.Checks {
+				if !check.Passed {
+					verdict.Value += "\n" + check.Message
+				}
+			}
+		}
+		fields = append(fields, verdict)
+	}
+	return fields
+}
+
+// overviewScores 概览评分（Lighthouse 各项评分）
+func (b *reportBuilder) overviewScores(results *models.TaskResults) []reportScore {
+	var scores []reportScore
+	if results.Performance != nil {
+		scores = append(scores, reportScore{Label: b.t("performance"), Score: results.Performance.Score})
+	}
+	if results.SEOCompliance != nil {
+		scores = append(scores, reportScore{Label: b.t("seo"), Score: results.SEOCompliance.Score})
+	}
+	if results.SecurityRisk != nil {
+		scores = append(scores, reportScore{Label: b.t("security"), Score: results.SecurityRisk.Score})
+	}
+	if results.Accessibility != nil {
+		scores = append(scores, reportScore{Label: b.t("accessibility"), Score: results.Accessibility.Score})
+	}
+	if results.TechStack != nil && results.TechStack.HeaderAnalysis != nil {
+		scores = append(scores, reportScore{Label: b.t("security_headers"), Score: results.TechStack.HeaderAnalysis.Score})
+	}
+	if results.EmailSecurity != nil {
+		scores = append(scores, reportScore{Label: b.t("email_security"), Score: results.EmailSecurity.Score})
+	}
+	return scores
+}
+
+// modulesSection 模块状态（只有一个模块时省略）
+func (b *reportBuilder) modulesSection() *reportSection {
+	if len(b.task.Modules) <= 1 {
+		return nil
+	}
+	names := make([]string, 0, len(b.task.Modules))
+	for name := range b.task.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := &reportTable{
+		Headers: []string{b.t("module"), b.t("status"), b.t("error")},
+		Widths:  []float64{0.25, 0.15, 0.6},
+	}
+	for _, name := range names {
+		module := b.task.Modules[name]
+		if module == nil {
+			continue
+		}
+		table.Rows = append(table.Rows, reportRow{
+			Cells: []string{name, b.statusText(module.Status), module.Error},
+			Level: statusLevel(module.Status),
+		})
+	}
+	return &reportSection{
+		Title:  b.t("modules"),
+		Blocks: []reportBlock{{Kind: "table", Table: table}},
+	}
+}
+
+// websiteInfoSection 网站信息
+func (b *reportBuilder) websiteInfoSection(info *models.WebsiteInfo) reportSection {
+	return reportSection{
+		Title: b.t("website_info"),
+		Blocks: []reportBlock{{Kind: "fields", Fields: b.nonEmpty([]reportField{
+			{Label: b.t("page_title"), Value: info.Title},
+			{Label: b.t("description"), Value: info.Description},
+			{Label: b.t("keywords"), Value: strings.Join(info.Keywords, ", ")},
+			{Label: b.t("language"), Value: info.Language},
+			{Label: b.t("charset"), Value: info.Charset},
+			{Label: b.t("author"), Value: info.Author},
+			{Label: b.t("generator"), Value: info.Generator},
+			{Label: b.t("viewport"), Value: info.Viewport},
+			{Label: b.t("robots"), Value: info.Robots},
+		})}},
+	}
+}
+
+// domainInfoSection 域名信息（含 DNS 安全检查的结论和发现）
+func (b *reportBuilder) domainInfoSection(info *models.DomainInfo) reportSection {
+	asn := strings.TrimSpace(strings.Join([]string{info.ASN, info.ASNName}, " "))
+	location := strings.Join(nonEmptyStrings(info.City, info.Country), ", ")
+	fields := []reportField{
+		{Label: b.t("domain"), Value: info.Domain},
+		{Label: b.t("ip"), Value: info.IP},
+		{Label: b.t("ipv4"), Value: strings.Join(info.IPv4, ", ")},
+		{Label: b.t("ipv6"), Value: strings.Join(info.IPv6, ", ")},
+		{Label: b.t("cname"), Value: strings.Join(info.CNAME, " -> ")},
+		{Label: b.t("mx"), Value: strings.Join(info.MX, "\n")},
+		{Label: b.t("ns"), Value: strings.Join(info.NS, "\n")},
+		{Label: b.t("txt"), Value: strings.Join(info.TXT, "\n")},
+		{Label: b.t("asn"), Value: asn},
+		{Label: b.t("location"), Value: location},
+		{Label: b.t("isp"), Value: info.ISP},
+		{Label: b.t("organization"), Value: info.Organization},
+	}
+	if dnssec := info.DNSSEC; dnssec != nil {
+		field := reportField{Label: b.t("dnssec"), Value: b.t("dnssec_invalid"), Level: "bad"}
+		switch {
+		case dnssec.Valid:
+			field.Value, field.Level = b.t("dnssec_valid"), "good"
+		case !dnssec.Signed && !dnssec.DSPresent:
+			field.Value, field.Level = b.t("dnssec_unsigned"), "warn"
+		case dnssec.Signed && !dnssec.DSPresent && len(dnssec.Errors) == 0:
+			field.Value, field.Level = b.t("dnssec_no_ds"), "warn"
+		}
+		if len(dnssec.Algorithms) > 0 {
+			field.Value += " (" + strings.Join(dnssec.Algorithms, ", ") + ")"
+		}
+		fields = append(fields, field)
+	}
+	if caa := info.CAA; caa != nil {
+		field := reportField{Label: b.t("caa"), Value: b.t("none"), Level: "warn"}
+		if len(caa.Records) > 0 {
+			field.Value, field.Level = strings.Join(caa.Issuers, ", "), ""
+			if caa.IssuerAllowed != nil && !*caa.IssuerAllowed {
+				field.Level = "bad"
+			}
+		}
+		fields = append(fields, field)
+		if len(caa.WildcardIssuers) > 0 {
+			fields = append(fields, reportField{Label: b.t("caa_issuewild"), Value: strings.Join(caa.WildcardIssuers, ", ")})
+		}
+	}
+	if ns := info.Nameservers; ns != nil && len(ns.Servers) > 0 {
+		field := reportField{Label: b.t("ns_consistency"), Value: b.t("consistent"), Level: "good"}
+		if !ns.Consistent {
+			field.Value, field.Level = b.t("inconsistent"), "warn"
+		}
+		fields = append(fields, field)
+	}
+
+	section := reportSection{
+		Title:  b.t("domain_info"),
+		Blocks: []reportBlock{{Kind: "fields", Fields: b.nonEmpty(fields)}},
+	}
+	if len(info.Findings) > 0 {
+		table := b.findingsTable(len(info.Findings), func(i int) (string, string, string) {
+			return info.Findings[i].Severity, info.Findings[i].Check, info.Findings[i].Message
+		})
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("findings"), Table: table})
+	}
+	return section
+}
+
+// sslSection SSL 证书
+func (b *reportBuilder) sslSection(info *models.SSLInfo) reportSection {
+	validLevel := "good"
+	if !info.IsValid {
+		validLevel = "bad"
+	}
+	daysLevel := "good"
+	switch {
+	case info.DaysRemaining < 7:
+		daysLevel = "bad"
+	case info.DaysRemaining < 30:
+		daysLevel = "warn"
+	}
+	publicKey := info.PublicKeyAlg
+	if info.KeySize > 0 {
+		publicKey = fmt.Sprintf("%s %d bit", info.PublicKeyAlg, info.KeySize)
+	}
+	fields := []reportField{
+		{Label: b.t("is_valid"), Value: b.yesNo(info.IsValid), Level: validLevel},
+		{Label: b.t("days_remaining"), Value: b.tf("days", info.DaysRemaining), Level: daysLevel},
+		{Label: b.t("issuer"), Value: info.Issuer},
+		{Label: b.t("subject"), Value: info.Subject},
+		{Label: b.t("valid_from"), Value: info.ValidFrom},
+		{Label: b.t("valid_to"), Value: info.ValidTo},
+		{Label: b.t("signature_alg"), Value: info.SignatureAlg},
+		{Label: b.t("public_key"), Value: publicKey},
+		{Label: b.t("serial_number"), Value: info.SerialNumber},
+		{Label: b.t("dns_names"), Value: strings.Join(info.DNSNames, ", ")},
+	}
+	// 证书链分析（旧的扫描结果没有证书链，不显示这些字段）
+	if len(info.Chain) > 0 {
+		trustedLevel, completeLevel := "good", "good"
+		if !info.ChainTrusted {
+			trustedLevel = "bad"
+		}
+		if !info.ChainComplete {
+			completeLevel = "warn"
+		}
+		fields = append(fields,
+			reportField{Label: b.t("chain_trusted"), Value: b.yesNo(info.ChainTrusted), Level: trustedLevel},
+			reportField{Label: b.t("chain_complete"), Value: b.yesNo(info.ChainComplete), Level: completeLevel},
+			reportField{Label: b.t("ocsp_stapled"), Value: b.yesNo(info.OCSPStapled)},
+			reportField{Label: b.t("sct_count"), Value: strconv.Itoa(info.SCTCount)},
+		)
+		if info.Revocation != nil {
+			level := ""
+			switch info.Revocation.Status {
+			case models.RevocationGood:
+				level = "good"
+			case models.RevocationRevoked:
+				level = "bad"
+			}
+			fields = append(fields, reportField{Label: b.t("revocation"), Value: info.Revocation.Status + " (" + info.Revocation.Method + ")", Level: level})
+		}
+	}
+
+	section := reportSection{
+		Title:  b.t("ssl_info"),
+		Blocks: []reportBlock{{Kind: "fields", Fields: b.nonEmpty(fields)}},
+	}
+	if len(info.Chain) > 0 {
+		table := &reportTable{
+			Headers: []string{b.t("subject"), b.t("valid_to"), b.t("public_key"), b.t("signature_alg")},
+			Widths:  []float64{0.46, 0.2, 0.16, 0.18},
+		}
+		for _, cert := range info.Chain {
+			level := ""
+			if cert.Source != "server" {
+				level = "warn"
+			}
+			table.Rows = append(table.Rows, reportRow{
+				Cells: []string{cert.Subject, cert.NotAfter, fmt.Sprintf("%s %d bit", cert.PublicKeyAlg, cert.KeySize), cert.SignatureAlg},
+				Level: level,
+			})
+		}
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("cert_chain"), Table: table})
+	}
+	if len(info.Findings) > 0 {
+		table := b.findingsTable(len(info.Findings), func(i int) (string, string, string) {
+			return info.Findings[i].Severity, info.Findings[i].Check, info.Findings[i].Message
+		})
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("findings"), Table: table})
+	}
+	return section
+}
+
+// techStackSection 技术栈和安全响应头
+func (b *reportBuilder) techStackSection(stack *models.TechStack) reportSection {
+	fields := b.nonEmpty([]reportField{
+		{Label: b.t("server"), Value: stack.Server},
+		{Label: b.t("powered_by"), Value: stack.PoweredBy},
+		{Label: b.t("content_type"), Value: stack.ContentType},
+		{Label: b.t("os"), Value: stack.OS},
+		{Label: b.t("technologies"), Value: strings.Join(stack.Technologies, ", ")},
+		{Label: b.t("framework"), Value: strings.Join(stack.Framework, ", ")},
+		{Label: b.t("cms"), Value: strings.Join(stack.CMS, ", ")},
+		{Label: b.t("prog_language"), Value: strings.Join(stack.Language, ", ")},
+		{Label: b.t("javascript_lib"), Value: strings.Join(stack.JavaScriptLib, ", ")},
+		{Label: b.t("analytics"), Value: strings.Join(stack.Analytics, ", ")},
+		{Label: b.t("cdn"), Value: strings.Join(stack.CDN, ", ")},
+		{Label: b.t("cache"), Value: strings.Join(stack.Cache, ", ")},
+		{Label: b.t("database"), Value: strings.Join(stack.Database, ", ")},
+	})
+	section := reportSection{
+		Title:  b.t("tech_stack"),
+		Blocks: []reportBlock{{Kind: "fields", Fields: fields}},
+	}
+	if len(stack.Detections) > 0 {
+		table := &reportTable{
+			Headers: []string{b.t("name"), b.t("version"), b.t("category"), b.t("confidence")},
+			Widths:  []float64{0.32, 0.16, 0.38, 0.14},
+		}
+		for _, detection := range stack.Detections {
+			table.Rows = append(table.Rows, reportRow{Cells: []string{
+				detection.Name, detection.Version, strings.Join(detection.Categories, ", "), fmt.Sprintf("%d%%", detection.Confidence),
+			}})
+		}
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("tech_detections"), Table: table})
+	}
+
+	if len(stack.SecurityHeaders) == 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "text", Title: b.t("security_headers"), Text: b.t("no_headers")})
+		return section
+	}
+	section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("security_headers"), Table: headersTable(b, stack.SecurityHeaders)})
+	return section
+}
+
+// headerAnalysisSection 安全响应头分析：评分、CSP/HSTS 解析结果、发现和每个响应头的修复建议
+func (b *reportBuilder) headerAnalysisSection(analysis *models.SecurityHeadersAnalysis) reportSection {
+	fields := []reportField{
+		{Label: b.t("grade"), Value: analysis.Grade, Level: reportScore{Score: analysis.Score}.Level()},
+	}
+	if analysis.CSP != nil {
+		fields = append(fields, reportField{Label: b.t("csp"), Value: analysis.CSP.Policy})
+	}
+	if analysis.HSTS != nil {
+		fields = append(fields, reportField{Label: b.t("hsts"), Value: fmt.Sprintf(b.t("hsts_summary"),
+			analysis.HSTS.MaxAge, b.yesNo(analysis.HSTS.IncludeSubDomains), b.yesNo(analysis.HSTS.PreloadEligible))})
+	}
+
+	section := reportSection{
+		Title: b.t("header_analysis"),
+		Blocks: []reportBlock{
+			{Kind: "scores", Scores: []reportScore{{Label: b.t("security_headers"), Score: analysis.Score}}},
+			{Kind: "fields", Fields: fields},
+		},
+	}
+	if len(analysis.Findings) == 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "text", Title: b.t("findings"), Text: b.t("no_findings")})
+		return section
+	}
+
+	table := b.findingsTable(len(analysis.Findings), func(i int) (string, string, string) {
+		return analysis.Findings[i].Severity, analysis.Findings[i].Check, analysis.Findings[i].Message
+	})
+	section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("findings"), Table: table})
+
+	remediation := &reportTable{
+		Headers: []string{b.t("header"), b.t("remediation")},
+		Widths:  []float64{0.3, 0.7},
+	}
+	for _, header := range analysis.Headers {
+		if header.Remediation != "" {
+			remediation.Rows = append(remediation.Rows, reportRow{Cells: []string{header.Header, header.Remediation}})
+		}
+	}
+	if len(remediation.Rows) > 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("remediation"), Table: remediation})
+	}
+	return section
+}
+
+// emailSecuritySection 邮件安全：评分、各项记录和发现（严重程度高的在前）
+func (b *reportBuilder) emailSecuritySection(email *models.EmailSecurityResult) reportSection {
+	fields := []reportField{
+		{Label: b.t("domain"), Value: email.Domain},
+		{Label: b.t("grade"), Value: email.Grade, Level: reportScore{Score: email.Score}.Level()},
+		{Label: b.t("mx"), Value: strings.Join(email.MX, "\n")},
+	}
+	if email.NullMX {
+		fields = append(fields, reportField{Label: b.t("mx"), Value: b.t("null_mx")})
+	}
+	if email.SPF != nil {
+		fields = append(fields, reportField{Label: b.t("spf"), Value: email.SPF.Record})
+	}
+	if email.DMARC != nil {
+		fields = append(fields, reportField{Label: b.t("dmarc"), Value: email.DMARC.Record})
+	}
+	selectors := make([]string, 0, len(email.DKIM))
+	for _, dkim := range email.DKIM {
+		if dkim.KeyBits > 0 {
+			selectors = append(selectors, fmt.Sprintf("%s (%s %d bit)", dkim.Selector, dkim.KeyType, dkim.KeyBits))
+		} else {
+			selectors = append(selectors, fmt.Sprintf("%s (%s)", dkim.Selector, dkim.KeyType))
+		}
+	}
+	fields = append(fields, reportField{Label: b.t("dkim_selectors"), Value: strings.Join(selectors, ", ")})
+	if email.MTASTS != nil {
+		fields = append(fields, reportField{Label: b.t("mta_sts"), Value: strings.TrimSpace(email.MTASTS.Mode + " " + email.MTASTS.Record)})
+	}
+	if email.TLSRPT != nil {
+		fields = append(fields, reportField{Label: b.t("tls_rpt"), Value: email.TLSRPT.Record})
+	}
+	if email.BIMI != nil {
+		fields = append(fields, reportField{Label: b.t("bimi"), Value: email.BIMI.Record})
+	}
+
+	section := reportSection{
+		Title: b.t("email_security"),
+		Blocks: []reportBlock{
+			{Kind: "scores", Scores: []reportScore{{Label: b.t("email_security"), Score: email.Score}}},
+			{Kind: "fields", Fields: b.nonEmpty(fields)},
+		},
+	}
+	if len(email.Findings) == 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "text", Title: b.t("findings"), Text: b.t("no_findings")})
+		return section
+	}
+
+	table := b.findingsTable(len(email.Findings), func(i int) (string, string, string) {
+		return email.Findings[i].Severity, email.Findings[i].Check, email.Findings[i].Message
+	})
+	section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("findings"), Table: table})
+	return section
+}
+
+// cookiesSection Cookie 安全：统计、Cookie 列表和发现（严重程度高的在前）
+func (b *reportBuilder) cookiesSection(audit *models.CookieAuditResult) reportSection {
+	section := reportSection{
+		Title: b.t("cookies"),
+		Blocks: []reportBlock{{Kind: "fields", Fields: []reportField{
+			{Label: b.t("pages_checked"), Value: strconv.Itoa(audit.PagesChecked)},
+			{Label: b.t("cookie_count"), Value: strconv.Itoa(len(audit.Cookies))},
+			{Label: b.t("trackers"), Value: strconv.Itoa(audit.Trackers)},
+			{Label: b.t("tracker_vendors"), Value: b.join(cookieTrackerVendors(audit))},
+		}}},
+	}
+
+	if len(audit.Cookies) > 0 {
+		table := &reportTable{
+			Headers: []string{b.t("cookie"), b.t("scope"), b.t("attributes"), b.t("lifetime"), b.t("tracker")},
+			Widths:  []float64{0.2, 0.22, 0.26, 0.16, 0.16},
+		}
+		for _, cookie := range audit.Cookies {
+			tracker := ""
+			if cookie.Tracker != nil {
+				tracker = cookie.Tracker.Vendor
+			}
+			table.Rows = append(table.Rows, reportRow{
+				Cells: []string{cookie.Name, cookieScope(cookie), b.cookieAttributes(cookie), b.cookieLifetime(cookie), tracker},
+			})
+		}
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("cookies"), Table: table})
+	}
+
+	if len(audit.Findings) == 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "text", Title: b.t("findings"), Text: b.t("no_findings")})
+		return section
+	}
+	// Cookie 名称区分大小写，不能使用 findingsTable（检查项列会转成大写）
+	table := &reportTable{
+		Headers: []string{b.t("severity"), b.t("cookie"), b.t("message")},
+		Widths:  []float64{0.12, 0.2, 0.68},
+	}
+	for _, finding := range audit.Findings {
+		level := ""
+		switch finding.Severity {
+		case models.SeverityCritical, models.SeverityHigh:
+			level = "bad"
+		case models.SeverityMedium:
+			level = "warn"
+		}
+		table.Rows = append(table.Rows, reportRow{
+			Cells: []string{b.t("sev_" + finding.Severity), finding.Cookie, finding.Message},
+			Level: level,
+		})
+	}
+	section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("findings"), Table: table})
+	return section
+}
+
+// cookieScope Cookie 的 Domain 和 Path（没有 Domain 时为设置它的主机）
+func cookieScope(cookie models.CookieInfo) string {
+	domain := cookie.Domain
+	if domain == "" {
+		if u, err := url.Parse(cookie.SetBy); err == nil {
+			domain = u.Hostname()
+		}
+	}
+	return domain + cookie.Path
+}
+
+// cookieAttributes Cookie 的安全属性（脚本推断的 Cookie 属性未知）
+func (b *reportBuilder) cookieAttributes(cookie models.CookieInfo) string {
+	if cookie.Source == models.CookieSourceScript {
+		return b.t("set_by_script")
+	}
+	var attributes []string
+	if cookie.Secure {
+		attributes = append(attributes, "Secure")
+	}
+	if cookie.HttpOnly {
+		attributes = append(attributes, "HttpOnly")
+	}
+	if cookie.SameSite != "" {
+		attributes = append(attributes, "SameSite="+cookie.SameSite)
+	}
+	return b.join(attributes)
+}
+
+// cookieLifetime Cookie 有效期（不足一天时按小时显示）
+func (b *reportBuilder) cookieLifetime(cookie models.CookieInfo) string {
+	switch {
+	case cookie.Source == models.CookieSourceScript:
+		return ""
+	case !cookie.Persistent:
+		return b.t("session_only")
+	case cookie.MaxAge >= 86400:
+		return b.tf("days", int(cookie.MaxAge/86400))
+	default:
+		return b.tf("hours", int((cookie.MaxAge+3599)/3600))
+	}
+}
+
+// findingsTable 发现表格（严重程度、检查项、说明），finding 返回第 i 个发现
+func (b *reportBuilder) findingsTable(count int, finding func(i int) (severity, check, message string)) *reportTable {
+	table := &reportTable{
+		Headers: []string{b.t("severity"), b.t("check"), b.t("message")},
+		Widths:  []float64{0.12, 0.13, 0.75},
+	}
+	for i := 0; i < count; i++ {
+		severity, check, message := finding(i)
+		level := ""
+		switch severity {
+		case models.SeverityCritical, models.SeverityHigh:
+			level = "bad"
+		case models.SeverityMedium:
+			level = "warn"
+		}
+		table.Rows = append(table.Rows, reportRow{
+			Cells: []string{b.t("sev_" + severity), strings.ToUpper(check), message},
+			Level: level,
+		})
+	}
+	return table
+}
+
+// headersTable 安全响应头表格（按名称排序）
+func headersTable(b *reportBuilder, headers map[string]string) *reportTable {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := &reportTable{
+		Headers: []string{b.t("header"), b.t("value")},
+		Widths:  []float64{0.35, 0.65},
+	}
+	for _, name := range names {
+		table.Rows = append(table.Rows, reportRow{Cells: []string{name, headers[name]}})
+	}
+	return table
+}
+
+// performanceSection 性能指标
+func (b *reportBuilder) performanceSection(perf *models.PerformanceMetrics) reportSection {
+	metrics := []struct {
+		name  string
+		value string
+		score int
+	}{
+		{"First Contentful Paint", formatMillis(perf.FCP), perf.FCPScore},
+		{"Largest Contentful Paint", formatMillis(perf.LCP), perf.LCPScore},
+		{"Cumulative Layout Shift", strconv.FormatFloat(perf.CLS, 'f', 3, 64), perf.CLSScore},
+		{"Total Blocking Time", formatMillis(perf.TBT), perf.TBTScore},
+		{"Speed Index", formatMillis(perf.SpeedIndex), perf.SpeedIndexScore},
+	}
+	table := &reportTable{
+		Headers: []string{b.t("metric"), b.t("value"), b.t("score")},
+		Widths:  []float64{0.5, 0.25, 0.25},
+	}
+	for _, m := range metrics {
+		score := reportScore{Score: m.score}
+		table.Rows = append(table.Rows, reportRow{Cells: []string{m.name, m.value, strconv.Itoa(m.score)}, Level: score.Level()})
+	}
+
+	section := reportSection{
+		Title: b.t("performance"),
+		Blocks: []reportBlock{
+			{Kind: "scores", Scores: []reportScore{{Label: b.t("performance"), Score: perf.Score}}},
+			{Kind: "table", Table: table},
+		},
+	}
+	if perf.LCPElement != "" {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "fields", Fields: []reportField{{Label: b.t("lcp_element"), Value: perf.LCPElement}}})
+	}
+	return section
+}
+
+// seoSection SEO 合规性
+func (b *reportBuilder) seoSection(seo *models.SEOCompliance) reportSection {
+	check := func(key string, ok bool) reportField {
+		level := "good"
+		if !ok {
+			level = "bad"
+		}
+		return reportField{Label: b.t(key), Value: b.yesNo(ok), Level: level}
+	}
+	return reportSection{
+		Title: b.t("seo"),
+		Blocks: []reportBlock{
+			{Kind: "scores", Scores: []reportScore{{Label: b.t("seo"), Score: seo.Score}}},
+			{Kind: "fields", Fields: []reportField{
+				check("has_title", seo.HasTitle),
+				check("has_description", seo.HasDescription),
+				check("has_viewport", seo.HasViewport),
+				check("has_robots_txt", seo.HasRobotsTxt),
+				check("has_canonical", seo.HasCanonical),
+				check("indexable", seo.Indexable),
+				{Label: b.t("spa_visibility"), Value: fmt.Sprintf("%.0f%%", seo.SPAVisibility*100)},
+			}},
+		},
+	}
+}
+
+// securitySection 前端安全风险
+func (b *reportBuilder) securitySection(risk *models.SecurityRisk) reportSection {
+	section := reportSection{
+		Title: b.t("security"),
+		Blocks: []reportBlock{
+			{Kind: "scores", Scores: []reportScore{{Label: b.t("security"), Score: risk.Score}}},
+			{Kind: "fields", Fields: []reportField{{Label: b.t("script_count"), Value: strconv.Itoa(risk.ScriptCount)}}},
+		},
+	}
+	if len(risk.Vulnerabilities) > 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "list", Title: b.t("vulnerabilities"), Items: risk.Vulnerabilities})
+	}
+	if len(risk.VulnerableLibraries) > 0 {
+		table := &reportTable{
+			Headers: []string{b.t("severity"), b.t("name"), b.t("version"), b.t("identifiers"), b.t("fixed_in")},
+			Widths:  []float64{0.12, 0.18, 0.12, 0.43, 0.15},
+		}
+		for _, lib := range risk.VulnerableLibraries {
+			level := "warn"
+			if lib.Severity == models.SeverityCritical || lib.Severity == models.SeverityHigh {
+				level = "bad"
+			}
+			table.Rows = append(table.Rows, reportRow{
+				Cells: []string{b.t("sev_" + lib.Severity), lib.Library, lib.Version, strings.Join(jsLibraryIdentifiers(lib), ", "), jsLibraryFixedVersion(lib)},
+				Level: level,
+			})
+		}
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("vulnerable_libs"), Table: table})
+	}
+	if len(risk.ThirdPartyScripts) > 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "list", Title: b.t("third_party"), Items: risk.ThirdPartyScripts})
+	}
+	if len(risk.SecurityHeaders) > 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "table", Title: b.t("security_headers"), Table: headersTable(b, risk.SecurityHeaders)})
+	}
+	return section
+}
+
+// accessibilitySection 可访问性
+func (b *reportBuilder) accessibilitySection(info *models.AccessibilityInfo) reportSection {
+	section := reportSection{
+		Title:  b.t("accessibility"),
+		Blocks: []reportBlock{{Kind: "scores", Scores: []reportScore{{Label: b.t("accessibility"), Score: info.Score}}}},
+	}
+	if len(info.Findings) > 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "list", Title: b.t("findings"), Items: info.Findings})
+	}
+	return section
+}
+
+// pageAuditSection 多页面审计：汇总评分、最差页面和各页面评分
+func (b *reportBuilder) pageAuditSection(audits *models.PageAuditResult) reportSection {
+	source := b.t("source_sitemap")
+	if audits.Source == models.PageAuditSourceLinks {
+		source = b.t("source_links")
+	}
+	fields := []reportField{
+		{Label: b.t("page_source"), Value: source},
+		{Label: b.t("discovered_urls"), Value: strconv.Itoa(audits.DiscoveredURLs)},
+		{Label: b.t("url_templates"), Value: strconv.Itoa(audits.Templates)},
+		{Label: b.t("audited_pages"), Value: fmt.Sprintf("%d / %d", audits.Aggregate.Audited, len(audits.Pages))},
+	}
+
+	var scores []reportScore
+	aggregates := []struct {
+		key       string
+		aggregate *models.ScoreAggregate
+	}{
+		{"performance", audits.Aggregate.Performance},
+		{"seo", audits.Aggregate.SEO},
+		{"accessibility", audits.Aggregate.Accessibility},
+	}
+	for _, a := range aggregates {
+		if a.aggregate == nil {
+			continue
+		}
+		scores = append(scores, reportScore{Label: b.tf("average_score", b.t(a.key)), Score: a.aggregate.Average})
+		worst := reportScore{Score: a.aggregate.Min}
+		fields = append(fields, reportField{
+			Label: b.tf("worst_page", b.t(a.key)),
+			Value: fmt.Sprintf("%s (%d)", a.aggregate.WorstURL, a.aggregate.Min),
+			Level: worst.Level(),
+		})
+	}
+
+	table := &reportTable{
+		Headers: []string{b.t("url"), b.t("template"), b.t("performance"), b.t("seo"), b.t("accessibility")},
+		Widths:  []float64{0.45, 0.19, 0.12, 0.12, 0.12},
+	}
+	for _, page := range audits.Pages {
+		if page.Error != "" {
+			table.Rows = append(table.Rows, reportRow{
+				Cells: []string{page.URL, page.Template, "-", "-", "-"},
+				Level: "bad",
+			})
+			continue
+		}
+		lowest := page.Performance
+		if page.SEO < lowest {
+			lowest = page.SEO
+		}
+		if page.Accessibility < lowest {
+			lowest = page.Accessibility
+		}
+		table.Rows = append(table.Rows, reportRow{
+			Cells: []string{page.URL, page.Template, strconv.Itoa(page.Performance), strconv.Itoa(page.SEO), strconv.Itoa(page.Accessibility)},
+			Level: reportScore{Score: lowest}.Level(),
+		})
+	}
+
+	section := reportSection{Title: b.t("page_audits")}
+	if len(scores) > 0 {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "scores", Scores: scores})
+	}
+	section.Blocks = append(section.Blocks,
+		reportBlock{Kind: "fields", Fields: fields},
+		reportBlock{Kind: "table", Table: table},
+	)
+	return section
+}
+
+// linkHealthSection 链接健康统计和链接列表（失效链接优先，最多 reportMaxLinkRows 条）
+func (b *reportBuilder) linkHealthSection(results *models.TaskResults) reportSection {
+	summary := results.Summary
+	deadLevel := "good"
+	if summary.Dead > 0 {
+		deadLevel = "bad"
+	}
+	fields := []reportField{
+		{Label: b.t("total_links"), Value: strconv.Itoa(summary.Total)},
+		{Label: b.t("alive_links"), Value: strconv.Itoa(summary.Alive)},
+		{Label: b.t("dead_links"), Value: strconv.Itoa(summary.Dead), Level: deadLevel},
+		{Label: b.t("avg_response"), Value: b.tf("ms", summary.AvgResponse)},
+	}
+	if summary.Timeout {
+		fields = append(fields, reportField{Label: b.t("scan_timeout"), Value: b.t("yes"), Level: "warn"})
+	}
+	if summary.Skipped > 0 {
+		fields = append(fields, reportField{Label: b.t("skipped_links"), Value: strconv.Itoa(summary.Skipped), Level: "warn"})
+	}
+
+	links := append([]models.HttpxResult(nil), results.LinkHealth...)
+	sort.SliceStable(links, func(i, j int) bool {
+		return isBrokenLink(links[i]) && !isBrokenLink(links[j])
+	})
+
+	table := &reportTable{
+		Headers: []string{b.t("url"), b.t("http_status"), b.t("response_time"), b.t("page_title")},
+		Widths:  []float64{0.5, 0.1, 0.15, 0.25},
+	}
+	if len(links) > reportMaxLinkRows {
+		table.Note = b.tf("rows_truncated", reportMaxLinkRows, len(links))
+		links = links[:reportMaxLinkRows]
+	}
+	for _, link := range links {
+		status := "-"
+		if link.StatusCode > 0 {
+			status = strconv.Itoa(link.StatusCode)
+		}
+		level := "good"
+		switch {
+		case isBrokenLink(link):
+			level = "bad"
+		case link.Skipped || link.StatusCode >= 300 || len(link.RedirectChain) > 0:
+			level = "warn"
+		}
+		table.Rows = append(table.Rows, reportRow{
+			Cells: []string{link.URL, status, b.tf("ms", link.ResponseTime), link.Title},
+			Level: level,
+		})
+	}
+
+	return reportSection{
+		Title: b.t("link_health"),
+		Blocks: []reportBlock{
+			{Kind: "fields", Fields: fields},
+			{Kind: "table", Table: table},
+		},
+	}
+}
+
+// aiSection AI 分析
+func (b *reportBuilder) aiSection(ai *models.AIAnalysis) reportSection {
+	section := reportSection{
+		Title: b.t("ai_analysis"),
+		Blocks: []reportBlock{
+			{Kind: "scores", Scores: []reportScore{
+				{Label: b.t("availability"), Score: ai.AvailabilityScore},
+				{Label: b.t("performance"), Score: ai.PerformanceScore},
+				{Label: b.t("security"), Score: ai.SecurityScore},
+				{Label: b.t("seo"), Score: ai.SEOScore},
+			}},
+		},
+	}
+	if ai.RiskLevel != "" {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "fields", Fields: []reportField{
+			{Label: b.t("risk_level"), Value: ai.RiskLevel, Level: riskLevel(ai.RiskLevel)},
+		}})
+	}
+	if ai.Summary != "" {
+		section.Blocks = append(section.Blocks, reportBlock{Kind: "text", Title: b.t("ai_summary"), Text: ai.Summary})
+	}
+	lists := []struct {
+		key   string
+		items []string
+	}{
+		{"highlights", ai.Highlights},
+		{"availability", ai.AvailabilityFindings},
+		{"performance", ai.PerformanceFindings},
+		{"security", ai.SecurityFindings},
+		{"seo", ai.SEOFindings},
+		{"recommendations", ai.Recommendations},
+	}
+	for _, list := range lists {
+		if len(list.items) > 0 {
+			section.Blocks = append(section.Blocks, reportBlock{Kind: "list", Title: b.t(list.key), Items: list.items})
+		}
+	}
+	return section
+}
+
+// nonEmpty 过滤值为空的字段
+func (b *reportBuilder) nonEmpty(fields []reportField) []reportField {
+	filtered := make([]reportField, 0, len(fields))
+	for _, field := range fields {
+		if strings.TrimSpace(field.Value) != "" {
+			filtered = append(filtered, field)
+		}
+	}
+	return filtered
+}
+
+// statusText 任务/模块状态文案
+func (b *reportBuilder) statusText(status models.TaskStatus) string {
+	if text, ok := reportStatusMessages[b.lang][status]; ok {
+		return text
+	}
+	return string(status)
+}
+
+// yesNo 布尔值文案
+func (b *reportBuilder) yesNo(value bool) string {
+	if value {
+		return b.t("yes")
+	}
+	return b.t("no")
+}
+
+// join 连接列表，为空时返回“无”
+func (b *reportBuilder) join(items []string) string {
+	if len(items) == 0 {
+		return b.t("none")
+	}
+	return strings.Join(items, ", ")
+}
+
+// formatTime 报告中的时间统一使用 UTC
+func (b *reportBuilder) formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// statusLevel 任务/模块状态对应的颜色等级
+func statusLevel(status models.TaskStatus) string {
+	switch status {
+	case models.TaskStatusCompleted:
+		return "good"
+	case models.TaskStatusFailed:
+		return "bad"
+	case models.TaskStatusCanceled:
+		return "warn"
+	default:
+		return ""
+	}
+}
+
+// riskLevel AI 风险等级对应的颜色等级（AI 按中文输出高/中/低，也兼容英文）
+func riskLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "低", "low":
+		return "good"
+	case "中", "medium":
+		return "warn"
+	case "高", "high":
+		return "bad"
+	default:
+		return ""
+	}
+}
+
+// formatMillis 格式化毫秒数
+func formatMillis(ms float64) string {
+	if ms >= 1000 {
+		return strconv.FormatFloat(ms/1000, 'f', 2, 64) + " s"
+	}
+	return strconv.FormatFloat(ms, 'f', 0, 64) + " ms"
+}
+
+// nonEmptyStrings 过滤空字符串
+func nonEmptyStrings(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
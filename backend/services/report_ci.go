@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	"web-checkly/models"
+)
+
+// CI 导出格式
+const (
+	ReportFormatSARIF = "sarif"
+	ReportFormatJUnit = "junit"
+)
+
+// sslExpiryWarningDays 证书剩余天数低于该值时报告为即将过期
+const sslExpiryWarningDays = 30
+
+// sarifToolVersion SARIF 中的工具版本（与 API 版本一致）
+const sarifToolVersion = "1.0"
+
+// sarifRule SARIF 规则定义
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	FullDescription      sarifMessage      `json:"fullDescription"`
+	DefaultConfiguration map[string]string `json:"defaultConfiguration"`
+}
+
+// sarifMessage SARIF 文本
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult SARIF 结果
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// sarifLocation SARIF 位置（扫描对象为 URL）
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// sarifRules WebCheckly 输出的规则，结果通过 ruleIndex 引用
+var sarifRules = []sarifRule{
+	newSARIFRule("broken-link", "BrokenLink", "Broken link", "The link returned an HTTP error status (>= 400) or could not be requested.", "error"),
+	newSARIFRule("security-issue", "SecurityIssue", "Front-end security issue", "Lighthouse reported a potential security issue, such as a missing security header.", "warning"),
+	newSARIFRule("vulnerable-js-library", "VulnerableJavaScriptLibrary", "JavaScript library with known vulnerabilities", "A script loaded by the site is a JavaScript library version with known vulnerabilities (CVE) in the retire.js vulnerability database.", "warning"),
+	newSARIFRule("security-header", "SecurityHeaderIssue", "Security header missing or misconfigured", "A security response header (Content-Security-Policy, HSTS, X-Frame-Options, Permissions-Policy, COOP/COEP/CORP and others) is missing or weakly configured.", "warning"),
+	newSARIFRule("insecure-cookie", "InsecureCookie", "Cookie missing security attributes", "A cookie set by the site lacks Secure, HttpOnly or SameSite, has an overly broad Domain/Path, a long-lived session, an invalid __Host-/__Secure- prefix, or is a third-party tracking cookie.", "warning"),
+	newSARIFRule("ssl-invalid", "SSLCertificateInvalid", "SSL certificate is not valid", "The SSL certificate presented by the site is expired, not yet valid or does not match the host.", "error"),
+	newSARIFRule("ssl-expiring", "SSLCertificateExpiring", "SSL certificate expires soon", fmt.Sprintf("The SSL certificate expires in less than %d days.", sslExpiryWarningDays), "warning"),
+	newSARIFRule("ssl-certificate", "SSLCertificateIssue", "SSL certificate chain or configuration issue", "The certificate chain, hostname coverage, key strength, revocation status or Certificate Transparency check reported a problem.", "warning"),
+	newSARIFRule("policy-violation", "PolicyViolation", "Scan policy threshold not met", "A threshold of the scan policy (for example min_performance or max_broken_links) was not met.", "error"),
+}
+
+// newSARIFRule 创建 SARIF 规则
+func newSARIFRule(id, name, short, full, level string) sarifRule {
+	return sarifRule{
+		ID:                   id,
+		Name:                 name,
+		ShortDescription:     sarifMessage{Text: short},
+		FullDescription:      sarifMessage{Text: full},
+		DefaultConfiguration: map[string]string{"level": level},
+	}
+}
+
+// sarifRuleIndex 规则在 sarifRules 中的位置
+func sarifRuleIndex(ruleID string) int {
+	for i, rule := range sarifRules {
+		if rule.ID == ruleID {
+			return i
+		}
+	}
+	return -1
+}
+
+// newSARIFResult 创建 SARIF 结果
+func newSARIFResult(ruleID, level, message, uri string, properties map[string]interface{}) sarifResult {
+	location := sarifLocation{}
+	location.PhysicalLocation.ArtifactLocation.URI = uri
+	return sarifResult{
+		RuleID:     ruleID,
+		RuleIndex:  sarifRuleIndex(ruleID),
+		Level:      level,
+		Message:    sarifMessage{Text: message},
+		Locations:  []sarifLocation{location},
+		Properties: properties,
+	}
+}
+
+// taskVerdict 返回任务的门禁结论（没有保存的结论时按任务策略即时计算）
+func taskVerdict(task *models.Task) *models.ScanVerdict {
+	if task.Verdict != nil {
+		return task.Verdict
+	}
+	return EvaluateScanPolicy(task, task.Policy)
+}
+
+// verdictText 门禁结论文本
+func verdictText(verdict *models.ScanVerdict) string {
+	if verdict.Passed {
+		return "passed"
+	}
+	return "failed"
+}
+
+// renderReportSARIF 将扫描发现和未通过的门禁检查导出为 SARIF 2.1.0
+func renderReportSARIF(task *models.Task) ([]byte, error) {
+	results := task.Results
+	if results == nil {
+		results = &models.TaskResults{}
+	}
+	verdict := taskVerdict(task)
+
+	findings := make([]sarifResult, 0)
+	for _, link := range results.LinkHealth {
+		if isBrokenLink(link) {
+			findings = append(findings, newSARIFResult("broken-link", "error",
+				fmt.Sprintf("Broken link: %s (%s)", link.URL, linkStatusText(link)), link.URL,
+				map[string]interface{}{"status_code": link.StatusCode, "response_time_ms": link.ResponseTime}))
+		}
+	}
+	if results.SecurityRisk != nil {
+		for _, issue := range results.SecurityRisk.Vulnerabilities {
+			findings = append(findings, newSARIFResult("security-issue", "warning", issue, task.TargetURL, nil))
+		}
+		for _, lib := range results.SecurityRisk.VulnerableLibraries {
+			level := "warning"
+			if lib.Severity == models.SeverityCritical || lib.Severity == models.SeverityHigh {
+				level = "error"
+			}
+			findings = append(findings, newSARIFResult("vulnerable-js-library", level, vulnerableLibraryIssue(lib), lib.URL,
+				map[string]interface{}{"library": lib.Library, "version": lib.Version, "severity": lib.Severity,
+					"identifiers": jsLibraryIdentifiers(lib), "fixed_in": jsLibraryFixedVersion(lib)}))
+		}
+	}
+	if results.TechStack != nil && results.TechStack.HeaderAnalysis != nil {
+		// 提示类发现不输出
+		for _, f := range results.TechStack.HeaderAnalysis.Findings {
+			if f.Severity == models.SeverityInfo {
+				continue
+			}
+			level := "warning"
+			if f.Severity == models.SeverityCritical || f.Severity == models.SeverityHigh {
+				level = "error"
+			}
+			findings = append(findings, newSARIFResult("security-header", level, f.Message, task.TargetURL,
+				map[string]interface{}{"check": f.Check, "id": f.ID, "severity": f.Severity}))
+		}
+	}
+	if results.Cookies != nil {
+		// 结果位置使用第一次设置该 Cookie 的地址；提示类发现不输出
+		setBy := make(map[string]string, len(results.Cookies.Cookies))
+		for _, cookie := range results.Cookies.Cookies {
+			if _, ok := setBy[cookie.Name]; !ok {
+				setBy[cookie.Name] = cookie.SetBy
+			}
+		}
+		for _, f := range results.Cookies.Findings {
+			if f.Severity == models.SeverityInfo {
+				continue
+			}
+			level := "warning"
+			if f.Severity == models.SeverityCritical || f.Severity == models.SeverityHigh {
+				level = "error"
+			}
+			uri := setBy[f.Cookie]
+			if uri == "" {
+				uri = task.TargetURL
+			}
+			findings = append(findings, newSARIFResult("insecure-cookie", level, fmt.Sprintf("%s: %s", f.Cookie, f.Message), uri,
+				map[string]interface{}{"cookie": f.Cookie, "id": f.ID, "severity": f.Severity}))
+		}
+	}
+	if ssl := results.SSLInfo; ssl != nil {
+		properties := map[string]interface{}{"days_remaining": ssl.DaysRemaining, "valid_to": ssl.ValidTo, "issuer": ssl.Issuer}
+		if !ssl.IsValid {
+			findings = append(findings, newSARIFResult("ssl-invalid", "error", "SSL certificate is not valid", task.TargetURL, properties))
+		} else if ssl.DaysRemaining < sslExpiryWarningDays {
+			findings = append(findings, newSARIFResult("ssl-expiring", "warning",
+				fmt.Sprintf("SSL certificate expires in %d day(s) (%s)", ssl.DaysRemaining, ssl.ValidTo), task.TargetURL, properties))
+		}
+		// 有效期已由上面两条规则报告；提示类发现不输出
+		for _, f := range ssl.Findings {
+			if f.Check == models.SSLCheckExpiry || f.Severity == models.SeverityInfo {
+				continue
+			}
+			level := "warning"
+			if f.Severity == models.SeverityCritical || f.Severity == models.SeverityHigh {
+				level = "error"
+			}
+			findings = append(findings, newSARIFResult("ssl-certificate", level, f.Message, task.TargetURL,
+				map[string]interface{}{"check": f.Check, "id": f.ID, "severity": f.Severity}))
+		}
+	}
+	for _, check := range verdict.Checks {
+		if !check.Passed {
+			findings = append(findings, newSARIFResult("policy-violation", "error",
+				fmt.Sprintf("%s: %s (expected %s, actual %s)", check.Name, check.Message, check.Expected, check.Actual), task.TargetURL,
+				map[string]interface{}{"check": check.Name, "expected": check.Expected, "actual": check.Actual}))
+		}
+	}
+
+	invocation := map[string]interface{}{
+		"executionSuccessful": task.Status == models.TaskStatusCompleted,
+		"startTimeUtc":        task.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if task.CompletedAt != nil {
+		invocation["endTimeUtc"] = task.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	if task.Error != "" {
+		invocation["toolExecutionNotifications"] = []map[string]interface{}{
+			{"level": "error", "message": sarifMessage{Text: task.Error}},
+		}
+	}
+
+	sarifLog := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]interface{}{{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":    "WebCheckly",
+					"version": sarifToolVersion,
+					"rules":   sarifRules,
+				},
+			},
+			"invocations": []map[string]interface{}{invocation},
+			"results":     findings,
+			"properties": map[string]interface{}{
+				"task_id":    task.ID,
+				"target_url": task.TargetURL,
+				"verdict":    verdictText(verdict),
+				"checks":     verdict.Checks,
+			},
+		}},
+	}
+
+	content, err := json.MarshalIndent(sarifLog, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render SARIF report: %w", err)
+	}
+	return content, nil
+}
+
+// junitTestSuites JUnit XML 根元素
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite JUnit 测试套件
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+// junitProperty JUnit 套件属性
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitTestCase JUnit 测试用例
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitFailure JUnit 失败信息
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// renderReportJUnit 将门禁检查导出为 JUnit XML：每项检查为一个测试用例，未通过的检查为失败用例
+// 因此 CI 中测试失败与任务的门禁结论一致，失效链接等相关条目写在失败详情中
+func renderReportJUnit(task *models.Task) ([]byte, error) {
+	verdict := taskVerdict(task)
+
+	duration := 0.0
+	if task.CompletedAt != nil {
+		duration = task.CompletedAt.Sub(task.CreatedAt).Seconds()
+	}
+	classname := "webcheckly." + strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(task.TargetURL, "https://"), "http://"), "/")
+
+	suite := junitTestSuite{
+		Name:      "WebCheckly scan policy",
+		Timestamp: task.CreatedAt.UTC().Format("2006-01-02T15:04:05"),
+		Time:      fmt.Sprintf("%.3f", duration),
+		Properties: []junitProperty{
+			{Name: "task_id", Value: task.ID},
+			{Name: "target_url", Value: task.TargetURL},
+			{Name: "verdict", Value: verdictText(verdict)},
+		},
+	}
+	for _, check := range verdict.Checks {
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("%s %s", check.Name, check.Expected),
+			Classname: classname,
+			Time:      "0",
+			SystemOut: fmt.Sprintf("%s (actual: %s)", check.Message, check.Actual),
+		}
+		if !check.Passed {
+			testCase.Failure = &junitFailure{
+				Message: check.Message,
+				Type:    check.Name,
+				Text:    strings.Join(check.Details, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Tests = len(suite.Cases)
+
+	report := junitTestSuites{
+		Name:     "WebCheckly",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return nil, fmt.Errorf("failed to render JUnit report: %w", err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
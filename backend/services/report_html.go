@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+)
+
+// reportHTMLTemplate 自包含的 HTML 报告模板（样式内联，不引用任何外部资源，可直接发送或打印）
+var reportHTMLTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(ratio float64) string { return fmt.Sprintf("%.0f%%", ratio*100) },
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - {{.TargetURL}}</title>
+<style>
+*{box-sizing:border-box}
+body{margin:0;background:#f3f5f9;color:#1f2933;font:14px/1.55 -apple-system,BlinkMacSystemFont,"Segoe UI","PingFang SC","Microsoft YaHei",Helvetica,Arial,sans-serif}
+.page{max-width:960px;margin:0 auto;padding:32px 24px}
+header.brand{background:#1d4ed8;color:#fff;border-radius:10px;padding:24px 28px;margin-bottom:24px}
+header.brand .logo{font-weight:700;letter-spacing:.5px;font-size:15px;opacity:.9}
+header.brand h1{margin:8px 0 4px;font-size:24px}
+header.brand .target{font-size:15px;word-break:break-all}
+header.brand .meta{font-size:12px;opacity:.8;margin-top:6px}
+section{background:#fff;border-radius:10px;padding:20px 24px;margin-bottom:18px;box-shadow:0 1px 2px rgba(15,23,42,.06);page-break-inside:avoid}
+section h2{margin:0 0 14px;font-size:18px;color:#1d4ed8;border-bottom:2px solid #e5e9f2;padding-bottom:8px}
+h3{font-size:14px;margin:16px 0 8px;color:#334155}
+dl.fields{display:grid;grid-template-columns:200px 1fr;gap:6px 16px;margin:0}
+dl.fields dt{color:#64748b}
+dl.fields dd{margin:0;word-break:break-word;white-space:pre-line}
+.scores{display:flex;flex-wrap:wrap;gap:12px;margin:4px 0 8px}
+.score{flex:1 1 150px;border:1px solid #e5e9f2;border-radius:8px;padding:12px 14px}
+.score .label{color:#64748b;font-size:12px}
+.score .value{font-size:26px;font-weight:700}
+.bar{height:6px;background:#e5e9f2;border-radius:3px;overflow:hidden;margin-top:6px}
+.bar span{display:block;height:100%}
+table{width:100%;border-collapse:collapse;font-size:12.5px;table-layout:fixed}
+th{background:#f1f5f9;text-align:left;color:#475569;font-weight:600}
+th,td{padding:6px 8px;border-bottom:1px solid #e5e9f2;word-break:break-all;vertical-align:top}
+.note{color:#64748b;font-size:12px;margin-top:6px}
+ul{margin:0;padding-left:20px}
+li{margin:3px 0}
+p.text{margin:0;white-space:pre-line}
+.good{color:#15803d}.warn{color:#b45309}.bad{color:#b91c1c}
+.bar .good{background:#16a34a}.bar .warn{background:#f59e0b}.bar .bad{background:#dc2626}
+tr.bad td:first-child{border-left:3px solid #dc2626}
+tr.warn td:first-child{border-left:3px solid #f59e0b}
+footer{text-align:center;color:#94a3b8;font-size:12px;margin:24px 0 8px}
+@media print{body{background:#fff}.page{padding:0}section{box-shadow:none;border:1px solid #e5e9f2}header.brand{-webkit-print-color-adjust:exact;print-color-adjust:exact}}
+</style>
+</head>
+<body>
+<div class="page">
+<header class="brand">
+<div class="logo">WebCheckly</div>
+<h1>{{.Title}}</h1>
+<div class="target">{{.TargetURL}}</div>
+<div class="meta">{{index .Labels "generated_at"}}: {{.GeneratedAt}}</div>
+</header>
+
+<section>
+<h2>{{index .Labels "overview"}}</h2>
+{{template "fields" .Overview}}
+{{if .Scores}}<h3>{{index .Labels "scores"}}</h3>{{template "scores" .Scores}}{{end}}
+</section>
+
+{{range .Sections}}
+<section>
+<h2>{{.Title}}</h2>
+{{range .Blocks}}
+{{if .Title}}<h3>{{.Title}}</h3>{{end}}
+{{if eq .Kind "fields"}}{{template "fields" .Fields}}
+{{else if eq .Kind "scores"}}{{template "scores" .Scores}}
+{{else if eq .Kind "table"}}
+<table>
+<colgroup>{{range .Table.Widths}}<col style="width:{{percent .}}">{{end}}</colgroup>
+<thead><tr>{{range .Table.Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Table.Rows}}<tr class="{{.Level}}">{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{end}}
+</tbody>
+</table>
+{{if .Table.Note}}<div class="note">{{.Table.Note}}</div>{{end}}
+{{else if eq .Kind "list"}}
+<ul>{{range .Items}}<li>{{.}}</li>{{end}}</ul>
+{{else if eq .Kind "text"}}
+<p class="text">{{.Text}}</p>
+{{end}}
+{{end}}
+</section>
+{{end}}
+
+<footer>{{.Footer}} · {{.GeneratedAt}}</footer>
+</div>
+</body>
+</html>
+{{define "fields"}}<dl class="fields">{{range .}}<dt>{{.Label}}</dt><dd{{if .Level}} class="{{.Level}}"{{end}}>{{.Value}}</dd>{{end}}</dl>{{end}}
+{{define "scores"}}<div class="scores">{{range .}}<div class="score"><div class="label">{{.Label}}</div><div class="value {{.Level}}">{{.Score}}</div><div class="bar"><span class="{{.Level}}" style="width:{{.Score}}%"></span></div></div>{{end}}</div>{{end}}
+`))
+
+// reportHTMLData HTML 模板数据
+type reportHTMLData struct {
+	*taskReport
+	Labels map[string]string
+}
+
+// renderReportHTML 渲染 HTML 报告
+func renderReportHTML(report *taskReport) ([]byte, error) {
+	var buf bytes.Buffer
+	data := reportHTMLData{taskReport: report, Labels: reportMessages[report.Lang]}
+	if err := reportHTMLTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
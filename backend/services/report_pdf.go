@@ -0,0 +1,308 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"web-checkly/utils"
+)
+
+// PDF 报告版式（单位：pt）
+const (
+	pdfMargin        = 48.0
+	pdfContentWidth  = utils.PDFPageWidth - 2*pdfMargin
+	pdfTopY          = 56.0                       // 续页内容起始位置
+	pdfBottomY       = utils.PDFPageHeight - 56.0 // 内容区底部（下方为页脚）
+	pdfLabelWidth    = 150.0                      // 字段名称列宽
+	pdfBodySize      = 9.5
+	pdfBodyLeading   = 13.0
+	pdfTableSize     = 8.5
+	pdfTableLeading  = 11.0
+	pdfTableMaxLines = 3 // 表格单元格最多显示的行数，超出部分截断
+)
+
+// PDF 报告配色
+var (
+	pdfBrandColor  = utils.PDFColor{R: 29, G: 78, B: 216}
+	pdfTextColor   = utils.PDFColor{R: 31, G: 41, B: 51}
+	pdfMutedColor  = utils.PDFColor{R: 100, G: 116, B: 139}
+	pdfBorderColor = utils.PDFColor{R: 226, G: 232, B: 240}
+	pdfHeaderFill  = utils.PDFColor{R: 241, G: 245, B: 249}
+	pdfWhite       = utils.PDFColor{R: 255, G: 255, B: 255}
+	pdfLevelColors = map[string]utils.PDFColor{
+		"good": {R: 21, G: 128, B: 61},
+		"warn": {R: 180, G: 83, B: 9},
+		"bad":  {R: 185, G: 28, B: 28},
+	}
+)
+
+// pdfReportWriter 按从上到下的顺序排版报告，空间不足时自动换页
+type pdfReportWriter struct {
+	pdf *utils.PDF
+	y   float64
+}
+
+// renderReportPDF 渲染 PDF 报告
+func renderReportPDF(report *taskReport) ([]byte, error) {
+	w := &pdfReportWriter{pdf: utils.NewPDF(report.Title + " - " + report.TargetURL)}
+	w.pdf.AddPage()
+	w.writeHeader(report)
+
+	w.writeSectionTitle(reportMessages[report.Lang]["overview"])
+	w.writeFields(report.Overview)
+	if len(report.Scores) > 0 {
+		w.writeSubtitle(reportMessages[report.Lang]["scores"])
+		w.writeScores(report.Scores)
+	}
+
+	for _, section := range report.Sections {
+		w.writeSectionTitle(section.Title)
+		for _, block := range section.Blocks {
+			if block.Title != "" {
+				w.writeSubtitle(block.Title)
+			}
+			switch block.Kind {
+			case "fields":
+				w.writeFields(block.Fields)
+			case "scores":
+				w.writeScores(block.Scores)
+			case "table":
+				w.writeTable(block.Table)
+			case "list":
+				w.writeList(block.Items)
+			case "text":
+				w.writeParagraph(block.Text, pdfTextColor)
+			}
+		}
+	}
+
+	w.writeFooters(report)
+	return w.pdf.Bytes()
+}
+
+// ensureSpace 剩余空间不足 height 时换页
+func (w *pdfReportWriter) ensureSpace(height float64) {
+	if w.y+height > pdfBottomY {
+		w.pdf.AddPage()
+		w.y = pdfTopY
+	}
+}
+
+// writeHeader 首页品牌页眉
+func (w *pdfReportWriter) writeHeader(report *taskReport) {
+	w.pdf.Rect(0, 0, utils.PDFPageWidth, 104, pdfBrandColor)
+	w.pdf.Text(pdfMargin, 32, 11, true, pdfWhite, "WebCheckly")
+	w.pdf.Text(pdfMargin, 58, 20, true, pdfWhite, report.Title)
+	w.pdf.Text(pdfMargin, 78, 11, false, pdfWhite, w.pdf.TruncateText(report.TargetURL, pdfContentWidth, 11, false))
+	w.pdf.Text(pdfMargin, 94, 8.5, false, pdfWhite, reportMessages[report.Lang]["generated_at"]+": "+report.GeneratedAt)
+	w.y = 128
+}
+
+// writeFooters 为每一页添加页脚和页码
+func (w *pdfReportWriter) writeFooters(report *taskReport) {
+	total := w.pdf.PageCount()
+	for i := 0; i < total; i++ {
+		w.pdf.SetPage(i)
+		footerY := utils.PDFPageHeight - 36
+		w.pdf.Line(pdfMargin, footerY-12, utils.PDFPageWidth-pdfMargin, footerY-12, 0.5, pdfBorderColor)
+		w.pdf.Text(pdfMargin, footerY, 8, false, pdfMutedColor, w.pdf.TruncateText(report.Footer+" | "+report.TargetURL, pdfContentWidth-60, 8, false))
+		pageNumber := fmt.Sprintf("%d / %d", i+1, total)
+		w.pdf.Text(utils.PDFPageWidth-pdfMargin-w.pdf.TextWidth(pageNumber, 8, false), footerY, 8, false, pdfMutedColor, pageNumber)
+	}
+}
+
+// writeSectionTitle 章节标题（与下方至少一行内容保持在同一页）
+func (w *pdfReportWriter) writeSectionTitle(title string) {
+	w.y += 10
+	w.ensureSpace(48)
+	w.pdf.Text(pdfMargin, w.y+14, 14, true, pdfBrandColor, title)
+	w.pdf.Line(pdfMargin, w.y+22, pdfMargin+pdfContentWidth, w.y+22, 1.2, pdfBorderColor)
+	w.y += 32
+}
+
+// writeSubtitle 内容块标题
+func (w *pdfReportWriter) writeSubtitle(title string) {
+	w.ensureSpace(36)
+	w.pdf.Text(pdfMargin, w.y+12, 10.5, true, pdfTextColor, title)
+	w.y += 20
+}
+
+// writeFields 名称-值字段，值过长时折行
+func (w *pdfReportWriter) writeFields(fields []reportField) {
+	valueWidth := pdfContentWidth - pdfLabelWidth
+	for _, field := range fields {
+		lines := w.pdf.WrapText(field.Value, valueWidth, pdfBodySize, false)
+		labelLines := w.pdf.WrapText(field.Label, pdfLabelWidth-10, pdfBodySize, false)
+		rows := len(lines)
+		if len(labelLines) > rows {
+			rows = len(labelLines)
+		}
+
+		color := pdfTextColor
+		if levelColor, ok := pdfLevelColors[field.Level]; ok {
+			color = levelColor
+		}
+		for i := 0; i < rows; i++ {
+			w.ensureSpace(pdfBodyLeading)
+			baseline := w.y + pdfBodySize
+			if i < len(labelLines) {
+				w.pdf.Text(pdfMargin, baseline, pdfBodySize, false, pdfMutedColor, labelLines[i])
+			}
+			if i < len(lines) {
+				w.pdf.Text(pdfMargin+pdfLabelWidth, baseline, pdfBodySize, false, color, lines[i])
+			}
+			w.y += pdfBodyLeading
+		}
+		w.y += 3
+	}
+	w.y += 4
+}
+
+// writeScores 评分卡片，每行最多 4 个
+func (w *pdfReportWriter) writeScores(scores []reportScore) {
+	const perRow, gap, height = 4, 10.0, 58.0
+	boxWidth := (pdfContentWidth - gap*(perRow-1)) / perRow
+
+	for start := 0; start < len(scores); start += perRow {
+		w.ensureSpace(height + 8)
+		for i := start; i < start+perRow && i < len(scores); i++ {
+			score := scores[i]
+			x := pdfMargin + float64(i-start)*(boxWidth+gap)
+			color := pdfLevelColors[score.Level()]
+
+			w.pdf.Rect(x, w.y, boxWidth, height, pdfHeaderFill)
+			w.pdf.Text(x+10, w.y+15, 8.5, false, pdfMutedColor, w.pdf.TruncateText(score.Label, boxWidth-20, 8.5, false))
+			w.pdf.Text(x+10, w.y+39, 20, true, color, strconv.Itoa(score.Score))
+
+			barWidth := boxWidth - 20
+			w.pdf.Rect(x+10, w.y+47, barWidth, 4, pdfBorderColor)
+			filled := barWidth * float64(clampScore(score.Score)) / 100
+			if filled > 0 {
+				w.pdf.Rect(x+10, w.y+47, filled, 4, color)
+			}
+		}
+		w.y += height + 8
+	}
+	w.y += 2
+}
+
+// writeTable 表格，换页时重复表头；行状态以左侧色条表示
+func (w *pdfReportWriter) writeTable(table *reportTable) {
+	if table == nil || len(table.Headers) == 0 {
+		return
+	}
+	widths := make([]float64, len(table.Headers))
+	for i := range widths {
+		if i < len(table.Widths) {
+			widths[i] = table.Widths[i] * pdfContentWidth
+		} else {
+			widths[i] = pdfContentWidth / float64(len(widths))
+		}
+	}
+
+	w.ensureSpace(pdfTableLeading*2 + 12)
+	w.writeTableHeader(table.Headers, widths)
+
+	for _, row := range table.Rows {
+		cells := make([][]string, len(widths))
+		lines := 1
+		for i := range widths {
+			text := ""
+			if i < len(row.Cells) {
+				text = row.Cells[i]
+			}
+			cells[i] = w.cellLines(text, widths[i]-8)
+			if len(cells[i]) > lines {
+				lines = len(cells[i])
+			}
+		}
+		height := float64(lines)*pdfTableLeading + 6
+
+		if w.y+height > pdfBottomY {
+			w.pdf.AddPage()
+			w.y = pdfTopY
+			w.writeTableHeader(table.Headers, widths)
+		}
+
+		if color, ok := pdfLevelColors[row.Level]; ok && row.Level != "good" {
+			w.pdf.Rect(pdfMargin, w.y, 2.5, height, color)
+		}
+		x := pdfMargin
+		for i, cellLines := range cells {
+			for j, line := range cellLines {
+				w.pdf.Text(x+5, w.y+3+pdfTableSize+float64(j)*pdfTableLeading, pdfTableSize, false, pdfTextColor, line)
+			}
+			x += widths[i]
+		}
+		w.y += height
+		w.pdf.Line(pdfMargin, w.y, pdfMargin+pdfContentWidth, w.y, 0.5, pdfBorderColor)
+	}
+
+	if table.Note != "" {
+		w.ensureSpace(pdfBodyLeading + 4)
+		w.pdf.Text(pdfMargin, w.y+4+8, 8, false, pdfMutedColor, table.Note)
+		w.y += pdfBodyLeading + 4
+	}
+	w.y += 8
+}
+
+// writeTableHeader 表头
+func (w *pdfReportWriter) writeTableHeader(headers []string, widths []float64) {
+	height := pdfTableLeading + 8
+	w.pdf.Rect(pdfMargin, w.y, pdfContentWidth, height, pdfHeaderFill)
+	x := pdfMargin
+	for i, header := range headers {
+		w.pdf.Text(x+5, w.y+4+pdfTableSize, pdfTableSize, true, pdfMutedColor, w.pdf.TruncateText(header, widths[i]-8, pdfTableSize, true))
+		x += widths[i]
+	}
+	w.y += height
+}
+
+// cellLines 单元格折行，超过 pdfTableMaxLines 行时截断
+func (w *pdfReportWriter) cellLines(text string, width float64) []string {
+	lines := w.pdf.WrapText(text, width, pdfTableSize, false)
+	if len(lines) > pdfTableMaxLines {
+		lines = lines[:pdfTableMaxLines]
+		lines[pdfTableMaxLines-1] = w.pdf.TruncateText(lines[pdfTableMaxLines-1]+" ...", width, pdfTableSize, false)
+	}
+	return lines
+}
+
+// writeList 列表
+func (w *pdfReportWriter) writeList(items []string) {
+	const indent = 12.0
+	for _, item := range items {
+		lines := w.pdf.WrapText(item, pdfContentWidth-indent, pdfBodySize, false)
+		for i, line := range lines {
+			w.ensureSpace(pdfBodyLeading)
+			baseline := w.y + pdfBodySize
+			if i == 0 {
+				w.pdf.Text(pdfMargin+2, baseline, pdfBodySize, true, pdfBrandColor, "-")
+			}
+			w.pdf.Text(pdfMargin+indent, baseline, pdfBodySize, false, pdfTextColor, line)
+			w.y += pdfBodyLeading
+		}
+		w.y += 2
+	}
+	w.y += 6
+}
+
+// writeParagraph 段落
+func (w *pdfReportWriter) writeParagraph(text string, color utils.PDFColor) {
+	for _, line := range w.pdf.WrapText(text, pdfContentWidth, pdfBodySize, false) {
+		w.ensureSpace(pdfBodyLeading)
+		w.pdf.Text(pdfMargin, w.y+pdfBodySize, pdfBodySize, false, color, line)
+		w.y += pdfBodyLeading
+	}
+	w.y += 8
+}
+
+// clampScore 将评分限制在 0-100
+func clampScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
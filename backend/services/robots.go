@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsUserAgent 进程内爬虫在 robots.txt 中匹配的 User-agent 名称
+const robotsUserAgent = "webcheckly"
+
+// maxRobotsCrawlDelay robots.txt 中 Crawl-delay 的上限（避免单个站点拖慢整个爬取）
+const maxRobotsCrawlDelay = 10 * time.Second
+
+// robotsRules 适用于进程内爬虫的 robots.txt 规则
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsRule 单条 Allow/Disallow 规则
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// fetchRobotsRules 读取站点的 robots.txt，读取失败或不存在时不限制
+func fetchRobotsRules(ctx context.Context, root *url.URL) *robotsRules {
+	body, err := fetchSitemapBody(ctx, root.String()+"/robots.txt")
+	if err != nil {
+		return &robotsRules{}
+	}
+	return parseRobots(body, robotsUserAgent)
+}
+
+// parseRobots 解析 robots.txt，使用与 userAgent 匹配的分组，没有时使用 User-agent: * 分组
+func parseRobots(body []byte, userAgent string) *robotsRules {
+	type group struct {
+		agents     []string
+		rules      []robotsRule
+		crawlDelay time.Duration
+	}
+	var groups []*group
+	var current *group
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// 连续的 User-agent 行属于同一分组
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			// 空的 Disallow 表示不限制
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value, re: compileRobotsPattern(value)})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					current.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+		lastWasAgent = false
+	}
+
+	var matched, wildcard *group
+	userAgent = strings.ToLower(userAgent)
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = g
+				}
+			} else if strings.Contains(userAgent, agent) && matched == nil {
+				matched = g
+			}
+		}
+	}
+	if matched == nil {
+		matched = wildcard
+	}
+	if matched == nil {
+		return &robotsRules{}
+	}
+
+	delay := matched.crawlDelay
+	if delay > maxRobotsCrawlDelay {
+		delay = maxRobotsCrawlDelay
+	}
+	return &robotsRules{rules: matched.rules, crawlDelay: delay}
+}
+
+// Allowed 路径是否允许爬取：使用匹配长度最长的规则，长度相同时 Allow 优先
+func (r *robotsRules) Allowed(u *url.URL) bool {
+	target := u.EscapedPath()
+	if target == "" {
+		target = "/"
+	}
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(target) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			longest = len(rule.pattern)
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// compileRobotsPattern 将 robots.txt 路径规则转换为正则（支持 * 通配符和 $ 结尾锚定）
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 认证扫描配置的限制
+const (
+	maxScanAuthHeaders   = 20
+	maxScanAuthCookies   = 50
+	maxScanAuthFields    = 20
+	maxScanAuthValueLen  = 4096
+	scanAuthLoginTimeout = 30 * time.Second
+	maxLoginPageBytes    = 2 << 20
+)
+
+// ErrInvalidScanAuth 认证扫描配置无效
+var ErrInvalidScanAuth = errors.New("invalid auth config")
+
+// ErrScanAuthDisabled 服务端未配置 SCAN_AUTH_ENCRYPTION_KEY，无法加密存储凭据
+var ErrScanAuthDisabled = errors.New("authenticated scanning is not enabled on this server")
+
+// forbiddenAuthHeaders 不允许自定义的请求头（由 HTTP 客户端管理，Cookie 请使用 cookies 字段）
+var forbiddenAuthHeaders = map[string]bool{
+	"Host":                true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Transfer-Encoding":   true,
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Upgrade":             true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Cookie":              true,
+}
+
+// headerNamePattern HTTP 请求头名称（token）
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// logoutURLPattern 退出登录地址：认证信息不发送给这些地址，避免链接检查或爬取时使会话失效
+var logoutURLPattern = regexp.MustCompile(`(?i)(log-?out|sign-?out|log-?off)`)
+
+// ValidateScanAuth 校验认证扫描配置（auth 为 nil 表示不认证）
+func ValidateScanAuth(auth *models.ScanAuth) error {
+	if auth == nil {
+		return nil
+	}
+	if !utils.SecretEncryptionEnabled() {
+		return ErrScanAuthDisabled
+	}
+	if len(auth.Headers) == 0 && len(auth.Cookies) == 0 && auth.BasicAuth == nil && auth.FormLogin == nil {
+		return fmt.Errorf("%w: at least one of headers, cookies, basic_auth or form_login is required", ErrInvalidScanAuth)
+	}
+
+	if len(auth.Headers) > maxScanAuthHeaders {
+		return fmt.Errorf("%w: at most %d headers allowed", ErrInvalidScanAuth, maxScanAuthHeaders)
+	}
+	for name, value := range auth.Headers {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: invalid header name %q", ErrInvalidScanAuth, name)
+		}
+		if forbiddenAuthHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("%w: header %q cannot be set", ErrInvalidScanAuth, name)
+		}
+		if auth.BasicAuth != nil && http.CanonicalHeaderKey(name) == "Authorization" {
+			return fmt.Errorf("%w: Authorization header conflicts with basic_auth", ErrInvalidScanAuth)
+		}
+		if !validAuthValue(value) {
+			return fmt.Errorf("%w: invalid value for header %q", ErrInvalidScanAuth, name)
+		}
+	}
+
+	if len(auth.Cookies) > maxScanAuthCookies {
+		return fmt.Errorf("%w: at most %d cookies allowed", ErrInvalidScanAuth, maxScanAuthCookies)
+	}
+	for name, value := range auth.Cookies {
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: invalid cookie name %q", ErrInvalidScanAuth, name)
+		}
+		if !validAuthValue(value) || strings.ContainsAny(value, ";\"") {
+			return fmt.Errorf("%w: invalid value for cookie %q", ErrInvalidScanAuth, name)
+		}
+	}
+
+	if basic := auth.BasicAuth; basic != nil {
+		if basic.Username == "" || strings.Contains(basic.Username, ":") || !validAuthValue(basic.Username) || !validAuthValue(basic.Password) {
+			return fmt.Errorf("%w: basic_auth requires a username without ':'", ErrInvalidScanAuth)
+		}
+	}
+
+	if login := auth.FormLogin; login != nil {
+		loginURL, err := url.Parse(login.LoginURL)
+		if err != nil || (loginURL.Scheme != "http" && loginURL.Scheme != "https") || loginURL.Hostname() == "" {
+			return fmt.Errorf("%w: form_login.login_url must be an http(s) URL", ErrInvalidScanAuth)
+		}
+		if utils.IsPrivateIP(loginURL.Hostname()) {
+			return fmt.Errorf("%w: form_login.login_url points to a private address", ErrInvalidScanAuth)
+		}
+		if login.Username == "" || login.Password == "" {
+			return fmt.Errorf("%w: form_login requires username and password", ErrInvalidScanAuth)
+		}
+		if len(login.ExtraFields) > maxScanAuthFields {
+			return fmt.Errorf("%w: at most %d extra_fields allowed", ErrInvalidScanAuth, maxScanAuthFields)
+		}
+		for _, value := range []string{login.UsernameField, login.PasswordField, login.Username, login.Password} {
+			if len(value) > maxScanAuthValueLen {
+				return fmt.Errorf("%w: form_login value too long", ErrInvalidScanAuth)
+			}
+		}
+	}
+	return nil
+}
+
+// validAuthValue 请求头和 Cookie 的值不能包含换行或控制字符（防止请求头注入）
+func validAuthValue(value string) bool {
+	if len(value) > maxScanAuthValueLen {
+		return false
+	}
+	for _, r := range value {
+		if (r < 0x20 && r != '\t') || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// ScanAuthSession 任务执行时使用的认证信息：自定义请求头、Cookie（含表单登录获得的 Cookie）和 Authorization
+// 只发送给与目标同站（忽略 www. 前缀）且不是退出登录地址的请求；为 nil 时表示不认证，所有方法都可以在 nil 上调用
+type ScanAuthSession struct {
+	target *url.URL
+	header http.Header
+}
+
+// ResolveScanAuth 根据任务的认证配置创建认证会话，配置了表单登录时先登录（auth 为 nil 时返回 nil）
+func ResolveScanAuth(ctx context.Context, targetURL string, auth *models.ScanAuth) (*ScanAuthSession, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	target, err := url.Parse(targetURL)
+	if err != nil || target.Hostname() == "" {
+		return nil, fmt.Errorf("invalid target url: %s", targetURL)
+	}
+
+	s := &ScanAuthSession{target: target, header: make(http.Header)}
+	for name, value := range auth.Headers {
+		s.header.Set(name, value)
+	}
+	if auth.BasicAuth != nil {
+		credentials := auth.BasicAuth.Username + ":" + auth.BasicAuth.Password
+		s.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	cookies := make(map[string]string, len(auth.Cookies))
+	for name, value := range auth.Cookies {
+		cookies[name] = value
+	}
+	if len(cookies) > 0 {
+		s.header.Set("Cookie", encodeCookies(cookies))
+	}
+
+	if auth.FormLogin != nil {
+		loginCookies, err := formLogin(ctx, s, auth.FormLogin)
+		if err != nil {
+			return nil, fmt.Errorf("form login failed: %w", err)
+		}
+		for _, cookie := range loginCookies {
+			cookies[cookie.Name] = cookie.Value
+		}
+		s.header.Set("Cookie", encodeCookies(cookies))
+		log.Printf("[ScanAuth] Form login succeeded for %s (%d cookies)", target.Host, len(loginCookies))
+	}
+	return s, nil
+}
+
+// appliesTo 是否向该地址发送认证信息：只发送给与目标协议和端口都相同的同站地址，
+// 避免 https 目标的凭据以明文发往 http 地址，或被同一主机上其他端口的服务收到
+func (s *ScanAuthSession) appliesTo(u *url.URL) bool {
+	return s != nil && sameOrigin(s.target, u) && !logoutURLPattern.MatchString(u.Path+"?"+u.RawQuery)
+}
+
+// sameOrigin 两个地址是否同站（忽略 www. 前缀）且协议和实际端口相同
+func sameOrigin(target, u *url.URL) bool {
+	return sameSite(target, u) && strings.EqualFold(target.Scheme, u.Scheme) && effectivePort(target) == effectivePort(u)
+}
+
+// effectivePort 地址实际使用的端口：未指定时为协议的默认端口
+func effectivePort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+// ScopePattern 与 appliesTo 对应的地址正则（不含退出登录地址的判断），用于限制外部工具（katana -cs）请求的范围
+func (s *ScanAuthSession) ScopePattern() string {
+	host := regexp.QuoteMeta(strings.TrimPrefix(strings.ToLower(s.target.Hostname()), "www."))
+	if strings.Contains(host, ":") {
+		host = `\[` + host + `\]`
+	}
+	port := ":" + effectivePort(s.target)
+	if s.target.Port() == "" {
+		port = "(" + port + ")?"
+	}
+	return `(?i)^` + regexp.QuoteMeta(s.target.Scheme) + `://(www\.)?` + host + port + `([/?#]|$)`
+}
+
+// Apply 为同站请求附加认证请求头
+func (s *ScanAuthSession) Apply(req *http.Request) {
+	if !s.appliesTo(req.URL) {
+		return
+	}
+	for name, values := range s.header {
+		req.Header[name] = append([]string(nil), values...)
+	}
+}
+
+// HTTPClient 创建安全 HTTP 客户端：重定向到其他网站（或退出登录地址）时去掉认证请求头，回到同站时重新附加
+func (s *ScanAuthSession) HTTPClient(timeout time.Duration) *http.Client {
+	client := utils.NewSafeHTTPClient(timeout)
+	if s == nil {
+		return client
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := utils.CheckSafeRedirect(req, via); err != nil {
+			return err
+		}
+		if s.appliesTo(req.URL) {
+			s.Apply(req)
+		} else {
+			for name := range s.header {
+				req.Header.Del(name)
+			}
+		}
+		return nil
+	}
+	return client
+}
+
+// HeaderArgs 外部工具（httpx、katana）的请求头参数，如 -H "Cookie: a=b"
+// 外部工具无法区分请求的主机，调用方需要保证只用于同站请求
+func (s *ScanAuthSession) HeaderArgs(flag string) []string {
+	if s == nil {
+		return nil
+	}
+	var args []string
+	for _, name := range s.headerNames() {
+		args = append(args, flag, name+": "+strings.Join(s.header[name], ", "))
+	}
+	return args
+}
+
+// Fingerprint 认证信息的摘要，用于区分缓存（如 Lighthouse 报告），不泄露凭据本身
+func (s *ScanAuthSession) Fingerprint() string {
+	if s == nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, name := range s.headerNames() {
+		fmt.Fprintf(h, "%s: %s\n", name, strings.Join(s.header[name], ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// headerNames 排序后的请求头名称（保证参数和摘要稳定）
+func (s *ScanAuthSession) headerNames() []string {
+	names := make([]string, 0, len(s.header))
+	for name := range s.header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formLogin 执行表单登录，返回登录后目标网站的 Cookie
+// 打开登录页面，找到包含密码字段的表单，保留表单中的已有字段（如 CSRF token），填入用户名和密码后提交
+func formLogin(ctx context.Context, s *ScanAuthSession, login *models.FormLogin) ([]*http.Cookie, error) {
+	usernameField := login.UsernameField
+	if usernameField == "" {
+		usernameField = "username"
+	}
+	passwordField := login.PasswordField
+	if passwordField == "" {
+		passwordField = "password"
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.HTTPClient(scanAuthLoginTimeout)
+	client.Jar = jar
+
+	// 1. 打开登录页面（获取会话 Cookie 和表单隐藏字段）
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, login.LoginURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+	s.Apply(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch login page: %w", err)
+	}
+	pageURL := resp.Request.URL
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxLoginPageBytes))
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("login page returned HTTP %d", resp.StatusCode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse login page: %w", err)
+	}
+
+	// 2. 填写表单（没有找到表单时直接向登录地址提交）
+	values := url.Values{}
+	action := pageURL
+	method := http.MethodPost
+	if form := findLoginForm(doc, passwordField); form != nil {
+		collectFormValues(form, values)
+		if ref := strings.TrimSpace(form.AttrOr("action", "")); ref != "" {
+			if resolved, err := pageURL.Parse(ref); err == nil {
+				action = resolved
+			}
+		}
+		if strings.EqualFold(form.AttrOr("method", ""), http.MethodGet) {
+			method = http.MethodGet
+		}
+	}
+	values.Set(usernameField, login.Username)
+	values.Set(passwordField, login.Password)
+	for name, value := range login.ExtraFields {
+		values.Set(name, value)
+	}
+
+	// 3. 提交表单
+	var body io.Reader
+	submitURL := *action
+	if method == http.MethodGet {
+		submitURL.RawQuery = values.Encode()
+	} else {
+		body = strings.NewReader(values.Encode())
+	}
+	req, err = http.NewRequestWithContext(ctx, method, submitURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login request: %w", err)
+	}
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+	req.Header.Set("Referer", pageURL.String())
+	req.Header.Set("Origin", pageURL.Scheme+"://"+pageURL.Host)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	s.Apply(req)
+	resp, err = client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit login form: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("login request returned HTTP %d", resp.StatusCode)
+	}
+
+	// 4. 登录后页面仍显示登录表单，通常是用户名或密码错误
+	if isHTMLResponse(resp) {
+		if result, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxLoginPageBytes)); err == nil && findLoginForm(result, passwordField) != nil {
+			return nil, errors.New("login form is still shown after submitting, check the credentials and field names")
+		}
+	}
+
+	cookies := jar.Cookies(s.target)
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("login did not set any cookie for %s", s.target.Host)
+	}
+	return cookies, nil
+}
+
+// findLoginForm 查找包含指定密码字段的表单
+func findLoginForm(doc *goquery.Document, passwordField string) *goquery.Selection {
+	var found *goquery.Selection
+	doc.Find("form").EachWithBreak(func(i int, form *goquery.Selection) bool {
+		form.Find("input").EachWithBreak(func(j int, input *goquery.Selection) bool {
+			if input.AttrOr("name", "") == passwordField {
+				found = form
+			}
+			return found == nil
+		})
+		return found == nil
+	})
+	return found
+}
+
+// collectFormValues 按浏览器提交表单的规则收集字段的默认值（跳过按钮、未选中的单选和复选框）
+func collectFormValues(form *goquery.Selection, values url.Values) {
+	form.Find("input[name], select[name], textarea[name]").Each(func(i int, field *goquery.Selection) {
+		name := field.AttrOr("name", "")
+		if _, disabled := field.Attr("disabled"); disabled {
+			return
+		}
+		switch goquery.NodeName(field) {
+		case "textarea":
+			values.Add(name, field.Text())
+		case "select":
+			option := field.Find("option[selected]").First()
+			if option.Length() == 0 {
+				option = field.Find("option").First()
+			}
+			if option.Length() > 0 {
+				values.Add(name, option.AttrOr("value", strings.TrimSpace(option.Text())))
+			}
+		default:
+			switch strings.ToLower(field.AttrOr("type", "text")) {
+			case "submit", "button", "image", "reset", "file":
+				return
+			case "checkbox", "radio":
+				if _, checked := field.Attr("checked"); !checked {
+					return
+				}
+				values.Add(name, field.AttrOr("value", "on"))
+			default:
+				values.Add(name, field.AttrOr("value", ""))
+			}
+		}
+	})
+}
+
+// encodeCookies 生成 Cookie 请求头（按名称排序）
+func encodeCookies(cookies map[string]string) string {
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+cookies[name])
+	}
+	return strings.Join(pairs, "; ")
+}
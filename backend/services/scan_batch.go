@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"web-checkly/database"
+	"web-checkly/models"
+
+	"github.com/google/uuid"
+)
+
+// maxScanBatchURLs 单个批量扫描最多包含的 URL 数
+const maxScanBatchURLs = 500
+
+// ErrInvalidScanBatch 批量扫描参数无效
+var ErrInvalidScanBatch = errors.New("invalid scan batch")
+
+// InvalidBatchURL 批量扫描中无法扫描的 URL 及原因
+type InvalidBatchURL struct {
+	Line  int    `json:"line"` // 在提交列表中的序号（从 1 开始）
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// InvalidBatchURLsError 批量扫描包含无效 URL（整批拒绝，不创建任何任务）
+type InvalidBatchURLsError struct {
+	URLs []InvalidBatchURL
+}
+
+func (e *InvalidBatchURLsError) Error() string {
+	return fmt.Sprintf("%d invalid urls in batch", len(e.URLs))
+}
+
+// IsCreditsError 判断预扣或创建使用记录的错误是否由积分不足或无权使用功能引起
+func IsCreditsError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "insufficient credits") || strings.Contains(msg, "access denied for feature")
+}
+
+// ParseScanBatchCSV 解析批量扫描 CSV：每行第一列为 URL，忽略空行、# 开头的注释行和表头
+func ParseScanBatchCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	reader.Comment = '#'
+
+	urls := make([]string, 0)
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse csv: %v", ErrInvalidScanBatch, err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff"))
+		if value == "" {
+			continue
+		}
+		if first {
+			first = false
+			switch strings.ToLower(value) {
+			case "url", "urls", "website", "site", "domain", "target", "target_url":
+				continue
+			}
+		}
+		urls = append(urls, value)
+	}
+	return urls, nil
+}
+
+// normalizeScanBatch 校验并规范化批量扫描参数，返回去重后的目标 URL
+func normalizeScanBatch(req *models.CreateBatchRequest) ([]string, error) {
+	if len(req.URLs) == 0 {
+		return nil, fmt.Errorf("%w: urls is required", ErrInvalidScanBatch)
+	}
+	if len(req.URLs) > maxScanBatchURLs {
+		return nil, fmt.Errorf("%w: at most %d urls per batch", ErrInvalidScanBatch, maxScanBatchURLs)
+	}
+	if err := ValidateScanPolicy(req.Policy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScanBatch, err)
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > 255 {
+		return nil, fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidScanBatch)
+	}
+	if len(req.Options) == 0 {
+		req.Options = []string{"link-health"} // 与创建任务一致，默认启用链接健康检查
+	}
+	if req.Language != "en" && req.Language != "zh" {
+		req.Language = "zh"
+	}
+	switch req.AIMode {
+	case "performance", "security", "seo", "balanced":
+	default:
+		req.AIMode = "balanced"
+	}
+
+	// 逐个校验 URL，有任何无效 URL 时整批拒绝，便于用户修正后重新提交
+	targets := make([]string, 0, len(req.URLs))
+	seen := make(map[string]bool, len(req.URLs))
+	var invalid []InvalidBatchURL
+	for i, rawURL := range req.URLs {
+		target, err := normalizeScanTarget(rawURL)
+		if err != nil {
+			invalid = append(invalid, InvalidBatchURL{Line: i + 1, URL: rawURL, Error: err.Error()})
+			continue
+		}
+		if seen[target] {
+			continue // 重复的 URL 只扫描一次
+		}
+		seen[target] = true
+		targets = append(targets, target)
+	}
+	if len(invalid) > 0 {
+		return nil, &InvalidBatchURLsError{URLs: invalid}
+	}
+
+	if req.Name == "" {
+		req.Name = targets[0]
+		if len(targets) > 1 {
+			req.Name = fmt.Sprintf("%s (+%d)", targets[0], len(targets)-1)
+		}
+	}
+	return targets, nil
+}
+
+// CreateScanBatch 创建批量扫描：一次性预扣全部子任务的积分，再为每个 URL 创建子任务
+// 预扣的使用记录在任务创建时写入 prepaid_usage_records，任务失败或取消时按任务退款，完成时不会重复扣费
+func (e *Executor) CreateScanBatch(userID string, req *models.CreateBatchRequest) (*models.ScanBatch, error) {
+	targets, err := normalizeScanBatch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &models.ScanBatch{
+		UserID:     userID,
+		Name:       req.Name,
+		Options:    req.Options,
+		Language:   req.Language,
+		AIMode:     req.AIMode,
+		Policy:     req.Policy,
+		TotalTasks: len(targets),
+	}
+	if err := database.CreateScanBatch(batch); err != nil {
+		return nil, err
+	}
+
+	usageRecordIDs, err := BatchPreDeductFeatureCostsForTasks(userID, len(targets), req.Options)
+	if err != nil {
+		if deleteErr := database.DeleteScanBatch(batch.ID); deleteErr != nil {
+			log.Printf("[ScanBatch] Failed to delete batch %s after pre-deduct failure: %v", batch.ID, deleteErr)
+		}
+		return nil, err
+	}
+
+	created := 0
+	var createErr error
+	for i, target := range targets {
+		task, err := e.taskManager.CreateTask(&models.CreateTaskRequest{
+			URL:                 target,
+			Options:             req.Options,
+			Language:            req.Language,
+			AIMode:              req.AIMode,
+			Policy:              req.Policy,
+			BatchID:             &batch.ID,
+			PrepaidUsageRecords: usageRecordIDs[i],
+		}, &userID)
+		if err != nil {
+			// 退还尚未创建任务的预扣积分，已创建的任务照常执行
+			createErr = err
+			for _, records := range usageRecordIDs[i:] {
+				refundBatchUsageRecords(records)
+			}
+			break
+		}
+		LinkUsageRecordsToTask(task.ID, usageRecordIDs[i])
+		e.StartTaskExecution(task.ID)
+		created++
+	}
+
+	if createErr != nil {
+		log.Printf("[ScanBatch] Failed to create task %d/%d for batch %s: %v", created+1, len(targets), batch.ID, createErr)
+		if created == 0 {
+			if err := database.DeleteScanBatch(batch.ID); err != nil {
+				log.Printf("[ScanBatch] Failed to delete batch %s: %v", batch.ID, err)
+			}
+			return nil, fmt.Errorf("failed to create batch tasks: %w", createErr)
+		}
+		if err := database.SetScanBatchTotalTasks(batch.ID, created); err != nil {
+			log.Printf("[ScanBatch] Failed to update total tasks for batch %s: %v", batch.ID, err)
+		}
+		batch.TotalTasks = created
+	}
+
+	log.Printf("[ScanBatch] Created batch %s with %d tasks for user %s", batch.ID, batch.TotalTasks, userID)
+	if err := loadScanBatchProgress(batch); err != nil {
+		log.Printf("[ScanBatch] Failed to load progress for batch %s: %v", batch.ID, err)
+	}
+	return batch, nil
+}
+
+// refundBatchUsageRecords 退还一个未创建任务的预扣使用记录
+func refundBatchUsageRecords(usageRecordIDs map[string]string) {
+	for feature, urID := range usageRecordIDs {
+		if err := RefundFeatureCost(urID, ""); err != nil {
+			log.Printf("[ScanBatch] Failed to refund usage record for %s: %v", feature, err)
+		}
+	}
+}
+
+// loadScanBatchProgress 统计子任务状态，计算批量扫描的汇总进度
+func loadScanBatchProgress(batch *models.ScanBatch) error {
+	progress, err := database.GetScanBatchProgress(batch.ID)
+	if err != nil {
+		return err
+	}
+
+	finished := progress.Completed + progress.Failed + progress.Canceled
+	if progress.Total > 0 {
+		progress.Percent = finished * 100 / progress.Total
+	}
+	progress.Status = models.BatchStatusRunning
+	if progress.Pending+progress.Running == 0 {
+		progress.Status = models.BatchStatusFinished
+		progress.Percent = 100
+	}
+	batch.Progress = progress
+	return nil
+}
+
+// GetScanBatch 获取用户的批量扫描详情（含汇总进度和子任务摘要）
+func GetScanBatch(batchID, userID string) (*models.ScanBatch, error) {
+	if _, err := uuid.Parse(batchID); err != nil {
+		return nil, database.ErrScanBatchNotFound
+	}
+	batch, err := database.GetScanBatch(batchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadScanBatchProgress(batch); err != nil {
+		return nil, err
+	}
+	tasks, err := database.GetScanBatchTaskSummaries(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	batch.Tasks = tasks
+	return batch, nil
+}
+
+// GetUserScanBatches 获取用户的批量扫描列表（含汇总进度）
+func GetUserScanBatches(userID string, limit, offset int) ([]*models.ScanBatch, int, error) {
+	batches, err := database.GetUserScanBatches(userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, batch := range batches {
+		if err := loadScanBatchProgress(batch); err != nil {
+			return nil, 0, err
+		}
+	}
+	total, err := database.CountUserScanBatches(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
+
+// ExportScanBatch 导出批量扫描的合并结果（csv: 每个子任务一行的摘要；json: 批量扫描和全部子任务结果）
+// 返回内容、Content-Type 和文件名
+func ExportScanBatch(batchID, userID, format string) ([]byte, string, string, error) {
+	if format != "csv" && format != "json" {
+		return nil, "", "", fmt.Errorf("%w: unsupported export format %q (supported: csv, json)", ErrInvalidScanBatch, format)
+	}
+
+	batch, err := GetScanBatch(batchID, userID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	tasks, err := database.GetScanBatchTasks(batch.ID)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	filename := "web-checkly-batch-" + batch.ID + "." + format
+	if format == "json" {
+		batch.Tasks = nil
+		data, err := json.MarshalIndent(scanBatchExport{Batch: batch, Tasks: tasks}, "", "  ")
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to marshal batch export: %w", err)
+		}
+		return data, "application/json", filename, nil
+	}
+
+	data, err := renderScanBatchCSV(tasks)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return data, "text/csv; charset=utf-8", filename, nil
+}
+
+// scanBatchExport JSON 导出的顶层结构
+type scanBatchExport struct {
+	Batch *models.ScanBatch `json:"batch"`
+	Tasks []*models.Task    `json:"tasks"`
+}
+
+// renderScanBatchCSV 每个子任务一行：状态、门禁结论、各项评分、链接和证书摘要
+func renderScanBatchCSV(tasks []*models.Task) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := []string{
+		"url", "task_id", "status", "verdict",
+		"performance", "seo", "security", "accessibility",
+		"total_links", "broken_links", "ssl_days_remaining",
+		"created_at", "completed_at", "error",
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	for _, task := range tasks {
+		row := []string{task.TargetURL, task.ID, string(task.Status), "", "", "", "", "", "", "", "", task.CreatedAt.Format(time.RFC3339), "", task.Error}
+		if task.Verdict != nil {
+			row[3] = verdictText(task.Verdict)
+		}
+		if task.CompletedAt != nil {
+			row[12] = task.CompletedAt.Format(time.RFC3339)
+		}
+		if results := task.Results; results != nil {
+			if results.Performance != nil {
+				row[4] = strconv.Itoa(results.Performance.Score)
+			}
+			if results.SEOCompliance != nil {
+				row[5] = strconv.Itoa(results.SEOCompliance.Score)
+			}
+			if results.SecurityRisk != nil {
+				row[6] = strconv.Itoa(results.SecurityRisk.Score)
+			}
+			if results.Accessibility != nil {
+				row[7] = strconv.Itoa(results.Accessibility.Score)
+			}
+			if results.LinkHealth != nil {
+				broken := 0
+				for _, link := range results.LinkHealth {
+					if isBrokenLink(link) {
+						broken++
+					}
+				}
+				row[8] = strconv.Itoa(len(results.LinkHealth))
+				row[9] = strconv.Itoa(broken)
+			}
+			if results.SSLInfo != nil {
+				row[10] = strconv.Itoa(results.SSLInfo.DaysRemaining)
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, fmt.Errorf("failed to write csv: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
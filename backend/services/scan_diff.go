@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"web-checkly/database"
+	"web-checkly/models"
+)
+
+// scoreRegressionThreshold 评分下降超过该值才视为变差（Lighthouse 评分在多次运行间存在波动）
+const scoreRegressionThreshold = 5
+
+// FindPreviousTask 获取同一目标URL的上一次已完成扫描，没有时返回 nil
+func FindPreviousTask(task *models.Task) (*models.Task, error) {
+	previousID, err := database.GetPreviousCompletedTaskID(task)
+	if err != nil {
+		return nil, err
+	}
+	if previousID == "" {
+		return nil, nil
+	}
+	return database.GetTask(previousID)
+}
+
+// DiffTasks 对比两次扫描的结果：current 为当前扫描，base 为基准扫描
+// 只对比两次扫描都包含的部分（例如基准扫描没有选择 link-health 时不对比链接）
+func DiffTasks(current, base *models.Task) *models.ScanDiff {
+	diff := &models.ScanDiff{
+		TaskID:         current.ID,
+		BaseTaskID:     base.ID,
+		TargetURL:      current.TargetURL,
+		BaseTargetURL:  base.TargetURL,
+		CreatedAt:      current.CreatedAt,
+		BaseCreatedAt:  base.CreatedAt,
+		Regressions:    []string{},
+		Improvements:   []string{},
+		ComparedScopes: []string{},
+	}
+
+	cur, prev := current.Results, base.Results
+	if cur == nil || prev == nil {
+		return diff
+	}
+
+	if len(cur.LinkHealth) > 0 && len(prev.LinkHealth) > 0 {
+		diff.ComparedScopes = append(diff.ComparedScopes, "links")
+		diff.Links = diffLinks(cur.LinkHealth, prev.LinkHealth)
+		if n := len(diff.Links.NewBroken); n > 0 {
+			diff.Regressions = append(diff.Regressions, fmt.Sprintf("%d new broken link(s)", n))
+		}
+		if n := len(diff.Links.Fixed); n > 0 {
+			diff.Improvements = append(diff.Improvements, fmt.Sprintf("%d broken link(s) fixed", n))
+		}
+	}
+
+	if cur.TechStack != nil && prev.TechStack != nil {
+		diff.ComparedScopes = append(diff.ComparedScopes, "security_headers", "technologies")
+		diff.SecurityHeaders = diffSecurityHeaders(cur.TechStack.SecurityHeaders, prev.TechStack.SecurityHeaders)
+		for _, change := range diff.SecurityHeaders {
+			switch change.Change {
+			case "removed":
+				diff.Regressions = append(diff.Regressions, "security header removed: "+change.Header)
+			case "added":
+				diff.Improvements = append(diff.Improvements, "security header added: "+change.Header)
+			}
+		}
+		diff.Technologies = diffTechnologies(cur.TechStack, prev.TechStack)
+	}
+
+	diff.Scores = diffScores(cur, prev)
+	if len(diff.Scores) > 0 {
+		diff.ComparedScopes = append(diff.ComparedScopes, "scores")
+	}
+	for _, score := range diff.Scores {
+		if score.LowerIsBetter {
+			continue // 指标的好坏由对应的评分反映，避免重复
+		}
+		switch {
+		case score.Regression:
+			diff.Regressions = append(diff.Regressions, fmt.Sprintf("%s score dropped %s -> %s", score.Metric, formatScore(score.Before), formatScore(score.After)))
+		case score.Delta >= scoreRegressionThreshold:
+			diff.Improvements = append(diff.Improvements, fmt.Sprintf("%s score improved %s -> %s", score.Metric, formatScore(score.Before), formatScore(score.After)))
+		}
+	}
+
+	if cur.SSLInfo != nil && prev.SSLInfo != nil {
+		diff.ComparedScopes = append(diff.ComparedScopes, "certificate")
+		diff.Certificate = diffCertificates(cur.SSLInfo, prev.SSLInfo)
+		if prev.SSLInfo.IsValid && !cur.SSLInfo.IsValid {
+			diff.Regressions = append(diff.Regressions, "SSL certificate is no longer valid")
+		} else if !prev.SSLInfo.IsValid && cur.SSLInfo.IsValid {
+			diff.Improvements = append(diff.Improvements, "SSL certificate is valid again")
+		}
+	}
+
+	diff.HasRegression = len(diff.Regressions) > 0
+	return diff
+}
+
+// isBrokenLink 链接是否失效（请求失败、状态码 >= 400、软 404 或锚点不存在，与 BuildScanSummary 一致）
+// 超时未检查（Skipped）的链接状态未知，不算失效
+func isBrokenLink(r models.HttpxResult) bool {
+	return !r.Skipped && (r.StatusCode <= 0 || r.StatusCode >= 400 || r.Soft404 || r.AnchorMissing)
+}
+
+// diffLinks 对比失效链接：当前失效而基准中不存在或可用的为新增，基准中失效而当前可用的为已修复
+func diffLinks(current, base []models.HttpxResult) *models.LinkDiff {
+	baseByURL := make(map[string]models.HttpxResult, len(base))
+	linkDiff := &models.LinkDiff{
+		NewBroken: []models.HttpxResult{},
+		Fixed:     []models.HttpxResult{},
+	}
+	for _, r := range base {
+		baseByURL[r.URL] = r
+		if isBrokenLink(r) {
+			linkDiff.BaseBroken++
+		}
+	}
+
+	seen := make(map[string]bool, len(current))
+	for _, r := range current {
+		if seen[r.URL] {
+			continue
+		}
+		seen[r.URL] = true
+
+		previous, existed := baseByURL[r.URL]
+		if isBrokenLink(r) {
+			linkDiff.BrokenCount++
+			if !existed || !isBrokenLink(previous) {
+				linkDiff.NewBroken = append(linkDiff.NewBroken, r)
+			}
+		} else if existed && isBrokenLink(previous) && !r.Skipped {
+			linkDiff.Fixed = append(linkDiff.Fixed, r)
+		}
+	}
+	return linkDiff
+}
+
+// diffSecurityHeaders 对比安全响应头（名称不区分大小写）
+func diffSecurityHeaders(current, base map[string]string) []models.HeaderChange {
+	normalize := func(headers map[string]string) map[string]string {
+		normalized := make(map[string]string, len(headers))
+		for name, value := range headers {
+			normalized[http.CanonicalHeaderKey(name)] = value
+		}
+		return normalized
+	}
+	cur, prev := normalize(current), normalize(base)
+
+	changes := make([]models.HeaderChange, 0)
+	for name, before := range prev {
+		after, ok := cur[name]
+		switch {
+		case !ok:
+			changes = append(changes, models.HeaderChange{Header: name, Change: "removed", Before: before})
+		case after != before:
+			changes = append(changes, models.HeaderChange{Header: name, Change: "changed", Before: before, After: after})
+		}
+	}
+	for name, after := range cur {
+		if _, ok := prev[name]; !ok {
+			changes = append(changes, models.HeaderChange{Header: name, Change: "added", After: after})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Header < changes[j].Header })
+	return changes
+}
+
+// diffScores 对比 Lighthouse 评分和性能指标（只包含两次扫描都有的结果）
+func diffScores(cur, prev *models.TaskResults) []models.ScoreDelta {
+	scores := make([]models.ScoreDelta, 0)
+	addScore := func(metric string, before, after int) {
+		delta := float64(after - before)
+		scores = append(scores, models.ScoreDelta{
+			Metric:     metric,
+			Before:     float64(before),
+			After:      float64(after),
+			Delta:      delta,
+			Regression: delta <= -scoreRegressionThreshold,
+		})
+	}
+	// 指标是否变差由其 Lighthouse 评分判断，原始数值受网络波动影响较大
+	addMetric := func(metric string, before, after float64, beforeScore, afterScore int) {
+		scores = append(scores, models.ScoreDelta{
+			Metric:        metric,
+			Before:        before,
+			After:         after,
+			Delta:         after - before,
+			Regression:    afterScore-beforeScore <= -scoreRegressionThreshold,
+			LowerIsBetter: true,
+		})
+	}
+
+	if cur.Performance != nil && prev.Performance != nil {
+		c, p := cur.Performance, prev.Performance
+		addScore("performance", p.Score, c.Score)
+		addMetric("fcp", p.FCP, c.FCP, p.FCPScore, c.FCPScore)
+		addMetric("lcp", p.LCP, c.LCP, p.LCPScore, c.LCPScore)
+		addMetric("cls", p.CLS, c.CLS, p.CLSScore, c.CLSScore)
+		addMetric("tbt", p.TBT, c.TBT, p.TBTScore, c.TBTScore)
+		addMetric("speed_index", p.SpeedIndex, c.SpeedIndex, p.SpeedIndexScore, c.SpeedIndexScore)
+	}
+	if cur.SEOCompliance != nil && prev.SEOCompliance != nil {
+		addScore("seo", prev.SEOCompliance.Score, cur.SEOCompliance.Score)
+	}
+	if cur.SecurityRisk != nil && prev.SecurityRisk != nil {
+		addScore("security", prev.SecurityRisk.Score, cur.SecurityRisk.Score)
+	}
+	if cur.Accessibility != nil && prev.Accessibility != nil {
+		addScore("accessibility", prev.Accessibility.Score, cur.Accessibility.Score)
+	}
+	return scores
+}
+
+// diffCertificates 对比证书字段（不含每天变化的剩余天数）
+func diffCertificates(cur, prev *models.SSLInfo) *models.CertificateDiff {
+	sortedNames := func(names []string) string {
+		sorted := append([]string(nil), names...)
+		sort.Strings(sorted)
+		return strings.Join(sorted, ",")
+	}
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"issuer", prev.Issuer, cur.Issuer},
+		{"subject", prev.Subject, cur.Subject},
+		{"serial_number", prev.SerialNumber, cur.SerialNumber},
+		{"valid_from", prev.ValidFrom, cur.ValidFrom},
+		{"valid_to", prev.ValidTo, cur.ValidTo},
+		{"is_valid", strconv.FormatBool(prev.IsValid), strconv.FormatBool(cur.IsValid)},
+		{"signature_alg", prev.SignatureAlg, cur.SignatureAlg},
+		{"public_key_alg", prev.PublicKeyAlg, cur.PublicKeyAlg},
+		{"key_size", strconv.Itoa(prev.KeySize), strconv.Itoa(cur.KeySize)},
+		{"dns_names", sortedNames(prev.DNSNames), sortedNames(cur.DNSNames)},
+	}
+
+	certDiff := &models.CertificateDiff{}
+	for _, f := range fields {
+		if f.before != f.after {
+			certDiff.Changes = append(certDiff.Changes, models.FieldChange{Field: f.name, Before: f.before, After: f.after})
+		}
+	}
+	certDiff.Changed = len(certDiff.Changes) > 0
+	return certDiff
+}
+
+// diffTechnologies 对比检测到的技术（合并各分类，名称不区分大小写）
+func diffTechnologies(cur, prev *models.TechStack) *models.TechnologyDiff {
+	collect := func(stack *models.TechStack) map[string]string {
+		technologies := make(map[string]string)
+		groups := [][]string{
+			stack.Technologies, stack.Framework, stack.CMS, stack.Language,
+			stack.JavaScriptLib, stack.Analytics, stack.CDN, stack.Cache, stack.Database,
+		}
+		for _, group := range groups {
+			for _, name := range group {
+				if name = strings.TrimSpace(name); name != "" {
+					technologies[strings.ToLower(name)] = name
+				}
+			}
+		}
+		return technologies
+	}
+	curTech, prevTech := collect(cur), collect(prev)
+
+	techDiff := &models.TechnologyDiff{Added: []string{}, Removed: []string{}}
+	for key, name := range curTech {
+		if _, ok := prevTech[key]; !ok {
+			techDiff.Added = append(techDiff.Added, name)
+		}
+	}
+	for key, name := range prevTech {
+		if _, ok := curTech[key]; !ok {
+			techDiff.Removed = append(techDiff.Removed, name)
+		}
+	}
+	sort.Strings(techDiff.Added)
+	sort.Strings(techDiff.Removed)
+	return techDiff
+}
+
+// formatScore 格式化评分（整数评分不带小数）
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"web-checkly/database"
+	"web-checkly/models"
+)
+
+// ErrInvalidScanPolicy 门禁策略无效
+var ErrInvalidScanPolicy = errors.New("invalid scan policy")
+
+// policyDetailLimit 每项检查最多列出的相关条目数
+const policyDetailLimit = 50
+
+// notMeasured 策略要求的结果没有检测时的实际值
+const notMeasured = "not measured"
+
+// scanPolicyRule 门禁规则（名称与策略的 JSON 字段、查询参数同名）
+type scanPolicyRule struct {
+	name    string
+	value   **int
+	isScore bool // 是否为 0-100 的评分下限
+}
+
+// scanPolicyRules 返回策略的全部规则，按检查顺序排列
+func scanPolicyRules(policy *models.ScanPolicy) []scanPolicyRule {
+	return []scanPolicyRule{
+		{"min_performance", &policy.MinPerformance, true},
+		{"min_seo", &policy.MinSEO, true},
+		{"min_security", &policy.MinSecurity, true},
+		{"min_accessibility", &policy.MinAccessibility, true},
+		{"max_broken_links", &policy.MaxBrokenLinks, false},
+		{"max_vulnerabilities", &policy.MaxVulnerabilities, false},
+		{"min_ssl_days", &policy.MinSSLDays, false},
+	}
+}
+
+// ValidateScanPolicy 校验门禁策略：评分下限为 0-100，其余阈值不能为负数
+func ValidateScanPolicy(policy *models.ScanPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for _, rule := range scanPolicyRules(policy) {
+		if *rule.value == nil {
+			continue
+		}
+		value := **rule.value
+		if value < 0 || (rule.isScore && value > 100) {
+			if rule.isScore {
+				return fmt.Errorf("%w: %s must be between 0 and 100", ErrInvalidScanPolicy, rule.name)
+			}
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidScanPolicy, rule.name)
+		}
+	}
+	return nil
+}
+
+// ApplyScanPolicyParams 用查询参数（如 min_performance=80&max_broken_links=0）覆盖门禁策略
+// 返回 nil 表示没有任何门禁参数
+func ApplyScanPolicyParams(base *models.ScanPolicy, param func(name string) string) (*models.ScanPolicy, error) {
+	policy := &models.ScanPolicy{}
+	if base != nil {
+		*policy = *base
+	}
+
+	found := false
+	for _, rule := range scanPolicyRules(policy) {
+		raw := strings.TrimSpace(param(rule.name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be an integer", ErrInvalidScanPolicy, rule.name)
+		}
+		*rule.value = &value
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return policy, ValidateScanPolicy(policy)
+}
+
+// EvaluateScanPolicy 按门禁策略检查任务结果
+// 策略为 nil 时使用默认检查：任务已完成、没有失效链接、证书有效（只检查任务实际执行了的项）；
+// 指定了策略时，策略要求的结果没有检测到视为未通过
+func EvaluateScanPolicy(task *models.Task, policy *models.ScanPolicy) *models.ScanVerdict {
+	results := task.Results
+	if results == nil {
+		results = &models.TaskResults{}
+	}
+
+	verdict := &models.ScanVerdict{Passed: true, EvaluatedAt: time.Now()}
+	add := func(check models.PolicyCheck) {
+		verdict.Checks = append(verdict.Checks, check)
+		if !check.Passed {
+			verdict.Passed = false
+		}
+	}
+
+	// 任务本身必须执行完成
+	statusCheck := models.PolicyCheck{
+		Name:     "task_status",
+		Passed:   task.Status == models.TaskStatusCompleted,
+		Expected: string(models.TaskStatusCompleted),
+		Actual:   string(task.Status),
+	}
+	if statusCheck.Passed {
+		statusCheck.Message = "scan completed"
+	} else {
+		statusCheck.Message = fmt.Sprintf("scan did not complete (status %s)", task.Status)
+		if task.Error != "" {
+			statusCheck.Details = []string{task.Error}
+		}
+	}
+	add(statusCheck)
+
+	// 证书有效性总是检查（证书无效对任何部署都是阻断问题）
+	if results.SSLInfo != nil {
+		check := models.PolicyCheck{
+			Name:     "ssl_valid",
+			Passed:   results.SSLInfo.IsValid,
+			Expected: "true",
+			Actual:   strconv.FormatBool(results.SSLInfo.IsValid),
+			Message:  "SSL certificate is valid",
+		}
+		if !check.Passed {
+			check.Message = "SSL certificate is not valid"
+		}
+		add(check)
+	}
+
+	if policy == nil {
+		if len(results.LinkHealth) > 0 {
+			add(checkBrokenLinks(results, 0))
+		}
+		return verdict
+	}
+
+	scores := []struct {
+		threshold *int
+		name      string
+		metric    string
+		measured  bool
+		score     func() int
+	}{
+		{policy.MinPerformance, "min_performance", "performance", results.Performance != nil, func() int { return results.Performance.Score }},
+		{policy.MinSEO, "min_seo", "seo", results.SEOCompliance != nil, func() int { return results.SEOCompliance.Score }},
+		{policy.MinSecurity, "min_security", "security", results.SecurityRisk != nil, func() int { return results.SecurityRisk.Score }},
+		{policy.MinAccessibility, "min_accessibility", "accessibility", results.Accessibility != nil, func() int { return results.Accessibility.Score }},
+	}
+	for _, s := range scores {
+		if s.threshold == nil {
+			continue
+		}
+		check := models.PolicyCheck{Name: s.name, Expected: fmt.Sprintf(">= %d", *s.threshold)}
+		if !s.measured {
+			check.Actual = notMeasured
+			check.Message = fmt.Sprintf("%s score was not measured (select the %s option)", s.metric, s.metric)
+		} else {
+			score := s.score()
+			check.Actual = strconv.Itoa(score)
+			check.Passed = score >= *s.threshold
+			if check.Passed {
+				check.Message = fmt.Sprintf("%s score %d meets %d", s.metric, score, *s.threshold)
+			} else {
+				check.Message = fmt.Sprintf("%s score %d is below %d", s.metric, score, *s.threshold)
+			}
+		}
+		add(check)
+	}
+
+	if policy.MaxBrokenLinks != nil {
+		if len(results.LinkHealth) == 0 {
+			add(models.PolicyCheck{
+				Name:     "max_broken_links",
+				Expected: fmt.Sprintf("<= %d", *policy.MaxBrokenLinks),
+				Actual:   notMeasured,
+				Message:  "links were not checked (select the link-health option)",
+			})
+		} else {
+			add(checkBrokenLinks(results, *policy.MaxBrokenLinks))
+		}
+	}
+
+	if policy.MaxVulnerabilities != nil {
+		check := models.PolicyCheck{Name: "max_vulnerabilities", Expected: fmt.Sprintf("<= %d", *policy.MaxVulnerabilities)}
+		if results.SecurityRisk == nil {
+			check.Actual = notMeasured
+			check.Message = "security was not checked (select the security option)"
+		} else {
+			issues := securityIssues(results.SecurityRisk)
+			count := len(issues)
+			check.Actual = strconv.Itoa(count)
+			check.Passed = count <= *policy.MaxVulnerabilities
+			check.Message = fmt.Sprintf("%d security issue(s) found", count)
+			if !check.Passed {
+				check.Details = limitDetails(issues)
+			}
+		}
+		add(check)
+	}
+
+	if policy.MinSSLDays != nil {
+		check := models.PolicyCheck{Name: "min_ssl_days", Expected: fmt.Sprintf(">= %d", *policy.MinSSLDays)}
+		if results.SSLInfo == nil {
+			check.Actual = notMeasured
+			check.Message = "SSL certificate was not checked (select the ssl-info option)"
+		} else {
+			days := results.SSLInfo.DaysRemaining
+			check.Actual = strconv.Itoa(days)
+			check.Passed = days >= *policy.MinSSLDays
+			if check.Passed {
+				check.Message = fmt.Sprintf("SSL certificate expires in %d day(s)", days)
+			} else {
+				check.Message = fmt.Sprintf("SSL certificate expires in %d day(s), less than %d", days, *policy.MinSSLDays)
+			}
+		}
+		add(check)
+	}
+
+	return verdict
+}
+
+// checkBrokenLinks 检查失效链接数
+func checkBrokenLinks(results *models.TaskResults, max int) models.PolicyCheck {
+	var broken []string
+	for _, link := range results.LinkHealth {
+		if isBrokenLink(link) {
+			broken = append(broken, fmt.Sprintf("%s (%s)", link.URL, linkStatusText(link)))
+		}
+	}
+
+	check := models.PolicyCheck{
+		Name:     "max_broken_links",
+		Passed:   len(broken) <= max,
+		Expected: fmt.Sprintf("<= %d", max),
+		Actual:   strconv.Itoa(len(broken)),
+		Message:  fmt.Sprintf("%d broken link(s) out of %d", len(broken), len(results.LinkHealth)),
+	}
+	if !check.Passed {
+		check.Details = limitDetails(broken)
+	}
+	return check
+}
+
+// linkStatusText 链接状态说明
+func linkStatusText(link models.HttpxResult) string {
+	if link.StatusCode <= 0 {
+		return "request failed"
+	}
+	return fmt.Sprintf("HTTP %d", link.StatusCode)
+}
+
+// limitDetails 限制相关条目数量
+func limitDetails(items []string) []string {
+	if len(items) <= policyDetailLimit {
+		return append([]string(nil), items...)
+	}
+	limited := append([]string(nil), items[:policyDetailLimit]...)
+	return append(limited, fmt.Sprintf("... and %d more", len(items)-policyDetailLimit))
+}
+
+// recordTaskVerdict 任务结束时按任务的门禁策略计算并保存结论
+func recordTaskVerdict(taskID string) {
+	task, err := database.GetTask(taskID)
+	if err != nil {
+		log.Printf("[Policy] Failed to load task %s: %v", taskID, err)
+		return
+	}
+	if task.Status != models.TaskStatusCompleted && task.Status != models.TaskStatusFailed {
+		return // 已取消的任务没有结论
+	}
+
+	verdict := EvaluateScanPolicy(task, task.Policy)
+	if err := database.SetTaskVerdict(taskID, verdict); err != nil {
+		log.Printf("[Policy] Failed to save verdict for task %s: %v", taskID, err)
+		return
+	}
+	log.Printf("[Policy] Task %s verdict: passed=%v", taskID, verdict.Passed)
+}
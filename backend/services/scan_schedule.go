@@ -0,0 +1,381 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"web-checkly/database"
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"github.com/google/uuid"
+)
+
+// 定时扫描计划参数
+const (
+	scanSchedulePollInterval = 30 * time.Second // 检查到期计划的间隔
+	scanScheduleClaimLease   = 5 * time.Minute  // 领取计划后的租约，触发失败时租约到期后重试
+	scanScheduleClaimBatch   = 20               // 每次最多领取的到期计划数
+	scanScheduleMinInterval  = time.Hour        // 两次执行之间的最小间隔
+	maxScanSchedulesPerUser  = 20               // 每个用户最多创建的计划数
+)
+
+var (
+	// ErrInvalidScanSchedule 定时扫描计划参数无效
+	ErrInvalidScanSchedule = errors.New("invalid scan schedule")
+	// ErrScanScheduleLimitReached 用户的定时扫描计划数量已达上限
+	ErrScanScheduleLimitReached = fmt.Errorf("scan schedule limit reached (max %d per user)", maxScanSchedulesPerUser)
+	// ErrScheduleTargetBlacklisted 目标网站在黑名单中
+	ErrScheduleTargetBlacklisted = errors.New("website blacklisted")
+)
+
+// CreateScanSchedule 创建定时扫描计划
+func CreateScanSchedule(userID string, req *models.CreateScheduleRequest) (*models.ScanSchedule, error) {
+	count, err := database.CountUserScanSchedules(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxScanSchedulesPerUser {
+		return nil, ErrScanScheduleLimitReached
+	}
+
+	schedule := &models.ScanSchedule{
+		UserID:         userID,
+		Name:           strings.TrimSpace(req.Name),
+		TargetURL:      req.URL,
+		Options:        req.Options,
+		Language:       req.Language,
+		AIMode:         req.AIMode,
+		CronExpression: strings.TrimSpace(req.CronExpression),
+		Timezone:       strings.TrimSpace(req.Timezone),
+		Status:         models.ScheduleStatusActive,
+	}
+	if err := normalizeScanSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	nextRunAt, err := nextScheduleRun(schedule.CronExpression, schedule.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = nextRunAt
+
+	if err := database.CreateScanSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ScanSchedule] Created schedule %s for user %s: %s (%s %s)", schedule.ID, userID, schedule.TargetURL, schedule.CronExpression, schedule.Timezone)
+	return schedule, nil
+}
+
+// GetScanSchedule 获取用户的定时扫描计划
+func GetScanSchedule(scheduleID, userID string) (*models.ScanSchedule, error) {
+	if _, err := uuid.Parse(scheduleID); err != nil {
+		return nil, database.ErrScanScheduleNotFound
+	}
+	return database.GetScanSchedule(scheduleID, userID)
+}
+
+// GetUserScanSchedules 获取用户的全部定时扫描计划
+func GetUserScanSchedules(userID string) ([]*models.ScanSchedule, error) {
+	return database.GetUserScanSchedules(userID)
+}
+
+// UpdateScanSchedule 更新定时扫描计划（只更新请求中提供的字段）
+// 修改 cron 表达式、时区或恢复暂停的计划时重新计算下次执行时间
+func UpdateScanSchedule(scheduleID, userID string, req *models.UpdateScheduleRequest) (*models.ScanSchedule, error) {
+	schedule, err := GetScanSchedule(scheduleID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rescheduled := false
+	if req.Name != nil {
+		schedule.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		schedule.TargetURL = *req.URL
+	}
+	if req.Options != nil {
+		schedule.Options = req.Options
+	}
+	if req.Language != nil {
+		schedule.Language = *req.Language
+	}
+	if req.AIMode != nil {
+		schedule.AIMode = *req.AIMode
+	}
+	if req.CronExpression != nil {
+		schedule.CronExpression = strings.TrimSpace(*req.CronExpression)
+		rescheduled = true
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = strings.TrimSpace(*req.Timezone)
+		rescheduled = true
+	}
+	if req.Status != nil {
+		switch *req.Status {
+		case models.ScheduleStatusActive:
+			if schedule.Status != models.ScheduleStatusActive {
+				rescheduled = true
+			}
+		case models.ScheduleStatusPaused:
+		default:
+			return nil, fmt.Errorf("%w: status must be active or paused", ErrInvalidScanSchedule)
+		}
+		schedule.Status = *req.Status
+		schedule.PausedReason = ""
+	}
+
+	if err := normalizeScanSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case schedule.Status == models.ScheduleStatusPaused:
+		schedule.NextRunAt = nil
+	case rescheduled || schedule.NextRunAt == nil:
+		nextRunAt, err := nextScheduleRun(schedule.CronExpression, schedule.Timezone, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		schedule.NextRunAt = nextRunAt
+	}
+
+	if err := database.UpdateScanSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ScanSchedule] Updated schedule %s (status: %s)", schedule.ID, schedule.Status)
+	return schedule, nil
+}
+
+// DeleteScanSchedule 删除定时扫描计划（已创建的任务保留）
+func DeleteScanSchedule(scheduleID, userID string) error {
+	if _, err := uuid.Parse(scheduleID); err != nil {
+		return database.ErrScanScheduleNotFound
+	}
+	if err := database.DeleteScanSchedule(scheduleID, userID); err != nil {
+		return err
+	}
+	log.Printf("[ScanSchedule] Deleted schedule %s", scheduleID)
+	return nil
+}
+
+// normalizeScanSchedule 校验并规范化计划参数（目标URL、cron 表达式、时区、语言和 AI 模式）
+func normalizeScanSchedule(schedule *models.ScanSchedule) error {
+	target, err := normalizeScheduleTarget(schedule.TargetURL)
+	if err != nil {
+		return err
+	}
+	schedule.TargetURL = target
+
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if err := validateScheduleCron(schedule.CronExpression, schedule.Timezone); err != nil {
+		return err
+	}
+
+	if schedule.Name == "" {
+		schedule.Name = target
+	}
+	if len(schedule.Name) > 255 {
+		return fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidScanSchedule)
+	}
+	if len(schedule.Options) == 0 {
+		schedule.Options = []string{"link-health"} // 与创建任务一致，默认启用链接健康检查
+	}
+	if schedule.Language != "en" && schedule.Language != "zh" {
+		schedule.Language = "zh"
+	}
+	switch schedule.AIMode {
+	case "performance", "security", "seo", "balanced":
+	default:
+		schedule.AIMode = "balanced"
+	}
+	return nil
+}
+
+// normalizeScheduleTarget 规范化目标URL并进行 SSRF 和网站黑名单检查
+func normalizeScheduleTarget(rawURL string) (string, error) {
+	target, err := normalizeScanTarget(rawURL)
+	if err != nil && !errors.Is(err, ErrScheduleTargetBlacklisted) {
+		return "", fmt.Errorf("%w: %v", ErrInvalidScanSchedule, err)
+	}
+	return target, err
+}
+
+// normalizeScanTarget 规范化扫描目标URL并进行 SSRF 和网站黑名单检查（定时扫描和批量扫描共用）
+// 网站在黑名单中时返回 ErrScheduleTargetBlacklisted
+func normalizeScanTarget(rawURL string) (string, error) {
+	if strings.TrimSpace(rawURL) == "" {
+		return "", errors.New("url is required")
+	}
+
+	target, err := utils.NormalizeURL(rawURL)
+	if err != nil {
+		return "", errors.New("invalid url")
+	}
+
+	parsedURL, err := url.Parse(target)
+	if err != nil || parsedURL.Hostname() == "" {
+		return "", errors.New("invalid url")
+	}
+	if utils.IsPrivateIP(parsedURL.Hostname()) {
+		return "", errors.New("private IP not allowed")
+	}
+	if database.IsWebsiteBlacklisted(target) {
+		return "", ErrScheduleTargetBlacklisted
+	}
+	return target, nil
+}
+
+// validateScheduleCron 校验 cron 表达式和时区，并确保相邻两次执行的间隔不小于 scanScheduleMinInterval
+func validateScheduleCron(expr, timezone string) error {
+	cron, err := utils.ParseCron(expr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidScanSchedule, err)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidScanSchedule, timezone)
+	}
+
+	// 检查接下来的若干次执行（覆盖一天内不均匀的表达式，如 "0,30 9 * * *"）
+	prev := cron.Next(time.Now().In(loc))
+	if prev.IsZero() {
+		return fmt.Errorf("%w: cron expression never fires", ErrInvalidScanSchedule)
+	}
+	for i := 0; i < 48; i++ {
+		next := cron.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) < scanScheduleMinInterval {
+			return fmt.Errorf("%w: runs must be at least %s apart", ErrInvalidScanSchedule, scanScheduleMinInterval)
+		}
+		prev = next
+	}
+	return nil
+}
+
+// nextScheduleRun 计算 after 之后的下次执行时间（cron 表达式按计划的时区解释）
+func nextScheduleRun(expr, timezone string, after time.Time) (*time.Time, error) {
+	cron, err := utils.ParseCron(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScanSchedule, err)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidScanSchedule, timezone)
+	}
+
+	next := cron.Next(after.In(loc))
+	if next.IsZero() {
+		return nil, fmt.Errorf("%w: cron expression never fires", ErrInvalidScanSchedule)
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// StartScanScheduler 启动定时扫描调度器：定期领取到期的计划并创建扫描任务
+// 多个 API 实例同时运行时通过 ClaimDueScanSchedules 的行锁保证同一次执行只触发一次
+func StartScanScheduler(executor *Executor) {
+	go func() {
+		ticker := time.NewTicker(scanSchedulePollInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runDueScanSchedules(executor)
+		}
+	}()
+
+	log.Println("[ScanSchedule] Scan scheduler started")
+}
+
+// runDueScanSchedules 触发所有到期的定时扫描计划
+func runDueScanSchedules(executor *Executor) {
+	for {
+		schedules, err := database.ClaimDueScanSchedules(scanScheduleClaimBatch, scanScheduleClaimLease)
+		if err != nil {
+			log.Printf("[ScanSchedule] Failed to claim due schedules: %v", err)
+			return
+		}
+		for _, schedule := range schedules {
+			runScanSchedule(executor, schedule)
+		}
+		if len(schedules) < scanScheduleClaimBatch {
+			return
+		}
+	}
+}
+
+// runScanSchedule 为到期的计划创建一次扫描任务
+// 积分在任务完成时扣除（与手动创建的任务相同），积分不足或用户、网站被拉黑时暂停计划
+func runScanSchedule(executor *Executor, schedule *models.ScanSchedule) {
+	runAt := time.Now()
+
+	if userUUID, err := uuid.Parse(schedule.UserID); err == nil && database.IsUserBlacklisted(userUUID) {
+		pauseScanSchedule(schedule, "user blacklisted")
+		return
+	}
+	if _, err := normalizeScheduleTarget(schedule.TargetURL); err != nil {
+		pauseScanSchedule(schedule, err.Error())
+		return
+	}
+
+	usageRecordIDs, err := BatchCreateUsageRecords(schedule.UserID, "", schedule.Options)
+	if err != nil {
+		if IsCreditsError(err) {
+			pauseScanSchedule(schedule, err.Error())
+			return
+		}
+		// 临时性错误：租约到期后重试
+		log.Printf("[ScanSchedule] Failed to create usage records for schedule %s: %v", schedule.ID, err)
+		return
+	}
+
+	userID := schedule.UserID
+	task, err := executor.taskManager.CreateTask(&models.CreateTaskRequest{
+		URL:        schedule.TargetURL,
+		Options:    schedule.Options,
+		Language:   schedule.Language,
+		AIMode:     schedule.AIMode,
+		ScheduleID: &schedule.ID,
+	}, &userID)
+	if err != nil {
+		for feature, urID := range usageRecordIDs {
+			if deleteErr := DeleteUsageRecord(urID); deleteErr != nil {
+				log.Printf("[ScanSchedule] Failed to delete usage record for %s: %v", feature, deleteErr)
+			}
+		}
+		log.Printf("[ScanSchedule] Failed to create task for schedule %s: %v", schedule.ID, err)
+		return
+	}
+	LinkUsageRecordsToTask(task.ID, usageRecordIDs)
+
+	// 从当前时间计算下次执行时间：服务停机期间错过的执行不会补跑
+	nextRunAt, err := nextScheduleRun(schedule.CronExpression, schedule.Timezone, runAt)
+	if err != nil {
+		log.Printf("[ScanSchedule] Failed to compute next run for schedule %s: %v", schedule.ID, err)
+		pauseScanSchedule(schedule, err.Error())
+	}
+	if err := database.RecordScanScheduleRun(schedule.ID, task.ID, runAt, nextRunAt); err != nil {
+		log.Printf("[ScanSchedule] Failed to record run for schedule %s: %v", schedule.ID, err)
+	}
+
+	log.Printf("[ScanSchedule] Schedule %s created task %s for URL: %s", schedule.ID, task.ID, schedule.TargetURL)
+	executor.StartTaskExecution(task.ID)
+}
+
+// pauseScanSchedule 自动暂停计划并记录原因
+func pauseScanSchedule(schedule *models.ScanSchedule, reason string) {
+	log.Printf("[ScanSchedule] Pausing schedule %s: %s", schedule.ID, reason)
+	if err := database.PauseScanSchedule(schedule.ID, reason); err != nil {
+		log.Printf("[ScanSchedule] Failed to pause schedule %s: %v", schedule.ID, err)
+	}
+}
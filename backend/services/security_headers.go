@@ -0,0 +1,524 @@
+package services
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"web-checkly/models"
+)
+
+const (
+	// hstsMinMaxAge HSTS max-age 低于该值（180 天）时提示
+	hstsMinMaxAge = 180 * 24 * 3600
+	// hstsPreloadMaxAge hstspreload.org 要求的最小 max-age（1 年）
+	hstsPreloadMaxAge = 365 * 24 * 3600
+	// securityHeaderMaxPenalty 每个响应头最多扣的分数（配置有问题的响应头不应比没有设置扣分更多）
+	securityHeaderMaxPenalty = 25
+)
+
+// securityHeaderPenalty 每个发现按严重程度扣分
+var securityHeaderPenalty = map[string]int{
+	models.SeverityCritical: 40,
+	models.SeverityHigh:     25,
+	models.SeverityMedium:   10,
+	models.SeverityLow:      5,
+}
+
+// securityHeaderNames 检查项对应的响应头名称（也是结论列表的顺序）
+var securityHeaderNames = []struct{ check, header string }{
+	{models.HeaderCheckCSP, "Content-Security-Policy"},
+	{models.HeaderCheckHSTS, "Strict-Transport-Security"},
+	{models.HeaderCheckFrame, "X-Frame-Options"},
+	{models.HeaderCheckContentType, "X-Content-Type-Options"},
+	{models.HeaderCheckReferrer, "Referrer-Policy"},
+	{models.HeaderCheckPermissions, "Permissions-Policy"},
+	{models.HeaderCheckCOOP, "Cross-Origin-Opener-Policy"},
+	{models.HeaderCheckCOEP, "Cross-Origin-Embedder-Policy"},
+	{models.HeaderCheckCORP, "Cross-Origin-Resource-Policy"},
+}
+
+// sensitivePermissions 授权给所有来源（*）时需要提示的浏览器功能
+var sensitivePermissions = map[string]bool{
+	"camera": true, "microphone": true, "geolocation": true, "payment": true, "usb": true, "serial": true,
+	"hid": true, "bluetooth": true, "display-capture": true, "clipboard-read": true,
+}
+
+// securityHeaderMessages 发现的说明文案（zh/en）
+var securityHeaderMessages = map[string]map[string]string{
+	"zh": {
+		"csp_missing":             "没有 Content-Security-Policy，浏览器不会限制页面加载的脚本，无法缓解 XSS",
+		"csp_report_only":         "只设置了 Content-Security-Policy-Report-Only，策略只上报不拦截",
+		"csp_no_script_src":       "CSP 没有 script-src 也没有 default-src，不限制脚本来源",
+		"csp_unsafe_inline":       "script-src 允许 'unsafe-inline' 且没有使用 nonce 或 hash，CSP 无法阻止 XSS",
+		"csp_unsafe_eval":         "script-src 允许 'unsafe-eval'，可以通过 eval() 等执行字符串代码",
+		"csp_wildcard":            "script-src 包含过宽的来源 %s，可以从任意站点加载脚本",
+		"csp_object_src":          "object-src 没有设置为 'none'，可以通过 <object>/<embed> 插件绕过脚本限制",
+		"csp_base_uri":            "CSP 没有设置 base-uri，注入的 <base> 标签可以改变相对路径脚本的来源",
+		"csp_nonce_hash":          "script-src 使用了 nonce 或 hash（严格 CSP）",
+		"hsts_no_https":           "页面没有使用 HTTPS，HSTS 不生效，流量可以被窃听和篡改",
+		"hsts_missing":            "没有 Strict-Transport-Security，首次访问或输入 http:// 时可能被降级为明文连接",
+		"hsts_invalid":            "Strict-Transport-Security 缺少有效的 max-age，浏览器会忽略该响应头",
+		"hsts_disabled":           "Strict-Transport-Security 的 max-age=0，会清除浏览器已记录的 HSTS",
+		"hsts_short_max_age":      "HSTS max-age 为 %d 秒，不足 180 天，建议至少 1 年（31536000）",
+		"hsts_no_subdomains":      "HSTS 没有 includeSubDomains，子域名仍可能被降级为明文连接",
+		"hsts_preload_ineligible": "HSTS 声明了 preload，但不满足预加载要求（max-age 至少 1 年且包含 includeSubDomains），提交会被拒绝",
+		"hsts_preload_eligible":   "HSTS 满足预加载要求，可以提交到 hstspreload.org",
+		"xfo_missing":             "没有 X-Frame-Options，CSP 也没有 frame-ancestors，页面可以被嵌入其他站点（点击劫持）",
+		"xfo_invalid":             "X-Frame-Options 的值 %s 无效（ALLOW-FROM 已不被浏览器支持），页面仍可以被嵌入",
+		"xcto_missing":            "没有 X-Content-Type-Options: nosniff，浏览器可能把上传的文件当作脚本或样式执行",
+		"xcto_invalid":            "X-Content-Type-Options 的值 %s 无效，只支持 nosniff",
+		"referrer_missing":        "没有 Referrer-Policy，使用浏览器默认策略（旧浏览器会把完整地址发送给第三方）",
+		"referrer_unsafe":         "Referrer-Policy 为 %s，跨站请求会带上完整地址（可能包含路径和参数中的敏感信息）",
+		"referrer_invalid":        "Referrer-Policy 的值 %s 无法识别，浏览器会使用默认策略",
+		"pp_missing":              "没有 Permissions-Policy，没有限制页面和嵌入的第三方内容使用摄像头、定位等浏览器功能",
+		"pp_feature_policy":       "只设置了已废弃的 Feature-Policy，新版浏览器使用 Permissions-Policy",
+		"pp_wildcard":             "Permissions-Policy 把 %s 授权给了所有来源（*）",
+		"pp_invalid":              "Permissions-Policy 无法解析：%s",
+		"coop_missing":            "没有 Cross-Origin-Opener-Policy，跨域窗口可以持有页面的窗口引用（XS-Leaks）",
+		"coop_unsafe_none":        "Cross-Origin-Opener-Policy 为 unsafe-none，没有隔离跨域窗口",
+		"coop_invalid":            "Cross-Origin-Opener-Policy 的值 %s 无法识别",
+		"coep_missing":            "没有 Cross-Origin-Embedder-Policy，页面不能启用跨域隔离（只在需要 SharedArrayBuffer 等功能时必需）",
+		"coep_invalid":            "Cross-Origin-Embedder-Policy 的值 %s 无法识别",
+		"corp_missing":            "没有 Cross-Origin-Resource-Policy，其他站点可以直接加载该资源",
+		"corp_invalid":            "Cross-Origin-Resource-Policy 的值 %s 无法识别",
+	},
+	"en": {
+		"csp_missing":             "No Content-Security-Policy; the browser does not restrict which scripts the page loads, so XSS is not mitigated",
+		"csp_report_only":         "Only Content-Security-Policy-Report-Only is set; violations are reported but not blocked",
+		"csp_no_script_src":       "The CSP has neither script-src nor default-src and does not restrict script sources",
+		"csp_unsafe_inline":       "script-src allows 'unsafe-inline' without a nonce or hash, so the CSP cannot stop XSS",
+		"csp_unsafe_eval":         "script-src allows 'unsafe-eval', so strings can be executed as code via eval() and similar",
+		"csp_wildcard":            "script-src contains the overly broad source %s and allows scripts from any site",
+		"csp_object_src":          "object-src is not 'none'; <object>/<embed> plugins can bypass the script restrictions",
+		"csp_base_uri":            "The CSP does not set base-uri; an injected <base> tag can change where relative scripts load from",
+		"csp_nonce_hash":          "script-src uses nonces or hashes (strict CSP)",
+		"hsts_no_https":           "The page is not served over HTTPS, so HSTS cannot apply and traffic can be intercepted or modified",
+		"hsts_missing":            "No Strict-Transport-Security; the first visit or a typed http:// URL can be downgraded to plain HTTP",
+		"hsts_invalid":            "Strict-Transport-Security has no valid max-age, so browsers ignore the header",
+		"hsts_disabled":           "Strict-Transport-Security sets max-age=0, which clears HSTS previously stored by browsers",
+		"hsts_short_max_age":      "HSTS max-age is %d seconds, less than 180 days; at least one year (31536000) is recommended",
+		"hsts_no_subdomains":      "HSTS does not include includeSubDomains; subdomains can still be downgraded to plain HTTP",
+		"hsts_preload_ineligible": "HSTS declares preload but does not meet the preload requirements (max-age of at least one year and includeSubDomains); a submission would be rejected",
+		"hsts_preload_eligible":   "HSTS meets the preload requirements and can be submitted to hstspreload.org",
+		"xfo_missing":             "No X-Frame-Options and no CSP frame-ancestors; the page can be framed by other sites (clickjacking)",
+		"xfo_invalid":             "X-Frame-Options value %s is invalid (ALLOW-FROM is no longer supported by browsers); the page can still be framed",
+		"xcto_missing":            "No X-Content-Type-Options: nosniff; browsers may execute uploaded files as scripts or stylesheets",
+		"xcto_invalid":            "X-Content-Type-Options value %s is invalid; only nosniff is supported",
+		"referrer_missing":        "No Referrer-Policy; the browser default applies (older browsers send the full URL to third parties)",
+		"referrer_unsafe":         "Referrer-Policy is %s; cross-site requests carry the full URL, which may leak sensitive paths or parameters",
+		"referrer_invalid":        "Referrer-Policy value %s is not recognized; browsers fall back to the default policy",
+		"pp_missing":              "No Permissions-Policy; the page and embedded third-party content are not restricted from using camera, geolocation and other browser features",
+		"pp_feature_policy":       "Only the deprecated Feature-Policy is set; current browsers use Permissions-Policy",
+		"pp_wildcard":             "Permissions-Policy grants %s to all origins (*)",
+		"pp_invalid":              "Permissions-Policy cannot be parsed: %s",
+		"coop_missing":            "No Cross-Origin-Opener-Policy; cross-origin windows can keep a reference to the page (XS-Leaks)",
+		"coop_unsafe_none":        "Cross-Origin-Opener-Policy is unsafe-none and does not isolate cross-origin windows",
+		"coop_invalid":            "Cross-Origin-Opener-Policy value %s is not recognized",
+		"coep_missing":            "No Cross-Origin-Embedder-Policy; the page cannot be cross-origin isolated (only required for features such as SharedArrayBuffer)",
+		"coep_invalid":            "Cross-Origin-Embedder-Policy value %s is not recognized",
+		"corp_missing":            "No Cross-Origin-Resource-Policy; other sites can load this resource directly",
+		"corp_invalid":            "Cross-Origin-Resource-Policy value %s is not recognized",
+	},
+}
+
+// securityHeaderRemediations 每个响应头的修复建议（zh/en），按检查项索引
+var securityHeaderRemediations = map[string]map[string]string{
+	"zh": {
+		models.HeaderCheckCSP:         "部署基于 nonce 或 hash 的严格 CSP，例如 Content-Security-Policy: script-src 'nonce-{随机值}' 'strict-dynamic'; object-src 'none'; base-uri 'none'; frame-ancestors 'self'；可以先用 Content-Security-Policy-Report-Only 收集违规报告，确认无误后再切换为强制模式",
+		models.HeaderCheckHSTS:        "全站启用 HTTPS 并把 HTTP 重定向到 HTTPS，然后设置 Strict-Transport-Security: max-age=31536000; includeSubDomains；确认所有子域名都支持 HTTPS 后再添加 preload 并提交到 hstspreload.org",
+		models.HeaderCheckFrame:       "设置 X-Frame-Options: DENY（需要被同源页面嵌入时使用 SAMEORIGIN），或在 CSP 中使用 frame-ancestors 'none' / 'self'",
+		models.HeaderCheckContentType: "设置 X-Content-Type-Options: nosniff，并确保所有响应都返回正确的 Content-Type",
+		models.HeaderCheckReferrer:    "设置 Referrer-Policy: strict-origin-when-cross-origin（或更严格的 same-origin、no-referrer）",
+		models.HeaderCheckPermissions: "设置 Permissions-Policy 关闭不需要的浏览器功能，例如 camera=(), microphone=(), geolocation=(), payment=()；需要时只授权给 self 或指定来源",
+		models.HeaderCheckCOOP:        "设置 Cross-Origin-Opener-Policy: same-origin（需要与跨域弹窗交互时使用 same-origin-allow-popups）",
+		models.HeaderCheckCOEP:        "需要跨域隔离时设置 Cross-Origin-Embedder-Policy: require-corp（或 credentialless），并确认所有跨域资源都返回 CORP 或 CORS 响应头",
+		models.HeaderCheckCORP:        "设置 Cross-Origin-Resource-Policy: same-origin（同站子域名需要使用时为 same-site，公开资源为 cross-origin）",
+	},
+	"en": {
+		models.HeaderCheckCSP:         "Deploy a strict nonce- or hash-based CSP, for example Content-Security-Policy: script-src 'nonce-{random}' 'strict-dynamic'; object-src 'none'; base-uri 'none'; frame-ancestors 'self'. Roll it out with Content-Security-Policy-Report-Only first and switch to enforcement once the reports are clean",
+		models.HeaderCheckHSTS:        "Serve the whole site over HTTPS and redirect HTTP to HTTPS, then set Strict-Transport-Security: max-age=31536000; includeSubDomains. Add preload and submit to hstspreload.org once every subdomain supports HTTPS",
+		models.HeaderCheckFrame:       "Set X-Frame-Options: DENY (SAMEORIGIN if same-origin pages embed it), or use frame-ancestors 'none' / 'self' in the CSP",
+		models.HeaderCheckContentType: "Set X-Content-Type-Options: nosniff and make sure every response has the correct Content-Type",
+		models.HeaderCheckReferrer:    "Set Referrer-Policy: strict-origin-when-cross-origin (or the stricter same-origin or no-referrer)",
+		models.HeaderCheckPermissions: "Set a Permissions-Policy that disables unused browser features, for example camera=(), microphone=(), geolocation=(), payment=(); grant features only to self or specific origins when needed",
+		models.HeaderCheckCOOP:        "Set Cross-Origin-Opener-Policy: same-origin (same-origin-allow-popups if the page needs to interact with cross-origin popups)",
+		models.HeaderCheckCOEP:        "If cross-origin isolation is needed, set Cross-Origin-Embedder-Policy: require-corp (or credentialless) and make sure every cross-origin resource sends CORP or CORS headers",
+		models.HeaderCheckCORP:        "Set Cross-Origin-Resource-Policy: same-origin (same-site if sibling subdomains use it, cross-origin for public resources)",
+	},
+}
+
+// headerAudit 一次安全响应头分析的上下文
+type headerAudit struct {
+	header http.Header
+	lang   string
+	result *models.SecurityHeadersAnalysis
+}
+
+// AnalyzeSecurityHeaders 分析页面响应的安全响应头（pageURL 为最终地址，用于判断是否使用 HTTPS）
+// 说明和修复建议使用任务语言
+func AnalyzeSecurityHeaders(header http.Header, pageURL *url.URL, lang string) *models.SecurityHeadersAnalysis {
+	a := &headerAudit{
+		header: header,
+		lang:   lang,
+		result: &models.SecurityHeadersAnalysis{
+			Headers:  []models.SecurityHeaderResult{},
+			Findings: []models.SecurityHeaderFinding{},
+		},
+	}
+
+	frameAncestors := a.checkCSP()
+	a.checkHSTS(pageURL != nil && strings.EqualFold(pageURL.Scheme, "https"))
+	a.checkFrameOptions(frameAncestors)
+	a.checkContentTypeOptions()
+	a.checkReferrerPolicy()
+	a.checkPermissionsPolicy()
+	a.checkCrossOriginPolicies()
+
+	result := a.result
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return severityRank[result.Findings[i].Severity] < severityRank[result.Findings[j].Severity]
+	})
+	penalties := make(map[string]int)
+	for _, f := range result.Findings {
+		penalties[f.Check] += securityHeaderPenalty[f.Severity]
+	}
+	result.Score = 100
+	for _, penalty := range penalties {
+		if penalty > securityHeaderMaxPenalty {
+			penalty = securityHeaderMaxPenalty
+		}
+		result.Score -= penalty
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	result.Grade = scoreGrade(result.Score)
+
+	// 每个响应头的结论：最严重的发现和修复建议（发现已按严重程度排序，提示类发现不需要修复）
+	for _, item := range securityHeaderNames {
+		headerResult := models.SecurityHeaderResult{
+			Check:  item.check,
+			Header: item.header,
+			Value:  strings.Join(header.Values(item.header), ", "),
+		}
+		for _, f := range result.Findings {
+			if f.Check == item.check && f.Severity != models.SeverityInfo {
+				headerResult.Severity = f.Severity
+				headerResult.Remediation = findingMessage(securityHeaderRemediations, lang, item.check)
+				break
+			}
+		}
+		result.Headers = append(result.Headers, headerResult)
+	}
+
+	log.Printf("[SecurityHeaders] Score %d (%s), %d findings", result.Score, result.Grade, len(result.Findings))
+	return result
+}
+
+// addFinding 记录一个发现，args 用于填充说明文案中的占位符
+func (a *headerAudit) addFinding(check, id, severity string, args ...interface{}) {
+	a.result.Findings = append(a.result.Findings, models.SecurityHeaderFinding{
+		Check:    check,
+		ID:       id,
+		Severity: severity,
+		Message:  findingMessage(securityHeaderMessages, a.lang, id, args...),
+	})
+}
+
+// checkCSP 解析 Content-Security-Policy，返回强制策略是否设置了 frame-ancestors
+// 同时设置了多个策略时只分析第一个（浏览器会同时执行所有策略，后面的策略只会更严格）
+func (a *headerAudit) checkCSP() bool {
+	policy := firstCSPPolicy(a.header.Values("Content-Security-Policy"))
+	reportOnly := false
+	if policy == "" {
+		policy = firstCSPPolicy(a.header.Values("Content-Security-Policy-Report-Only"))
+		reportOnly = policy != ""
+	}
+	if policy == "" {
+		a.addFinding(models.HeaderCheckCSP, "csp_missing", models.SeverityHigh)
+		return false
+	}
+
+	directives := parseCSP(policy)
+	csp := &models.CSPAnalysis{Policy: policy, ReportOnly: reportOnly, Directives: directives}
+	a.result.CSP = csp
+	if uri := directives["report-uri"]; len(uri) > 0 {
+		csp.ReportURI = uri[0]
+	} else if group := directives["report-to"]; len(group) > 0 {
+		csp.ReportURI = group[0]
+	}
+
+	scriptSrc, hasScriptSrc := directives["script-src"]
+	if !hasScriptSrc {
+		scriptSrc, hasScriptSrc = directives["default-src"]
+	}
+	for _, source := range scriptSrc {
+		lower := strings.ToLower(source)
+		switch {
+		case strings.HasPrefix(lower, "'nonce-"):
+			csp.UsesNonce = true
+		case strings.HasPrefix(lower, "'sha256-"), strings.HasPrefix(lower, "'sha384-"), strings.HasPrefix(lower, "'sha512-"):
+			csp.UsesHash = true
+		case lower == "'strict-dynamic'":
+			csp.StrictDynamic = true
+		}
+	}
+
+	if reportOnly {
+		// 只上报不拦截，等同于没有 CSP；策略本身的问题在切换为强制模式前再处理
+		a.addFinding(models.HeaderCheckCSP, "csp_report_only", models.SeverityHigh)
+		return false
+	}
+
+	if !hasScriptSrc {
+		a.addFinding(models.HeaderCheckCSP, "csp_no_script_src", models.SeverityHigh)
+	} else {
+		strict := csp.UsesNonce || csp.UsesHash
+		for _, source := range scriptSrc {
+			switch strings.ToLower(source) {
+			case "'unsafe-inline'":
+				// 使用 nonce 或 hash 时支持 CSP2 的浏览器会忽略 'unsafe-inline'（用于兼容旧浏览器）
+				if !strict {
+					a.addFinding(models.HeaderCheckCSP, "csp_unsafe_inline", models.SeverityHigh)
+				}
+			case "'unsafe-eval'":
+				a.addFinding(models.HeaderCheckCSP, "csp_unsafe_eval", models.SeverityMedium)
+			case "*", "http:", "https:", "data:", "blob:":
+				// 'strict-dynamic' 配合 nonce 或 hash 时会忽略主机和协议白名单
+				if !(csp.StrictDynamic && strict) {
+					a.addFinding(models.HeaderCheckCSP, "csp_wildcard", models.SeverityHigh, source)
+				}
+			}
+		}
+		if strict {
+			a.addFinding(models.HeaderCheckCSP, "csp_nonce_hash", models.SeverityInfo)
+		}
+	}
+
+	objectSrc, ok := directives["object-src"]
+	if !ok {
+		objectSrc = directives["default-src"]
+	}
+	if len(objectSrc) != 1 || objectSrc[0] != "'none'" {
+		a.addFinding(models.HeaderCheckCSP, "csp_object_src", models.SeverityMedium)
+	}
+	// base-uri 不会回退到 default-src
+	if _, ok := directives["base-uri"]; !ok {
+		a.addFinding(models.HeaderCheckCSP, "csp_base_uri", models.SeverityMedium)
+	}
+
+	_, frameAncestors := directives["frame-ancestors"]
+	return frameAncestors
+}
+
+// firstCSPPolicy 返回响应头中的第一个 CSP 策略（多个策略以逗号分隔或分多个响应头）
+func firstCSPPolicy(values []string) string {
+	for _, value := range values {
+		for _, policy := range strings.Split(value, ",") {
+			if policy = strings.TrimSpace(policy); policy != "" {
+				return policy
+			}
+		}
+	}
+	return ""
+}
+
+// parseCSP 解析 CSP 策略为指令（小写）到来源列表的映射，重复的指令以第一个为准（与浏览器一致）
+func parseCSP(policy string) map[string][]string {
+	directives := make(map[string][]string)
+	for _, part := range strings.Split(policy, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, exists := directives[name]; exists {
+			continue
+		}
+		directives[name] = fields[1:]
+	}
+	return directives
+}
+
+// checkHSTS 解析 Strict-Transport-Security（只对 HTTPS 页面生效）
+func (a *headerAudit) checkHSTS(https bool) {
+	if !https {
+		a.addFinding(models.HeaderCheckHSTS, "hsts_no_https", models.SeverityHigh)
+		return
+	}
+	value := a.header.Get("Strict-Transport-Security")
+	if value == "" {
+		a.addFinding(models.HeaderCheckHSTS, "hsts_missing", models.SeverityHigh)
+		return
+	}
+
+	hsts := &models.HSTSAnalysis{MaxAge: -1}
+	for _, part := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(arg), `"`), 10, 64); err == nil && maxAge >= 0 {
+				hsts.MaxAge = maxAge
+			}
+		case "includesubdomains":
+			hsts.IncludeSubDomains = true
+		case "preload":
+			hsts.Preload = true
+		}
+	}
+	if hsts.MaxAge < 0 {
+		a.addFinding(models.HeaderCheckHSTS, "hsts_invalid", models.SeverityHigh)
+		return
+	}
+	a.result.HSTS = hsts
+	hsts.PreloadEligible = hsts.MaxAge >= hstsPreloadMaxAge && hsts.IncludeSubDomains && hsts.Preload
+
+	switch {
+	case hsts.MaxAge == 0:
+		a.addFinding(models.HeaderCheckHSTS, "hsts_disabled", models.SeverityHigh)
+		return
+	case hsts.MaxAge < hstsMinMaxAge:
+		a.addFinding(models.HeaderCheckHSTS, "hsts_short_max_age", models.SeverityMedium, hsts.MaxAge)
+	}
+	if !hsts.IncludeSubDomains {
+		a.addFinding(models.HeaderCheckHSTS, "hsts_no_subdomains", models.SeverityLow)
+	}
+	if hsts.Preload && !hsts.PreloadEligible {
+		a.addFinding(models.HeaderCheckHSTS, "hsts_preload_ineligible", models.SeverityLow)
+	} else if hsts.PreloadEligible {
+		a.addFinding(models.HeaderCheckHSTS, "hsts_preload_eligible", models.SeverityInfo)
+	}
+}
+
+// checkFrameOptions 检查 X-Frame-Options（CSP frame-ancestors 优先，设置后不再要求 X-Frame-Options）
+func (a *headerAudit) checkFrameOptions(frameAncestors bool) {
+	if frameAncestors {
+		return
+	}
+	value := strings.TrimSpace(a.header.Get("X-Frame-Options"))
+	switch {
+	case value == "":
+		a.addFinding(models.HeaderCheckFrame, "xfo_missing", models.SeverityMedium)
+	case !strings.EqualFold(value, "DENY") && !strings.EqualFold(value, "SAMEORIGIN"):
+		a.addFinding(models.HeaderCheckFrame, "xfo_invalid", models.SeverityMedium, value)
+	}
+}
+
+// checkContentTypeOptions 检查 X-Content-Type-Options
+func (a *headerAudit) checkContentTypeOptions() {
+	value := strings.TrimSpace(a.header.Get("X-Content-Type-Options"))
+	switch {
+	case value == "":
+		a.addFinding(models.HeaderCheckContentType, "xcto_missing", models.SeverityMedium)
+	case !strings.EqualFold(value, "nosniff"):
+		a.addFinding(models.HeaderCheckContentType, "xcto_invalid", models.SeverityMedium, value)
+	}
+}
+
+// checkReferrerPolicy 检查 Referrer-Policy（多个值时浏览器使用最后一个能识别的值）
+func (a *headerAudit) checkReferrerPolicy() {
+	value := strings.Join(a.header.Values("Referrer-Policy"), ",")
+	if strings.TrimSpace(value) == "" {
+		a.addFinding(models.HeaderCheckReferrer, "referrer_missing", models.SeverityLow)
+		return
+	}
+
+	policy := ""
+	for _, token := range strings.Split(value, ",") {
+		switch token = strings.ToLower(strings.TrimSpace(token)); token {
+		case "no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+			"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url":
+			policy = token
+		}
+	}
+	switch policy {
+	case "":
+		a.addFinding(models.HeaderCheckReferrer, "referrer_invalid", models.SeverityLow, value)
+	case "unsafe-url":
+		a.addFinding(models.HeaderCheckReferrer, "referrer_unsafe", models.SeverityMedium, policy)
+	case "no-referrer-when-downgrade":
+		a.addFinding(models.HeaderCheckReferrer, "referrer_unsafe", models.SeverityLow, policy)
+	}
+}
+
+// checkPermissionsPolicy 解析 Permissions-Policy（结构化字段：feature=(allowlist)，以逗号分隔）
+func (a *headerAudit) checkPermissionsPolicy() {
+	value := strings.Join(a.header.Values("Permissions-Policy"), ",")
+	if strings.TrimSpace(value) == "" {
+		if a.header.Get("Feature-Policy") != "" {
+			a.addFinding(models.HeaderCheckPermissions, "pp_feature_policy", models.SeverityLow)
+		} else {
+			a.addFinding(models.HeaderCheckPermissions, "pp_missing", models.SeverityLow)
+		}
+		return
+	}
+
+	var wildcard []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		feature, allowlist, ok := strings.Cut(entry, "=")
+		feature = strings.ToLower(strings.TrimSpace(feature))
+		allowlist = strings.TrimSpace(allowlist)
+		if !ok || feature == "" || allowlist == "" {
+			a.addFinding(models.HeaderCheckPermissions, "pp_invalid", models.SeverityLow, entry)
+			return
+		}
+		if !sensitivePermissions[feature] {
+			continue
+		}
+		for _, origin := range strings.Fields(strings.Trim(allowlist, "()")) {
+			if origin == "*" {
+				wildcard = append(wildcard, feature)
+				break
+			}
+		}
+	}
+	if len(wildcard) > 0 {
+		a.addFinding(models.HeaderCheckPermissions, "pp_wildcard", models.SeverityMedium, strings.Join(wildcard, ", "))
+	}
+}
+
+// checkCrossOriginPolicies 检查 COOP、COEP 和 CORP（忽略 report-to 等参数）
+func (a *headerAudit) checkCrossOriginPolicies() {
+	coop := headerToken(a.header.Get("Cross-Origin-Opener-Policy"))
+	switch coop {
+	case "":
+		a.addFinding(models.HeaderCheckCOOP, "coop_missing", models.SeverityLow)
+	case "same-origin", "same-origin-allow-popups", "noopener-allow-popups":
+	case "unsafe-none":
+		a.addFinding(models.HeaderCheckCOOP, "coop_unsafe_none", models.SeverityLow)
+	default:
+		a.addFinding(models.HeaderCheckCOOP, "coop_invalid", models.SeverityLow, coop)
+	}
+
+	// COEP 只在需要跨域隔离时使用，没有设置不扣分
+	coep := headerToken(a.header.Get("Cross-Origin-Embedder-Policy"))
+	switch coep {
+	case "", "unsafe-none":
+		a.addFinding(models.HeaderCheckCOEP, "coep_missing", models.SeverityInfo)
+	case "require-corp", "credentialless":
+	default:
+		a.addFinding(models.HeaderCheckCOEP, "coep_invalid", models.SeverityLow, coep)
+	}
+
+	corp := headerToken(a.header.Get("Cross-Origin-Resource-Policy"))
+	switch corp {
+	case "":
+		a.addFinding(models.HeaderCheckCORP, "corp_missing", models.SeverityInfo)
+	case "same-origin", "same-site", "cross-origin":
+	default:
+		a.addFinding(models.HeaderCheckCORP, "corp_invalid", models.SeverityLow, corp)
+	}
+}
+
+// headerToken 返回响应头的值（小写，去掉 ; 之后的参数）
+func headerToken(value string) string {
+	token, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.Trim(strings.TrimSpace(token), `"`))
+}
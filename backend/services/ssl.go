@@ -1,21 +1,23 @@
 package services
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/tls"
 	"fmt"
 	"log"
-	"net"
 	"net/url"
 	"strings"
 	"time"
 
 	"web-checkly/models"
+	"web-checkly/utils"
 )
 
-// CollectSSLInfo 收集SSL证书信息
-func CollectSSLInfo(targetURL string) (*models.SSLInfo, error) {
+// CollectSSLInfo 收集SSL证书信息，并分析证书链、主机名覆盖、密钥强度、吊销状态和 Certificate Transparency
+// 发现的问题按任务语言（lang）记录在 SSLInfo.Findings 中
+func CollectSSLInfo(ctx context.Context, targetURL string, lang string) (*models.SSLInfo, error) {
 	log.Printf("[SSLInfo] Collecting SSL certificate info from: %s", targetURL)
 
 	parsedURL, err := url.Parse(targetURL)
@@ -42,15 +44,20 @@ func CollectSSLInfo(targetURL string) (*models.SSLInfo, error) {
 	}
 
 	// 连接到服务器获取证书（带超时）
-	dialer := &net.Dialer{
-		Timeout: 10 * time.Second,
+	// 使用安全 Dialer：连接时拒绝内网地址，防止 DNS 重绑定
+	// 证书链由 analyzeCertificates 单独验证；Go 客户端总是请求 OCSP 装订和 SCT
+	dialer := &tls.Dialer{
+		NetDialer: utils.NewSafeDialer(10 * time.Second),
+		Config: &tls.Config{
+			ServerName:         hostname,
+			InsecureSkipVerify: true, // 允许自签名证书
+		},
 	}
-	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
-		InsecureSkipVerify: true, // 允许自签名证书
-	})
+	rawConn, err := dialer.DialContext(ctx, "tcp", host)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
+	conn := rawConn.(*tls.Conn)
 	defer conn.Close()
 
 	state := conn.ConnectionState()
@@ -58,6 +65,17 @@ func CollectSSLInfo(targetURL string) (*models.SSLInfo, error) {
 		return nil, fmt.Errorf("no certificates found")
 	}
 
+	info := sslInfoFromState(ctx, hostname, lang, state)
+
+	log.Printf("[SSLInfo] Collected info: Issuer=%s, ValidTo=%s, DaysRemaining=%d, ChainTrusted=%v, Findings=%d",
+		info.Issuer, info.ValidTo, info.DaysRemaining, info.ChainTrusted, len(info.Findings))
+
+	return info, nil
+}
+
+// sslInfoFromState 从 TLS 握手状态提取服务器证书信息并分析证书链（state 至少包含一个证书）
+// 供 ssl-info 和进程内 TLS 配置扫描共用
+func sslInfoFromState(ctx context.Context, hostname, lang string, state tls.ConnectionState) *models.SSLInfo {
 	cert := state.PeerCertificates[0]
 	info := &models.SSLInfo{}
 
@@ -126,8 +144,7 @@ func CollectSSLInfo(targetURL string) (*models.SSLInfo, error) {
 		}
 	}
 
-	log.Printf("[SSLInfo] Collected info: Issuer=%s, ValidTo=%s, DaysRemaining=%d",
-		info.Issuer, info.ValidTo, info.DaysRemaining)
+	analyzeCertificates(ctx, hostname, lang, state, info)
 
-	return info, nil
+	return info
 }
@@ -0,0 +1,577 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// certFetchTimeout 下载中间证书（AIA）、查询 OCSP 和下载 CRL 的超时时间
+	certFetchTimeout = 5 * time.Second
+	// aiaMaxCertSize 通过 AIA 下载的证书最大字节数
+	aiaMaxCertSize = 64 << 10
+	// aiaMaxDepth 最多通过 AIA 补全的中间证书数
+	aiaMaxDepth = 3
+	// crlMaxSize CRL 最大字节数（超过时不检查）
+	crlMaxSize = 10 << 20
+	// certExpiringSoonDays / certExpiringDays 证书剩余天数低于该值时提示
+	certExpiringSoonDays = 7
+	certExpiringDays     = 30
+)
+
+var (
+	// oidSCTList 证书内嵌的 SCT 列表扩展（RFC 6962 3.3）
+	oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+	// oidTLSFeature TLS Feature 扩展（RFC 7633），包含 status_request(5) 时表示 OCSP Must-Staple
+	oidTLSFeature = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+)
+
+// sslFindingMessages 发现的说明文案（zh/en）
+var sslFindingMessages = map[string]map[string]string{
+	"zh": {
+		"cert_expired":             "证书已于 %s 过期",
+		"cert_not_yet_valid":       "证书尚未生效（生效时间 %s），请检查服务器时间或证书配置",
+		"cert_expiring_soon":       "证书将在 %d 天后过期，请立即续期",
+		"cert_expiring":            "证书将在 %d 天后过期，请确认已配置自动续期",
+		"hostname_mismatch":        "证书不包含目标主机名 %s，浏览器会显示证书错误",
+		"san_variant_missing":      "证书不包含 %s，但该主机名可以解析，访问时会出现证书错误",
+		"chain_untrusted":          "证书链无法验证到受信任的根证书：%s",
+		"chain_self_signed":        "使用自签名证书，浏览器和大多数客户端不会信任",
+		"chain_incomplete":         "服务器没有发送中间证书（%s），浏览器可能自动补全，但 curl、移动应用等客户端将无法建立连接",
+		"chain_misordered":         "服务器发送的证书链顺序不正确，部分旧客户端可能验证失败",
+		"chain_contains_root":      "服务器发送了根证书（%s），这是多余的，会增加握手数据量",
+		"chain_cert_expired":       "证书链中的 %s 已过期",
+		"weak_key":                 "%s 使用弱密钥（%s %d 位），RSA 至少应为 2048 位，ECDSA 至少 256 位",
+		"weak_signature":           "%s 使用不安全的签名算法 %s",
+		"cert_revoked":             "证书已被吊销（吊销时间 %s），浏览器会拒绝连接",
+		"ocsp_must_staple_missing": "证书要求 OCSP 装订（Must-Staple），但服务器没有附带 OCSP 响应，Firefox 等浏览器会拒绝连接",
+		"ocsp_not_stapled":         "服务器没有启用 OCSP 装订，客户端需要单独查询证书状态",
+		"revocation_check_failed":  "无法检查证书吊销状态：%s",
+		"sct_missing":              "证书没有 Certificate Transparency SCT，Chrome 和 Safari 会拒绝公开信任的证书",
+	},
+	"en": {
+		"cert_expired":             "The certificate expired at %s",
+		"cert_not_yet_valid":       "The certificate is not valid yet (valid from %s); check the server clock or the certificate",
+		"cert_expiring_soon":       "The certificate expires in %d day(s); renew it now",
+		"cert_expiring":            "The certificate expires in %d days; make sure automatic renewal is configured",
+		"hostname_mismatch":        "The certificate does not cover the target host %s; browsers will show a certificate error",
+		"san_variant_missing":      "The certificate does not cover %s although that name resolves; visitors will get a certificate error",
+		"chain_untrusted":          "The certificate chain does not lead to a trusted root: %s",
+		"chain_self_signed":        "The certificate is self-signed; browsers and most clients will not trust it",
+		"chain_incomplete":         "The server does not send the intermediate certificate (%s); browsers may fill the gap, but curl, mobile apps and other clients will fail to connect",
+		"chain_misordered":         "The server sends the certificate chain in the wrong order; some older clients may fail to validate it",
+		"chain_contains_root":      "The server sends the root certificate (%s), which is unnecessary and enlarges the handshake",
+		"chain_cert_expired":       "%s in the certificate chain has expired",
+		"weak_key":                 "%s uses a weak key (%s %d bits); use at least 2048-bit RSA or 256-bit ECDSA",
+		"weak_signature":           "%s is signed with the insecure algorithm %s",
+		"cert_revoked":             "The certificate has been revoked (at %s); browsers will refuse the connection",
+		"ocsp_must_staple_missing": "The certificate requires OCSP stapling (Must-Staple) but the server does not staple a response; Firefox and others will refuse the connection",
+		"ocsp_not_stapled":         "OCSP stapling is not enabled; clients have to query the certificate status separately",
+		"revocation_check_failed":  "Could not check the revocation status: %s",
+		"sct_missing":              "The certificate has no Certificate Transparency SCTs; Chrome and Safari reject publicly trusted certificates without them",
+	},
+}
+
+// sslAudit 一次证书分析的上下文
+type sslAudit struct {
+	ctx    context.Context
+	lang   string
+	info   *models.SSLInfo
+	client *http.Client
+	now    time.Time
+}
+
+// addFinding 记录一个发现（按任务语言生成说明）
+func (a *sslAudit) addFinding(check, id, severity string, args ...interface{}) {
+	a.info.Findings = append(a.info.Findings, models.SSLFinding{
+		Check:    check,
+		ID:       id,
+		Severity: severity,
+		Message:  findingMessage(sslFindingMessages, a.lang, id, args...),
+	})
+}
+
+// analyzeCertificates 分析证书链、主机名覆盖、密钥强度、吊销状态和 CT，结果写入 info
+func analyzeCertificates(ctx context.Context, hostname, lang string, state tls.ConnectionState, info *models.SSLInfo) {
+	a := &sslAudit{
+		ctx:    ctx,
+		lang:   lang,
+		info:   info,
+		client: utils.NewSafeHTTPClient(certFetchTimeout),
+		now:    time.Now(),
+	}
+	certs := state.PeerCertificates
+	leaf := certs[0]
+
+	a.checkExpiry(leaf)
+	a.checkHostnames(leaf, hostname)
+	chain := a.checkChain(certs)
+	a.checkKeys(chain)
+
+	var issuer *x509.Certificate
+	for _, cert := range chain[1:] {
+		if bytes.Equal(cert.RawSubject, leaf.RawIssuer) {
+			issuer = cert
+			break
+		}
+	}
+	a.checkRevocation(leaf, issuer, state.OCSPResponse)
+	a.checkCT(leaf, state.SignedCertificateTimestamps)
+
+	sort.SliceStable(info.Findings, func(i, j int) bool {
+		return severityRank[info.Findings[i].Severity] < severityRank[info.Findings[j].Severity]
+	})
+}
+
+// checkExpiry 检查叶子证书的有效期
+func (a *sslAudit) checkExpiry(leaf *x509.Certificate) {
+	days := a.info.DaysRemaining
+	switch {
+	case a.now.After(leaf.NotAfter):
+		a.addFinding(models.SSLCheckExpiry, "cert_expired", models.SeverityCritical, leaf.NotAfter.UTC().Format(time.RFC3339))
+	case a.now.Before(leaf.NotBefore):
+		a.addFinding(models.SSLCheckExpiry, "cert_not_yet_valid", models.SeverityCritical, leaf.NotBefore.UTC().Format(time.RFC3339))
+	case days < certExpiringSoonDays:
+		a.addFinding(models.SSLCheckExpiry, "cert_expiring_soon", models.SeverityHigh, days)
+	case days < certExpiringDays:
+		a.addFinding(models.SSLCheckExpiry, "cert_expiring", models.SeverityMedium, days)
+	}
+}
+
+// checkHostnames 检查证书是否覆盖目标主机名，以及 apex 与 www 两个变体
+// 变体只在能解析时才报告（没有使用的主机名不需要证书）
+func (a *sslAudit) checkHostnames(leaf *x509.Certificate, hostname string) {
+	covered := leaf.VerifyHostname(hostname) == nil
+	a.info.HostnameCoverage = append(a.info.HostnameCoverage, models.HostnameCoverage{Host: hostname, Covered: covered, Target: true})
+	if !covered {
+		a.addFinding(models.SSLCheckHostname, "hostname_mismatch", models.SeverityCritical, hostname)
+	}
+	if net.ParseIP(hostname) != nil {
+		return
+	}
+
+	variant := ""
+	if apex := strings.TrimPrefix(hostname, "www."); apex != hostname {
+		variant = apex
+	} else if organizationalDomain(hostname) == hostname {
+		variant = "www." + hostname
+	}
+	if variant == "" {
+		return
+	}
+	covered = leaf.VerifyHostname(variant) == nil
+	a.info.HostnameCoverage = append(a.info.HostnameCoverage, models.HostnameCoverage{Host: variant, Covered: covered})
+	if !covered {
+		lookupCtx, cancel := context.WithTimeout(a.ctx, certFetchTimeout)
+		defer cancel()
+		if addrs, err := net.DefaultResolver.LookupHost(lookupCtx, variant); err == nil && len(addrs) > 0 {
+			a.addFinding(models.SSLCheckHostname, "san_variant_missing", models.SeverityMedium, variant)
+		}
+	}
+}
+
+// checkChain 验证证书链：是否可信、是否缺少中间证书、顺序和多余的根证书
+// 返回用于后续检查的证书链（可信时为验证通过的路径，否则为服务器发送的证书）
+func (a *sslAudit) checkChain(certs []*x509.Certificate) []*x509.Certificate {
+	leaf := certs[0]
+	sources := make(map[*x509.Certificate]string)
+	for _, cert := range certs {
+		sources[cert] = "server"
+	}
+
+	// 顺序：每个证书应由下一个证书签发
+	for i := 0; i+1 < len(certs); i++ {
+		if !bytes.Equal(certs[i].RawIssuer, certs[i+1].RawSubject) {
+			a.addFinding(models.SSLCheckChain, "chain_misordered", models.SeverityLow)
+			break
+		}
+	}
+	for _, cert := range certs[1:] {
+		if isSelfSigned(cert) {
+			a.addFinding(models.SSLCheckChain, "chain_contains_root", models.SeverityInfo, cert.Subject.CommonName)
+			break
+		}
+	}
+	for _, cert := range certs[1:] {
+		if a.now.After(cert.NotAfter) && !isSelfSigned(cert) {
+			a.addFinding(models.SSLCheckChain, "chain_cert_expired", models.SeverityCritical, cert.Subject.String())
+		}
+	}
+
+	chain, err := a.verifyChain(leaf, certs[1:])
+	a.info.ChainComplete = err == nil
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		// 尝试通过 AIA 下载缺少的中间证书，区分"缺少中间证书"和"不受信任的根证书"
+		fetched := a.fetchIntermediates(certs[len(certs)-1])
+		for _, cert := range fetched {
+			sources[cert] = "aia"
+		}
+		if len(fetched) > 0 {
+			if fetchedChain, fetchedErr := a.verifyChain(leaf, append(append([]*x509.Certificate{}, certs[1:]...), fetched...)); fetchedErr == nil {
+				chain, err = fetchedChain, nil
+				a.addFinding(models.SSLCheckChain, "chain_incomplete", models.SeverityHigh, fetched[0].Subject.CommonName)
+			}
+		}
+	}
+
+	switch {
+	case err == nil:
+		a.info.ChainTrusted = true
+	case len(certs) == 1 && isSelfSigned(leaf):
+		a.addFinding(models.SSLCheckChain, "chain_self_signed", models.SeverityCritical)
+	default:
+		a.addFinding(models.SSLCheckChain, "chain_untrusted", models.SeverityCritical, err.Error())
+	}
+	if chain == nil {
+		chain = certs
+	}
+
+	for _, cert := range chain {
+		source, ok := sources[cert]
+		if !ok {
+			source = "trust_store"
+		}
+		a.info.Chain = append(a.info.Chain, chainCertificate(cert, source))
+	}
+	return chain
+}
+
+// verifyChain 使用系统信任库验证证书链（不检查主机名，由 checkHostnames 单独检查）
+// 叶子证书过期时在其有效期内验证，避免过期掩盖证书链本身的问题
+func (a *sslAudit) verifyChain(leaf *x509.Certificate, intermediates []*x509.Certificate) ([]*x509.Certificate, error) {
+	pool := x509.NewCertPool()
+	for _, cert := range intermediates {
+		pool.AddCert(cert)
+	}
+	at := a.now
+	if at.After(leaf.NotAfter) || at.Before(leaf.NotBefore) {
+		at = leaf.NotAfter.Add(-time.Minute)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Intermediates: pool,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+// fetchIntermediates 从证书的 AIA（Authority Information Access）地址下载签发者证书，直到遇到自签名证书
+func (a *sslAudit) fetchIntermediates(cert *x509.Certificate) []*x509.Certificate {
+	var fetched []*x509.Certificate
+	for len(fetched) < aiaMaxDepth && len(cert.IssuingCertificateURL) > 0 && !isSelfSigned(cert) {
+		issuer, err := a.fetchCertificate(cert.IssuingCertificateURL[0])
+		if err != nil {
+			log.Printf("[SSLInfo] Warning: Failed to fetch issuer certificate from %s: %v", cert.IssuingCertificateURL[0], err)
+			break
+		}
+		if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+			break
+		}
+		fetched = append(fetched, issuer)
+		cert = issuer
+	}
+	return fetched
+}
+
+// fetchCertificate 下载单个证书（DER 或 PEM）
+func (a *sslAudit) fetchCertificate(url string) (*x509.Certificate, error) {
+	data, err := a.fetch(url, aiaMaxCertSize)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseCertificate(data)
+}
+
+// fetch 通过安全 HTTP 客户端下载（证书中的地址由证书签发方控制，不能访问内网）
+func (a *sslAudit) fetch(url string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("response larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// checkKeys 检查证书链中（不含根证书）的密钥长度和签名算法
+func (a *sslAudit) checkKeys(chain []*x509.Certificate) {
+	for i, cert := range chain {
+		if i > 0 && isSelfSigned(cert) {
+			continue // 根证书的签名不被验证
+		}
+		name := cert.Subject.CommonName
+		if name == "" {
+			name = cert.Subject.String()
+		}
+		if bits := publicKeyBits(cert); bits > 0 {
+			weak := false
+			switch cert.PublicKey.(type) {
+			case *rsa.PublicKey:
+				weak = bits < 2048
+			case *ecdsa.PublicKey:
+				weak = bits < 256
+			}
+			if weak {
+				a.addFinding(models.SSLCheckKey, "weak_key", models.SeverityHigh, name, cert.PublicKeyAlgorithm.String(), bits)
+			}
+		}
+		switch cert.SignatureAlgorithm {
+		case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+			a.addFinding(models.SSLCheckKey, "weak_signature", models.SeverityHigh, name, cert.SignatureAlgorithm.String())
+		}
+	}
+}
+
+// checkRevocation 检查吊销状态：优先使用服务器装订的 OCSP 响应，其次查询 OCSP 服务器，最后使用 CRL
+func (a *sslAudit) checkRevocation(leaf, issuer *x509.Certificate, stapled []byte) {
+	a.info.MustStaple = hasMustStaple(leaf)
+	a.info.OCSPStapled = len(stapled) > 0
+	if !a.info.OCSPStapled {
+		if a.info.MustStaple {
+			a.addFinding(models.SSLCheckRevocation, "ocsp_must_staple_missing", models.SeverityHigh)
+		} else if len(leaf.OCSPServer) > 0 {
+			a.addFinding(models.SSLCheckRevocation, "ocsp_not_stapled", models.SeverityInfo)
+		}
+	}
+	if issuer == nil {
+		return
+	}
+
+	var revocation *models.RevocationInfo
+	switch {
+	case a.info.OCSPStapled:
+		revocation = &models.RevocationInfo{Method: "ocsp_stapled"}
+		if len(leaf.OCSPServer) > 0 {
+			revocation.Source = leaf.OCSPServer[0]
+		}
+		a.applyOCSPResponse(revocation, stapled, leaf, issuer)
+	case len(leaf.OCSPServer) > 0:
+		revocation = &models.RevocationInfo{Method: "ocsp", Source: leaf.OCSPServer[0]}
+		a.queryOCSP(revocation, leaf, issuer)
+	case len(leaf.CRLDistributionPoints) > 0:
+		revocation = &models.RevocationInfo{Method: "crl", Source: leaf.CRLDistributionPoints[0]}
+		a.checkCRL(revocation, leaf, issuer)
+	default:
+		return
+	}
+	a.info.Revocation = revocation
+
+	switch {
+	case revocation.Status == models.RevocationRevoked:
+		a.addFinding(models.SSLCheckRevocation, "cert_revoked", models.SeverityCritical, revocation.RevokedAt)
+	case revocation.Error != "":
+		a.addFinding(models.SSLCheckRevocation, "revocation_check_failed", models.SeverityInfo, revocation.Error)
+	}
+}
+
+// queryOCSP 向 OCSP 服务器查询证书状态
+func (a *sslAudit) queryOCSP(revocation *models.RevocationInfo, leaf, issuer *x509.Certificate) {
+	revocation.Status = models.RevocationUnknown
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodPost, revocation.Source, bytes.NewReader(request))
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		revocation.Error = fmt.Sprintf("OCSP responder returned HTTP %d", resp.StatusCode)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, aiaMaxCertSize))
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	a.applyOCSPResponse(revocation, data, leaf, issuer)
+}
+
+// applyOCSPResponse 解析 OCSP 响应（验证签名、证书序列号和有效期）
+func (a *sslAudit) applyOCSPResponse(revocation *models.RevocationInfo, data []byte, leaf, issuer *x509.Certificate) {
+	revocation.Status = models.RevocationUnknown
+	resp, err := ocsp.ParseResponseForCert(data, leaf, issuer)
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	if !resp.NextUpdate.IsZero() && a.now.After(resp.NextUpdate) {
+		revocation.Error = fmt.Sprintf("OCSP response expired at %s", resp.NextUpdate.UTC().Format(time.RFC3339))
+		return
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		revocation.Status = models.RevocationGood
+	case ocsp.Revoked:
+		revocation.Status = models.RevocationRevoked
+		revocation.RevokedAt = resp.RevokedAt.UTC().Format(time.RFC3339)
+	}
+}
+
+// checkCRL 下载 CRL 并查找证书序列号（CRL 需由签发者签名）
+func (a *sslAudit) checkCRL(revocation *models.RevocationInfo, leaf, issuer *x509.Certificate) {
+	revocation.Status = models.RevocationUnknown
+	data, err := a.fetch(revocation.Source, crlMaxSize)
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		revocation.Error = err.Error()
+		return
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		revocation.Error = fmt.Sprintf("invalid CRL signature: %v", err)
+		return
+	}
+	if !crl.NextUpdate.IsZero() && a.now.After(crl.NextUpdate) {
+		revocation.Error = fmt.Sprintf("CRL expired at %s", crl.NextUpdate.UTC().Format(time.RFC3339))
+		return
+	}
+	revocation.Status = models.RevocationGood
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			revocation.Status = models.RevocationRevoked
+			revocation.RevokedAt = entry.RevocationTime.UTC().Format(time.RFC3339)
+			return
+		}
+	}
+}
+
+// checkCT 统计 Certificate Transparency SCT（证书内嵌和 TLS 扩展）
+// 只对可信的证书要求 SCT（私有 CA 签发的证书不需要）
+func (a *sslAudit) checkCT(leaf *x509.Certificate, tlsSCTs [][]byte) {
+	a.info.SCTCount = embeddedSCTCount(leaf) + len(tlsSCTs)
+	if a.info.ChainTrusted && a.info.SCTCount == 0 {
+		a.addFinding(models.SSLCheckCT, "sct_missing", models.SeverityMedium)
+	}
+}
+
+// embeddedSCTCount 证书内嵌的 SCT 数量
+// 扩展值是 OCTET STRING 包裹的 TLS 编码列表：2 字节总长度，每个 SCT 前有 2 字节长度
+func embeddedSCTCount(cert *x509.Certificate) int {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSCTList) {
+			continue
+		}
+		var list []byte
+		if _, err := asn1.Unmarshal(ext.Value, &list); err != nil || len(list) < 2 {
+			return 0
+		}
+		list = list[2:]
+		count := 0
+		for len(list) >= 2 {
+			size := int(binary.BigEndian.Uint16(list))
+			if len(list) < 2+size {
+				break
+			}
+			count++
+			list = list[2+size:]
+		}
+		return count
+	}
+	return 0
+}
+
+// hasMustStaple 证书是否包含 TLS Feature 扩展中的 status_request（OCSP Must-Staple）
+func hasMustStaple(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidTLSFeature) {
+			continue
+		}
+		var features []int
+		if _, err := asn1.Unmarshal(ext.Value, &features); err != nil {
+			return false
+		}
+		for _, feature := range features {
+			if feature == 5 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSelfSigned 是否为自签名证书（根证书）
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// publicKeyBits 公钥长度（ECDSA 为曲线位数）
+func publicKeyBits(cert *x509.Certificate) int {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	}
+	return 0
+}
+
+// chainCertificate 转换为证书链条目
+func chainCertificate(cert *x509.Certificate, source string) models.ChainCertificate {
+	return models.ChainCertificate{
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		NotAfter:     cert.NotAfter.UTC().Format(time.RFC3339),
+		SignatureAlg: cert.SignatureAlgorithm.String(),
+		PublicKeyAlg: cert.PublicKeyAlgorithm.String(),
+		KeySize:      publicKeyBits(cert),
+		IsCA:         cert.IsCA,
+		SelfSigned:   isSelfSigned(cert),
+		Source:       source,
+	}
+}
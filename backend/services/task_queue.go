@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"web-checkly/database"
+	"web-checkly/models"
+
+	"github.com/google/uuid"
+)
+
+// 持久化任务队列参数
+const (
+	taskLeaseDuration     = 2 * time.Minute   // 租约时长（可见性超时），超时未续约的任务会被其他 worker 重新领取
+	taskHeartbeatInterval = 10 * time.Second  // 心跳（续约）间隔，需明显小于租约时长；其他进程取消任务后也通过心跳感知
+	taskQueuePollInterval = 2 * time.Second   // 队列轮询间隔
+	taskRecoverInterval   = 1 * time.Minute   // 检查重试耗尽任务的间隔
+	taskExecutionTimeout  = 300 * time.Second // 单个任务最长执行时间
+	taskMaxAttempts       = 3                 // 单个任务最多被领取执行的次数
+)
+
+// newWorkerID 生成当前进程的 worker 标识（主机名 + 进程号 + 随机后缀）
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
+
+// WorkerID 返回当前执行器的 worker 标识
+func (e *Executor) WorkerID() string {
+	return e.workerID
+}
+
+// Start 启动队列消费（从 tasks 表领取任务并执行）
+// 进程重启或崩溃后，pending 任务和租约过期的 running 任务都会被重新领取执行
+// ctx 取消后停止领取新任务，已在执行的任务不受影响
+func (e *Executor) Start(ctx context.Context) {
+	e.startOnce.Do(func() {
+		log.Printf("[Executor] Task queue worker started: %s (max workers: %d)", e.workerID, e.maxWorkers)
+		go e.pollQueue(ctx)
+	})
+}
+
+// pollQueue 轮询任务队列
+func (e *Executor) pollQueue(ctx context.Context) {
+	ticker := time.NewTicker(taskQueuePollInterval)
+	defer ticker.Stop()
+	recoverTicker := time.NewTicker(taskRecoverInterval)
+	defer recoverTicker.Stop()
+
+	// 启动时先处理一次重试耗尽的任务
+	e.failExhaustedTasks()
+
+	for {
+		e.drainQueue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[Executor] Task queue worker stopped: %s", e.workerID)
+			return
+		case <-recoverTicker.C:
+			e.failExhaustedTasks()
+		case <-ticker.C:
+		case <-e.wakeup:
+		}
+	}
+}
+
+// drainQueue 在有空闲 worker 时持续领取任务
+func (e *Executor) drainQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		// 先占用 worker 槽位再领取任务，避免领取后无法执行导致租约空转
+		select {
+		case e.workerPool <- struct{}{}:
+		default:
+			return
+		}
+
+		taskID, attempts, err := database.ClaimNextTask(e.workerID, taskLeaseDuration, taskMaxAttempts)
+		if err != nil {
+			<-e.workerPool
+			log.Printf("[Executor] Failed to claim task: %v", err)
+			return
+		}
+		if taskID == "" {
+			<-e.workerPool
+			return
+		}
+
+		log.Printf("[Executor] Claimed task %s (attempt %d/%d)", taskID, attempts, taskMaxAttempts)
+		go e.runClaimedTask(taskID)
+	}
+}
+
+// SetMaxWorkers 设置最大并发任务数（需在 Start 之前调用）
+func (e *Executor) SetMaxWorkers(maxWorkers int) {
+	if maxWorkers <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.maxWorkers = maxWorkers
+	e.workerPool = make(chan struct{}, maxWorkers)
+}
+
+// Shutdown 停止执行器：将当前 worker 正在执行的任务放回队列，由其他 worker 立即接管
+// 调用前应先取消 Start 的 ctx，避免继续领取新任务
+func (e *Executor) Shutdown() {
+	// 标记所有执行中的任务租约已丢失，避免进程退出前再写入最终状态
+	e.activeTasks.Range(func(key, _ interface{}) bool {
+		e.lostLeases.Store(key, true)
+		return true
+	})
+
+	count, err := database.RequeueWorkerTasks(e.workerID)
+	if err != nil {
+		log.Printf("[Executor] Failed to requeue tasks on shutdown: %v", err)
+		return
+	}
+	log.Printf("[Executor] Worker %s shut down, requeued %d running tasks", e.workerID, count)
+}
+
+// runClaimedTask 执行已领取的任务，执行期间定期续约
+func (e *Executor) runClaimedTask(taskID string) {
+	defer func() { <-e.workerPool }()
+
+	// 任务执行不继承队列的 ctx：停止领取新任务时不应中断正在执行的任务
+	ctx, cancel := context.WithTimeout(context.Background(), taskExecutionTimeout)
+	defer cancel()
+
+	// 记录执行中的任务，用于取消（CancelTask）和优雅退出（Shutdown）
+	e.activeTasks.Store(taskID, cancel)
+	defer e.activeTasks.Delete(taskID)
+
+	heartbeatDone := make(chan struct{})
+	go e.heartbeat(ctx, cancel, taskID, heartbeatDone)
+
+	e.ExecuteTask(ctx, taskID)
+
+	close(heartbeatDone)
+	e.lostLeases.Delete(taskID)
+	if err := database.ReleaseTaskLease(taskID, e.workerID); err != nil {
+		log.Printf("[Executor] Failed to release lease for task %s: %v", taskID, err)
+	}
+}
+
+// heartbeat 定期续约任务，租约丢失时取消任务执行
+func (e *Executor) heartbeat(ctx context.Context, cancel context.CancelFunc, taskID string, done <-chan struct{}) {
+	ticker := time.NewTicker(taskHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := database.RenewTaskLease(taskID, e.workerID, taskLeaseDuration)
+			if err != nil {
+				// 数据库暂时不可用时不中断任务，下次心跳再试
+				log.Printf("[Executor] Failed to renew lease for task %s: %v", taskID, err)
+				continue
+			}
+			if !ok {
+				log.Printf("[Executor] Lease lost for task %s, cancelling execution", taskID)
+				e.lostLeases.Store(taskID, true)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// isLeaseLost 检查任务租约是否已丢失（丢失后不应再写入任务的最终状态）
+func (e *Executor) isLeaseLost(taskID string) bool {
+	_, lost := e.lostLeases.Load(taskID)
+	return lost
+}
+
+// isTaskCanceled 检查任务是否已被用户取消
+func (e *Executor) isTaskCanceled(taskID string) bool {
+	task, err := e.taskManager.GetTask(taskID)
+	if err != nil {
+		return false
+	}
+	return task.Status == models.TaskStatusCanceled
+}
+
+// CancelTask 取消任务
+// 任务和模块状态置为 canceled 并退回费用；如果任务正在本进程执行，立即取消其上下文，
+// 插件通过 exec.CommandContext 启动的外部工具进程（含子进程）随之终止。
+// 在其他 worker 上执行的任务会在下一次心跳时发现租约失效并自行取消。
+func (e *Executor) CancelTask(taskID string) error {
+	if err := e.taskManager.CancelTask(taskID); err != nil {
+		return err
+	}
+
+	if cancel, ok := e.activeTasks.Load(taskID); ok {
+		log.Printf("[Executor] Cancelling running task %s", taskID)
+		e.lostLeases.Store(taskID, true)
+		cancel.(context.CancelFunc)()
+	}
+
+	// 积分在任务完成时才扣除，取消时退回（删除）未结算的使用记录
+	refundTaskCosts(taskID)
+	return nil
+}
+
+// failExhaustedTasks 将重试次数耗尽的任务标记为失败并退回其费用
+func (e *Executor) failExhaustedTasks() {
+	errorMsg := fmt.Sprintf("Task execution interrupted %d times, giving up", taskMaxAttempts)
+	taskIDs, err := database.FailExhaustedTasks(taskMaxAttempts, errorMsg)
+	if err != nil {
+		log.Printf("[Executor] Failed to check exhausted tasks: %v", err)
+		return
+	}
+	for _, taskID := range taskIDs {
+		log.Printf("[Executor] Task %s exhausted %d attempts, marked as failed", taskID, taskMaxAttempts)
+		refundTaskCosts(taskID)
+		finishTask(taskID)
+	}
+}
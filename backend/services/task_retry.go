@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"web-checkly/models"
+	"web-checkly/services/plugin"
+)
+
+var (
+	// ErrInvalidRetryModule 请求重新执行的模块不属于该任务
+	ErrInvalidRetryModule = errors.New("module is not part of the task")
+	// ErrNoModulesToRetry 没有需要重新执行的模块
+	ErrNoModulesToRetry = errors.New("no modules to retry")
+)
+
+// ResolveRetryOptions 确定需要重新执行的扫描选项（保持任务原有选项的顺序）
+// modules 为空时重新执行所有失败（或已取消）的模块；有子模块的插件（如 lighthouse）展开为任务选中的
+// 子模块（如 performance/seo/security/accessibility）
+func (e *Executor) ResolveRetryOptions(task *models.Task, modules []string) ([]string, error) {
+	if task.Status != models.TaskStatusCompleted && task.Status != models.TaskStatusFailed &&
+		task.Status != models.TaskStatusCanceled {
+		return nil, ErrTaskNotRetryable
+	}
+
+	if len(modules) == 0 {
+		for name, module := range task.Modules {
+			if module.Status == models.TaskStatusFailed || module.Status == models.TaskStatusCanceled {
+				modules = append(modules, name)
+			}
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range modules {
+		if containsString(task.Options, name) {
+			selected[name] = true
+			continue
+		}
+		subModules := pluginSubModules(name)
+		if len(subModules) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRetryModule, name)
+		}
+		for _, module := range subModules {
+			if containsString(task.Options, module) {
+				selected[module] = true
+			}
+		}
+	}
+
+	retryOptions := make([]string, 0, len(selected))
+	for _, option := range task.Options {
+		if selected[option] {
+			retryOptions = append(retryOptions, option)
+		}
+	}
+	// 没有可执行的插件（如 katana 与 link-health 同时选中时不执行 link-health）
+	if len(retryOptions) == 0 || len(e.determinePlugins(&models.Task{Options: retryOptions})) == 0 {
+		return nil, ErrNoModulesToRetry
+	}
+	return retryOptions, nil
+}
+
+// RetryTask 将已结束的任务放回队列，只重新执行 retryOptions 对应的模块
+// usageRecordIDs 为调用方为这些模块预扣积分（BatchPreDeductFeatureCosts）产生的使用记录，
+// 任务未能放回队列、重新执行的模块全部失败或任务被取消时退回。新结果会合并到任务已有的结果中。
+func (e *Executor) RetryTask(taskID string, retryOptions []string, usageRecordIDs map[string]string) error {
+	if err := e.taskManager.RetryTask(taskID, retryOptions, retryModuleNames(retryOptions), usageRecordIDs); err != nil {
+		refundPrepaidCosts(&models.Task{ID: taskID, PrepaidUsageRecords: usageRecordIDs})
+		return err
+	}
+
+	e.StartTaskExecution(taskID)
+	return nil
+}
+
+// retryModuleNames 返回重新执行时需要重置状态的模块（重新执行子模块时同时重置所属插件的模块，如 lighthouse）
+func retryModuleNames(retryOptions []string) []string {
+	names := append([]string{}, retryOptions...)
+	for _, name := range plugin.SelectPlugins(retryOptions) {
+		if len(pluginSubModules(name)) > 0 && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pluginSubModules 返回插件的子模块（插件未注册时返回空）
+func pluginSubModules(name string) []string {
+	p, err := plugin.GetPlugin(name)
+	if err != nil {
+		return nil
+	}
+	return plugin.SubModules(p)
+}
+
+// executionOptions 返回本次执行的扫描选项：重新执行时只包含需要重新执行的模块
+func executionOptions(task *models.Task) []string {
+	if len(task.RetryModules) > 0 {
+		return task.RetryModules
+	}
+	return task.Options
+}
+
+// upstreamDataFromResults 将任务已有的结果转换为上游插件输出数据
+// 重新执行部分模块时，未重新执行的依赖模块使用已有结果作为下游插件（如 AI 分析）的输入
+func upstreamDataFromResults(results *models.TaskResults) map[string]interface{} {
+	data := make(map[string]interface{})
+	if results == nil {
+		return data
+	}
+
+	if results.WebsiteInfo != nil {
+		data["website-info"] = results.WebsiteInfo
+	}
+	if results.DomainInfo != nil {
+		data["domain-info"] = results.DomainInfo
+	}
+	if results.SSLInfo != nil {
+		data["ssl-info"] = results.SSLInfo
+	}
+	if results.TechStack != nil {
+		data["tech-stack"] = results.TechStack
+	}
+	if results.EmailSecurity != nil {
+		data["email-security"] = results.EmailSecurity
+	}
+	if results.Cookies != nil {
+		data["cookies"] = results.Cookies
+	}
+	if results.LinkHealth != nil {
+		data["link-health"] = results.LinkHealth
+	}
+	if results.SecurityRisk != nil && results.SecurityRisk.VulnerableLibraries != nil {
+		data["js-libraries"] = results.SecurityRisk.VulnerableLibraries
+	}
+
+	lighthouseData := make(map[string]interface{})
+	if results.Performance != nil {
+		lighthouseData["performance"] = results.Performance
+	}
+	if results.SEOCompliance != nil {
+		lighthouseData["seo"] = results.SEOCompliance
+	}
+	if results.SecurityRisk != nil {
+		lighthouseData["security"] = results.SecurityRisk
+	}
+	if results.Accessibility != nil {
+		lighthouseData["accessibility"] = results.Accessibility
+	}
+	if len(lighthouseData) > 0 {
+		data["lighthouse"] = lighthouseData
+	}
+
+	return data
+}
+
+// hasCompletedModules 检查任务是否有已完成的模块
+func hasCompletedModules(task *models.Task) bool {
+	for _, module := range task.Modules {
+		if module.Status == models.TaskStatusCompleted {
+			return true
+		}
+	}
+	return false
+}
+
+// refundPrepaidCosts 退回本次执行预扣的积分（批量扫描创建任务或重新执行模块时预扣，已退款的记录会被跳过）
+func refundPrepaidCosts(task *models.Task) {
+	for feature, usageRecordID := range task.PrepaidUsageRecords {
+		if usageRecordID == "" {
+			continue
+		}
+		if err := RefundFeatureCost(usageRecordID, task.ID); err != nil {
+			log.Printf("[Executor] Failed to refund prepaid cost for %s (task %s): %v", feature, task.ID, err)
+		} else {
+			log.Printf("[Executor] Refunded prepaid cost for %s (task %s)", feature, task.ID)
+		}
+	}
+}
+
+// seedUpstreamOptions 重新执行时将已有结果作为上游插件输出写入插件选项
+func seedUpstreamOptions(task *models.Task, pluginOptions map[string]interface{}) {
+	if len(task.RetryModules) == 0 {
+		return
+	}
+	for name, data := range upstreamDataFromResults(task.Results) {
+		pluginOptions[plugin.UpstreamOptionKey(name)] = data
+	}
+}
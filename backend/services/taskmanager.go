@@ -1,11 +1,13 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
 	"web-checkly/database"
 	"web-checkly/models"
+	"web-checkly/services/plugin"
 
 	"github.com/google/uuid"
 )
@@ -33,9 +35,9 @@ func (tm *TaskManager) CreateTask(req *models.CreateTaskRequest, userID *string)
 
 	// 根据选项初始化模块状态
 	moduleNames := []string{
-		"website-info", "domain-info", "ssl-info", "tech-stack",
-		"link-health", "performance", "seo", "security", "accessibility",
-		"ai-analysis",
+		"website-info", "domain-info", "ssl-info", "tech-stack", "email-security",
+		"js-libraries", "cookies", "link-health", "performance", "seo", "security", "accessibility",
+		"sitemap-audit", "ai-analysis",
 	}
 
 	for _, opt := range options {
@@ -50,19 +52,14 @@ func (tm *TaskManager) CreateTask(req *models.CreateTaskRequest, userID *string)
 		}
 	}
 
-	// 如果启用了 performance/seo/security/accessibility，需要 lighthouse 模块
-	needLighthouse := false
-	for _, opt := range options {
-		if opt == "performance" || opt == "seo" || opt == "security" || opt == "accessibility" {
-			needLighthouse = true
-			break
-		}
-	}
-	if needLighthouse {
-		modules["lighthouse"] = &models.ModuleStatus{
-			Name:     "lighthouse",
-			Status:   models.TaskStatusPending,
-			Progress: models.TaskProgress{Current: 0, Total: 0},
+	// 选中了插件的子模块（如 performance/seo/security/accessibility）时，同时需要插件模块（如 lighthouse）
+	for _, name := range plugin.SelectPlugins(options) {
+		if len(pluginSubModules(name)) > 0 {
+			modules[name] = &models.ModuleStatus{
+				Name:     name,
+				Status:   models.TaskStatusPending,
+				Progress: models.TaskProgress{Current: 0, Total: 0},
+			}
 		}
 	}
 
@@ -93,6 +90,12 @@ func (tm *TaskManager) CreateTask(req *models.CreateTaskRequest, userID *string)
 		Progress:  models.TaskProgress{Current: 0, Total: 0},
 		Modules:   modules,
 	}
+	task.ScheduleID = req.ScheduleID
+	task.BatchID = req.BatchID
+	task.PrepaidUsageRecords = req.PrepaidUsageRecords
+	task.Policy = req.Policy
+	task.Crawl = req.Crawl
+	task.Auth = req.Auth
 
 	// 存储任务到数据库
 	if err := database.CreateTask(task); err != nil {
@@ -124,6 +127,7 @@ func (tm *TaskManager) GetTaskStatus(taskID string) (*models.TaskStatusResponse,
 		Progress:  task.Progress,
 		Modules:   task.Modules,
 		Error:     task.Error,
+		Verdict:   task.Verdict,
 	}, nil
 }
 
@@ -159,77 +163,60 @@ func (tm *TaskManager) UpdateTaskStatus(taskID string, status models.TaskStatus)
 
 // UpdateTaskProgress 更新任务进度
 func (tm *TaskManager) UpdateTaskProgress(taskID string, current, total int) error {
-	// 获取当前任务以获取modules
-	task, err := database.GetTask(taskID)
-	if err != nil {
-		return err
-	}
-
-	progress := models.TaskProgress{Current: current, Total: total}
-	return database.UpdateTaskProgress(taskID, progress, task.Modules)
+	return database.SetTaskProgress(taskID, models.TaskProgress{Current: current, Total: total})
 }
 
-// UpdateModuleStatus 更新模块状态
+// UpdateModuleStatus 更新模块状态（在事务中锁定任务行，并发执行的插件不会互相覆盖模块状态）
 func (tm *TaskManager) UpdateModuleStatus(taskID, moduleName string, status models.TaskStatus, errorMsg string) error {
-	// 获取当前任务
-	task, err := database.GetTask(taskID)
-	if err != nil {
-		return err
-	}
-
-	// 更新模块状态
-	module, ok := task.Modules[moduleName]
-	if !ok {
-		// 如果模块不存在，创建它
-		module = &models.ModuleStatus{
-			Name:     moduleName,
-			Status:   status,
-			Progress: models.TaskProgress{Current: 0, Total: 0},
+	return database.ModifyTaskModules(taskID, func(taskStatus models.TaskStatus, modules map[string]*models.ModuleStatus) bool {
+		// 任务已取消：模块状态保持 canceled，忽略执行中的插件回写
+		if taskStatus == models.TaskStatusCanceled {
+			return false
 		}
-		task.Modules[moduleName] = module
-	}
 
-	module.Status = status
-	module.Error = errorMsg
+		module, ok := modules[moduleName]
+		if !ok {
+			// 如果模块不存在，创建它
+			module = &models.ModuleStatus{
+				Name:     moduleName,
+				Status:   status,
+				Progress: models.TaskProgress{Current: 0, Total: 0},
+			}
+			modules[moduleName] = module
+		}
 
-	if status == models.TaskStatusRunning && module.StartedAt == nil {
-		now := time.Now()
-		module.StartedAt = &now
-	}
+		module.Status = status
+		module.Error = errorMsg
 
-	if status == models.TaskStatusCompleted || status == models.TaskStatusFailed {
-		now := time.Now()
-		module.CompletedAt = &now
-	}
+		if status == models.TaskStatusRunning && module.StartedAt == nil {
+			now := time.Now()
+			module.StartedAt = &now
+		}
 
-	// 更新到数据库
-	return database.UpdateTaskProgress(taskID, task.Progress, task.Modules)
+		if status == models.TaskStatusCompleted || status == models.TaskStatusFailed || status == models.TaskStatusCanceled {
+			now := time.Now()
+			module.CompletedAt = &now
+		}
+		return true
+	})
 }
 
 // UpdateModuleProgress 更新模块进度
 func (tm *TaskManager) UpdateModuleProgress(taskID, moduleName string, current, total int) error {
-	// 获取当前任务
-	task, err := database.GetTask(taskID)
-	if err != nil {
-		return err
-	}
-
-	// 更新模块进度
-	module, ok := task.Modules[moduleName]
-	if !ok {
-		module = &models.ModuleStatus{
-			Name:     moduleName,
-			Status:   models.TaskStatusRunning,
-			Progress: models.TaskProgress{Current: current, Total: total},
+	return database.ModifyTaskModules(taskID, func(_ models.TaskStatus, modules map[string]*models.ModuleStatus) bool {
+		module, ok := modules[moduleName]
+		if !ok {
+			module = &models.ModuleStatus{
+				Name:   moduleName,
+				Status: models.TaskStatusRunning,
+			}
+			modules[moduleName] = module
 		}
-		task.Modules[moduleName] = module
-	}
 
-	module.Progress.Current = current
-	module.Progress.Total = total
-
-	// 更新到数据库
-	return database.UpdateTaskProgress(taskID, task.Progress, task.Modules)
+		module.Progress.Current = current
+		module.Progress.Total = total
+		return true
+	})
 }
 
 // SetTaskResults 设置任务结果
@@ -237,64 +224,76 @@ func (tm *TaskManager) SetTaskResults(taskID string, results *models.TaskResults
 	return database.UpdateTaskResults(taskID, results)
 }
 
-// AppendKatanaResult 追加katana发现的链接（用于实时推送）
+// AppendKatanaResults 批量追加katana发现的链接（用于实时推送）
 // 注意：这个方法主要用于实时推送，实际结果最终通过SetTaskResults保存
-// 重要：此方法只更新 katana_results 字段，不会覆盖其他字段（如 link_health）
-func (tm *TaskManager) AppendKatanaResult(taskID string, result interface{}) error {
-	// 获取当前任务
-	task, err := database.GetTask(taskID)
+// 重要：此方法只原子地追加 katana_results 字段，不会覆盖其他字段（如 link_health）
+func (tm *TaskManager) AppendKatanaResults(taskID string, results []interface{}) error {
+	return database.AppendTaskKatanaResults(taskID, results)
+}
+
+// ErrTaskNotCancelable 任务已结束，无法取消
+var ErrTaskNotCancelable = errors.New("task is not pending or running")
+
+// CancelTask 取消任务：任务状态和所有未结束的模块状态置为 canceled
+func (tm *TaskManager) CancelTask(taskID string) error {
+	canceled, err := database.CancelTask(taskID, "Task canceled by user")
 	if err != nil {
 		return err
 	}
+	if !canceled {
+		return ErrTaskNotCancelable
+	}
 
-	// 初始化结果对象（如果不存在）
-	if task.Results == nil {
-		task.Results = &models.TaskResults{
-			Summary: models.ScanSummary{},
+	now := time.Now()
+	err = database.ModifyTaskModules(taskID, func(_ models.TaskStatus, modules map[string]*models.ModuleStatus) bool {
+		for _, module := range modules {
+			if module.Status == models.TaskStatusPending || module.Status == models.TaskStatusRunning {
+				module.Status = models.TaskStatusCanceled
+				module.CompletedAt = &now
+			}
 		}
+		return true
+	})
+	if err != nil {
+		log.Printf("[TaskManager] Failed to mark modules canceled for task %s: %v", taskID, err)
+	}
+
+	log.Printf("[TaskManager] Canceled task: %s", taskID)
+	return nil
+}
+
+// ErrTaskNotRetryable 任务未结束，无法重新执行
+var ErrTaskNotRetryable = errors.New("task is not completed, failed or canceled")
+
+// RetryTask 将已结束的任务放回队列，只重新执行 retryModules 对应的模块
+// moduleNames 中的模块状态重置为 pending，其他模块保持原状态；usageRecordIDs 为本次预扣费的使用记录
+func (tm *TaskManager) RetryTask(taskID string, retryModules []string, moduleNames []string, usageRecordIDs map[string]string) error {
+	task, err := database.GetTask(taskID)
+	if err != nil {
+		return err
 	}
 
-	// 保存现有的 link_health 和其他字段，避免被覆盖
-	existingLinkHealth := task.Results.LinkHealth
-	existingWebsiteInfo := task.Results.WebsiteInfo
-	existingDomainInfo := task.Results.DomainInfo
-	existingSSLInfo := task.Results.SSLInfo
-	existingTechStack := task.Results.TechStack
-	existingPerformance := task.Results.Performance
-	existingSEOCompliance := task.Results.SEOCompliance
-	existingSecurityRisk := task.Results.SecurityRisk
-	existingAccessibility := task.Results.Accessibility
-	existingAIAnalysis := task.Results.AIAnalysis
-	existingSummary := task.Results.Summary
-
-	// 追加katana结果
-	if task.Results.KatanaResults == nil {
-		task.Results.KatanaResults = []interface{}{}
+	if task.Modules == nil {
+		task.Modules = make(map[string]*models.ModuleStatus)
+	}
+	for _, name := range moduleNames {
+		task.Modules[name] = &models.ModuleStatus{
+			Name:     name,
+			Status:   models.TaskStatusPending,
+			Progress: models.TaskProgress{Current: 0, Total: 0},
+		}
 	}
 
-	if katanaResults, ok := task.Results.KatanaResults.([]interface{}); ok {
-		task.Results.KatanaResults = append(katanaResults, result)
-	} else {
-		// 如果不是数组，转换为数组
-		task.Results.KatanaResults = []interface{}{task.Results.KatanaResults, result}
+	reset, err := database.ResetTaskForRetry(taskID, retryModules, usageRecordIDs, task.Modules)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrTaskNotRetryable
 	}
 
-	// 恢复其他字段，确保不会被覆盖
-	task.Results.LinkHealth = existingLinkHealth
-	task.Results.WebsiteInfo = existingWebsiteInfo
-	task.Results.DomainInfo = existingDomainInfo
-	task.Results.SSLInfo = existingSSLInfo
-	task.Results.TechStack = existingTechStack
-	task.Results.Performance = existingPerformance
-	task.Results.SEOCompliance = existingSEOCompliance
-	task.Results.SecurityRisk = existingSecurityRisk
-	task.Results.Accessibility = existingAccessibility
-	task.Results.AIAnalysis = existingAIAnalysis
-	task.Results.Summary = existingSummary
-
-	// 更新到数据库（注意：频繁更新可能影响性能，可以考虑批量更新）
-	// 注意：这里不更新 status，因为任务可能还在运行中
-	return database.UpdateTaskResultsWithoutStatus(taskID, task.Results)
+	log.Printf("[TaskManager] Task %s queued for retry: %v", taskID, retryModules)
+	return nil
 }
 
 // SetTaskError 设置任务错误
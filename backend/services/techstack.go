@@ -1,12 +1,14 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -16,26 +18,21 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
-// CollectTechStack 收集网站技术栈信息（整合服务器信息）
-func CollectTechStack(targetURL string) (*models.TechStack, error) {
+// CollectTechStack 收集网站技术栈信息（整合服务器信息和安全响应头分析，auth 为 nil 时匿名请求）
+// lang 为安全响应头分析的说明和修复建议使用的语言；ctx 取消时中止页面请求和 httpx 检测
+func CollectTechStack(ctx context.Context, targetURL string, lang string, auth *ScanAuthSession) (*models.TechStack, error) {
 	log.Printf("[TechStack] Collecting tech stack info from: %s", targetURL)
 
-	// 使用共享的HTTP客户端
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
+	// 使用共享的安全 Transport（连接时拒绝内网地址，防止 DNS 重绑定）；认证扫描时附加认证信息
+	client := auth.HTTPClient(15 * time.Second)
 
-	req, err := http.NewRequest("GET", targetURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	auth.Apply(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -57,19 +54,40 @@ func CollectTechStack(targetURL string) (*models.TechStack, error) {
 		MetaTags:        make(map[string]string),
 	}
 
-	// 从HTTP响应头检测服务器信息和技术栈
+	// 从HTTP响应头收集服务器信息和安全响应头
 	detectFromHeaders(resp, stack)
+	stack.HeaderAnalysis = AnalyzeSecurityHeaders(resp.Header, resp.Request.URL, lang)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, techPageMaxBytes))
+	if err != nil {
+		log.Printf("[TechStack] Error reading page body: %v", err)
+	}
 
-	// 从HTML内容检测
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	// 从HTML内容收集元标签
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err == nil {
 		detectFromHTML(doc, stack)
 	}
 
-	// 使用httpx检测技术栈
-	httpxTechs, err := detectTechFromHttpx(targetURL)
+	// 签名库指纹识别（响应头、Cookie、meta、脚本地址、HTML）
+	db := techSignatures()
+	stack.Detections = db.detect(&techPage{
+		URL:     resp.Request.URL.String(),
+		Headers: resp.Header,
+		Cookies: resp.Cookies(),
+		HTML:    string(body),
+		Doc:     doc,
+	})
+	applyTechDetections(stack, db)
+
+	// 使用httpx检测技术栈（补充签名库没有识别到的技术）
+	httpxTechs, err := detectTechFromHttpx(ctx, targetURL, auth)
 	if err == nil && len(httpxTechs) > 0 {
-		stack.Technologies = httpxTechs
+		for _, tech := range httpxTechs {
+			if !contains(stack.Technologies, tech) {
+				stack.Technologies = append(stack.Technologies, tech)
+			}
+		}
 		log.Printf("[TechStack] Detected technologies from httpx: %v", httpxTechs)
 	}
 
@@ -79,16 +97,19 @@ func CollectTechStack(targetURL string) (*models.TechStack, error) {
 	return stack, nil
 }
 
-// detectTechFromHttpx 使用httpx检测技术栈
-func detectTechFromHttpx(targetURL string) ([]string, error) {
+// detectTechFromHttpx 使用httpx检测技术栈（ctx 取消时终止 httpx）
+func detectTechFromHttpx(ctx context.Context, targetURL string, auth *ScanAuthSession) ([]string, error) {
 	log.Printf("[TechStack] Running httpx tech detection for: %s", targetURL)
 
-	cmd := exec.Command(
-		"httpx",
+	args := []string{
 		"-json",
 		"-tech-detect",
 		"-u", targetURL,
-	)
+	}
+	// 只请求目标页面本身，可以直接附加认证请求头
+	args = append(args, auth.HeaderArgs("-H")...)
+	cmd := exec.CommandContext(ctx, "httpx", args...)
+	configureCommandCancel(cmd)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -121,17 +142,8 @@ func detectFromHeaders(resp *http.Response, stack *models.TechStack) {
 	// 	stack.Server = server
 	// }
 
-	// X-Powered-By
+	// X-Powered-By（对应的语言和框架由签名库识别）
 	stack.PoweredBy = resp.Header.Get("X-Powered-By")
-	if stack.PoweredBy != "" {
-		if strings.Contains(strings.ToLower(stack.PoweredBy), "php") {
-			stack.Language = append(stack.Language, "PHP")
-		}
-		if strings.Contains(strings.ToLower(stack.PoweredBy), "asp.net") {
-			stack.Framework = append(stack.Framework, "ASP.NET")
-			stack.Language = append(stack.Language, "C#")
-		}
-	}
 
 	// Content-Type
 	stack.ContentType = resp.Header.Get("Content-Type")
@@ -157,9 +169,13 @@ func detectFromHeaders(resp *http.Response, stack *models.TechStack) {
 		"X-XSS-Protection",
 		"Strict-Transport-Security",
 		"Content-Security-Policy",
+		"Content-Security-Policy-Report-Only",
 		"Referrer-Policy",
 		"Permissions-Policy",
 		"X-Permitted-Cross-Domain-Policies",
+		"Cross-Origin-Opener-Policy",
+		"Cross-Origin-Embedder-Policy",
+		"Cross-Origin-Resource-Policy",
 	}
 
 	for _, header := range securityHeaders {
@@ -169,27 +185,6 @@ func detectFromHeaders(resp *http.Response, stack *models.TechStack) {
 		}
 	}
 
-	// CDN检测
-	cdnHeaders := []string{"CF-Ray", "X-Served-By", "X-Cache", "Server"}
-	for _, header := range cdnHeaders {
-		value := resp.Header.Get(header)
-		if value != "" {
-			if strings.Contains(strings.ToLower(value), "cloudflare") {
-				if !contains(stack.CDN, "Cloudflare") {
-					stack.CDN = append(stack.CDN, "Cloudflare")
-				}
-			} else if strings.Contains(strings.ToLower(value), "cloudfront") {
-				if !contains(stack.CDN, "CloudFront") {
-					stack.CDN = append(stack.CDN, "CloudFront")
-				}
-			} else if strings.Contains(strings.ToLower(value), "fastly") {
-				if !contains(stack.CDN, "Fastly") {
-					stack.CDN = append(stack.CDN, "Fastly")
-				}
-			}
-		}
-	}
-
 	// 缓存技术
 	cacheControl := resp.Header.Get("Cache-Control")
 	if cacheControl != "" {
@@ -202,10 +197,8 @@ func detectFromHeaders(resp *http.Response, stack *models.TechStack) {
 	}
 }
 
+// detectFromHTML 收集技术相关的元标签（技术识别由签名库完成）
 func detectFromHTML(doc *goquery.Document, stack *models.TechStack) {
-	html, _ := doc.Html()
-	htmlLower := strings.ToLower(html)
-
 	// Meta标签 - 只收集技术相关的标签，排除已在网站信息中显示的标签
 	// 排除的标签：title, description, keywords, author, generator, viewport, robots, charset
 	excludedMetaTags := map[string]bool{
@@ -233,125 +226,6 @@ func detectFromHTML(doc *goquery.Document, stack *models.TechStack) {
 			stack.MetaTags[property] = content
 		}
 	})
-
-	// Generator检测（CMS/框架）
-	doc.Find("meta[name='generator']").Each(func(i int, s *goquery.Selection) {
-		content := strings.ToLower(s.AttrOr("content", ""))
-		if strings.Contains(content, "wordpress") {
-			if !contains(stack.CMS, "WordPress") {
-				stack.CMS = append(stack.CMS, "WordPress")
-			}
-		} else if strings.Contains(content, "drupal") {
-			if !contains(stack.CMS, "Drupal") {
-				stack.CMS = append(stack.CMS, "Drupal")
-			}
-		} else if strings.Contains(content, "joomla") {
-			if !contains(stack.CMS, "Joomla") {
-				stack.CMS = append(stack.CMS, "Joomla")
-			}
-		}
-	})
-
-	// JavaScript库检测
-	doc.Find("script").Each(func(i int, s *goquery.Selection) {
-		src := s.AttrOr("src", "")
-		content, _ := s.Html()
-
-		// jQuery
-		if strings.Contains(src, "jquery") || strings.Contains(content, "jQuery") {
-			if !contains(stack.JavaScriptLib, "jQuery") {
-				stack.JavaScriptLib = append(stack.JavaScriptLib, "jQuery")
-			}
-		}
-
-		// React
-		if strings.Contains(src, "react") || strings.Contains(content, "React") {
-			if !contains(stack.JavaScriptLib, "React") {
-				stack.JavaScriptLib = append(stack.JavaScriptLib, "React")
-			}
-		}
-
-		// Vue
-		if strings.Contains(src, "vue") || strings.Contains(content, "Vue") {
-			if !contains(stack.JavaScriptLib, "Vue.js") {
-				stack.JavaScriptLib = append(stack.JavaScriptLib, "Vue.js")
-			}
-		}
-
-		// Angular
-		if strings.Contains(src, "angular") || strings.Contains(content, "ng-") {
-			if !contains(stack.JavaScriptLib, "Angular") {
-				stack.JavaScriptLib = append(stack.JavaScriptLib, "Angular")
-			}
-		}
-	})
-
-	// 分析工具检测
-	analyticsPatterns := map[string]string{
-		"google-analytics": "Google Analytics",
-		"gtag":             "Google Tag Manager",
-		"ga(":              "Google Analytics",
-		"analytics.js":     "Google Analytics",
-		"baidu":            "Baidu Analytics",
-		"cnzz":             "CNZZ",
-	}
-
-	for pattern, name := range analyticsPatterns {
-		if strings.Contains(htmlLower, pattern) {
-			if !contains(stack.Analytics, name) {
-				stack.Analytics = append(stack.Analytics, name)
-			}
-		}
-	}
-
-	// 框架检测
-	frameworkPatterns := map[*regexp.Regexp]string{
-		regexp.MustCompile(`(?i)laravel`):  "Laravel",
-		regexp.MustCompile(`(?i)rails`):    "Ruby on Rails",
-		regexp.MustCompile(`(?i)django`):   "Django",
-		regexp.MustCompile(`(?i)flask`):    "Flask",
-		regexp.MustCompile(`(?i)express`):  "Express.js",
-		regexp.MustCompile(`(?i)next\.js`): "Next.js",
-		regexp.MustCompile(`(?i)nuxt`):     "Nuxt.js",
-		regexp.MustCompile(`(?i)vue`):      "Vue.js",
-		regexp.MustCompile(`(?i)react`):    "React",
-		regexp.MustCompile(`(?i)angular`):  "Angular",
-	}
-
-	for pattern, name := range frameworkPatterns {
-		if pattern.MatchString(htmlLower) {
-			if !contains(stack.Framework, name) {
-				stack.Framework = append(stack.Framework, name)
-			}
-		}
-	}
-
-	// 编程语言检测
-	if strings.Contains(htmlLower, ".php") || strings.Contains(htmlLower, "php") {
-		if !contains(stack.Language, "PHP") {
-			stack.Language = append(stack.Language, "PHP")
-		}
-	}
-	if strings.Contains(htmlLower, "asp.net") || strings.Contains(htmlLower, "aspx") {
-		if !contains(stack.Language, "C#") {
-			stack.Language = append(stack.Language, "C#")
-		}
-	}
-	if strings.Contains(htmlLower, ".jsp") {
-		if !contains(stack.Language, "Java") {
-			stack.Language = append(stack.Language, "Java")
-		}
-	}
-	if strings.Contains(htmlLower, ".py") || strings.Contains(htmlLower, "python") {
-		if !contains(stack.Language, "Python") {
-			stack.Language = append(stack.Language, "Python")
-		}
-	}
-	if strings.Contains(htmlLower, "ruby") || strings.Contains(htmlLower, ".rb") {
-		if !contains(stack.Language, "Ruby") {
-			stack.Language = append(stack.Language, "Ruby")
-		}
-	}
 }
 
 func contains(slice []string, item string) bool {
@@ -53,6 +53,7 @@ func RunTestSSL(ctx context.Context, targetURL string) (*TestSSLResult, error) {
 		"--fast",
 		fmt.Sprintf("%s:%d", host, port),
 	)
+	configureCommandCancel(cmd)
 
 	stdout, err := cmd.StderrPipe() // testssl输出到stderr
 	if err != nil {
@@ -284,12 +285,16 @@ func appendIfNotExists(slice []string, item string) []string {
 	return append(slice, item)
 }
 
-// CollectTestSSLInfo 收集testssl检测结果（用于插件）
-func CollectTestSSLInfo(targetURL string) (*TestSSLResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second) // testssl可能需要更长时间
+// CollectTestSSLInfo 收集HTTPS配置检测结果（用于插件）
+// 根据 TLS_SCANNER_BACKEND 选择进程内 TLS 扫描器（默认）或 testssl.sh，两者结果格式相同
+func CollectTestSSLInfo(ctx context.Context, targetURL string, lang string) (*TestSSLResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second) // testssl可能需要更长时间
 	defer cancel()
 
-	return RunTestSSL(ctx, targetURL)
+	if TLSScannerBackend() == TLSScannerTestSSL {
+		return RunTestSSL(ctx, targetURL)
+	}
+	return RunNativeTLSScan(ctx, targetURL, lang)
 }
 
 // ConvertTestSSLToSSLInfo 将TestSSL结果转换为SSLInfo格式（用于兼容现有系统）
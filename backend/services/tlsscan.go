@@ -0,0 +1,899 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"web-checkly/utils"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// TLS 配置扫描后端（环境变量 TLS_SCANNER_BACKEND）
+const (
+	TLSScannerNative  = "native"  // 进程内 crypto/tls 握手探测（默认）
+	TLSScannerTestSSL = "testssl" // testssl.sh 命令行工具
+)
+
+const (
+	// tlsScanHandshakeTimeout 单次握手（含建立 TCP 连接）的超时时间
+	tlsScanHandshakeTimeout = 10 * time.Second
+	// tlsScanUserAgent 读取 HSTS 响应头时使用的 User-Agent
+	tlsScanUserAgent = "Mozilla/5.0 (compatible; WebCheckly-TLSScanner/1.0)"
+	// versionSSL30 SSL 3.0（crypto/tls 不支持，只通过原始 ClientHello 探测）
+	versionSSL30 = 0x0300
+	// tlsMaxRecordLength 读取 ServerHello 时允许的最大记录长度
+	tlsMaxRecordLength = 16384 + 2048
+)
+
+// TLS 扩展类型
+const (
+	extServerName          = 0
+	extSupportedGroups     = 10
+	extECPointFormats      = 11
+	extSignatureAlgorithms = 13
+	extSupportedVersions   = 43
+	extKeyShare            = 51
+	extRenegotiationInfo   = 0xff01
+)
+
+// tlsScanVersions 探测的协议版本（从高到低）
+var tlsScanVersions = []struct {
+	Version uint16
+	Name    string
+}{
+	{tls.VersionTLS13, "TLS 1.3"},
+	{tls.VersionTLS12, "TLS 1.2"},
+	{tls.VersionTLS11, "TLS 1.1"},
+	{tls.VersionTLS10, "TLS 1.0"},
+	{versionSSL30, "SSL 3.0"},
+}
+
+// tlsScanGroups 探测的密钥交换组
+var tlsScanGroups = []struct {
+	ID   tls.CurveID
+	Name string
+}{
+	{tls.X25519MLKEM768, "X25519MLKEM768"},
+	{tls.X25519, "X25519"},
+	{tls.CurveP256, "P-256"},
+	{tls.CurveP384, "P-384"},
+	{tls.CurveP521, "P-521"},
+}
+
+// tlsScanCipherSuites 通过原始 ClientHello 枚举的 TLS 1.0-1.2 加密套件（IANA 名称）
+// 包含 crypto/tls 不实现的 DHE、CAMELLIA、NULL、出口级和匿名套件，用于发现不安全的配置
+var tlsScanCipherSuites = map[uint16]string{
+	0x0001: "TLS_RSA_WITH_NULL_MD5",
+	0x0002: "TLS_RSA_WITH_NULL_SHA",
+	0x0003: "TLS_RSA_EXPORT_WITH_RC4_40_MD5",
+	0x0004: "TLS_RSA_WITH_RC4_128_MD5",
+	0x0005: "TLS_RSA_WITH_RC4_128_SHA",
+	0x0008: "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA",
+	0x0009: "TLS_RSA_WITH_DES_CBC_SHA",
+	0x000A: "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	0x0014: "TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA",
+	0x0016: "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA",
+	0x0018: "TLS_DH_anon_WITH_RC4_128_MD5",
+	0x002F: "TLS_RSA_WITH_AES_128_CBC_SHA",
+	0x0033: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA",
+	0x0034: "TLS_DH_anon_WITH_AES_128_CBC_SHA",
+	0x0035: "TLS_RSA_WITH_AES_256_CBC_SHA",
+	0x0039: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA",
+	0x003A: "TLS_DH_anon_WITH_AES_256_CBC_SHA",
+	0x003B: "TLS_RSA_WITH_NULL_SHA256",
+	0x003C: "TLS_RSA_WITH_AES_128_CBC_SHA256",
+	0x003D: "TLS_RSA_WITH_AES_256_CBC_SHA256",
+	0x0041: "TLS_RSA_WITH_CAMELLIA_128_CBC_SHA",
+	0x0045: "TLS_DHE_RSA_WITH_CAMELLIA_128_CBC_SHA",
+	0x0067: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256",
+	0x006B: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256",
+	0x0084: "TLS_RSA_WITH_CAMELLIA_256_CBC_SHA",
+	0x0088: "TLS_DHE_RSA_WITH_CAMELLIA_256_CBC_SHA",
+	0x009C: "TLS_RSA_WITH_AES_128_GCM_SHA256",
+	0x009D: "TLS_RSA_WITH_AES_256_GCM_SHA384",
+	0x009E: "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256",
+	0x009F: "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384",
+	0xC007: "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+	0xC008: "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA",
+	0xC009: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+	0xC00A: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+	0xC011: "TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+	0xC012: "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+	0xC013: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+	0xC014: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+	0xC016: "TLS_ECDH_anon_WITH_RC4_128_SHA",
+	0xC018: "TLS_ECDH_anon_WITH_AES_128_CBC_SHA",
+	0xC019: "TLS_ECDH_anon_WITH_AES_256_CBC_SHA",
+	0xC023: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+	0xC024: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384",
+	0xC027: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+	0xC028: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384",
+	0xC02B: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	0xC02C: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	0xC02F: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	0xC030: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	0xCCA8: "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+	0xCCA9: "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+	0xCCAA: "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+}
+
+// tls13CipherSuites TLS 1.3 加密套件（crypto/tls 不允许配置，同样通过原始 ClientHello 枚举）
+var tls13CipherSuites = map[uint16]string{
+	0x1301: "TLS_AES_128_GCM_SHA256",
+	0x1302: "TLS_AES_256_GCM_SHA384",
+	0x1303: "TLS_CHACHA20_POLY1305_SHA256",
+}
+
+// tlsScanMessages 漏洞和建议的说明文案（zh/en）
+var tlsScanMessages = map[string]map[string]string{
+	"zh": {
+		"vuln_ssl3":              "POODLE：服务器支持 SSL 3.0",
+		"vuln_null":              "服务器支持不加密的 NULL 加密套件",
+		"vuln_export":            "FREAK/Logjam：服务器支持出口级加密套件",
+		"vuln_anon":              "服务器支持不验证身份的匿名加密套件",
+		"vuln_rc4":               "服务器支持 RC4 加密套件",
+		"vuln_sweet32":           "SWEET32：服务器支持 64 位分组的 3DES/DES 加密套件",
+		"vuln_renegotiation":     "服务器不支持安全重协商（RFC 5746）",
+		"rec_disable_ssl3":       "禁用 SSL 3.0",
+		"rec_disable_legacy_tls": "禁用 TLS 1.0 和 TLS 1.1，只保留 TLS 1.2 及以上版本",
+		"rec_enable_tls12":       "启用 TLS 1.2，当前服务器只支持已废弃的协议版本",
+		"rec_enable_tls13":       "启用 TLS 1.3",
+		"rec_disable_weak":       "移除不安全的加密套件：%s",
+		"rec_forward_secrecy":    "启用 ECDHE 加密套件以提供前向保密",
+		"rec_renegotiation":      "升级 TLS 库以支持安全重协商（RFC 5746）",
+		"rec_enable_hsts":        "添加 Strict-Transport-Security 响应头（max-age 至少 15552000 秒）",
+		"rec_ocsp_stapling":      "启用 OCSP Stapling，减少客户端查询吊销状态的延迟",
+		"rec_enable_h2":          "通过 ALPN 启用 HTTP/2",
+		"rec_session_resumption": "启用会话恢复（Session Ticket 或 Session ID），减少重复握手的开销",
+	},
+	"en": {
+		"vuln_ssl3":              "POODLE: SSL 3.0 is offered",
+		"vuln_null":              "NULL cipher suites (no encryption) are offered",
+		"vuln_export":            "FREAK/Logjam: export-grade cipher suites are offered",
+		"vuln_anon":              "Anonymous (unauthenticated) cipher suites are offered",
+		"vuln_rc4":               "RC4 cipher suites are offered",
+		"vuln_sweet32":           "SWEET32: 64-bit block 3DES/DES cipher suites are offered",
+		"vuln_renegotiation":     "Secure renegotiation (RFC 5746) is not supported",
+		"rec_disable_ssl3":       "Disable SSL 3.0",
+		"rec_disable_legacy_tls": "Disable TLS 1.0 and TLS 1.1 and keep only TLS 1.2 and later",
+		"rec_enable_tls12":       "Enable TLS 1.2; the server only offers deprecated protocol versions",
+		"rec_enable_tls13":       "Enable TLS 1.3",
+		"rec_disable_weak":       "Remove insecure cipher suites: %s",
+		"rec_forward_secrecy":    "Enable ECDHE cipher suites to provide forward secrecy",
+		"rec_renegotiation":      "Upgrade the TLS library to support secure renegotiation (RFC 5746)",
+		"rec_enable_hsts":        "Add a Strict-Transport-Security header (max-age of at least 15552000 seconds)",
+		"rec_ocsp_stapling":      "Enable OCSP stapling to spare clients a revocation lookup",
+		"rec_enable_h2":          "Enable HTTP/2 via ALPN",
+		"rec_session_resumption": "Enable session resumption (session tickets or session IDs) to avoid full handshakes",
+	},
+}
+
+// errHelloRejected 服务器以告警或断开连接拒绝了 ClientHello
+var errHelloRejected = errors.New("client hello rejected")
+
+// TLSScannerBackend 当前使用的 TLS 配置扫描后端
+func TLSScannerBackend() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("TLS_SCANNER_BACKEND")), TLSScannerTestSSL) {
+		return TLSScannerTestSSL
+	}
+	return TLSScannerNative
+}
+
+// TLSScanner 进程内 TLS 配置扫描器
+// 协议版本、密钥交换组、ALPN、会话恢复和证书通过 crypto/tls 握手探测；
+// crypto/tls 无法配置的部分（SSL 3.0、TLS 1.3 加密套件、crypto/tls 不实现的加密套件、安全重协商扩展）
+// 发送原始 ClientHello 并只解析 ServerHello
+type TLSScanner struct {
+	// Dial 建立 TCP 连接（默认使用拒绝内网地址的安全 Dialer）
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Timeout 单次握手的超时时间
+	Timeout time.Duration
+	// Lang 漏洞和建议的语言（zh/en）
+	Lang string
+}
+
+// NewTLSScanner 创建使用安全 Dialer 的 TLS 配置扫描器
+func NewTLSScanner(lang string) *TLSScanner {
+	return &TLSScanner{
+		Dial:    utils.NewSafeDialer(tlsScanHandshakeTimeout).DialContext,
+		Timeout: tlsScanHandshakeTimeout,
+		Lang:    lang,
+	}
+}
+
+// RunNativeTLSScan 使用进程内 TLS 握手检测目标的 HTTPS 配置，结果与 testssl.sh 后端格式相同
+func RunNativeTLSScan(ctx context.Context, targetURL string, lang string) (*TestSSLResult, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+	host := parsedURL.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("empty hostname")
+	}
+
+	// 与 ssl-info 相同：URL 没有指定端口时检测 443
+	port := 443
+	if parsedURL.Port() != "" {
+		if port, err = strconv.Atoi(parsedURL.Port()); err != nil {
+			return nil, fmt.Errorf("invalid port: %w", err)
+		}
+	}
+
+	return NewTLSScanner(lang).Scan(ctx, host, port)
+}
+
+// Scan 探测 host:port 的 TLS 配置
+func (s *TLSScanner) Scan(ctx context.Context, host string, port int) (*TestSSLResult, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	serverName := host
+	if net.ParseIP(host) != nil {
+		serverName = "" // IP 地址不发送 SNI
+	}
+
+	log.Printf("[TLSScan] Scanning TLS configuration of %s", addr)
+	start := time.Now()
+
+	result := &TestSSLResult{
+		Host:             host,
+		Port:             port,
+		Protocols:        []string{},
+		Ciphers:          []string{},
+		Vulnerabilities:  []string{},
+		Recommendations:  []string{},
+		ProtocolDetails:  make(map[string]string),
+		CipherDetails:    make(map[string]string),
+		CertificateChain: []string{},
+	}
+
+	// 基准握手：证书和 OCSP 装订（服务器只支持 crypto/tls 不实现的套件时跳过）
+	state, err := s.handshake(ctx, addr, s.config(host))
+	if err != nil {
+		log.Printf("[TLSScan] Handshake with %s failed: %v", addr, err)
+	} else if len(state.PeerCertificates) > 0 {
+		result.CertificateInfo = sslInfoFromState(ctx, host, s.Lang, state)
+		for _, cert := range state.PeerCertificates {
+			result.CertificateChain = append(result.CertificateChain, cert.Subject.String())
+		}
+	}
+	result.OCSP = len(state.OCSPResponse) > 0
+
+	// 协议版本（TLS 1.0-1.3 使用 crypto/tls）和每个版本的加密套件（原始 ClientHello），按版本并发
+	offered := make(map[uint16]bool)
+	suites := make(map[uint16][]uint16)
+	renegotiation := make(map[uint16]bool) // 只记录原始 ClientHello 成功的版本
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, v := range tlsScanVersions {
+		wg.Add(1)
+		go func(version uint16) {
+			defer wg.Done()
+			ok, negotiated := s.probeVersion(ctx, addr, host, version)
+			found, secureRenegotiation := s.enumerateCipherSuites(ctx, addr, serverName, version)
+			mu.Lock()
+			defer mu.Unlock()
+			if len(found) > 0 {
+				renegotiation[version] = secureRenegotiation
+			} else if ok {
+				// 原始 ClientHello 被拒绝（如服务器要求特定扩展）时至少记录协商到的套件
+				found = []uint16{negotiated}
+			}
+			offered[version] = ok || len(found) > 0
+			suites[version] = found
+		}(v.Version)
+	}
+	wg.Wait()
+
+	for _, v := range tlsScanVersions {
+		if offered[v.Version] {
+			result.Protocols = append(result.Protocols, v.Name)
+			result.ProtocolDetails[v.Name] = "offered"
+		} else {
+			result.ProtocolDetails[v.Name] = "not offered"
+		}
+	}
+	if len(result.Protocols) == 0 {
+		return nil, fmt.Errorf("no supported TLS protocol version found")
+	}
+
+	// 加密套件：CipherDetails 记录支持该套件的协议版本和强度
+	cipherVersions := make(map[string][]string)
+	for _, v := range tlsScanVersions {
+		for _, id := range suites[v.Version] {
+			name := cipherSuiteName(id)
+			if _, seen := cipherVersions[name]; !seen {
+				result.Ciphers = append(result.Ciphers, name)
+			}
+			cipherVersions[name] = append(cipherVersions[name], v.Name)
+		}
+	}
+	for _, name := range result.Ciphers {
+		result.CipherDetails[name] = fmt.Sprintf("%s (%s)", strings.Join(cipherVersions[name], ", "), cipherSuiteStrength(name))
+	}
+
+	// 安全重协商只存在于 TLS 1.2 及以下版本，取支持的最高版本的 ServerHello
+	secureRenegotiation, renegotiationKnown := true, false
+	legacyOffered := false
+	for _, v := range tlsScanVersions {
+		if v.Version == tls.VersionTLS13 || !offered[v.Version] {
+			continue
+		}
+		legacyOffered = true
+		if supported, ok := renegotiation[v.Version]; ok {
+			secureRenegotiation, renegotiationKnown = supported, true
+		}
+		break
+	}
+	switch {
+	case !legacyOffered:
+		result.ProtocolDetails["secure_renegotiation"] = "not applicable (TLS 1.3 only)"
+	case !renegotiationKnown:
+		result.ProtocolDetails["secure_renegotiation"] = "unknown"
+	case secureRenegotiation:
+		result.ProtocolDetails["secure_renegotiation"] = "supported"
+	default:
+		result.ProtocolDetails["secure_renegotiation"] = "not supported"
+	}
+
+	// 密钥交换组
+	var groups []string
+	for _, group := range tlsScanGroups {
+		config := s.config(host)
+		config.CurvePreferences = []tls.CurveID{group.ID}
+		config.CipherSuites = ecdheCipherSuiteIDs() // 只提供 ECDHE 套件，避免以 RSA 密钥交换完成握手
+		if _, err := s.handshake(ctx, addr, config); err == nil {
+			groups = append(groups, group.Name)
+		}
+	}
+	result.ProtocolDetails["key_exchange_groups"] = strings.Join(groups, ", ")
+
+	// ALPN
+	var alpn []string
+	for _, proto := range []string{"h2", "http/1.1"} {
+		config := s.config(host)
+		config.NextProtos = []string{proto}
+		if state, err := s.handshake(ctx, addr, config); err == nil && state.NegotiatedProtocol == proto {
+			alpn = append(alpn, proto)
+		}
+	}
+	result.ProtocolDetails["alpn"] = strings.Join(alpn, ", ")
+
+	// 会话恢复和 HSTS：第一次连接发送 HEAD 请求（读取响应时接收 TLS 1.3 会话票据），第二次连接尝试恢复
+	config := s.config(host)
+	config.NextProtos = []string{"http/1.1"}
+	config.ClientSessionCache = tls.NewLRUClientSessionCache(1)
+	hostHeader := host
+	if port != 443 {
+		hostHeader = addr
+	} else if strings.Contains(host, ":") {
+		hostHeader = "[" + host + "]"
+	}
+	header, err := s.fetchHeaders(ctx, addr, config, hostHeader)
+	if err != nil {
+		log.Printf("[TLSScan] Failed to read HTTP headers from %s: %v", addr, err)
+	} else {
+		result.HSTS = header.Get("Strict-Transport-Security") != ""
+		result.HPKP = header.Get("Public-Key-Pins") != ""
+	}
+	resumed := false
+	if state, err := s.handshake(ctx, addr, config); err == nil {
+		resumed = state.DidResume
+	}
+	if resumed {
+		result.ProtocolDetails["session_resumption"] = "supported"
+	} else {
+		result.ProtocolDetails["session_resumption"] = "not supported"
+	}
+
+	s.assess(result, offered, secureRenegotiation, slices.Contains(alpn, "h2"), resumed)
+
+	log.Printf("[TLSScan] Scan of %s completed in %v. Protocols: %v, Ciphers: %d, Grade: %s, Vulnerabilities: %d",
+		addr, time.Since(start).Round(time.Millisecond), result.Protocols, len(result.Ciphers), result.Grade, len(result.Vulnerabilities))
+
+	return result, nil
+}
+
+// assess 根据探测结果记录漏洞和建议，并评级
+// 评级参考 SSL Labs 的上限规则，取各项中最低的一级：
+// F：不支持 TLS 1.2 及以上版本，或支持 SSL 3.0、NULL、出口级、匿名加密套件
+// C：支持 RC4 加密套件，或不支持安全重协商
+// B：支持 TLS 1.0/1.1、3DES/DES 加密套件，或没有前向保密的加密套件
+// 其余为 A；同时支持 TLS 1.3 并启用 HSTS 时为 A+
+func (s *TLSScanner) assess(result *TestSSLResult, offered map[uint16]bool, secureRenegotiation, h2, resumed bool) {
+	grade := "A"
+	capGrade := func(g string) {
+		// 评级字母越大越差
+		if g > grade {
+			grade = g
+		}
+	}
+	vulnerability := func(id string) {
+		result.Vulnerabilities = append(result.Vulnerabilities, findingMessage(tlsScanMessages, s.Lang, id))
+	}
+	recommend := func(id string, args ...interface{}) {
+		result.Recommendations = append(result.Recommendations, findingMessage(tlsScanMessages, s.Lang, id, args...))
+	}
+
+	if offered[versionSSL30] {
+		vulnerability("vuln_ssl3")
+		recommend("rec_disable_ssl3")
+		capGrade("F")
+	}
+	if !offered[tls.VersionTLS12] && !offered[tls.VersionTLS13] {
+		recommend("rec_enable_tls12")
+		capGrade("F")
+	}
+	if offered[tls.VersionTLS10] || offered[tls.VersionTLS11] {
+		recommend("rec_disable_legacy_tls")
+		capGrade("B")
+	}
+	if !offered[tls.VersionTLS13] {
+		recommend("rec_enable_tls13")
+	}
+
+	var insecure []string
+	var hasNull, hasExport, hasAnon, hasRC4, has64BitBlock bool
+	forwardSecrecy := offered[tls.VersionTLS13]
+	for _, name := range result.Ciphers {
+		switch {
+		case strings.Contains(name, "_NULL_"):
+			hasNull = true
+		case strings.Contains(name, "EXPORT"):
+			hasExport = true
+		case strings.Contains(name, "_anon_"):
+			hasAnon = true
+		case strings.Contains(name, "RC4"):
+			hasRC4 = true
+		case strings.Contains(name, "DES"):
+			has64BitBlock = true
+		default:
+			if isForwardSecret(name) {
+				forwardSecrecy = true
+			}
+			continue
+		}
+		insecure = append(insecure, name)
+	}
+	if hasNull {
+		vulnerability("vuln_null")
+		capGrade("F")
+	}
+	if hasExport {
+		vulnerability("vuln_export")
+		capGrade("F")
+	}
+	if hasAnon {
+		vulnerability("vuln_anon")
+		capGrade("F")
+	}
+	if hasRC4 {
+		vulnerability("vuln_rc4")
+		capGrade("C")
+	}
+	if has64BitBlock {
+		vulnerability("vuln_sweet32")
+		capGrade("B")
+	}
+	if len(insecure) > 0 {
+		recommend("rec_disable_weak", strings.Join(insecure, ", "))
+	}
+	if !forwardSecrecy {
+		recommend("rec_forward_secrecy")
+		capGrade("B")
+	}
+	if !secureRenegotiation {
+		vulnerability("vuln_renegotiation")
+		recommend("rec_renegotiation")
+		capGrade("C")
+	}
+
+	if !result.HSTS {
+		recommend("rec_enable_hsts")
+	}
+	if !result.OCSP {
+		recommend("rec_ocsp_stapling")
+	}
+	if !h2 {
+		recommend("rec_enable_h2")
+	}
+	if !resumed {
+		recommend("rec_session_resumption")
+	}
+
+	if grade == "A" && offered[tls.VersionTLS13] && result.HSTS {
+		grade = "A+"
+	}
+	result.Grade = grade
+}
+
+// config 探测使用的 crypto/tls 基础配置：不验证证书，允许 TLS 1.0 和 crypto/tls 支持的全部加密套件
+func (s *TLSScanner) config(host string) *tls.Config {
+	var suites []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites = append(suites, suite.ID)
+	}
+	return &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, // 只检测配置，证书由 sslInfoFromState 单独验证
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites:       suites,
+	}
+}
+
+// dialTLS 建立 TLS 连接并完成握手，返回的连接在 Timeout 后超时
+func (s *TLSScanner) dialTLS(ctx context.Context, addr string, config *tls.Config) (*tls.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	rawConn, err := s.Dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	rawConn.SetDeadline(time.Now().Add(s.Timeout))
+
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshake 完成一次握手后关闭连接，返回连接状态
+func (s *TLSScanner) handshake(ctx context.Context, addr string, config *tls.Config) (tls.ConnectionState, error) {
+	conn, err := s.dialTLS(ctx, addr, config)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState(), nil
+}
+
+// probeVersion 只允许单个协议版本握手，返回是否支持及协商到的加密套件
+func (s *TLSScanner) probeVersion(ctx context.Context, addr, host string, version uint16) (bool, uint16) {
+	if version == versionSSL30 {
+		return false, 0
+	}
+	config := s.config(host)
+	config.MinVersion = version
+	config.MaxVersion = version
+	state, err := s.handshake(ctx, addr, config)
+	if err != nil || state.Version != version {
+		return false, 0
+	}
+	return true, state.CipherSuite
+}
+
+// fetchHeaders 通过 TLS 连接发送 HEAD / 请求并返回响应头
+func (s *TLSScanner) fetchHeaders(ctx context.Context, addr string, config *tls.Config, hostHeader string) (http.Header, error) {
+	conn, err := s.dialTLS(ctx, addr, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req, err := http.NewRequest(http.MethodHead, "https://"+hostHeader+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", tlsScanUserAgent)
+	req.Close = true
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+// enumerateCipherSuites 枚举服务器在指定版本下支持的加密套件：
+// 每次提供剩余的全部候选套件，服务器选中的套件记为支持并从候选中移除，直到服务器拒绝握手
+// 同时返回第一次 ServerHello 是否包含 renegotiation_info 扩展（安全重协商）
+func (s *TLSScanner) enumerateCipherSuites(ctx context.Context, addr, serverName string, version uint16) ([]uint16, bool) {
+	var remaining []uint16
+	for id, name := range tlsScanCipherSuites {
+		if version < tls.VersionTLS12 && tls12OnlyCipherSuite(name) {
+			continue
+		}
+		if version <= tls.VersionTLS12 {
+			remaining = append(remaining, id)
+		}
+	}
+	if version == tls.VersionTLS13 {
+		for id := range tls13CipherSuites {
+			remaining = append(remaining, id)
+		}
+	}
+	slices.Sort(remaining)
+
+	var found []uint16
+	secureRenegotiation := false
+	for len(remaining) > 0 {
+		if ctx.Err() != nil {
+			break
+		}
+		hello, err := s.rawHello(ctx, addr, serverName, version, remaining)
+		if err != nil || hello.version != version {
+			break
+		}
+		idx := slices.Index(remaining, hello.cipherSuite)
+		if idx < 0 {
+			break // 服务器选择了未提供的套件，停止枚举
+		}
+		if len(found) == 0 {
+			_, secureRenegotiation = hello.extensions[extRenegotiationInfo]
+		}
+		found = append(found, hello.cipherSuite)
+		remaining = slices.Delete(remaining, idx, idx+1)
+	}
+	return found, secureRenegotiation
+}
+
+// serverHello 原始 ClientHello 探测得到的 ServerHello
+type serverHello struct {
+	version     uint16 // 协商的版本（TLS 1.3 取 supported_versions 扩展）
+	cipherSuite uint16
+	extensions  map[uint16][]byte
+}
+
+// rawHello 发送原始 ClientHello，只读取服务器的 ServerHello（或 HelloRetryRequest），不完成握手
+func (s *TLSScanner) rawHello(ctx context.Context, addr, serverName string, version uint16, suites []uint16) (*serverHello, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	conn, err := s.Dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	if _, err := conn.Write(buildClientHello(serverName, version, suites)); err != nil {
+		return nil, err
+	}
+	return readServerHello(bufio.NewReader(conn))
+}
+
+// buildClientHello 构造 ClientHello 记录
+// TLS 1.3 附带随机的 X25519 key_share，服务器据此即可回复 ServerHello；SSL 3.0 不带扩展
+func buildClientHello(serverName string, version uint16, suites []uint16) []byte {
+	legacyVersion := version
+	if legacyVersion > tls.VersionTLS12 {
+		legacyVersion = tls.VersionTLS12
+	}
+	recordVersion := uint16(tls.VersionTLS10)
+	if version == versionSSL30 {
+		recordVersion = versionSSL30
+	}
+
+	b := cryptobyte.NewBuilder(nil)
+	b.AddUint8(22) // handshake
+	b.AddUint16(recordVersion)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(1) // client_hello
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint16(legacyVersion)
+			b.AddBytes(randomBytes(32))
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				if version == tls.VersionTLS13 {
+					b.AddBytes(randomBytes(32)) // 兼容中间设备的 legacy_session_id
+				}
+			})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				for _, id := range suites {
+					b.AddUint16(id)
+				}
+			})
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddUint8(0) // null 压缩
+			})
+			if version == versionSSL30 {
+				return
+			}
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				addClientHelloExtensions(b, serverName, version)
+			})
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+// addClientHelloExtensions 写入 ClientHello 扩展
+func addClientHelloExtensions(b *cryptobyte.Builder, serverName string, version uint16) {
+	extension := func(typ uint16, body func(b *cryptobyte.Builder)) {
+		b.AddUint16(typ)
+		b.AddUint16LengthPrefixed(body)
+	}
+	uint16List := func(values ...uint16) func(b *cryptobyte.Builder) {
+		return func(b *cryptobyte.Builder) {
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				for _, v := range values {
+					b.AddUint16(v)
+				}
+			})
+		}
+	}
+
+	if serverName != "" {
+		extension(extServerName, func(b *cryptobyte.Builder) {
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddUint8(0) // host_name
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddBytes([]byte(serverName))
+				})
+			})
+		})
+	}
+	extension(extSupportedGroups, uint16List(uint16(tls.X25519), uint16(tls.CurveP256), uint16(tls.CurveP384), uint16(tls.CurveP521)))
+	extension(extECPointFormats, func(b *cryptobyte.Builder) {
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint8(0) // uncompressed
+		})
+	})
+	extension(extSignatureAlgorithms, uint16List(
+		uint16(tls.ECDSAWithP256AndSHA256), uint16(tls.ECDSAWithP384AndSHA384), uint16(tls.ECDSAWithP521AndSHA512),
+		uint16(tls.PSSWithSHA256), uint16(tls.PSSWithSHA384), uint16(tls.PSSWithSHA512), uint16(tls.Ed25519),
+		uint16(tls.PKCS1WithSHA256), uint16(tls.PKCS1WithSHA384), uint16(tls.PKCS1WithSHA512),
+		uint16(tls.PKCS1WithSHA1), uint16(tls.ECDSAWithSHA1),
+	))
+	if version == tls.VersionTLS13 {
+		extension(extSupportedVersions, func(b *cryptobyte.Builder) {
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddUint16(tls.VersionTLS13)
+			})
+		})
+		extension(extKeyShare, func(b *cryptobyte.Builder) {
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddUint16(uint16(tls.X25519))
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddBytes(randomBytes(32))
+				})
+			})
+		})
+		return
+	}
+	extension(extRenegotiationInfo, func(b *cryptobyte.Builder) {
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {})
+	})
+}
+
+// readServerHello 读取服务器的第一条握手消息并解析 ServerHello；告警或断开连接视为拒绝
+func readServerHello(r io.Reader) (*serverHello, error) {
+	var handshake []byte
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, errHelloRejected
+		}
+		length := int(header[3])<<8 | int(header[4])
+		if length > tlsMaxRecordLength {
+			return nil, fmt.Errorf("TLS record too large: %d", length)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, errHelloRejected
+		}
+
+		switch header[0] {
+		case 21: // alert
+			return nil, errHelloRejected
+		case 22: // handshake
+			handshake = append(handshake, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected TLS record type %d", header[0])
+		}
+
+		if len(handshake) < 4 {
+			continue
+		}
+		if handshake[0] != 2 {
+			return nil, fmt.Errorf("unexpected handshake message type %d", handshake[0])
+		}
+		msgLen := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if len(handshake) >= 4+msgLen {
+			return parseServerHello(handshake[4 : 4+msgLen])
+		}
+	}
+}
+
+// parseServerHello 解析 ServerHello 消息体
+func parseServerHello(msg []byte) (*serverHello, error) {
+	s := cryptobyte.String(msg)
+	hello := &serverHello{extensions: make(map[uint16][]byte)}
+	var sessionID cryptobyte.String
+	if !s.ReadUint16(&hello.version) || !s.Skip(32) || !s.ReadUint8LengthPrefixed(&sessionID) ||
+		!s.ReadUint16(&hello.cipherSuite) || !s.Skip(1) {
+		return nil, fmt.Errorf("malformed ServerHello")
+	}
+	if s.Empty() {
+		return hello, nil
+	}
+
+	var extensions cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&extensions) {
+		return nil, fmt.Errorf("malformed ServerHello extensions")
+	}
+	for !extensions.Empty() {
+		var typ uint16
+		var data cryptobyte.String
+		if !extensions.ReadUint16(&typ) || !extensions.ReadUint16LengthPrefixed(&data) {
+			return nil, fmt.Errorf("malformed ServerHello extensions")
+		}
+		hello.extensions[typ] = data
+	}
+	if v, ok := hello.extensions[extSupportedVersions]; ok && len(v) == 2 {
+		hello.version = uint16(v[0])<<8 | uint16(v[1])
+	}
+	return hello, nil
+}
+
+// randomBytes 生成 n 字节随机数
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// cipherSuiteName 加密套件的 IANA 名称
+func cipherSuiteName(id uint16) string {
+	if name, ok := tlsScanCipherSuites[id]; ok {
+		return name
+	}
+	if name, ok := tls13CipherSuites[id]; ok {
+		return name
+	}
+	return tls.CipherSuiteName(id)
+}
+
+// ecdheCipherSuiteIDs crypto/tls 支持的 ECDHE 加密套件
+func ecdheCipherSuiteIDs() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if strings.HasPrefix(suite.Name, "TLS_ECDHE_") {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids
+}
+
+// tls12OnlyCipherSuite 套件是否只能用于 TLS 1.2（AEAD 或 SHA-256/384 MAC）
+func tls12OnlyCipherSuite(name string) bool {
+	return strings.Contains(name, "_GCM_") || strings.Contains(name, "CHACHA20") ||
+		strings.HasSuffix(name, "_SHA256") || strings.HasSuffix(name, "_SHA384")
+}
+
+// isForwardSecret 套件是否提供前向保密（ECDHE/DHE 密钥交换或 TLS 1.3）
+func isForwardSecret(name string) bool {
+	return strings.HasPrefix(name, "TLS_ECDHE_") || strings.HasPrefix(name, "TLS_DHE_") || !strings.Contains(name, "_WITH_")
+}
+
+// cipherSuiteStrength 加密套件强度：insecure（NULL、出口级、匿名、RC4）、weak（DES/3DES、无前向保密或 CBC 模式）、strong
+func cipherSuiteStrength(name string) string {
+	switch {
+	case strings.Contains(name, "_NULL_") || strings.Contains(name, "EXPORT") ||
+		strings.Contains(name, "_anon_") || strings.Contains(name, "RC4"):
+		return "insecure"
+	case strings.Contains(name, "DES") || !isForwardSecret(name) || strings.Contains(name, "_CBC_"):
+		return "weak"
+	default:
+		return "strong"
+	}
+}
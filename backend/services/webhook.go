@@ -0,0 +1,584 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"web-checkly/database"
+	"web-checkly/models"
+	"web-checkly/utils"
+
+	"github.com/google/uuid"
+)
+
+// Webhook 投递参数
+const (
+	webhookPollInterval      = 5 * time.Second  // 检查待投递记录的间隔
+	webhookClaimLease        = 1 * time.Minute  // 领取后的租约，投递进程崩溃时租约到期后重新投递（需大于单次投递的超时时间）
+	webhookClaimBatch        = 20               // 每次最多领取的投递记录数（同一批并发投递）
+	webhookRequestTimeout    = 10 * time.Second // 单次投递的超时时间
+	webhookMaxAttempts       = 6                // 最多投递次数（含首次）
+	webhookBaseBackoff       = 30 * time.Second // 首次重试间隔，之后每次翻倍
+	webhookResponseBodyLimit = 1024             // 投递记录中保存的响应体长度上限
+	maxWebhooksPerUser       = 10               // 每个用户最多注册的 Webhook 数
+)
+
+// Webhook 请求头
+const (
+	WebhookSignatureHeader = "X-WebCheckly-Signature" // 签名：t=<unix 时间戳>,v1=<hex(HMAC-SHA256(secret, "<t>.<body>"))>
+	WebhookEventHeader     = "X-WebCheckly-Event"     // 事件类型
+	WebhookDeliveryHeader  = "X-WebCheckly-Delivery"  // 投递ID（重试时不变，可用于去重）
+)
+
+var (
+	// ErrInvalidWebhook Webhook 参数无效
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrWebhookLimitReached 用户的 Webhook 数量已达上限
+	ErrWebhookLimitReached = fmt.Errorf("webhook limit reached (max %d per user)", maxWebhooksPerUser)
+)
+
+// webhookClient 投递使用的 HTTP 客户端（不跟随重定向，连接时拒绝内网地址，防止 DNS 重绑定）
+var webhookClient = &http.Client{
+	Timeout:   webhookRequestTimeout,
+	Transport: utils.SafeTransport,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webhookPrivateClient 允许投递到内网地址时（WEBHOOK_ALLOW_PRIVATE_URLS）使用的 HTTP 客户端
+var webhookPrivateClient = &http.Client{
+	Timeout: webhookRequestTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webhookWakeup 有新的待投递记录时唤醒本进程的投递循环
+var webhookWakeup = make(chan struct{}, 1)
+
+// CreateWebhookEndpoint 注册 Webhook 接收地址，返回的记录包含签名密钥（之后不再返回）
+func CreateWebhookEndpoint(userID string, req *models.CreateWebhookRequest) (*models.WebhookEndpoint, error) {
+	count, err := database.CountUserWebhookEndpoints(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, ErrWebhookLimitReached
+	}
+
+	target, err := validateWebhookURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		UserID:      userID,
+		URL:         target,
+		Description: strings.TrimSpace(req.Description),
+		Secret:      secret,
+		Events:      events,
+		IsActive:    true,
+	}
+	if len(endpoint.Description) > 255 {
+		return nil, fmt.Errorf("%w: description must be at most 255 characters", ErrInvalidWebhook)
+	}
+	if err := database.CreateWebhookEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Webhook] Created webhook %s for user %s: %s %v", endpoint.ID, userID, endpoint.URL, endpoint.Events)
+	return endpoint, nil
+}
+
+// GetWebhookEndpoint 获取用户的 Webhook 接收地址（不含签名密钥）
+func GetWebhookEndpoint(endpointID, userID string) (*models.WebhookEndpoint, error) {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return nil, database.ErrWebhookNotFound
+	}
+	endpoint, err := database.GetWebhookEndpoint(endpointID, userID)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+// GetUserWebhookEndpoints 获取用户的全部 Webhook 接收地址（不含签名密钥）
+func GetUserWebhookEndpoints(userID string) ([]*models.WebhookEndpoint, error) {
+	endpoints, err := database.GetUserWebhookEndpoints(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoint := range endpoints {
+		endpoint.Secret = ""
+	}
+	return endpoints, nil
+}
+
+// UpdateWebhookEndpoint 更新 Webhook 接收地址（只更新请求中提供的字段）
+func UpdateWebhookEndpoint(endpointID, userID string, req *models.UpdateWebhookRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := GetWebhookEndpoint(endpointID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if endpoint.URL, err = validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		endpoint.Description = strings.TrimSpace(*req.Description)
+		if len(endpoint.Description) > 255 {
+			return nil, fmt.Errorf("%w: description must be at most 255 characters", ErrInvalidWebhook)
+		}
+	}
+	if req.Events != nil {
+		if endpoint.Events, err = normalizeWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := database.UpdateWebhookEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+// DeleteWebhookEndpoint 删除 Webhook 接收地址
+func DeleteWebhookEndpoint(endpointID, userID string) error {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return database.ErrWebhookNotFound
+	}
+	if err := database.DeleteWebhookEndpoint(endpointID, userID); err != nil {
+		return err
+	}
+	log.Printf("[Webhook] Deleted webhook %s", endpointID)
+	return nil
+}
+
+// GetWebhookDeliveries 获取用户 Webhook 的投递记录
+func GetWebhookDeliveries(endpointID, userID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	if _, err := GetWebhookEndpoint(endpointID, userID); err != nil {
+		return nil, err
+	}
+	return database.GetWebhookDeliveries(endpointID, limit, offset)
+}
+
+// SendWebhookPing 向 Webhook 发送一条 ping 测试事件（与普通事件一样签名、记录和重试）
+func SendWebhookPing(endpointID, userID string) (string, error) {
+	endpoint, err := GetWebhookEndpoint(endpointID, userID)
+	if err != nil {
+		return "", err
+	}
+	deliveryID, err := enqueueWebhookDelivery(endpoint.ID, models.WebhookEventPing, models.WebhookEventData{})
+	if err != nil {
+		return "", err
+	}
+	wakeWebhookDispatcher()
+	return deliveryID, nil
+}
+
+// validateWebhookURL 校验接收地址：只允许 http/https，默认禁止内网地址
+// 本地开发调试时可设置 WEBHOOK_ALLOW_PRIVATE_URLS=true 允许投递到内网地址（如本机 HTTP 服务）
+func validateWebhookURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Hostname() == "" {
+		return "", fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if !webhookAllowPrivateURLs() && utils.IsPrivateIP(parsedURL.Hostname()) {
+		return "", fmt.Errorf("%w: private IP not allowed", ErrInvalidWebhook)
+	}
+	return parsedURL.String(), nil
+}
+
+// webhookAllowPrivateURLs 是否允许投递到内网地址（环境变量 WEBHOOK_ALLOW_PRIVATE_URLS）
+func webhookAllowPrivateURLs() bool {
+	allow, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_URLS"))
+	return allow
+}
+
+// normalizeWebhookEvents 校验订阅的事件，为空时订阅全部事件
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return append([]string(nil), models.WebhookEvents...), nil
+	}
+
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !containsString(models.WebhookEvents, event) {
+			return nil, fmt.Errorf("%w: unknown event %q (supported: %s)", ErrInvalidWebhook, event, strings.Join(models.WebhookEvents, ", "))
+		}
+		if !containsString(normalized, event) {
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+// generateWebhookSecret 生成签名密钥
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// SignWebhookPayload 计算 Webhook 签名请求头的值
+// 接收方应使用相同的密钥计算 HMAC-SHA256("<t>.<body>") 并与 v1 比较，同时检查时间戳以防重放
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// EmitWebhookEvent 为订阅了该事件的用户 Webhook 创建投递记录（投递由 StartWebhookDispatcher 异步完成）
+func EmitWebhookEvent(userID, event string, data models.WebhookEventData) {
+	if userID == "" {
+		return // 匿名任务没有 Webhook
+	}
+
+	endpoints, err := database.GetSubscribedWebhookEndpoints(userID, event)
+	if err != nil {
+		log.Printf("[Webhook] Failed to load webhooks for user %s: %v", userID, err)
+		return
+	}
+	for _, endpoint := range endpoints {
+		if _, err := enqueueWebhookDelivery(endpoint.ID, event, data); err != nil {
+			log.Printf("[Webhook] Failed to enqueue %s for webhook %s: %v", event, endpoint.ID, err)
+		}
+	}
+	if len(endpoints) > 0 {
+		wakeWebhookDispatcher()
+	}
+}
+
+// enqueueWebhookDelivery 生成请求体并创建待投递记录
+func enqueueWebhookDelivery(endpointID, event string, data models.WebhookEventData) (string, error) {
+	deliveryID := uuid.New().String()
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        deliveryID,
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	if err := database.CreateWebhookDelivery(deliveryID, endpointID, event, payload); err != nil {
+		return "", err
+	}
+	return deliveryID, nil
+}
+
+// wakeWebhookDispatcher 唤醒本进程的投递循环
+func wakeWebhookDispatcher() {
+	select {
+	case webhookWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// StartWebhookDispatcher 启动 Webhook 投递循环
+// 投递记录持久化在数据库中，API 和 worker 进程都可以投递，通过 ClaimDueWebhookDeliveries 的行锁避免重复投递
+func StartWebhookDispatcher() {
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			dispatchDueWebhooks()
+			select {
+			case <-ticker.C:
+			case <-webhookWakeup:
+			}
+		}
+	}()
+
+	log.Println("[Webhook] Webhook dispatcher started")
+}
+
+// dispatchDueWebhooks 投递所有到期的记录
+func dispatchDueWebhooks() {
+	for {
+		deliveries, err := database.ClaimDueWebhookDeliveries(webhookClaimBatch, webhookClaimLease)
+		if err != nil {
+			log.Printf("[Webhook] Failed to claim deliveries: %v", err)
+			return
+		}
+		// 同一批并发投递：整批在单次投递的超时时间内完成，不会超出租约导致其他进程重复投递
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				deliverWebhook(delivery)
+			}(delivery)
+		}
+		wg.Wait()
+		if len(deliveries) < webhookClaimBatch {
+			return
+		}
+	}
+}
+
+// deliverWebhook 投递一条记录并记录结果，失败时按指数退避安排重试
+func deliverWebhook(delivery *models.WebhookDelivery) {
+	endpoint, err := database.GetWebhookEndpointByID(delivery.EndpointID)
+	if err != nil {
+		log.Printf("[Webhook] Failed to load webhook %s for delivery %s: %v", delivery.EndpointID, delivery.ID, err)
+		return // 租约到期后重试
+	}
+	if !endpoint.IsActive {
+		recordWebhookAttempt(delivery, models.WebhookDeliveryFailed, 0, "webhook disabled", "", nil)
+		return
+	}
+
+	statusCode, responseBody, err := postWebhook(endpoint, delivery)
+	if err == nil && statusCode >= 200 && statusCode < 300 {
+		recordWebhookAttempt(delivery, models.WebhookDeliverySuccess, statusCode, "", responseBody, nil)
+		log.Printf("[Webhook] Delivered %s %s to %s (attempt %d)", delivery.Event, delivery.ID, endpoint.URL, delivery.Attempts)
+		return
+	}
+
+	errorMsg := ""
+	if err != nil {
+		errorMsg = err.Error()
+	} else {
+		errorMsg = fmt.Sprintf("unexpected status code %d", statusCode)
+	}
+
+	if delivery.Attempts >= webhookMaxAttempts {
+		recordWebhookAttempt(delivery, models.WebhookDeliveryFailed, statusCode, errorMsg, responseBody, nil)
+		log.Printf("[Webhook] Delivery %s to %s failed after %d attempts: %s", delivery.ID, endpoint.URL, delivery.Attempts, errorMsg)
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(webhookBaseBackoff * time.Duration(1<<uint(delivery.Attempts-1)))
+	recordWebhookAttempt(delivery, models.WebhookDeliveryPending, statusCode, errorMsg, responseBody, &nextAttemptAt)
+	log.Printf("[Webhook] Delivery %s to %s failed (attempt %d/%d), retrying at %s: %s", delivery.ID, endpoint.URL, delivery.Attempts, webhookMaxAttempts, nextAttemptAt.Format(time.RFC3339), errorMsg)
+}
+
+// postWebhook 发送签名后的请求，返回响应状态码和（截断的）响应体
+func postWebhook(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, string, error) {
+	parsedURL, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid webhook url: %w", err)
+	}
+	// 投递时再次检查，防止域名解析结果变为内网地址
+	if !webhookAllowPrivateURLs() && utils.IsPrivateIP(parsedURL.Hostname()) {
+		return 0, "", fmt.Errorf("webhook url resolves to a private IP")
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "WebCheckly-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(endpoint.Secret, time.Now().Unix(), body))
+
+	client := webhookClient
+	if webhookAllowPrivateURLs() {
+		client = webhookPrivateClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// recordWebhookAttempt 保存投递结果
+func recordWebhookAttempt(delivery *models.WebhookDelivery, status models.WebhookDeliveryStatus, statusCode int, errorMsg, responseBody string, nextAttemptAt *time.Time) {
+	if err := database.RecordWebhookDeliveryAttempt(delivery.ID, status, statusCode, errorMsg, responseBody, nextAttemptAt); err != nil {
+		log.Printf("[Webhook] Failed to record delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// webhookTaskSummary 生成 Webhook 中的任务摘要
+func webhookTaskSummary(task *models.Task) *models.WebhookTaskSummary {
+	summary := &models.WebhookTaskSummary{
+		ID:          task.ID,
+		Status:      task.Status,
+		TargetURL:   task.TargetURL,
+		Options:     task.Options,
+		ScheduleID:  task.ScheduleID,
+		Error:       task.Error,
+		CreatedAt:   task.CreatedAt,
+		CompletedAt: task.CompletedAt,
+		Modules:     make(map[string]models.TaskStatus, len(task.Modules)),
+		Verdict:     task.Verdict,
+	}
+	for name, module := range task.Modules {
+		if module != nil {
+			summary.Modules[name] = module.Status
+		}
+	}
+
+	if results := task.Results; results != nil {
+		if len(results.LinkHealth) > 0 {
+			scanSummary := results.Summary
+			summary.Summary = &scanSummary
+		}
+		scores := make(map[string]int)
+		if results.Performance != nil {
+			scores["performance"] = results.Performance.Score
+		}
+		if results.SEOCompliance != nil {
+			scores["seo"] = results.SEOCompliance.Score
+		}
+		if results.SecurityRisk != nil {
+			scores["security"] = results.SecurityRisk.Score
+		}
+		if results.Accessibility != nil {
+			scores["accessibility"] = results.Accessibility.Score
+		}
+		if len(scores) > 0 {
+			summary.Scores = scores
+		}
+	}
+	return summary
+}
+
+// certificateExpiryThresholds 证书剩余天数告警阈值（天，从大到小）
+// 同一证书每跨过一个阈值只告警一次，避免定时扫描每次都重复告警
+var certificateExpiryThresholds = []int{30, 14, 7, 1}
+
+// notifyTaskFinished 任务结束（完成或失败）时发送 task.completed / task.failed 事件；
+// 证书剩余天数跨过告警阈值时发送 certificate.expiring 事件；
+// 定时扫描任务完成后与上一次扫描对比，存在变差的项时发送 schedule.regression 事件
+func notifyTaskFinished(taskID string) {
+	task, err := database.GetTask(taskID)
+	if err != nil || task.UserID == nil {
+		return
+	}
+
+	switch task.Status {
+	case models.TaskStatusCompleted:
+		EmitWebhookEvent(*task.UserID, models.WebhookEventTaskCompleted, models.WebhookEventData{Task: webhookTaskSummary(task)})
+	case models.TaskStatusFailed:
+		EmitWebhookEvent(*task.UserID, models.WebhookEventTaskFailed, models.WebhookEventData{Task: webhookTaskSummary(task)})
+		return
+	default:
+		return
+	}
+
+	if task.Results == nil {
+		return
+	}
+	previous, err := FindPreviousTask(task)
+	if err != nil {
+		log.Printf("[Webhook] Failed to find previous scan for task %s: %v", taskID, err)
+		return
+	}
+	notifyCertificateExpiring(task, previous)
+
+	if task.ScheduleID == nil || previous == nil {
+		return
+	}
+	if diff := DiffTasks(task, previous); diff.HasRegression {
+		EmitWebhookEvent(*task.UserID, models.WebhookEventScheduleRegression, models.WebhookEventData{
+			Task: webhookTaskSummary(task),
+			Diff: diff,
+		})
+	}
+}
+
+// notifyCertificateExpiring 证书剩余天数跨过告警阈值时发送 certificate.expiring 事件
+// 与同一目标的上一次扫描比较：上一次扫描是同一证书且已处于同一阈值时不再告警（换了证书后重新计算）
+func notifyCertificateExpiring(task, previous *models.Task) {
+	ssl := task.Results.SSLInfo
+	if ssl == nil {
+		return
+	}
+	threshold := certificateExpiryThreshold(ssl.DaysRemaining)
+	if threshold == 0 {
+		return
+	}
+	if previous != nil && previous.Results != nil && previous.Results.SSLInfo != nil {
+		prev := previous.Results.SSLInfo
+		if prev.SerialNumber == ssl.SerialNumber && certificateExpiryThreshold(prev.DaysRemaining) == threshold {
+			return
+		}
+	}
+
+	host := task.TargetURL
+	if parsed, err := url.Parse(task.TargetURL); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	EmitWebhookEvent(*task.UserID, models.WebhookEventCertExpiring, models.WebhookEventData{
+		Task: webhookTaskSummary(task),
+		Certificate: &models.CertificateExpiryAlert{
+			Host:          host,
+			Issuer:        ssl.Issuer,
+			SerialNumber:  ssl.SerialNumber,
+			ValidTo:       ssl.ValidTo,
+			DaysRemaining: ssl.DaysRemaining,
+			Threshold:     threshold,
+		},
+	})
+}
+
+// certificateExpiryThreshold 剩余天数所处的告警阈值（不低于剩余天数的最小阈值），不需要告警时返回 0
+func certificateExpiryThreshold(daysRemaining int) int {
+	threshold := 0
+	for _, t := range certificateExpiryThresholds {
+		if daysRemaining <= t {
+			threshold = t
+		}
+	}
+	return threshold
+}
+
+// notifyModuleFailed 模块失败时发送 module.failed 事件
+func notifyModuleFailed(task *models.Task, moduleName, errorMsg string) {
+	if task.UserID == nil {
+		return
+	}
+	EmitWebhookEvent(*task.UserID, models.WebhookEventModuleFailed, models.WebhookEventData{
+		Task: webhookTaskSummary(task),
+		Module: &models.ModuleStatus{
+			Name:   moduleName,
+			Status: models.TaskStatusFailed,
+			Error:  errorMsg,
+		},
+	})
+}
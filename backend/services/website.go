@@ -12,19 +12,12 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
-// CollectWebsiteInfo 收集网站信息
-func CollectWebsiteInfo(targetURL string) (*models.WebsiteInfo, error) {
+// CollectWebsiteInfo 收集网站信息（auth 为 nil 时匿名请求）
+func CollectWebsiteInfo(targetURL string, auth *ScanAuthSession) (*models.WebsiteInfo, error) {
 	log.Printf("[WebsiteInfo] Collecting website info from: %s", targetURL)
 
-	// 使用共享的HTTP客户端
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
+	// 使用共享的安全 Transport（连接时拒绝内网地址，防止 DNS 重绑定）；认证扫描时附加认证信息
+	client := auth.HTTPClient(15 * time.Second)
 
 	req, err := http.NewRequest("GET", targetURL, nil)
 	if err != nil {
@@ -32,6 +25,7 @@ func CollectWebsiteInfo(targetURL string) (*models.WebsiteInfo, error) {
 	}
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	auth.Apply(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
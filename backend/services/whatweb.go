@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"log"
 	"os/exec"
-	"strings"
 	"time"
 
 	"web-checkly/models"
@@ -46,6 +45,7 @@ func RunWhatWeb(ctx context.Context, targetURL string) (*WhatWebResult, error) {
 		"--no-errors",
 		targetURL,
 	)
+	configureCommandCancel(cmd)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -156,115 +156,57 @@ func RunWhatWeb(ctx context.Context, targetURL string) (*WhatWebResult, error) {
 	return result, nil
 }
 
-// categorizeTechnology 分类技术栈
+// categorizeTechnology 按指纹签名库中的分类归类 WhatWeb 插件（签名库中没有的归入 Plugins）
 func categorizeTechnology(techName string, result *WhatWebResult) {
-	techLower := strings.ToLower(techName)
-
-	// 框架检测
-	frameworkKeywords := []string{"laravel", "rails", "django", "flask", "express", "next", "nuxt", "vue", "react", "angular", "spring"}
-	for _, keyword := range frameworkKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.Framework, techName) {
-				result.Framework = append(result.Framework, techName)
-			}
-			return
-		}
-	}
-
-	// CMS检测
-	cmsKeywords := []string{"wordpress", "drupal", "joomla", "magento", "shopify", "prestashop", "typo3", "concrete5"}
-	for _, keyword := range cmsKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.CMS, techName) {
-				result.CMS = append(result.CMS, techName)
-			}
-			return
-		}
+	var fields []string
+	if tech := techSignatures().lookup(techName); tech != nil {
+		fields = tech.fields()
 	}
-
-	// 编程语言检测
-	languageKeywords := []string{"php", "python", "ruby", "java", "asp", "node", "perl"}
-	for _, keyword := range languageKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.Language, techName) {
-				result.Language = append(result.Language, techName)
-			}
-			return
-		}
-	}
-
-	// JavaScript库检测
-	jsKeywords := []string{"jquery", "react", "vue", "angular", "backbone", "ember", "knockout"}
-	for _, keyword := range jsKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.JavaScript, techName) {
-				result.JavaScript = append(result.JavaScript, techName)
-			}
-			return
-		}
-	}
-
-	// 数据库检测
-	databaseKeywords := []string{"mysql", "postgresql", "mongodb", "redis", "sqlite", "mariadb"}
-	for _, keyword := range databaseKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.Database, techName) {
-				result.Database = append(result.Database, techName)
-			}
-			return
+	if len(fields) == 0 {
+		if !containsString(result.Plugins, techName) {
+			result.Plugins = append(result.Plugins, techName)
 		}
+		return
 	}
 
-	// CDN检测
-	cdnKeywords := []string{"cloudflare", "cloudfront", "fastly", "akamai", "maxcdn", "keycdn"}
-	for _, keyword := range cdnKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.CDN, techName) {
-				result.CDN = append(result.CDN, techName)
+	for _, field := range fields {
+		var list *[]string
+		switch field {
+		case techFieldCMS:
+			list = &result.CMS
+		case techFieldFramework:
+			list = &result.Framework
+		case techFieldLanguage:
+			list = &result.Language
+		case techFieldJavaScript:
+			list = &result.JavaScript
+		case techFieldAnalytics:
+			list = &result.Analytics
+		case techFieldCDN:
+			list = &result.CDN
+		case techFieldDatabase:
+			list = &result.Database
+		case techFieldServer:
+			if result.Server == "" {
+				result.Server = techName
 			}
-			return
-		}
-	}
-
-	// 分析工具检测
-	analyticsKeywords := []string{"google-analytics", "gtag", "analytics", "baidu", "cnzz", "matomo", "piwik"}
-	for _, keyword := range analyticsKeywords {
-		if strings.Contains(techLower, keyword) {
-			if !containsString(result.Analytics, techName) {
-				result.Analytics = append(result.Analytics, techName)
+		case techFieldOS:
+			if result.OS == "" {
+				result.OS = techName
 			}
-			return
-		}
-	}
-
-	// Web服务器检测
-	if strings.Contains(techLower, "nginx") || strings.Contains(techLower, "apache") ||
-		strings.Contains(techLower, "iis") || strings.Contains(techLower, "lighttpd") {
-		if result.Server == "" {
-			result.Server = techName
+		default:
+			// WhatWeb 结果没有缓存分类
+			list = &result.Plugins
 		}
-		return
-	}
-
-	// 操作系统检测
-	if strings.Contains(techLower, "linux") || strings.Contains(techLower, "windows") ||
-		strings.Contains(techLower, "unix") || strings.Contains(techLower, "centos") ||
-		strings.Contains(techLower, "ubuntu") || strings.Contains(techLower, "debian") {
-		if result.OS == "" {
-			result.OS = techName
+		if list != nil && !containsString(*list, techName) {
+			*list = append(*list, techName)
 		}
-		return
-	}
-
-	// 其他技术栈添加到Plugins列表
-	if !containsString(result.Plugins, techName) {
-		result.Plugins = append(result.Plugins, techName)
 	}
 }
 
 // CollectWhatWebInfo 收集whatweb检测结果（用于插件）
-func CollectWhatWebInfo(targetURL string) (*WhatWebResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func CollectWhatWebInfo(ctx context.Context, targetURL string) (*WhatWebResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	return RunWhatWeb(ctx, targetURL)
@@ -328,6 +270,8 @@ func MergeWhatWebToTechStack(whatweb *WhatWebResult, existing *models.TechStack)
 		OS:              whatweb.OS,
 		SecurityHeaders: existing.SecurityHeaders, // 保留现有的安全头
 		MetaTags:        existing.MetaTags,        // 保留现有的元标签
+		Detections:      existing.Detections,      // 保留签名库识别结果
+		HeaderAnalysis:  existing.HeaderAnalysis,  // 保留安全响应头分析
 		ContentType:     existing.ContentType,
 		ContentLength:   existing.ContentLength,
 		LastModified:    existing.LastModified,
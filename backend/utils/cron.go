@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule 解析后的 cron 表达式（标准 5 段：分 时 日 月 周）
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周都被限定时，两者满足其一即可（与标准 cron 一致）
+	domRestricted, dowRestricted bool
+}
+
+// cronField cron 字段的取值范围
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{min: 0, max: 59}
+	cronHour   = cronField{min: 0, max: 23}
+	cronDom    = cronField{min: 1, max: 31}
+	cronMonth  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 周：0 和 7 都表示周日
+	cronDow = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronDescriptors 预定义的 cron 表达式
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析标准 5 段 cron 表达式（分 时 日 月 周）
+// 支持 *、数字、范围（1-5）、步长（*/15、1-30/5）、列表（1,15）、月份和星期名称（JAN、MON）
+// 以及 @hourly、@daily、@weekly、@monthly、@yearly 等预定义表达式
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	schedule := &CronSchedule{}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if schedule.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if schedule.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %w", err)
+	}
+	if schedule.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if schedule.dow, err = parseCronField(fields[4], cronDow); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %w", err)
+	}
+
+	// 7 与 0 都表示周日
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1 << 0
+	}
+	schedule.domRestricted = fields[2] != "*" && fields[2] != "?"
+	schedule.dowRestricted = fields[4] != "*" && fields[4] != "?"
+
+	return schedule, nil
+}
+
+// parseCronField 解析单个 cron 字段，返回取值的位图
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("empty value in %q", field)
+		}
+
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			rangePart = part[:idx]
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = spec.min, spec.max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], spec); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(bounds[1], spec); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := parseCronValue(rangePart, spec)
+			if err != nil {
+				return 0, err
+			}
+			start, end = value, value
+			// "5/10" 表示从 5 开始每 10 个单位
+			if step > 1 {
+				end = spec.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析 cron 字段中的单个值（数字或名称）
+func parseCronValue(value string, spec cronField) (int, error) {
+	if n, ok := spec.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", n, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// Next 返回 after 之后（不含）的下一次执行时间，使用 after 所在的时区
+// 表达式在 5 年内都不会触发（如 2 月 30 日）时返回零值
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 检查日期是否满足日和周字段
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// defaultDNSTimeout 单次 DNS 查询超时
+	defaultDNSTimeout = 3 * time.Second
+	// dnsUDPPayloadSize EDNS0 通告的 UDP 报文大小（DNS Flag Day 2020 推荐值，避免分片）
+	dnsUDPPayloadSize = 1232
+)
+
+// defaultDNSServers 没有配置 DNS_RESOLVER 且无法读取 /etc/resolv.conf 时使用的公共解析器
+var defaultDNSServers = []string{"1.1.1.1:53", "8.8.8.8:53"}
+
+// ErrDNSResponseMismatch 响应的 ID 或问题与查询不一致
+var ErrDNSResponseMismatch = errors.New("DNS response does not match query")
+
+// DNSQuery 单次 DNS 查询
+type DNSQuery struct {
+	Name             string
+	Type             dnsmessage.Type
+	Recursion        bool // RD 标志（向递归解析器查询时为 true，直接查询权威服务器时为 false）
+	DNSSEC           bool // EDNS0 DO 标志：要求返回 RRSIG 等 DNSSEC 记录
+	CheckingDisabled bool // CD 标志：要求解析器即使验证失败也返回数据（用于自行验证 DNSSEC）
+}
+
+// DNSClient 直接发送 DNS 报文的客户端
+// 与系统解析器（net.LookupIP 等）不同，可以指定解析器、读取 DNSSEC 记录和 AD/AA 标志、直接查询权威服务器；
+// UDP 响应被截断时自动改用 TCP
+type DNSClient struct {
+	Servers []string      // 递归解析器地址（host:port），依次尝试
+	Timeout time.Duration // 单次查询超时
+}
+
+// NewDNSClient 创建使用配置的递归解析器的 DNS 客户端
+// 解析器优先读取 DNS_RESOLVER 环境变量（逗号分隔，如 "1.1.1.1,8.8.8.8:53"），
+// 未配置时使用 /etc/resolv.conf 中的 nameserver，都没有时使用公共解析器
+func NewDNSClient() *DNSClient {
+	servers := ParseDNSServers(os.Getenv("DNS_RESOLVER"))
+	if len(servers) == 0 {
+		servers = systemDNSServers()
+	}
+	if len(servers) == 0 {
+		servers = defaultDNSServers
+	}
+	return &DNSClient{Servers: servers, Timeout: defaultDNSTimeout}
+}
+
+// ParseDNSServers 解析逗号分隔的解析器列表，没有端口时补充 53
+func ParseDNSServers(value string) []string {
+	var servers []string
+	for _, server := range strings.Split(value, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+// systemDNSServers 读取 /etc/resolv.conf 中的 nameserver
+func systemDNSServers() []string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			servers = append(servers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	return servers
+}
+
+// Query 向配置的递归解析器查询（依次尝试各个解析器，直到有一个返回响应）
+func (c *DNSClient) Query(ctx context.Context, q DNSQuery) (*dnsmessage.Message, error) {
+	q.Recursion = true
+	var lastErr error
+	for _, server := range c.Servers {
+		msg, err := c.Exchange(ctx, server, q)
+		if err == nil {
+			return msg, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no DNS servers configured")
+	}
+	return nil, lastErr
+}
+
+// Exchange 向指定服务器发送一次查询（UDP，截断时改用 TCP）
+func (c *DNSClient) Exchange(ctx context.Context, server string, q DNSQuery) (*dnsmessage.Message, error) {
+	name, err := dnsmessage.NewName(fqdn(q.Name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS name %q: %w", q.Name, err)
+	}
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               uint16(rand.Uint32()),
+			RecursionDesired: q.Recursion,
+			CheckingDisabled: q.CheckingDisabled,
+		},
+		Questions: []dnsmessage.Question{{Name: name, Type: q.Type, Class: dnsmessage.ClassINET}},
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(dnsUDPPayloadSize, dnsmessage.RCodeSuccess, q.DNSSEC); err != nil {
+		return nil, err
+	}
+	query.Additionals = []dnsmessage.Resource{{Header: opt, Body: &dnsmessage.OPTResource{}}}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack DNS query: %w", err)
+	}
+
+	resp, err := c.exchange(ctx, "udp", server, packed, query)
+	if err == nil && resp.Truncated {
+		resp, err = c.exchange(ctx, "tcp", server, packed, query)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("DNS query %s %s to %s failed: %w", q.Name, q.Type, server, err)
+	}
+	return resp, nil
+}
+
+// exchange 通过指定协议发送报文并读取响应
+func (c *DNSClient) exchange(ctx context.Context, network, server string, packed []byte, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultDNSTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	var buf []byte
+	if network == "tcp" {
+		// TCP 报文前加 2 字节长度
+		framed := make([]byte, 2+len(packed))
+		binary.BigEndian.PutUint16(framed, uint16(len(packed)))
+		copy(framed[2:], packed)
+		if _, err := conn.Write(framed); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, 65535)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return nil, err
+			}
+			// 忽略 ID 不匹配的报文（可能是之前超时查询的迟到响应），继续等待
+			if n >= 2 && binary.BigEndian.Uint16(buf) == query.Header.ID {
+				buf = buf[:n]
+				break
+			}
+		}
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, fmt.Errorf("failed to parse DNS response: %w", err)
+	}
+	if resp.Header.ID != query.Header.ID || !resp.Header.Response ||
+		len(resp.Questions) != 1 || !strings.EqualFold(resp.Questions[0].Name.String(), query.Questions[0].Name.String()) ||
+		resp.Questions[0].Type != query.Questions[0].Type {
+		return nil, ErrDNSResponseMismatch
+	}
+	return &resp, nil
+}
+
+// fqdn 补全末尾的点
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
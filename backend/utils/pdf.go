@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A4 页面尺寸（单位：pt）
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// PDFColor RGB 颜色（0-255）
+type PDFColor struct {
+	R, G, B uint8
+}
+
+// PDF 极简 PDF 生成器：只支持文字、矩形和线条，坐标原点在页面左上角
+// 拉丁字符使用标准字体 Helvetica（无需嵌入），中文等其他字符使用 PDF 预定义的 CJK 字体 STSong-Light（由阅读器提供字形），
+// 因此生成的文件很小且不依赖任何字体文件或无头浏览器
+type PDF struct {
+	title   string
+	pages   []*bytes.Buffer
+	current int
+}
+
+// NewPDF 创建 PDF 文档，title 写入文档属性
+func NewPDF(title string) *PDF {
+	return &PDF{title: title, current: -1}
+}
+
+// AddPage 新增一页并设为当前页
+func (p *PDF) AddPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.current = len(p.pages) - 1
+}
+
+// PageCount 返回页数
+func (p *PDF) PageCount() int {
+	return len(p.pages)
+}
+
+// SetPage 切换当前页（从 0 开始），用于生成完所有页后补充页眉页脚
+func (p *PDF) SetPage(index int) {
+	if index >= 0 && index < len(p.pages) {
+		p.current = index
+	}
+}
+
+// page 返回当前页内容，没有页面时自动新增
+func (p *PDF) page() *bytes.Buffer {
+	if p.current < 0 {
+		p.AddPage()
+	}
+	return p.pages[p.current]
+}
+
+// Rect 绘制填充矩形，(x, y) 为左上角
+func (p *PDF) Rect(x, y, w, h float64, fill PDFColor) {
+	fmt.Fprintf(p.page(), "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(fill), x, PDFPageHeight-y-h, w, h)
+}
+
+// Line 绘制线段
+func (p *PDF) Line(x1, y1, x2, y2, width float64, color PDFColor) {
+	fmt.Fprintf(p.page(), "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n", pdfColor(color), width, x1, PDFPageHeight-y1, x2, PDFPageHeight-y2)
+}
+
+// Text 在 (x, y) 处绘制单行文字，y 为基线位置
+func (p *PDF) Text(x, y, size float64, bold bool, color PDFColor, text string) {
+	buf := p.page()
+	baseline := PDFPageHeight - y
+	for _, run := range splitPDFRuns(text) {
+		if run.cjk {
+			// CJK 字体没有粗体，使用描边模拟（文字渲染模式属于图形状态，每段文字都需要重新设置）
+			if bold {
+				fmt.Fprintf(buf, "BT /F3 %.2f Tf %s rg %s RG 2 Tr %.2f w %.2f %.2f Td %s Tj ET\n",
+					size, pdfColor(color), pdfColor(color), size/30, x, baseline, pdfUCS2String(run.text))
+			} else {
+				fmt.Fprintf(buf, "BT /F3 %.2f Tf %s rg 0 Tr %.2f %.2f Td %s Tj ET\n",
+					size, pdfColor(color), x, baseline, pdfUCS2String(run.text))
+			}
+		} else {
+			font := "F1"
+			if bold {
+				font = "F2"
+			}
+			fmt.Fprintf(buf, "BT /%s %.2f Tf %s rg 0 Tr %.2f %.2f Td %s Tj ET\n",
+				font, size, pdfColor(color), x, baseline, pdfLiteralString(run.text))
+		}
+		x += pdfRunWidth(run, size, bold)
+	}
+}
+
+// TextWidth 计算文字宽度（pt）
+func (p *PDF) TextWidth(text string, size float64, bold bool) float64 {
+	width := 0.0
+	for _, run := range splitPDFRuns(text) {
+		width += pdfRunWidth(run, size, bold)
+	}
+	return width
+}
+
+// WrapText 按宽度折行：拉丁文字优先在空格处断行，CJK 字符可在任意位置断行，原文中的换行符保留
+func (p *PDF) WrapText(text string, maxWidth, size float64, bold bool) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		lines = append(lines, p.wrapParagraph(paragraph, maxWidth, size, bold)...)
+	}
+	return lines
+}
+
+// wrapParagraph 对单个段落折行
+func (p *PDF) wrapParagraph(text string, maxWidth, size float64, bold bool) []string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	start, lastSpace := 0, -1
+	width := 0.0
+	for i := 0; i < len(runes); i++ {
+		w := pdfRuneWidth(runes[i], size, bold)
+		if runes[i] == ' ' {
+			lastSpace = i
+		}
+		if width+w > maxWidth && i > start {
+			end := i
+			if lastSpace > start && runes[i] != ' ' && runes[i] < utf8.RuneSelf {
+				end = lastSpace // 在最后一个空格处断开，避免拆分单词
+			}
+			lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+			start = end
+			for start < len(runes) && runes[start] == ' ' {
+				start++
+			}
+			lastSpace = -1
+			width = 0
+			i = start - 1
+			continue
+		}
+		width += w
+	}
+	if start < len(runes) {
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
+
+// TruncateText 截断超出宽度的文字并追加省略号
+func (p *PDF) TruncateText(text string, maxWidth, size float64, bold bool) string {
+	if p.TextWidth(text, size, bold) <= maxWidth {
+		return text
+	}
+	ellipsis := "..."
+	limit := maxWidth - p.TextWidth(ellipsis, size, bold)
+	width := 0.0
+	for i, r := range text {
+		width += pdfRuneWidth(r, size, bold)
+		if width > limit {
+			return text[:i] + ellipsis
+		}
+	}
+	return text
+}
+
+// Bytes 生成 PDF 文件内容
+func (p *PDF) Bytes() ([]byte, error) {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	// 对象编号从 1 开始，按写入顺序分配
+	writeObject := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: Catalog, 2: Pages, 3-7: 字体, 8: Info，之后每页依次为页面对象和内容流
+	const firstPageObject = 9
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+i*2)
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] >>",
+		strings.Join(kids, " "), len(p.pages), PDFPageWidth, PDFPageHeight))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [6 0 R] >>")
+	writeObject("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 7 0 R /DW 1000 >>")
+	writeObject("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	writeObject(fmt.Sprintf("<< /Title %s /Producer (WebCheckly) /CreationDate (D:%s) >>",
+		pdfTextString(p.title), time.Now().UTC().Format("20060102150405Z")))
+
+	for i, content := range p.pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			firstPageObject+i*2+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(content.Bytes()); err != nil {
+			return nil, fmt.Errorf("failed to compress page %d: %w", i+1, err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress page %d: %w", i+1, err)
+		}
+		writeObject(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 8 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+	return out.Bytes(), nil
+}
+
+// pdfRun 使用同一字体的连续文字
+type pdfRun struct {
+	text string
+	cjk  bool
+}
+
+// splitPDFRuns 将文字按字体拆分：ASCII 字符使用 Helvetica，其他字符使用 CJK 字体
+func splitPDFRuns(text string) []pdfRun {
+	var runs []pdfRun
+	var current strings.Builder
+	currentCJK := false
+	for _, r := range text {
+		if r < 0x20 || r == 0x7f {
+			r = ' '
+		}
+		cjk := r >= utf8.RuneSelf
+		if current.Len() > 0 && cjk != currentCJK {
+			runs = append(runs, pdfRun{text: current.String(), cjk: currentCJK})
+			current.Reset()
+		}
+		currentCJK = cjk
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		runs = append(runs, pdfRun{text: current.String(), cjk: currentCJK})
+	}
+	return runs
+}
+
+// pdfRunWidth 计算一段同字体文字的宽度
+func pdfRunWidth(run pdfRun, size float64, bold bool) float64 {
+	width := 0.0
+	for _, r := range run.text {
+		width += pdfRuneWidth(r, size, bold)
+	}
+	return width
+}
+
+// pdfRuneWidth 计算单个字符的宽度（Helvetica 使用标准字宽表，CJK 字符为全角）
+func pdfRuneWidth(r rune, size float64, bold bool) float64 {
+	if r < 0x20 || r == 0x7f {
+		r = ' '
+	}
+	if r >= utf8.RuneSelf {
+		return size
+	}
+	widths := helveticaWidths
+	if bold {
+		widths = helveticaBoldWidths
+	}
+	return float64(widths[r-0x20]) * size / 1000
+}
+
+// pdfColor 生成颜色操作数
+func pdfColor(c PDFColor) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// pdfLiteralString 生成 PDF 字面量字符串（仅 ASCII）
+func pdfLiteralString(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return "(" + replacer.Replace(text) + ")"
+}
+
+// pdfUCS2String 生成 UCS-2 编码的十六进制字符串（BMP 之外的字符替换为 ?）
+func pdfUCS2String(text string) string {
+	var b strings.Builder
+	b.WriteString("<")
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfTextString 生成文档属性使用的文本字符串（带 BOM 的 UTF-16BE）
+func pdfTextString(text string) string {
+	return "<FEFF" + strings.TrimPrefix(pdfUCS2String(text), "<")
+}
+
+// helveticaWidths Helvetica 字宽表（0x20-0x7E，单位为 1/1000 字号）
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths Helvetica-Bold 字宽表（0x20-0x7E，单位为 1/1000 字号）
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress 连接目标为内网、回环、链路本地或云元数据地址
+var ErrPrivateAddress = errors.New("connection to private address not allowed")
+
+// maxSafeRedirects 安全 HTTP 客户端最多跟随的重定向次数
+const maxSafeRedirects = 10
+
+// SafeDialControl net.Dialer 的 Control 钩子：在 DNS 解析之后、建立连接之前检查实际连接的 IP
+// IsPrivateIP 只在创建任务时检查一次主机名，之后采集时会重新解析；攻击者可以让域名在检查时解析为公网地址、
+// 采集时解析为内网地址（DNS 重绑定）。在连接时检查可以保证无论解析结果如何变化都不会连接到内网地址。
+func SafeDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+	}
+	// IPv6 链路本地地址可能带有 zone（fe80::1%eth0）
+	if i := strings.IndexByte(host, '%'); i >= 0 {
+		host = host[:i]
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateIPAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// NewSafeDialer 创建在连接时拒绝内网地址的 Dialer
+func NewSafeDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   SafeDialControl,
+	}
+}
+
+// NewSafeTransport 创建在连接时拒绝内网地址的 Transport
+// 不使用环境变量中的代理：经代理连接时 Control 钩子检查的是代理地址，无法保护实际目标
+func NewSafeTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:                 nil,
+		DialContext:           NewSafeDialer(10 * time.Second).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// SafeTransport 所有采集器共享的安全 Transport（复用连接池）
+var SafeTransport = NewSafeTransport()
+
+// CheckSafeRedirect http.Client 的 CheckRedirect：只允许重定向到 http/https 的公网地址
+// 连接时的检查已经能阻止访问内网，这里提前拒绝以返回更明确的错误
+func CheckSafeRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxSafeRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxSafeRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to unsupported scheme %q not allowed", req.URL.Scheme)
+	}
+	if IsPrivateIP(req.URL.Hostname()) {
+		return fmt.Errorf("%w: redirect to %s", ErrPrivateAddress, req.URL.Hostname())
+	}
+	return nil
+}
+
+// NewSafeHTTPClient 创建使用安全 Transport 并校验重定向目标的 HTTP 客户端
+func NewSafeHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     SafeTransport,
+		CheckRedirect: CheckSafeRedirect,
+	}
+}
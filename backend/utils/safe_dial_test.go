@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// rebindingResolver 模拟 DNS 重绑定：同一类型的第一次查询返回公网地址，之后返回 rebound
+type rebindingResolver struct {
+	public  netip.Addr
+	rebound netip.Addr
+
+	mu      sync.Mutex
+	queries map[dnsmessage.Type]int
+}
+
+// resolver 返回使用该模拟 DNS 服务器的 net.Resolver（不发出真实的 DNS 查询）
+func (r *rebindingResolver) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go r.serve(server)
+			return client, nil
+		},
+	}
+}
+
+// serve 按 DNS over TCP 的格式（2 字节长度前缀）应答查询
+func (r *rebindingResolver) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		answer, err := r.answer(query)
+		if err != nil {
+			return
+		}
+		binary.BigEndian.PutUint16(length[:], uint16(len(answer)))
+		if _, err := conn.Write(append(length[:], answer...)); err != nil {
+			return
+		}
+	}
+}
+
+// answer 构造应答：查询类型与地址族不匹配时返回空应答
+func (r *rebindingResolver) answer(query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.queries[question.Type]++
+	addr := r.rebound
+	if r.queries[question.Type] == 1 {
+		addr = r.public
+	}
+	r.mu.Unlock()
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true, RecursionAvailable: true})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+	rr := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 0}
+	switch {
+	case question.Type == dnsmessage.TypeA && addr.Is4():
+		err = builder.AResource(rr, dnsmessage.AResource{A: addr.As4()})
+	case question.Type == dnsmessage.TypeAAAA && addr.Is6():
+		err = builder.AAAAResource(rr, dnsmessage.AAAAResource{AAAA: addr.As16()})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return builder.Finish()
+}
+
+// newRebindingResolver 创建第一次解析为 public、之后解析为 rebound 的模拟 DNS
+func newRebindingResolver(public, rebound string) *rebindingResolver {
+	return &rebindingResolver{
+		public:  netip.MustParseAddr(public),
+		rebound: netip.MustParseAddr(rebound),
+		queries: make(map[dnsmessage.Type]int),
+	}
+}
+
+func TestSafeDialerBlocksDNSRebinding(t *testing.T) {
+	cases := []struct {
+		name    string
+		public  string
+		rebound string
+	}{
+		{"loopback", "93.184.216.34", "127.0.0.1"},
+		{"cloud metadata", "93.184.216.34", "169.254.169.254"},
+		{"private", "93.184.216.34", "10.0.0.1"},
+		{"ipv6 loopback", "2606:2800:220:1::1", "::1"},
+		{"ipv6 link-local", "2606:2800:220:1::1", "fe80::1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dns := newRebindingResolver(tc.public, tc.rebound)
+			resolver := dns.resolver()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			// 创建任务时的检查看到的是公网地址
+			addrs, err := resolver.LookupNetIP(ctx, "ip", "rebind.example")
+			if err != nil {
+				t.Fatalf("first lookup: %v", err)
+			}
+			if len(addrs) != 1 || addrs[0].String() != tc.public {
+				t.Fatalf("first lookup = %v, want %s", addrs, tc.public)
+			}
+
+			// 采集时重新解析得到内网地址，连接时必须被拒绝
+			dialer := NewSafeDialer(2 * time.Second)
+			dialer.Resolver = resolver
+			conn, err := dialer.DialContext(ctx, "tcp", "rebind.example:80")
+			if err == nil {
+				conn.Close()
+				t.Fatalf("dial to rebound %s succeeded", tc.rebound)
+			}
+			if !errors.Is(err, ErrPrivateAddress) {
+				t.Fatalf("dial error = %v, want ErrPrivateAddress", err)
+			}
+		})
+	}
+}
+
+func TestSafeTransportBlocksDNSRebinding(t *testing.T) {
+	dns := newRebindingResolver("93.184.216.34", "127.0.0.1")
+	resolver := dns.resolver()
+	if _, err := resolver.LookupNetIP(context.Background(), "ip4", "rebind.example"); err != nil {
+		t.Fatalf("first lookup: %v", err)
+	}
+
+	// 与 SafeTransport 相同的配置，只替换解析器
+	dialer := NewSafeDialer(2 * time.Second)
+	dialer.Resolver = resolver
+	transport := NewSafeTransport()
+	transport.DialContext = dialer.DialContext
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport, CheckRedirect: CheckSafeRedirect}
+
+	resp, err := client.Get("http://rebind.example/")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to rebound host succeeded")
+	}
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("request error = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestSafeDialControl(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1:80",
+		"169.254.169.254:80",
+		"10.1.2.3:443",
+		"[::1]:80",
+		"[fe80::1%eth0]:80",
+		"[::]:80",
+		"not-an-address",
+	}
+	for _, address := range blocked {
+		if err := SafeDialControl("tcp", address, nil); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("SafeDialControl(%q) = %v, want ErrPrivateAddress", address, err)
+		}
+	}
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1::1]:443"} {
+		if err := SafeDialControl("tcp", address, nil); err != nil {
+			t.Errorf("SafeDialControl(%q) = %v, want nil", address, err)
+		}
+	}
+}
+
+func TestCheckSafeRedirect(t *testing.T) {
+	cases := []struct {
+		location string
+		private  bool
+	}{
+		{"http://127.0.0.1/admin", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[::1]:8080/", true},
+		{"http://localhost/", true},
+		{"file:///etc/passwd", false},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(http.MethodGet, tc.location, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CheckSafeRedirect(req, nil)
+		if err == nil {
+			t.Errorf("CheckSafeRedirect(%q) = nil, want error", tc.location)
+			continue
+		}
+		if tc.private && !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckSafeRedirect(%q) = %v, want ErrPrivateAddress", tc.location, err)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://93.184.216.34/", nil)
+	if err := CheckSafeRedirect(req, nil); err != nil {
+		t.Errorf("CheckSafeRedirect(public) = %v, want nil", err)
+	}
+	if err := CheckSafeRedirect(req, make([]*http.Request, maxSafeRedirects)); err == nil {
+		t.Error("CheckSafeRedirect did not stop after maxSafeRedirects")
+	}
+}
+
+func TestCheckSafeRedirectRejectsRedirectToPrivateHost(t *testing.T) {
+	// 测试服务器本身在回环地址上，这里只验证重定向检查，因此使用普通 Transport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second, CheckRedirect: CheckSafeRedirect}
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("redirect to metadata address was followed")
+	}
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("redirect error = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestIsPrivateIPLiterals(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "127.0.0.1:8080", "169.254.169.254", "::1", "[::1]:80", "fe80::1", "fd12::1", "[fe80::1]:443", "10.0.0.1"} {
+		if !IsPrivateIP(host) {
+			t.Errorf("IsPrivateIP(%q) = false, want true", host)
+		}
+	}
+	for _, host := range []string{"93.184.216.34", "93.184.216.34:443", "2606:2800:220:1::1", "[2606:2800:220:1::1]:443"} {
+		if IsPrivateIP(host) {
+			t.Errorf("IsPrivateIP(%q) = true, want false", host)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrSecretKeyNotConfigured 未配置 SCAN_AUTH_ENCRYPTION_KEY，无法加密或解密敏感配置
+var ErrSecretKeyNotConfigured = errors.New("SCAN_AUTH_ENCRYPTION_KEY environment variable is not set")
+
+// secretPrefix 密文格式版本前缀（便于以后更换算法）
+const secretPrefix = "v1:"
+
+// SecretEncryptionEnabled 是否配置了敏感配置的加密密钥
+func SecretEncryptionEnabled() bool {
+	return strings.TrimSpace(os.Getenv("SCAN_AUTH_ENCRYPTION_KEY")) != ""
+}
+
+// secretCipher 使用 SCAN_AUTH_ENCRYPTION_KEY 派生的 256 位密钥创建 AES-GCM
+func secretCipher() (cipher.AEAD, error) {
+	secret := strings.TrimSpace(os.Getenv("SCAN_AUTH_ENCRYPTION_KEY"))
+	if secret == "" {
+		return nil, ErrSecretKeyNotConfigured
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret 使用 AES-256-GCM 加密敏感配置（如认证扫描的凭据），返回可存入数据库的文本
+func EncryptSecret(plaintext []byte) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return secretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的密文（密钥变更或数据被篡改时返回错误）
+func DecryptSecret(ciphertext string) ([]byte, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(ciphertext, secretPrefix) {
+		return nil, errors.New("unsupported secret format")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, secretPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("secret too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return plaintext, nil
+}
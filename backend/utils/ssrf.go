@@ -33,14 +33,22 @@ var blockedDomainSuffixes = []string{
 
 // IsPrivateIP 检查主机名或IP是否为私有地址或禁止访问的地址
 func IsPrivateIP(host string) bool {
-	// 移除端口号
-	host = strings.Split(host, ":")[0]
+	// 移除端口号（IPv6 地址本身包含冒号，只有 host:port 或 [IPv6]:port 形式才拆分）
 	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
 
 	if host == "" {
 		return true // 空主机名视为不安全
 	}
 
+	// IP 地址直接按地址范围判断（下面的子串匹配会误伤包含 "::1" 的公网 IPv6 地址）
+	if ip := net.ParseIP(host); ip != nil {
+		return isPrivateIPAddress(ip)
+	}
+
 	hostLower := strings.ToLower(host)
 
 	// 检查是否为禁止的域名（精确匹配或包含）
@@ -62,13 +70,6 @@ func IsPrivateIP(host string) bool {
 		return true
 	}
 
-	// 尝试解析为IP地址
-	ip := net.ParseIP(host)
-	if ip != nil {
-		// 直接是IP地址
-		return isPrivateIPAddress(ip)
-	}
-
 	// 解析域名（DNS查询）
 	ips, err := net.LookupIP(host)
 	if err != nil {
@@ -11,7 +11,8 @@ function ReportSummary({ results }: { results: ScanResult[] }) {
   const stats = useMemo(() => {
     const total = results.length
     const alive = results.filter((r) => r.status > 0 && r.status < 400).length
-    const dead = total - alive
+    // 超时未检查的链接不计入失效
+    const dead = total - alive - results.filter((r) => r.skipped).length
     const avg = total > 0
       ? Math.round(results.reduce((sum, r) => sum + (r.response_time || 0), 0) / total)
       : 0
@@ -7,6 +7,9 @@ import { useLanguage } from "@/contexts/LanguageContext"
 
 type FilterType = "all" | "error" | "slow"
 
+// 超时未检查（skipped）的链接状态未知，不算异常
+const isErrorResult = (r: ScanResult) => !r.skipped && (r.status >= 400 || r.status === 0)
+
 function ResultTable({ results }: { results: ScanResult[] }) {
   const { t } = useLanguage()
   const [filter, setFilter] = useState<FilterType>("all")
@@ -43,7 +46,7 @@ function ResultTable({ results }: { results: ScanResult[] }) {
     let filtered = results
 
     if (filter === "error") {
-      filtered = results.filter((r) => r.status >= 400 || r.status === 0)
+      filtered = results.filter(isErrorResult)
     } else if (filter === "slow") {
       filtered = results.filter((r) => r.response_time > 1000)
     }
@@ -52,8 +55,8 @@ function ResultTable({ results }: { results: ScanResult[] }) {
     return [...filtered].sort((a, b) => {
       if (a.status !== b.status) {
         // 异常状态优先
-        if (a.status === 0 || a.status >= 400) return -1
-        if (b.status === 0 || b.status >= 400) return 1
+        if (isErrorResult(a)) return -1
+        if (isErrorResult(b)) return 1
         return a.status - b.status
       }
       return b.response_time - a.response_time
@@ -107,7 +110,7 @@ function ResultTable({ results }: { results: ScanResult[] }) {
           }`}
         >
           <span className={`relative z-10 ${filter === "error" ? "drop-shadow-[0_1px_2px_rgba(0,0,0,0.3)]" : ""}`}>
-            {t("scan.filterError")} ({results.filter((r) => r.status >= 400 || r.status === 0).length})
+            {t("scan.filterError")} ({results.filter(isErrorResult).length})
           </span>
           {filter === "error" && (
             <div className="absolute inset-0 bg-white/20"></div>
@@ -153,7 +156,7 @@ function ResultTable({ results }: { results: ScanResult[] }) {
                   {r.url}
                 </td>
                 <td className="p-3 text-center">
-                  <StatusBadge status={r.status} />
+                  <StatusBadge status={r.status} skipped={r.skipped} />
                 </td>
                 <td className="p-3 max-w-xs truncate text-gray-300/80" title={r.title}>
                   {r.title || <span className="text-tech-cyan/40">-</span>}
@@ -3,12 +3,15 @@
 import { memo } from "react"
 import { useLanguage } from "@/contexts/LanguageContext"
 
-function StatusBadge({ status }: { status: number }) {
+function StatusBadge({ status, skipped }: { status: number; skipped?: boolean }) {
   const { t } = useLanguage()
   let color = "bg-tech-surface text-gray-300 border-tech-border/30"
   let displayStatus = status.toString()
 
-  if (status === 0) {
+  if (skipped) {
+    color = "bg-tech-surface text-gray-400 border-tech-border/30"
+    displayStatus = t("scan.statusSkipped")
+  } else if (status === 0) {
     color = "bg-red-950/60 text-red-400 border-red-500/40 shadow-[0_0_8px_rgba(239,68,68,0.3)]"
     displayStatus = t("scan.statusFailed")
   } else if (status >= 200 && status < 300) {
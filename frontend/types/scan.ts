@@ -6,6 +6,7 @@ export interface ScanResult {
   ip: string
   tls: boolean
   cdn: boolean
+  skipped?: boolean // 到达时间上限前没有检查
 }
 
 export interface Progress {
@@ -221,6 +222,7 @@ export interface TaskResults {
     total: number
     alive: number
     dead: number
+    skipped?: number
     avg_response: number
     timeout: boolean
   }
@@ -170,6 +170,7 @@ export const translations = {
       exportingProgress: "正在导出 {type}...",
       progressLabel: "检测进度",
       statusFailed: "失败",
+      statusSkipped: "未检查",
       exportFailed: "导出失败，请重试",
       scanning: "扫描中",
       rescan: "重新扫描",
@@ -1730,6 +1731,7 @@ export const translations = {
       exportingProgress: "Exporting {type}...",
       progressLabel: "Scan Progress",
       statusFailed: "Failed",
+      statusSkipped: "Not checked",
       exportFailed: "Export failed, please try again",
       scanning: "Scanning",
       rescan: "Rescan",